@@ -0,0 +1,109 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package command
+
+import (
+	"flag"
+	"io/ioutil"
+	"strings"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/talos-systems/kres/internal/config"
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/project/auto"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Dag implements 'dag' command.
+type Dag struct {
+	Meta
+}
+
+// Help implements cli.Command.
+func (c *Dag) Help() string {
+	helpText := `
+Usage: kres dag
+
+	Dump the dependency graph of the project in Graphviz DOT format, e.g.:
+
+	  kres dag | dot -Tpng > dag.png
+
+	Nodes which are part of a cycle are highlighted.
+
+Options:
+
+	--output=dag.dot                    Write the graph to the file instead of stdout
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis implements cli.Command.
+func (c *Dag) Synopsis() string {
+	return "Dump the dependency graph in Graphviz DOT format."
+}
+
+// Run implements cli.Command.
+func (c *Dag) Run(args []string) int {
+	var out string
+
+	flags := flag.NewFlagSet("dag", flag.ContinueOnError)
+	flags.StringVar(&out, "output", "", "")
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	var err error
+
+	options := meta.Options{}
+
+	options.Config, err = config.NewProvider(".kres.yaml")
+	if err != nil {
+		c.Ui.Error(err.Error())
+
+		return 1
+	}
+
+	proj, err := auto.Build(&options)
+	if err != nil {
+		c.Ui.Error(err.Error())
+
+		return 1
+	}
+
+	if err := proj.LoadConfig(options.Config); err != nil {
+		c.Ui.Error(err.Error())
+
+		return 1
+	}
+
+	dot := dag.DumpDot(proj.Targets())
+
+	if out == "" {
+		c.Ui.Output(strings.TrimSuffix(dot, "\n"))
+
+		return 0
+	}
+
+	if err := ioutil.WriteFile(out, []byte(dot), 0o644); err != nil {
+		c.Ui.Error(err.Error())
+
+		return 1
+	}
+
+	return 0
+}
+
+// NewDag creates Dag command.
+func NewDag(m Meta) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &Dag{
+			Meta: m,
+		}, nil
+	}
+}
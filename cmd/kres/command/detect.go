@@ -0,0 +1,178 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/config"
+	"github.com/talos-systems/kres/internal/project/auto"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Detect implements 'detect' command.
+type Detect struct {
+	Meta
+}
+
+// Help implements cli.Command.
+func (c *Detect) Help() string {
+	helpText := `
+Usage: kres detect
+
+	Run the project detection and print the detected options (directories, source files,
+	commands, etc.) without generating any files, e.g. to find out why a directory is not built.
+
+Options:
+
+	--format=yaml                       Output format (yaml or json, default: yaml)
+	--exclude=examples,hack/*           Paths (globs) excluded from the detection, as in 'kres gen'
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis implements cli.Command.
+func (c *Detect) Synopsis() string {
+	return "Print the detected project options."
+}
+
+// Run implements cli.Command.
+func (c *Detect) Run(args []string) int {
+	var format, exclude string
+
+	flags := flag.NewFlagSet("detect", flag.ContinueOnError)
+	flags.StringVar(&format, "format", "yaml", "")
+	flags.StringVar(&exclude, "exclude", "", "")
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	var err error
+
+	options := meta.Options{}
+
+	if exclude != "" {
+		options.ExcludePaths = strings.Split(exclude, ",")
+	}
+
+	options.Config, err = config.NewProvider(".kres.yaml")
+	if err != nil {
+		c.Ui.Error(err.Error())
+
+		return 1
+	}
+
+	if _, err = auto.Build(&options); err != nil {
+		c.Ui.Error(err.Error())
+
+		return 1
+	}
+
+	dump, err := dumpOptions(&options)
+	if err != nil {
+		c.Ui.Error(err.Error())
+
+		return 1
+	}
+
+	var buf bytes.Buffer
+
+	switch format {
+	case "yaml":
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+
+		err = encoder.Encode(dump)
+	case "json":
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+
+		err = encoder.Encode(dump)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+
+	if err != nil {
+		c.Ui.Error(err.Error())
+
+		return 1
+	}
+
+	c.Ui.Output(strings.TrimSuffix(buf.String(), "\n"))
+
+	for _, warning := range options.Warnings() {
+		c.Ui.Warn(warning)
+	}
+
+	return 0
+}
+
+// redacted replaces values of the options fields tagged with `kres:"secret"`.
+const redacted = "<redacted>"
+
+// dumpOptions converts detected options to a generic map keyed by the field names.
+//
+// Fields tagged with `kres:"-"` are skipped, values of the fields tagged with `kres:"secret"` are redacted.
+func dumpOptions(options *meta.Options) (map[string]interface{}, error) {
+	value := reflect.ValueOf(options).Elem()
+	typ := value.Type()
+
+	dump := map[string]interface{}{}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		switch field.Tag.Get("kres") {
+		case "-":
+			continue
+		case "secret":
+			if !value.Field(i).IsZero() {
+				dump[field.Name] = redacted
+
+				continue
+			}
+		}
+
+		// round-trip via JSON, so that nested structures are keyed by the field names as well
+		raw, err := json.Marshal(value.Field(i).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("error encoding %s: %w", field.Name, err)
+		}
+
+		var v interface{}
+
+		if err = json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("error encoding %s: %w", field.Name, err)
+		}
+
+		dump[field.Name] = v
+	}
+
+	return dump, nil
+}
+
+// NewDetect creates Detect command.
+func NewDetect(m Meta) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &Detect{
+			Meta: m,
+		}, nil
+	}
+}
@@ -5,20 +5,40 @@
 package command
 
 import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/mitchellh/cli"
 
 	"github.com/talos-systems/kres/internal/config"
 	"github.com/talos-systems/kres/internal/output"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
 	"github.com/talos-systems/kres/internal/output/codecov"
+	"github.com/talos-systems/kres/internal/output/codeowners"
+	"github.com/talos-systems/kres/internal/output/community"
+	"github.com/talos-systems/kres/internal/output/conform"
+	"github.com/talos-systems/kres/internal/output/dependabot"
 	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
 	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/editorconfig"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
 	"github.com/talos-systems/kres/internal/output/gitignore"
+	"github.com/talos-systems/kres/internal/output/gitlab"
 	"github.com/talos-systems/kres/internal/output/golangci"
+	"github.com/talos-systems/kres/internal/output/goreleaser"
 	"github.com/talos-systems/kres/internal/output/license"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/precommit"
 	"github.com/talos-systems/kres/internal/output/release"
+	"github.com/talos-systems/kres/internal/output/renovate"
+	"github.com/talos-systems/kres/internal/output/security"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/auto"
 	"github.com/talos-systems/kres/internal/project/meta"
 )
@@ -43,6 +63,36 @@ Usage: kres gen
 Options:
 
 	--outputs=output1,output2           Additional outputs to be generated
+	--ci=drone,github                   CI systems to generate configuration for (drone, gitlab, github, tekton, circleci, buildkite or azure, default: drone)
+	--components=services/foo,...       Directories of the components generated as separate projects (default: repository root)
+	--exclude=examples,hack/*           Paths (globs) excluded from the builds, linting and tests
+	--go-formatter=gofmt                Formatter Go sources are verified with (gofumpt or gofmt, default: gofumpt)
+	--tekton-executor=kaniko            Image build executor for Tekton pipelines (kaniko or buildkit)
+	--gitlab-stages=lint,test,build     Names of the GitLab CI stages lint, test and build jobs are assigned to
+	--workflow-dispatch                 Enable manual 'workflow_dispatch' trigger for GitHub Actions
+	--path-filters                      Skip CI steps if the sources they depend on were not changed
+	--platforms=linux/amd64,linux/arm64 Default platforms to build images for (default: linux/amd64)
+	--workers=N                         Number of outputs generated concurrently (default: number of CPUs)
+	--build-cache=s3                    Remote cache backend for image builds in CI (registry or s3, default: none)
+	--build-cache-ref=bucket            Image repository (registry) or bucket name (s3) of the build cache
+	--build-cache-region=us-east-1      Region of the build cache S3 bucket
+	--build-cache-endpoint=URL          Endpoint of the S3-compatible build cache storage
+	--cosign-key=keyless                Sign release images with cosign (file path, KMS URI or 'keyless')
+	--dependabot-schedule=weekly        Interval of Dependabot updates (daily, weekly or monthly)
+	--dependabot-reviewers=user1,user2  Reviewers assigned to Dependabot pull requests
+	--code-owners=@org/team             Default owners of the project files in .github/CODEOWNERS
+	--directory-owners=dir=@owner       Owners of the directory in .github/CODEOWNERS (might be repeated)
+	--security-contact=EMAIL            Address vulnerabilities are reported to in SECURITY.md
+	--security-overwrite                Replace hand-written SECURITY.md with the generated one
+	--funding=github=user1,user2        Sponsor handles of the platform in .github/FUNDING.yml (might be repeated)
+	--community-overwrite               Replace hand-written FUNDING.yml, issue and pull request templates with the generated ones
+	--editorconfig=GLOB:key=value       Override .editorconfig property for the files matching the glob (might be repeated)
+	--image-group=image=cmd1,cmd2       Build the commands into a single image instead of an image per command (might be repeated)
+	--make-alias=ci=lint,unit-tests     Makefile target running the targets one by one (might be repeated, might redefine all)
+	--variable=NAME=value               Extra variable for the Makefile and CI configuration (might be repeated)
+	--buildkite-agent=tag=value         Buildkite agent tag to target the pipeline steps to (might be repeated)
+	--diff                              Print the diff against the files on disk instead of writing them (fails on changes)
+	--check                             Only report files which are out of date (fails on changes)
 `
 
 	return strings.TrimSpace(helpText)
@@ -55,47 +105,262 @@ func (c *Gen) Synopsis() string {
 
 // Run implements cli.Command.
 func (c *Gen) Run(args []string) int {
-	c.Ui.Info("gen started")
+	var (
+		ci, platforms, cosignKey                string
+		components, exclude                     string
+		tektonExecutor, goFormatter             string
+		gitlabStages                            string
+		buildCache                              meta.BuildCache
+		dependabotSchedule, dependabotReviewers string
+		codeOwners, securityContact             string
+		workflowDispatch, githubActions         bool
+		pathFilters, securityOverwrite          bool
+		communityOverwrite                      bool
+		diff, check                             bool
+		workers                                 int
+		variables                               = variablesFlag{}
+		buildkiteAgents                         = variablesFlag{}
+		directoryOwners                         = ownersFlag{}
+		funding                                 = fundingFlag{}
+		editorConfig                            = editorConfigFlag{}
+		imageGroups                             = imageGroupsFlag{}
+		makeAliases                             = makeAliasesFlag{}
+	)
 
-	outputs := []output.Writer{
-		dockerfile.NewOutput(),
-		makefile.NewOutput(),
-		golangci.NewOutput(),
-		license.NewOutput(),
-		gitignore.NewOutput(),
-		drone.NewOutput(),
-		codecov.NewOutput(),
-		release.NewOutput(),
-	}
+	flags := flag.NewFlagSet("gen", flag.ContinueOnError)
+	flags.StringVar(&ci, "ci", "drone", "")
+	flags.StringVar(&components, "components", ".", "")
+	flags.StringVar(&exclude, "exclude", "", "")
+	flags.StringVar(&tektonExecutor, "tekton-executor", tekton.ExecutorKaniko, "")
+	flags.StringVar(&gitlabStages, "gitlab-stages", "lint,test,build", "")
+	flags.StringVar(&goFormatter, "go-formatter", meta.GoFormatterGofumpt, "")
+	flags.StringVar(&platforms, "platforms", "linux/amd64", "")
+	flags.StringVar(&buildCache.Type, "build-cache", "", "")
+	flags.StringVar(&buildCache.Ref, "build-cache-ref", "", "")
+	flags.StringVar(&buildCache.Region, "build-cache-region", "us-east-1", "")
+	flags.StringVar(&buildCache.Endpoint, "build-cache-endpoint", "", "")
+	flags.StringVar(&cosignKey, "cosign-key", "", "")
+	flags.StringVar(&dependabotSchedule, "dependabot-schedule", "weekly", "")
+	flags.StringVar(&dependabotReviewers, "dependabot-reviewers", "", "")
+	flags.StringVar(&codeOwners, "code-owners", "", "")
+	flags.StringVar(&securityContact, "security-contact", "", "")
+	flags.Var(variables, "variable", "")
+	flags.Var(buildkiteAgents, "buildkite-agent", "")
+	flags.Var(directoryOwners, "directory-owners", "")
+	flags.Var(funding, "funding", "")
+	flags.Var(editorConfig, "editorconfig", "")
+	flags.Var(imageGroups, "image-group", "")
+	flags.Var(makeAliases, "make-alias", "")
+	flags.IntVar(&workers, "workers", 0, "")
+	flags.BoolVar(&workflowDispatch, "workflow-dispatch", false, "")
+	flags.BoolVar(&pathFilters, "path-filters", false, "")
+	flags.BoolVar(&securityOverwrite, "security-overwrite", false, "")
+	flags.BoolVar(&communityOverwrite, "community-overwrite", false, "")
+	flags.BoolVar(&diff, "diff", false, "")
+	flags.BoolVar(&check, "check", false, "")
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
 
-	var err error
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
 
-	options := meta.Options{}
+	switch buildCache.Type {
+	case "":
+	case meta.BuildCacheRegistry, meta.BuildCacheS3:
+		if buildCache.Ref == "" {
+			c.Ui.Error("--build-cache-ref is required with --build-cache")
 
-	options.Config, err = config.NewProvider(".kres.yaml")
-	if err != nil {
-		c.Ui.Error(err.Error())
+			return 1
+		}
+	default:
+		c.Ui.Error(fmt.Sprintf("unsupported build cache type %q", buildCache.Type))
 
 		return 1
 	}
 
-	proj, err := auto.Build(&options)
-	if err != nil {
-		c.Ui.Error(err.Error())
+	if goFormatter != meta.GoFormatterGofumpt && goFormatter != meta.GoFormatterGofmt {
+		c.Ui.Error(fmt.Sprintf("unsupported Go formatter %q", goFormatter))
 
 		return 1
 	}
 
-	if err := proj.LoadConfig(options.Config); err != nil {
-		c.Ui.Error(err.Error())
+	stages := strings.Split(gitlabStages, ",")
+	if len(stages) != 3 || stages[0] == "" || stages[1] == "" || stages[2] == "" {
+		c.Ui.Error(fmt.Sprintf("--gitlab-stages should list lint, test and build stage names, got %q", gitlabStages))
 
 		return 1
 	}
 
-	if err := proj.Compile(outputs); err != nil {
-		c.Ui.Error(err.Error())
+	// S3 credentials are injected from the CI secrets
+	buildCache.AccessKeySecret = "build_cache_access_key_id"
+	buildCache.SecretKeySecret = "build_cache_secret_access_key"
 
-		return 1
+	c.Ui.Info("gen started")
+
+	// repository-level outputs (CI configuration, .github) are generated once at the repository root,
+	// every component is compiled into them with the steps running in the component directory
+	shared := []output.Writer{
+		renovate.NewOutput(),
+		dependabot.NewOutput(),
+		codeowners.NewOutput(),
+		security.NewOutput(),
+		community.NewOutput(),
+		conform.NewOutput(),
+		precommit.NewOutput(),
+	}
+
+	for _, system := range strings.Split(ci, ",") {
+		switch strings.TrimSpace(system) {
+		case "drone":
+			droneOutput := drone.NewOutput()
+			droneOutput.PathFilters = pathFilters
+
+			shared = append(shared, droneOutput)
+		case "gitlab":
+			shared = append(shared, gitlab.NewOutput())
+		case "github":
+			workflow := ghworkflow.NewOutput()
+			workflow.WorkflowDispatch = workflowDispatch
+			workflow.PathFilters = pathFilters
+			githubActions = true
+
+			shared = append(shared, workflow)
+		case "tekton":
+			if tektonExecutor != tekton.ExecutorKaniko && tektonExecutor != tekton.ExecutorBuildKit {
+				c.Ui.Error(fmt.Sprintf("unsupported Tekton executor %q", tektonExecutor))
+
+				return 1
+			}
+
+			pipeline := tekton.NewOutput()
+			pipeline.Executor = tektonExecutor
+
+			shared = append(shared, pipeline)
+		case "circleci":
+			shared = append(shared, circleci.NewOutput())
+		case "buildkite":
+			shared = append(shared, buildkite.NewOutput())
+		case "azure":
+			shared = append(shared, azurepipelines.NewOutput())
+		default:
+			c.Ui.Error(fmt.Sprintf("unsupported CI system %q", system))
+
+			return 1
+		}
+	}
+
+	// every component is generated as a separate project rooted at the component directory
+	generate := func(root string) ([]output.Writer, error) {
+		outputs := []output.Writer{
+			dockerfile.NewOutput(),
+			dockerignore.NewOutput(),
+			makefile.NewOutput(),
+			golangci.NewOutput(),
+			license.NewOutput(),
+			gitignore.NewOutput(),
+			codecov.NewOutput(),
+			release.NewOutput(),
+			goreleaser.NewOutput(),
+			editorconfig.NewOutput(),
+		}
+
+		var err error
+
+		options := meta.Options{
+			Root: root,
+
+			GitLabStages: meta.GitLabStages{
+				Lint:  stages[0],
+				Test:  stages[1],
+				Build: stages[2],
+			},
+			Platforms: strings.Split(platforms, ","),
+			CosignKey: cosignKey,
+
+			GoFormatter: goFormatter,
+
+			BuildCache: buildCache,
+
+			GitHubActions:      githubActions,
+			DependabotSchedule: dependabotSchedule,
+			ExtraVariables:     variables,
+			BuildkiteAgents:    buildkiteAgents,
+			DirectoryOwners:    directoryOwners,
+			SecurityContact:    securityContact,
+			SecurityOverwrite:  securityOverwrite,
+			Funding:            funding,
+			CommunityOverwrite: communityOverwrite,
+			EditorConfig:       editorConfig,
+			ImageGroups:        imageGroups,
+			MakeAliases:        makeAliases,
+		}
+
+		if dependabotReviewers != "" {
+			options.DependabotReviewers = strings.Split(dependabotReviewers, ",")
+		}
+
+		if codeOwners != "" {
+			options.CodeOwners = strings.Split(codeOwners, ",")
+		}
+
+		if exclude != "" {
+			options.ExcludePaths = strings.Split(exclude, ",")
+		}
+
+		options.Config, err = config.NewProvider(filepath.Join(root, ".kres.yaml"))
+		if err != nil {
+			return nil, err
+		}
+
+		proj, err := auto.Build(&options)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := proj.LoadConfig(options.Config); err != nil {
+			return nil, err
+		}
+
+		proj.Workers = workers
+
+		for _, out := range outputs {
+			out.Root(root)
+		}
+
+		for _, out := range shared {
+			if component, ok := out.(output.ComponentWriter); ok {
+				component.Component(root)
+			}
+		}
+
+		if err := proj.Compile(append(outputs, shared...)); err != nil {
+			return nil, err
+		}
+
+		for _, warning := range options.Warnings() {
+			c.Ui.Warn(warning)
+		}
+
+		return outputs, nil
+	}
+
+	var outputs []output.Writer
+
+	for _, component := range strings.Split(components, ",") {
+		componentOutputs, err := generate(strings.TrimSpace(component))
+		if err != nil {
+			c.Ui.Error(err.Error())
+
+			return 1
+		}
+
+		outputs = append(outputs, componentOutputs...)
+	}
+
+	outputs = append(outputs, shared...)
+
+	if diff || check {
+		return c.diff(outputs, check)
 	}
 
 	for _, out := range outputs {
@@ -111,6 +376,219 @@ func (c *Gen) Run(args []string) int {
 	return 0
 }
 
+// diff reports the changes to the generated files without writing them.
+func (c *Gen) diff(outputs []output.Writer, check bool) int {
+	var changed []string
+
+	for _, out := range outputs {
+		changes, err := out.Diff()
+		if err != nil {
+			c.Ui.Error(err.Error())
+
+			return 1
+		}
+
+		for _, change := range changes {
+			changed = append(changed, change.Filename)
+
+			if !check {
+				c.Ui.Output(strings.TrimSuffix(change.Diff, "\n"))
+			}
+		}
+	}
+
+	if len(changed) > 0 {
+		c.Ui.Error(fmt.Sprintf("generated files are out of date, run 'kres gen': %s", strings.Join(changed, ", ")))
+
+		return 1
+	}
+
+	c.Ui.Info("generated files are up to date")
+
+	return 0
+}
+
+// variablesFlag collects NAME=value pairs.
+type variablesFlag map[string]string
+
+// String implements flag.Value.
+func (f variablesFlag) String() string {
+	pairs := make([]string, 0, len(f))
+
+	for name, value := range f {
+		pairs = append(pairs, name+"="+value)
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value.
+func (f variablesFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("variable should be in NAME=value format: %q", value)
+	}
+
+	f[parts[0]] = parts[1]
+
+	return nil
+}
+
+// ownersFlag collects dir=owner1,owner2 assignments.
+type ownersFlag map[string][]string
+
+// String implements flag.Value.
+func (f ownersFlag) String() string {
+	pairs := make([]string, 0, len(f))
+
+	for dir, owners := range f {
+		pairs = append(pairs, dir+"="+strings.Join(owners, ","))
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, " ")
+}
+
+// Set implements flag.Value.
+func (f ownersFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("directory owners should be in dir=owner1,owner2 format: %q", value)
+	}
+
+	f[parts[0]] = append(f[parts[0]], strings.Split(parts[1], ",")...)
+
+	return nil
+}
+
+// fundingFlag collects platform=handle1,handle2 sponsor handles.
+type fundingFlag map[string][]string
+
+// String implements flag.Value.
+func (f fundingFlag) String() string {
+	pairs := make([]string, 0, len(f))
+
+	for platform, handles := range f {
+		pairs = append(pairs, platform+"="+strings.Join(handles, ","))
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, " ")
+}
+
+// Set implements flag.Value.
+func (f fundingFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("funding should be in platform=handle1,handle2 format: %q", value)
+	}
+
+	f[parts[0]] = append(f[parts[0]], strings.Split(parts[1], ",")...)
+
+	return nil
+}
+
+// imageGroupsFlag collects image=cmd1,cmd2 groups.
+type imageGroupsFlag map[string][]string
+
+// String implements flag.Value.
+func (f imageGroupsFlag) String() string {
+	pairs := make([]string, 0, len(f))
+
+	for image, commands := range f {
+		pairs = append(pairs, image+"="+strings.Join(commands, ","))
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, " ")
+}
+
+// Set implements flag.Value.
+func (f imageGroupsFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("image group should be in image=cmd1,cmd2 format: %q", value)
+	}
+
+	f[parts[0]] = append(f[parts[0]], strings.Split(parts[1], ",")...)
+
+	return nil
+}
+
+// makeAliasesFlag collects alias=target1,target2 Makefile aliases.
+type makeAliasesFlag map[string][]string
+
+// String implements flag.Value.
+func (f makeAliasesFlag) String() string {
+	pairs := make([]string, 0, len(f))
+
+	for alias, targets := range f {
+		pairs = append(pairs, alias+"="+strings.Join(targets, ","))
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, " ")
+}
+
+// Set implements flag.Value.
+func (f makeAliasesFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("make alias should be in alias=target1,target2 format: %q", value)
+	}
+
+	f[parts[0]] = append(f[parts[0]], strings.Split(parts[1], ",")...)
+
+	return nil
+}
+
+// editorConfigFlag collects GLOB:key=value overrides.
+type editorConfigFlag map[string]map[string]string
+
+// String implements flag.Value.
+func (f editorConfigFlag) String() string {
+	pairs := []string{}
+
+	for glob, properties := range f {
+		for key, value := range properties {
+			pairs = append(pairs, glob+":"+key+"="+value)
+		}
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, " ")
+}
+
+// Set implements flag.Value.
+func (f editorConfigFlag) Set(value string) error {
+	idx := strings.LastIndex(value, ":")
+	if idx <= 0 {
+		return fmt.Errorf("editorconfig override should be in GLOB:key=value format: %q", value)
+	}
+
+	glob := value[:idx]
+
+	parts := strings.SplitN(value[idx+1:], "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("editorconfig override should be in GLOB:key=value format: %q", value)
+	}
+
+	if f[glob] == nil {
+		f[glob] = map[string]string{}
+	}
+
+	f[glob][parts[0]] = parts[1]
+
+	return nil
+}
+
 // NewGen creates Gen command.
 func NewGen(m Meta) cli.CommandFactory {
 	return func() (cli.Command, error) {
@@ -0,0 +1,87 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package command_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/talos-systems/kres/cmd/kres/command"
+)
+
+func TestGenComponents(t *testing.T) {
+	dir := t.TempDir()
+
+	components := []string{"services/foo", "services/bar"}
+
+	for _, component := range components {
+		writeFile(t, filepath.Join(dir, component, "go.mod"), "module example.com/"+component+"\n\ngo 1.17\n")
+		writeFile(t, filepath.Join(dir, component, "main.go"), "package main\n\nfunc main() {}\n")
+	}
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	require.NoError(t, os.Chdir(dir))
+
+	t.Cleanup(func() {
+		require.NoError(t, os.Chdir(wd))
+	})
+
+	ui := cli.NewMockUi()
+	gen := &command.Gen{Meta: command.Meta{Ui: ui}}
+
+	require.Equal(t, 0, gen.Run([]string{
+		"--components=services/foo,services/bar",
+		"--ci=drone,github",
+		"--code-owners=@org/team",
+	}), ui.ErrorWriter.String())
+
+	// repository-level files are generated once at the root
+	repoFiles := []string{".drone.yml", ".github/workflows/ci.yaml", ".github/CODEOWNERS"}
+
+	for _, name := range repoFiles {
+		assert.FileExists(t, name)
+	}
+
+	for _, component := range components {
+		for _, name := range []string{"Makefile", "Dockerfile", ".dockerignore"} {
+			assert.FileExists(t, filepath.Join(component, name))
+		}
+
+		for _, name := range repoFiles {
+			assert.NoFileExists(t, filepath.Join(component, name))
+		}
+	}
+
+	drone, err := ioutil.ReadFile(".drone.yml")
+	require.NoError(t, err)
+
+	workflow, err := ioutil.ReadFile(".github/workflows/ci.yaml")
+	require.NoError(t, err)
+
+	// every component has its own steps running in the component directory
+	for _, prefix := range []string{"services-foo", "services-bar"} {
+		assert.Contains(t, string(drone), "name: "+prefix+"-base\n")
+	}
+
+	for _, component := range components {
+		assert.Contains(t, string(drone), "- cd "+component+"\n")
+		assert.Contains(t, string(workflow), "working-directory: "+component+"\n")
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0o644))
+}
@@ -0,0 +1,247 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package command
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/talos-systems/kres/internal/config"
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/project/auto"
+	"github.com/talos-systems/kres/internal/project/common"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+const defaultMainTemplate = `{{ if .Header }}{{ .Header }}
+
+{{ end }}// Package main implements {{ .Name }}.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "{{ .Name }}: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	return nil
+}
+`
+
+var commandNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Scaffold implements 'scaffold' command.
+type Scaffold struct {
+	Meta
+}
+
+// Help implements cli.Command.
+func (c *Scaffold) Help() string {
+	helpText := `
+Usage: kres scaffold [options] <name> [-- gen options]
+
+	Create a new command cmd/<name> with a minimal main.go and regenerate build instructions,
+	so that the command gets its own build and image targets.
+
+	Options after '--' are passed to 'kres gen'.
+
+Options:
+
+	--template=main.go.tmpl             Go template of main.go (fields: .Name, .Package, .Header)
+	--module=.                          Directory of the Go module to create the command in
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis implements cli.Command.
+func (c *Scaffold) Synopsis() string {
+	return "Create a new command and regenerate build instructions."
+}
+
+// Run implements cli.Command.
+func (c *Scaffold) Run(args []string) int {
+	var templatePath, module string
+
+	flags := flag.NewFlagSet("scaffold", flag.ContinueOnError)
+	flags.StringVar(&templatePath, "template", "", "")
+	flags.StringVar(&module, "module", ".", "")
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if flags.NArg() == 0 {
+		c.Ui.Error("command name is required")
+
+		return 1
+	}
+
+	name, genArgs := flags.Arg(0), flags.Args()[1:]
+
+	if len(genArgs) > 0 && genArgs[0] == "--" {
+		genArgs = genArgs[1:]
+	}
+
+	if !commandNameRegexp.MatchString(name) {
+		c.Ui.Error(fmt.Sprintf("invalid command name %q", name))
+
+		return 1
+	}
+
+	dir := filepath.Join(module, "cmd", name)
+
+	if _, err := os.Stat(dir); err == nil {
+		c.Ui.Error(fmt.Sprintf("command directory %q already exists", dir))
+
+		return 1
+	}
+
+	contents, err := c.render(templatePath, name, module)
+	if err != nil {
+		c.Ui.Error(err.Error())
+
+		return 1
+	}
+
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		c.Ui.Error(err.Error())
+
+		return 1
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(dir, "main.go"), contents, 0o644); err != nil {
+		c.Ui.Error(err.Error())
+
+		return 1
+	}
+
+	c.Ui.Info(fmt.Sprintf("created %s", filepath.Join(dir, "main.go")))
+
+	// commands are detected from cmd/ directories, so the build is regenerated from scratch
+	gen := &Gen{Meta: c.Meta}
+
+	return gen.Run(genArgs)
+}
+
+// render executes main.go template for the command.
+func (c *Scaffold) render(templatePath, name, module string) ([]byte, error) {
+	text := defaultMainTemplate
+
+	if templatePath != "" {
+		contents, err := ioutil.ReadFile(templatePath)
+		if err != nil {
+			return nil, err
+		}
+
+		text = string(contents)
+	}
+
+	tmpl, err := template.New("main.go").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template: %w", err)
+	}
+
+	options, header, err := c.detect()
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg string
+
+	for _, goModule := range options.GoModules {
+		if path.Clean(goModule.Directory) == path.Clean(filepath.ToSlash(module)) {
+			pkg = path.Join(goModule.CanonicalPath, "cmd", name)
+		}
+	}
+
+	if pkg == "" {
+		return nil, fmt.Errorf("no Go module in %q", module)
+	}
+
+	var buf bytes.Buffer
+
+	if err = tmpl.Execute(&buf, struct {
+		Name    string
+		Package string
+		Header  string
+	}{
+		Name:    name,
+		Package: pkg,
+		Header:  header,
+	}); err != nil {
+		return nil, fmt.Errorf("error executing template: %w", err)
+	}
+
+	contents, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("template produced invalid Go source: %w", err)
+	}
+
+	return contents, nil
+}
+
+// detect runs project detection, returning the options and the license header of Go files (if any).
+func (c *Scaffold) detect() (*meta.Options, string, error) {
+	var err error
+
+	options := &meta.Options{}
+
+	options.Config, err = config.NewProvider(".kres.yaml")
+	if err != nil {
+		return nil, "", err
+	}
+
+	proj, err := auto.Build(options)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err = proj.LoadConfig(options.Config); err != nil {
+		return nil, "", err
+	}
+
+	var header string
+
+	if err = dag.Walk(proj, func(node dag.Node) error {
+		if license, ok := node.(*common.LicenseHeader); ok {
+			header = license.Comment("*.go")
+		}
+
+		return nil
+	}, nil); err != nil {
+		return nil, "", err
+	}
+
+	return options, header, nil
+}
+
+// NewScaffold creates Scaffold command.
+func NewScaffold(m Meta) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &Scaffold{
+			Meta: m,
+		}, nil
+	}
+}
@@ -0,0 +1,59 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// Validate implements 'validate' command.
+type Validate struct {
+	Meta
+}
+
+// Help implements cli.Command.
+func (c *Validate) Help() string {
+	helpText := `
+Usage: kres validate [options]
+
+	Regenerate build instructions in memory and compare them with the files on disk
+	(every file emitted by 'kres gen': Makefile, Dockerfile, CI configuration, .golangci.yml, etc.),
+	nothing is written. Exits with non-zero code listing the files which are out of date,
+	e.g. to verify in CI that 'kres gen' was run after the config changes.
+
+	Generation timestamp in the preamble of the files is ignored.
+
+Options:
+
+	Options are the same as for 'kres gen', they should match the options the files were generated with.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis implements cli.Command.
+func (c *Validate) Synopsis() string {
+	return "Verify generated files are up to date."
+}
+
+// Run implements cli.Command.
+func (c *Validate) Run(args []string) int {
+	gen := &Gen{
+		Meta: c.Meta,
+	}
+
+	return gen.Run(append(append([]string(nil), args...), "--check"))
+}
+
+// NewValidate creates Validate command.
+func NewValidate(m Meta) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &Validate{
+			Meta: m,
+		}, nil
+	}
+}
@@ -36,8 +36,12 @@ func realMain() int {
 	c := cli.NewCLI(version.Name, version.Tag)
 	c.Args = os.Args[1:]
 	c.Commands = map[string]cli.CommandFactory{
-		"gen":     command.NewGen(meta),
-		"version": command.NewVersion(meta),
+		"dag":      command.NewDag(meta),
+		"detect":   command.NewDetect(meta),
+		"gen":      command.NewGen(meta),
+		"scaffold": command.NewScaffold(meta),
+		"validate": command.NewValidate(meta),
+		"version":  command.NewVersion(meta),
 	}
 	c.HelpWriter = os.Stdout
 	c.ErrorWriter = os.Stderr
@@ -68,6 +68,21 @@ func NewProvider(path string) (*Provider, error) {
 	return provider, nil
 }
 
+// Documents returns config documents of the kind in the order specified.
+//
+// Documents are used to instantiate objects which are declared in the config only.
+func (provider *Provider) Documents(kind string) []Document {
+	var docs []Document
+
+	for _, doc := range provider.docs {
+		if doc.Kind == kind {
+			docs = append(docs, doc)
+		}
+	}
+
+	return docs
+}
+
 // Load config into passed object.
 //
 // All the matching configs are loaded in the order specified.
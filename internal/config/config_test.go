@@ -68,3 +68,15 @@ func TestLoad(t *testing.T) {
 	err = provider.Load(&noSpec)
 	assert.EqualError(t, err, "missing spec for config block config_test.Foo/NoSpec")
 }
+
+func TestDocuments(t *testing.T) {
+	provider, err := config.NewProvider("testdata/.kres.yaml")
+	assert.NoError(t, err)
+
+	docs := provider.Documents("config_test.Foo")
+	assert.Len(t, docs, 5)
+	assert.Equal(t, "Bar", docs[0].Name)
+	assert.Equal(t, "", docs[1].Name)
+
+	assert.Empty(t, provider.Documents("config_test.Baz"))
+}
@@ -45,6 +45,13 @@ func (node *BaseNode) AddInput(input ...Node) {
 	node.inputs = append(node.inputs, input...)
 }
 
+// SourcePaths implements Node interface.
+//
+// By default node doesn't consume any sources directly.
+func (node *BaseNode) SourcePaths() []string {
+	return nil
+}
+
 // BaseGraph implements core functionality of DAG.
 //
 // BaseGraph is designed to be embedded into other types.
@@ -0,0 +1,131 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package dag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DumpDot renders the graph reachable from the nodes in Graphviz DOT format.
+//
+// Nodes are labeled with the name and the type, edges go from the input to the node.
+// Nodes and edges which are part of a cycle are highlighted in red.
+func DumpDot(nodes []Node) string {
+	var ordered []Node
+
+	ids := make(map[Node]int)
+
+	walk(nodes, func(node Node) error { //nolint: errcheck
+		ids[node] = len(ordered)
+		ordered = append(ordered, node)
+
+		return nil
+	}, make(map[Node]struct{}))
+
+	components := stronglyConnectedComponents(ordered)
+
+	// edge within the strongly connected component is a part of a cycle
+	inCycle := func(from, to Node) bool {
+		return components[from] == components[to]
+	}
+
+	var b strings.Builder
+
+	b.WriteString("digraph kres {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box];\n")
+
+	for _, node := range ordered {
+		attrs := fmt.Sprintf("label=%q", fmt.Sprintf("%s\n%T", node.Name(), node))
+
+		for _, input := range node.Inputs() {
+			if inCycle(node, input) {
+				attrs += ", color=red"
+
+				break
+			}
+		}
+
+		fmt.Fprintf(&b, "\tn%d [%s];\n", ids[node], attrs)
+	}
+
+	for _, node := range ordered {
+		for _, input := range node.Inputs() {
+			attrs := ""
+
+			if inCycle(node, input) {
+				attrs = " [color=red]"
+			}
+
+			fmt.Fprintf(&b, "\tn%d -> n%d%s;\n", ids[input], ids[node], attrs)
+		}
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// stronglyConnectedComponents assigns component index to every node (Tarjan's algorithm).
+func stronglyConnectedComponents(nodes []Node) map[Node]int {
+	var (
+		index, component int
+		stack            []Node
+		connect          func(node Node)
+	)
+
+	indices := make(map[Node]int)
+	lowlinks := make(map[Node]int)
+	onStack := make(map[Node]bool)
+	components := make(map[Node]int)
+
+	connect = func(node Node) {
+		indices[node] = index
+		lowlinks[node] = index
+		index++
+
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, input := range node.Inputs() {
+			if _, visited := indices[input]; !visited {
+				connect(input)
+
+				if lowlinks[input] < lowlinks[node] {
+					lowlinks[node] = lowlinks[input]
+				}
+			} else if onStack[input] && indices[input] < lowlinks[node] {
+				lowlinks[node] = indices[input]
+			}
+		}
+
+		if lowlinks[node] != indices[node] {
+			return
+		}
+
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+
+			components[top] = component
+
+			if top == node {
+				break
+			}
+		}
+
+		component++
+	}
+
+	for _, node := range nodes {
+		if _, visited := indices[node]; !visited {
+			connect(node)
+		}
+	}
+
+	return components
+}
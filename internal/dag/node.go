@@ -6,6 +6,7 @@ package dag
 
 import (
 	"reflect"
+	"sort"
 )
 
 // Node in directed acyclic graph, recording parent nodes as inputs.
@@ -14,6 +15,9 @@ type Node interface {
 	Inputs() []Node
 	InputNames() []string
 	AddInput(...Node)
+
+	// SourcePaths returns paths (globs) of the source files the node consumes directly.
+	SourcePaths() []string
 }
 
 // NodeCondition checks the node for a specific condition.
@@ -47,3 +51,31 @@ func GatherMatchingInputNames(node Node, condition NodeCondition) []string {
 
 	return result
 }
+
+// GatherSourcePaths returns sorted list of source paths consumed by the node and all of its inputs.
+//
+// Empty result means that the node doesn't depend on any specific source paths.
+func GatherSourcePaths(node Node) []string {
+	paths := map[string]struct{}{}
+
+	collect := func(node Node) error {
+		for _, path := range node.SourcePaths() {
+			paths[path] = struct{}{}
+		}
+
+		return nil
+	}
+
+	collect(node)                //nolint: errcheck
+	WalkNode(node, collect, nil) //nolint: errcheck
+
+	result := make([]string, 0, len(paths))
+
+	for path := range paths {
+		result = append(result, path)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
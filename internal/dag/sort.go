@@ -0,0 +1,71 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package dag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TopologicalSort returns all the nodes of the graph so that every node comes after its inputs.
+//
+// Order is the same as the order of the nodes visited by Walk, so it is deterministic.
+// Cycles in the graph are reported as errors.
+func TopologicalSort(graph Graph) ([]Node, error) {
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	var (
+		sorted []Node
+		path   []Node
+		visit  func(node Node) error
+	)
+
+	state := make(map[Node]int)
+
+	visit = func(node Node) error {
+		switch state[node] {
+		case visited:
+			return nil
+		case visiting:
+			names := []string{}
+
+			for i := len(path) - 1; i >= 0; i-- {
+				names = append([]string{path[i].Name()}, names...)
+
+				if path[i] == node {
+					break
+				}
+			}
+
+			return fmt.Errorf("cycle detected: %s -> %s", strings.Join(names, " -> "), node.Name())
+		}
+
+		state[node] = visiting
+		path = append(path, node)
+
+		for _, input := range node.Inputs() {
+			if err := visit(input); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[node] = visited
+		sorted = append(sorted, node)
+
+		return nil
+	}
+
+	for _, target := range graph.Targets() {
+		if err := visit(target); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
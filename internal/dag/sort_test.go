@@ -0,0 +1,84 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package dag_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/talos-systems/kres/internal/dag"
+)
+
+type node struct {
+	dag.BaseNode
+}
+
+func newNode(name string, inputs ...dag.Node) *node {
+	n := &node{
+		BaseNode: dag.NewBaseNode(name),
+	}
+
+	n.AddInput(inputs...)
+
+	return n
+}
+
+func names(nodes []dag.Node) []string {
+	result := make([]string, 0, len(nodes))
+
+	for _, n := range nodes {
+		result = append(result, n.Name())
+	}
+
+	return result
+}
+
+func TestTopologicalSort(t *testing.T) {
+	toolchain := newNode("toolchain")
+	lint := newNode("lint", toolchain)
+	unitTests := newNode("unit-tests", toolchain)
+	build := newNode("build", toolchain, lint)
+	image := newNode("image", build, unitTests)
+
+	var graph dag.BaseGraph
+
+	graph.AddTarget(image, lint)
+
+	nodes, err := dag.TopologicalSort(&graph)
+	require.NoError(t, err)
+
+	// every node comes after its inputs, shared inputs are returned once
+	assert.Equal(t, []string{"toolchain", "lint", "build", "unit-tests", "image"}, names(nodes))
+
+	// order is the same as the Walk order
+	var walked []dag.Node
+
+	require.NoError(t, dag.Walk(&graph, func(n dag.Node) error {
+		walked = append(walked, n)
+
+		return nil
+	}, nil))
+
+	assert.Equal(t, walked, nodes)
+}
+
+func TestTopologicalSortCycle(t *testing.T) {
+	toolchain := newNode("toolchain")
+	lint := newNode("lint", toolchain)
+	build := newNode("build", lint)
+
+	toolchain.AddInput(build)
+
+	var graph dag.BaseGraph
+
+	graph.AddTarget(build)
+
+	_, err := dag.TopologicalSort(&graph)
+	require.Error(t, err)
+
+	assert.EqualError(t, err, "cycle detected: build -> lint -> toolchain -> build")
+}
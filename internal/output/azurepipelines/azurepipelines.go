@@ -0,0 +1,294 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package azurepipelines implements output to Azure Pipelines.
+package azurepipelines
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = "azure-pipelines.yml"
+
+	// container is the name of the build container resource.
+	container = "build"
+
+	// buildxCache is the path of the buildx layer cache on the agent.
+	buildxCache = "$(Pipeline.Workspace)/buildx-cache"
+
+	// buildxCacheArgs are the default CI_ARGS, jobs with the remote build cache override them.
+	buildxCacheArgs = "--cache-from=type=local,src=" + buildxCache + " --cache-to=type=local,dest=" + buildxCache + "-new,mode=max"
+)
+
+// Output implements Azure Pipelines generation.
+//
+// Every node is a stage with a single container job running the make target, the Docker socket
+// of the agent is mounted into the build container, as targets are built with docker buildx.
+type Output struct {
+	output.FileAdapter
+	output.ComponentAdapter
+
+	jobs []*Job
+
+	env map[string]string
+
+	gitLFS bool
+
+	// DefaultBranch is the branch stages publishing `latest` artifacts run on.
+	DefaultBranch  string
+	BuildContainer string
+	VMImage        string
+}
+
+// NewOutput creates new Azure Pipelines output.
+func NewOutput() *Output {
+	output := &Output{
+		DefaultBranch:  "master",
+		BuildContainer: "autonomy/build-container:latest",
+		VMImage:        "ubuntu-latest",
+	}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Job appends a stage running the job to the pipeline.
+//
+// Jobs of the monorepo components are renamed and run the target in the component directory.
+func (o *Output) Job(job *Job) {
+	job.name, job.dependsOn = o.ComponentStep(job.name, job.dependsOn)
+
+	if dir := o.ComponentDir(); dir != "." {
+		job.workingDirectory = dir
+	}
+
+	o.jobs = append(o.jobs, job)
+}
+
+// Environment sets a pipeline variable.
+func (o *Output) Environment(name, value string) {
+	if o.env == nil {
+		o.env = make(map[string]string)
+	}
+
+	o.env[name] = value
+}
+
+// GitLFS pulls Git LFS objects on checkout.
+func (o *Output) GitLFS() {
+	o.gitLFS = true
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileAzurePipelines(o)
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.pipeline(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) pipeline(w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	// jobs might depend on nodes which don't produce stages (e.g. toolchain), skip them in dependsOn
+	stageNames := make(map[string]struct{}, len(o.jobs))
+
+	for _, job := range o.jobs {
+		stageNames[identifier(job.name)] = struct{}{}
+	}
+
+	stages := make([]stageSpec, 0, len(o.jobs))
+
+	for _, job := range o.jobs {
+		stages = append(stages, job.compile(o, stageNames))
+	}
+
+	variables := make(map[string]string, len(o.env)+1)
+
+	variables["CI_ARGS"] = buildxCacheArgs
+
+	for name, value := range o.env {
+		variables[name] = value
+	}
+
+	pipeline := pipelineSpec{
+		Trigger: triggerSpec{
+			Branches: filterSpec{Include: []string{o.DefaultBranch}},
+			Tags:     &filterSpec{Include: []string{"v*"}},
+		},
+		PR: triggerSpec{
+			Branches: filterSpec{Include: []string{"*"}},
+		},
+		Pool: poolSpec{
+			VMImage: o.VMImage,
+		},
+		Resources: resourcesSpec{
+			Containers: []containerSpec{
+				{
+					Container: container,
+					Image:     o.BuildContainer,
+					Options:   "-v /var/run/docker.sock:/var/run/docker.sock",
+				},
+			},
+		},
+		Stages: stages,
+	}
+
+	if len(variables) > 0 {
+		pipeline.Variables = variables
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(pipeline); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// steps returns steps of the job: checkout, buildx setup and buildx layer cache around the job steps.
+//
+// Builds run in buildx, so the layers are exported to the local cache of the stage (CI_ARGS),
+// which is saved with the `Cache@2` task keyed on the commit.
+func (o *Output) steps(job []interface{}) []interface{} {
+	checkout := map[string]interface{}{
+		"checkout": "self",
+	}
+
+	if o.gitLFS {
+		checkout["lfs"] = true
+	}
+
+	steps := []interface{}{
+		checkout,
+		scriptSpec{
+			Script:      "docker buildx create --driver docker-container --name local --use && docker buildx inspect --bootstrap",
+			DisplayName: "set up buildx",
+		},
+		taskSpec{
+			Task:        "Cache@2",
+			DisplayName: "cache buildx layers",
+			Inputs: map[string]string{
+				"key":         `buildx | "$(Agent.OS)" | "$(System.StageName)" | "$(Build.SourceVersion)"`,
+				"restoreKeys": `buildx | "$(Agent.OS)" | "$(System.StageName)"`,
+				"path":        buildxCache,
+			},
+		},
+	}
+
+	steps = append(steps, job...)
+
+	// cache exports don't remove stale layers, so the cache is replaced with the new export
+	return append(steps, scriptSpec{
+		Script:      fmt.Sprintf("if [ -d %[1]s-new ]; then rm -rf %[1]s && mv %[1]s-new %[1]s; fi", buildxCache),
+		DisplayName: "rotate buildx cache",
+	})
+}
+
+// identifier converts the name to the stage (job) identifier, which might only contain letters, numbers and underscores.
+func identifier(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+type pipelineSpec struct {
+	Trigger   triggerSpec       `yaml:"trigger"`
+	PR        triggerSpec       `yaml:"pr"`
+	Pool      poolSpec          `yaml:"pool"`
+	Variables map[string]string `yaml:"variables,omitempty"`
+	Resources resourcesSpec     `yaml:"resources"`
+	Stages    []stageSpec       `yaml:"stages"`
+}
+
+type triggerSpec struct {
+	Branches filterSpec  `yaml:"branches"`
+	Tags     *filterSpec `yaml:"tags,omitempty"`
+}
+
+type filterSpec struct {
+	Include []string `yaml:"include"`
+}
+
+type poolSpec struct {
+	VMImage string `yaml:"vmImage"`
+}
+
+type resourcesSpec struct {
+	Containers []containerSpec `yaml:"containers"`
+}
+
+type containerSpec struct {
+	Container string `yaml:"container"`
+	Image     string `yaml:"image"`
+	Options   string `yaml:"options"`
+}
+
+type stageSpec struct {
+	Stage       string `yaml:"stage"`
+	DisplayName string `yaml:"displayName"`
+	// DependsOn is always set, as stages depend on the previous stage by default.
+	DependsOn []string  `yaml:"dependsOn"`
+	Condition string    `yaml:"condition,omitempty"`
+	Jobs      []jobSpec `yaml:"jobs"`
+}
+
+type jobSpec struct {
+	Job       string        `yaml:"job"`
+	Container string        `yaml:"container"`
+	Steps     []interface{} `yaml:"steps"`
+}
+
+type scriptSpec struct {
+	Script           string            `yaml:"script"`
+	DisplayName      string            `yaml:"displayName"`
+	WorkingDirectory string            `yaml:"workingDirectory,omitempty"`
+	Env              map[string]string `yaml:"env,omitempty"`
+}
+
+type taskSpec struct {
+	Task        string            `yaml:"task"`
+	DisplayName string            `yaml:"displayName"`
+	Inputs      map[string]string `yaml:"inputs"`
+}
+
+// Compiler is implemented by project blocks which support Azure Pipelines generation.
+type Compiler interface {
+	CompileAzurePipelines(*Output) error
+}
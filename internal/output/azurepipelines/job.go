@@ -0,0 +1,161 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package azurepipelines
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Conditions of the stages.
+const (
+	conditionTag    = "and(succeeded(), startsWith(variables['Build.SourceBranch'], 'refs/tags/'))"
+	conditionBranch = "and(succeeded(), eq(variables['Build.SourceBranch'], 'refs/heads/%s'))"
+)
+
+// Job is an Azure Pipelines job which calls make target.
+type Job struct {
+	name string
+
+	target    string
+	before    []string
+	dependsOn []string
+	env       map[string]string
+
+	// workingDirectory is the directory of the monorepo component the target runs in.
+	workingDirectory string
+
+	preSteps []interface{}
+
+	onlyOnTag bool
+	branch    string
+}
+
+// MakeJob creates a job which calls make target.
+func MakeJob(target string, args ...string) *Job {
+	return &Job{
+		name:   target,
+		target: strings.TrimSpace(fmt.Sprintf("make %s %s", target, strings.Join(args, " "))),
+		env:    make(map[string]string),
+	}
+}
+
+// Name provides a name to a job.
+func (job *Job) Name(name string) *Job {
+	job.name = name
+
+	return job
+}
+
+// Environment appends an environment variable to the job.
+func (job *Job) Environment(name, value string) *Job {
+	job.env[name] = value
+
+	return job
+}
+
+// EnvironmentFromSecret passes the pipeline (secret) variable to the job under a different name.
+//
+// Secret variables are not exposed to the scripts unless mapped explicitly.
+func (job *Job) EnvironmentFromSecret(name, variable string) *Job {
+	job.env[name] = fmt.Sprintf("$(%s)", variable)
+
+	return job
+}
+
+// BeforeCommands runs the commands before the target, in the same script, so that the job environment is available.
+func (job *Job) BeforeCommands(commands ...string) *Job {
+	job.before = append(job.before, commands...)
+
+	return job
+}
+
+// DependsOn sets the jobs which should succeed before the job runs.
+func (job *Job) DependsOn(names ...string) *Job {
+	job.dependsOn = append(job.dependsOn, names...)
+
+	return job
+}
+
+// OnlyOnTag runs the job only on tags.
+func (job *Job) OnlyOnTag() *Job {
+	job.onlyOnTag = true
+
+	return job
+}
+
+// OnlyOnBranch runs the job only on the specified branch.
+func (job *Job) OnlyOnBranch(branch string) *Job {
+	job.branch = branch
+
+	return job
+}
+
+// DockerLogin sets up login to the registry.
+//
+// Credentials are taken from the DOCKER_USERNAME and DOCKER_PASSWORD pipeline variables.
+func (job *Job) DockerLogin() *Job {
+	return job.dockerLogin("login to registry", "", "DOCKER_USERNAME", "DOCKER_PASSWORD")
+}
+
+// DockerLoginRegistry sets up login to the specified registry with credentials from the pipeline variables.
+func (job *Job) DockerLoginRegistry(registry, usernameVariable, passwordVariable string) *Job {
+	return job.dockerLogin(fmt.Sprintf("login to %s", registry), registry, usernameVariable, passwordVariable)
+}
+
+func (job *Job) dockerLogin(displayName, registry, usernameVariable, passwordVariable string) *Job {
+	job.preSteps = append(job.preSteps, scriptSpec{
+		Script:      strings.TrimSpace(fmt.Sprintf(`echo "${REGISTRY_PASSWORD}" | docker login --username "${REGISTRY_USERNAME}" --password-stdin %s`, registry)),
+		DisplayName: displayName,
+		Env: map[string]string{
+			"REGISTRY_USERNAME": fmt.Sprintf("$(%s)", usernameVariable),
+			"REGISTRY_PASSWORD": fmt.Sprintf("$(%s)", passwordVariable),
+		},
+	})
+
+	return job
+}
+
+func (job *Job) compile(o *Output, stageNames map[string]struct{}) stageSpec {
+	dependsOn := []string{}
+
+	for _, name := range job.dependsOn {
+		if _, ok := stageNames[identifier(name)]; ok {
+			dependsOn = append(dependsOn, identifier(name))
+		}
+	}
+
+	run := scriptSpec{
+		Script:           strings.Join(append(append([]string(nil), job.before...), job.target), " && "),
+		DisplayName:      job.name,
+		WorkingDirectory: job.workingDirectory,
+	}
+
+	if len(job.env) > 0 {
+		run.Env = job.env
+	}
+
+	spec := stageSpec{
+		Stage:       identifier(job.name),
+		DisplayName: job.name,
+		DependsOn:   dependsOn,
+		Jobs: []jobSpec{
+			{
+				Job:       identifier(job.name),
+				Container: container,
+				Steps:     o.steps(append(append([]interface{}(nil), job.preSteps...), run)),
+			},
+		},
+	}
+
+	switch {
+	case job.onlyOnTag:
+		spec.Condition = conditionTag
+	case job.branch != "":
+		spec.Condition = fmt.Sprintf(conditionBranch, job.branch)
+	}
+
+	return spec
+}
@@ -0,0 +1,181 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package buildkite implements output to Buildkite pipeline.
+package buildkite
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = ".buildkite/pipeline.yml"
+
+	dockerPlugin = "docker#v5.3.0"
+)
+
+// Output implements Buildkite pipeline generation.
+//
+// Every step runs make target in the build container via the Docker plugin, the Docker socket
+// of the agent is mounted into the container.
+type Output struct {
+	output.FileAdapter
+	output.ComponentAdapter
+
+	steps []*Step
+
+	env    map[string]string
+	agents map[string]string
+
+	gitLFS bool
+
+	// DefaultBranch is the branch steps publishing `latest` artifacts run on.
+	DefaultBranch  string
+	BuildContainer string
+}
+
+// NewOutput creates new Buildkite pipeline output.
+func NewOutput() *Output {
+	output := &Output{
+		DefaultBranch:  "master",
+		BuildContainer: "autonomy/build-container:latest",
+	}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Step appends a step to the pipeline.
+//
+// Steps of the monorepo components are renamed and run the commands in the component directory,
+// artifact paths are relative to the repository root.
+func (o *Output) Step(step *Step) {
+	step.key, step.dependsOn = o.ComponentStep(step.key, step.dependsOn)
+
+	if dir := o.ComponentDir(); dir != "." {
+		step.commands = append([]string{"cd " + dir}, step.commands...)
+
+		for i := range step.artifactPaths {
+			step.artifactPaths[i] = o.ComponentPath(step.artifactPaths[i])
+		}
+	}
+
+	o.steps = append(o.steps, step)
+}
+
+// Environment sets an environment variable for all the steps.
+func (o *Output) Environment(name, value string) {
+	if o.env == nil {
+		o.env = make(map[string]string)
+	}
+
+	o.env[name] = value
+}
+
+// Agent sets the agent tag (e.g. `queue`) the steps are targeted at.
+func (o *Output) Agent(tag, value string) {
+	if o.agents == nil {
+		o.agents = make(map[string]string)
+	}
+
+	o.agents[tag] = value
+}
+
+// GitLFS pulls Git LFS objects before every step.
+func (o *Output) GitLFS() {
+	o.gitLFS = true
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileBuildkite(o)
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.pipeline(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) pipeline(w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	// steps might depend on nodes which don't produce steps (e.g. toolchain), skip them in depends_on
+	keys := make(map[string]struct{}, len(o.steps))
+
+	for _, step := range o.steps {
+		keys[step.key] = struct{}{}
+	}
+
+	steps := make([]stepSpec, 0, len(o.steps))
+
+	for _, step := range o.steps {
+		steps = append(steps, step.compile(o, keys))
+	}
+
+	pipeline := struct {
+		Env   map[string]string `yaml:"env,omitempty"`
+		Steps []stepSpec        `yaml:"steps"`
+	}{
+		Env:   o.env,
+		Steps: steps,
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(pipeline); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+type stepSpec struct {
+	Label         string                        `yaml:"label"`
+	Key           string                        `yaml:"key"`
+	Commands      []string                      `yaml:"commands"`
+	DependsOn     []string                      `yaml:"depends_on,omitempty"`
+	If            string                        `yaml:"if,omitempty"`
+	Branches      string                        `yaml:"branches,omitempty"`
+	Agents        map[string]string             `yaml:"agents,omitempty"`
+	Env           map[string]string             `yaml:"env,omitempty"`
+	ArtifactPaths []string                      `yaml:"artifact_paths,omitempty"`
+	Plugins       []map[string]dockerPluginSpec `yaml:"plugins"`
+}
+
+type dockerPluginSpec struct {
+	Image                string   `yaml:"image"`
+	AlwaysPull           bool     `yaml:"always-pull"`
+	PropagateEnvironment bool     `yaml:"propagate-environment"`
+	Environment          []string `yaml:"environment,omitempty"`
+	Volumes              []string `yaml:"volumes"`
+}
+
+// Compiler is implemented by project blocks which support Buildkite pipeline generation.
+type Compiler interface {
+	CompileBuildkite(*Output) error
+}
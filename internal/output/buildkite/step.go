@@ -0,0 +1,182 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package buildkite
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Step is a Buildkite step which calls make target.
+type Step struct {
+	key string
+
+	commands      []string
+	dependsOn     []string
+	env           map[string]string
+	secrets       []string
+	assignments   []string
+	artifactPaths []string
+
+	onlyOnTag bool
+	branch    string
+}
+
+// MakeStep creates a step which calls make target.
+func MakeStep(target string, args ...string) *Step {
+	return &Step{
+		key:      target,
+		commands: []string{strings.TrimSpace(fmt.Sprintf("make %s %s", target, strings.Join(args, " ")))},
+		env:      make(map[string]string),
+	}
+}
+
+// Name provides a name (key) to a step.
+func (step *Step) Name(name string) *Step {
+	step.key = name
+
+	return step
+}
+
+// Environment appends an environment variable to the step.
+func (step *Step) Environment(name, value string) *Step {
+	step.env[name] = value
+
+	return step
+}
+
+// EnvironmentFromSecret passes the agent environment variable (secret) into the build container.
+func (step *Step) EnvironmentFromSecret(name string) *Step {
+	step.secrets = append(step.secrets, name)
+
+	return step
+}
+
+// EnvironmentFromVariable passes the agent environment variable to the target under a different name.
+func (step *Step) EnvironmentFromVariable(name, variable string) *Step {
+	step.assignments = append(step.assignments, fmt.Sprintf(`%s="$${%s}"`, name, variable))
+	step.secrets = append(step.secrets, variable)
+
+	return step
+}
+
+// BeforeCommands prepends commands to the step.
+func (step *Step) BeforeCommands(commands ...string) *Step {
+	step.commands = append(append([]string(nil), commands...), step.commands...)
+
+	return step
+}
+
+// DependsOn sets the steps which should succeed before the step runs.
+func (step *Step) DependsOn(keys ...string) *Step {
+	step.dependsOn = append(step.dependsOn, keys...)
+
+	return step
+}
+
+// ArtifactPaths uploads the files produced by the step as build artifacts.
+func (step *Step) ArtifactPaths(paths ...string) *Step {
+	step.artifactPaths = append(step.artifactPaths, paths...)
+
+	return step
+}
+
+// OnlyOnTag runs the step only on tag builds.
+func (step *Step) OnlyOnTag() *Step {
+	step.onlyOnTag = true
+
+	return step
+}
+
+// OnlyOnBranch runs the step only on the builds of the branch.
+func (step *Step) OnlyOnBranch(branch string) *Step {
+	step.branch = branch
+
+	return step
+}
+
+// DockerLogin logs in to the registry before running the target.
+//
+// Credentials are taken from the DOCKER_USERNAME and DOCKER_PASSWORD environment variables of the agent.
+func (step *Step) DockerLogin() *Step {
+	return step.DockerLoginRegistry("", "DOCKER_USERNAME", "DOCKER_PASSWORD")
+}
+
+// DockerLoginRegistry logs in to the specified registry with credentials from the agent environment variables.
+func (step *Step) DockerLoginRegistry(registry, usernameVariable, passwordVariable string) *Step {
+	// $$ escapes interpolation at pipeline upload time, so that the variables are expanded in the container
+	step.commands = append([]string{
+		strings.TrimSpace(fmt.Sprintf(`echo "$${%s}" | docker login --username "$${%s}" --password-stdin %s`, passwordVariable, usernameVariable, registry)),
+	}, step.commands...)
+
+	step.secrets = append(step.secrets, usernameVariable, passwordVariable)
+
+	return step
+}
+
+func (step *Step) compile(o *Output, keys map[string]struct{}) stepSpec {
+	env := make(map[string]string, len(step.env))
+
+	for name, value := range step.env {
+		env[name] = value
+	}
+
+	dependsOn := []string{}
+
+	for _, key := range step.dependsOn {
+		if _, ok := keys[key]; ok {
+			dependsOn = append(dependsOn, key)
+		}
+	}
+
+	commands := append([]string(nil), step.commands...)
+
+	// make target is the last command
+	if len(step.assignments) > 0 {
+		commands[len(commands)-1] = strings.Join(append(append([]string(nil), step.assignments...), commands[len(commands)-1]), " ")
+	}
+
+	if o.gitLFS {
+		commands = append([]string{"git lfs pull"}, commands...)
+	}
+
+	secrets := append([]string(nil), step.secrets...)
+	sort.Strings(secrets)
+
+	spec := stepSpec{
+		Label:         step.key,
+		Key:           step.key,
+		Commands:      commands,
+		DependsOn:     dependsOn,
+		Agents:        o.agents,
+		ArtifactPaths: step.artifactPaths,
+		Plugins: []map[string]dockerPluginSpec{
+			{
+				dockerPlugin: {
+					Image:                o.BuildContainer,
+					AlwaysPull:           true,
+					PropagateEnvironment: true,
+					Environment:          secrets,
+					Volumes:              []string{"/var/run/docker.sock:/var/run/docker.sock"},
+				},
+			},
+		},
+	}
+
+	if len(env) > 0 {
+		spec.Env = env
+	}
+
+	switch {
+	case step.onlyOnTag:
+		spec.If = "build.tag != null"
+	case step.branch != "":
+		spec.Branches = step.branch
+		spec.If = "build.tag == null"
+	}
+
+	return spec
+}
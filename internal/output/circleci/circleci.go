@@ -0,0 +1,271 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package circleci implements output to CircleCI config.
+package circleci
+
+import (
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = ".circleci/config.yml"
+
+	// tagFilter matches release tags.
+	tagFilter = "/^v.*/"
+)
+
+// Output implements CircleCI config generation.
+//
+// Every job runs make target in the Docker executor with the remote Docker engine,
+// the workflow runs jobs in the order of their dependencies.
+type Output struct {
+	output.FileAdapter
+	output.ComponentAdapter
+
+	jobs []*Job
+
+	env map[string]string
+
+	gitLFS bool
+
+	// Workflow is the name of the workflow running the jobs.
+	Workflow string
+	// DefaultBranch is the branch jobs publishing `latest` artifacts run on.
+	DefaultBranch  string
+	BuildContainer string
+	DockerVersion  string
+}
+
+// NewOutput creates new CircleCI config output.
+func NewOutput() *Output {
+	output := &Output{
+		Workflow:       "default",
+		DefaultBranch:  "master",
+		BuildContainer: "autonomy/build-container:latest",
+		DockerVersion:  "20.10.14",
+	}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Job appends a job to the workflow.
+//
+// Jobs of the monorepo components are renamed and run the target in the component directory.
+func (o *Output) Job(job *Job) {
+	job.name, job.requires = o.ComponentStep(job.name, job.requires)
+
+	if dir := o.ComponentDir(); dir != "." {
+		job.workingDirectory = dir
+	}
+
+	o.jobs = append(o.jobs, job)
+}
+
+// Environment sets an environment variable for all the jobs.
+func (o *Output) Environment(name, value string) {
+	if o.env == nil {
+		o.env = make(map[string]string)
+	}
+
+	o.env[name] = value
+}
+
+// GitLFS pulls Git LFS objects after the checkout in every job.
+func (o *Output) GitLFS() {
+	o.gitLFS = true
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileCircleCI(o)
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.config(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) config(w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	// jobs might depend on nodes which don't produce jobs (e.g. toolchain), skip them in requires
+	jobNames := make(map[string]struct{}, len(o.jobs))
+
+	for _, job := range o.jobs {
+		jobNames[job.name] = struct{}{}
+	}
+
+	jobs := &yaml.Node{
+		Kind: yaml.MappingNode,
+	}
+
+	workflowJobs := make([]map[string]workflowJobSpec, 0, len(o.jobs))
+
+	for _, job := range o.jobs {
+		var spec yaml.Node
+
+		if err := spec.Encode(job.compile(o)); err != nil {
+			return err
+		}
+
+		jobs.Content = append(jobs.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: job.name}, &spec)
+
+		workflowJobs = append(workflowJobs, map[string]workflowJobSpec{
+			job.name: job.workflowJob(jobNames),
+		})
+	}
+
+	config := struct {
+		Version   string                  `yaml:"version"`
+		Jobs      *yaml.Node              `yaml:"jobs"`
+		Workflows map[string]workflowSpec `yaml:"workflows"`
+	}{
+		Version: "2.1",
+		Jobs:    jobs,
+		Workflows: map[string]workflowSpec{
+			o.Workflow: {
+				Jobs: workflowJobs,
+			},
+		},
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(config); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// steps returns steps of the job: checkout, remote Docker and buildx layer cache around the job steps.
+//
+// Builds run in buildx, so the layers are exported to the local cache of the job (CI_ARGS),
+// which is saved as CircleCI cache keyed on the revision.
+func (o *Output) steps(job []interface{}) []interface{} {
+	steps := []interface{}{
+		"checkout",
+	}
+
+	if o.gitLFS {
+		steps = append(steps, map[string]interface{}{
+			"run": map[string]string{
+				"name":    "pull LFS objects",
+				"command": "git lfs pull",
+			},
+		})
+	}
+
+	steps = append(steps,
+		map[string]interface{}{
+			"setup_remote_docker": map[string]string{
+				"version": o.DockerVersion,
+			},
+		},
+		map[string]interface{}{
+			"run": map[string]string{
+				"name":    "set up buildx",
+				"command": "docker buildx create --driver docker-container --name local --use && docker buildx inspect --bootstrap",
+			},
+		},
+		map[string]interface{}{
+			"restore_cache": map[string][]string{
+				"keys": {cacheKey, cacheKeyPrefix},
+			},
+		},
+	)
+
+	steps = append(steps, job...)
+
+	steps = append(steps,
+		map[string]interface{}{
+			"run": map[string]string{
+				"name": "rotate buildx cache",
+				// cache exports don't remove stale layers, so the cache is replaced with the new export
+				"command": fmt.Sprintf("if [ -d %[1]s-new ]; then rm -rf %[1]s && mv %[1]s-new %[1]s; fi", buildxCache),
+				"when":    "always",
+			},
+		},
+		map[string]interface{}{
+			"save_cache": map[string]interface{}{
+				"key":   cacheKey,
+				"paths": []string{buildxCache},
+				"when":  "always",
+			},
+		},
+	)
+
+	return steps
+}
+
+const (
+	buildxCache = "/tmp/buildx-cache"
+
+	cacheKeyPrefix = `buildx-v1-{{ .Environment.CIRCLE_JOB }}-`
+	cacheKey       = cacheKeyPrefix + `{{ .Revision }}`
+
+	// buildxCacheArgs are the default CI_ARGS, jobs with the remote build cache override them.
+	buildxCacheArgs = "--cache-from=type=local,src=" + buildxCache + " --cache-to=type=local,dest=" + buildxCache + "-new,mode=max"
+)
+
+type dockerSpec struct {
+	Image string `yaml:"image"`
+}
+
+type jobSpec struct {
+	Docker      []dockerSpec      `yaml:"docker"`
+	Environment map[string]string `yaml:"environment,omitempty"`
+	Steps       []interface{}     `yaml:"steps"`
+}
+
+type workflowSpec struct {
+	Jobs []map[string]workflowJobSpec `yaml:"jobs"`
+}
+
+type workflowJobSpec struct {
+	Requires []string    `yaml:"requires,omitempty"`
+	Filters  filtersSpec `yaml:"filters"`
+}
+
+type filtersSpec struct {
+	Branches *filterSpec `yaml:"branches,omitempty"`
+	Tags     *filterSpec `yaml:"tags,omitempty"`
+}
+
+type filterSpec struct {
+	Only   string `yaml:"only,omitempty"`
+	Ignore string `yaml:"ignore,omitempty"`
+}
+
+// Compiler is implemented by project blocks which support CircleCI config generation.
+type Compiler interface {
+	CompileCircleCI(*Output) error
+}
@@ -0,0 +1,189 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package circleci
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Job is a CircleCI job which calls make target.
+type Job struct {
+	name string
+
+	target   string
+	requires []string
+	env      map[string]string
+	secrets  []string
+	before   []string
+
+	// workingDirectory is the directory of the monorepo component the target runs in.
+	workingDirectory string
+
+	preSteps []interface{}
+
+	onlyOnTag bool
+	branch    string
+}
+
+// MakeJob creates a job which calls make target.
+func MakeJob(target string, args ...string) *Job {
+	return &Job{
+		name:   target,
+		target: strings.TrimSpace(fmt.Sprintf("make %s %s", target, strings.Join(args, " "))),
+		env:    make(map[string]string),
+	}
+}
+
+// Name provides a name to a job.
+func (job *Job) Name(name string) *Job {
+	job.name = name
+
+	return job
+}
+
+// Environment appends an environment variable to the job.
+func (job *Job) Environment(name, value string) *Job {
+	job.env[name] = value
+
+	return job
+}
+
+// EnvironmentFromSecret passes the project environment variable to the job under a different name.
+func (job *Job) EnvironmentFromSecret(name, variable string) *Job {
+	job.secrets = append(job.secrets, fmt.Sprintf(`%s="${%s}"`, name, variable))
+
+	return job
+}
+
+// BeforeCommands runs the commands before the target, in the same shell and working directory.
+func (job *Job) BeforeCommands(commands ...string) *Job {
+	job.before = append(job.before, commands...)
+
+	return job
+}
+
+// Requires sets the jobs which should succeed before the job runs.
+func (job *Job) Requires(requires ...string) *Job {
+	job.requires = append(job.requires, requires...)
+
+	return job
+}
+
+// OnlyOnTag runs the job only on release tags.
+func (job *Job) OnlyOnTag() *Job {
+	job.onlyOnTag = true
+
+	return job
+}
+
+// OnlyOnBranch runs the job only on the specified branch.
+func (job *Job) OnlyOnBranch(branch string) *Job {
+	job.branch = branch
+
+	return job
+}
+
+// DockerLogin sets up login to the registry.
+//
+// Credentials are taken from the DOCKER_USERNAME and DOCKER_PASSWORD environment variables
+// of the project (or context).
+func (job *Job) DockerLogin() *Job {
+	job.preSteps = append(job.preSteps, map[string]interface{}{
+		"run": map[string]string{
+			"name":    "login to registry",
+			"command": `echo "${DOCKER_PASSWORD}" | docker login --username "${DOCKER_USERNAME}" --password-stdin`,
+		},
+	})
+
+	return job
+}
+
+// DockerLoginRegistry sets up login to the specified registry with credentials from the environment variables.
+func (job *Job) DockerLoginRegistry(registry, usernameVariable, passwordVariable string) *Job {
+	job.preSteps = append(job.preSteps, map[string]interface{}{
+		"run": map[string]string{
+			"name":    fmt.Sprintf("login to %s", registry),
+			"command": fmt.Sprintf(`echo "${%s}" | docker login --username "${%s}" --password-stdin %s`, passwordVariable, usernameVariable, registry),
+		},
+	})
+
+	return job
+}
+
+func (job *Job) compile(o *Output) jobSpec {
+	env := make(map[string]string, len(o.env)+len(job.env)+1)
+
+	env["CI_ARGS"] = buildxCacheArgs
+
+	for name, value := range o.env {
+		env[name] = value
+	}
+
+	for name, value := range job.env {
+		env[name] = value
+	}
+
+	command := map[string]string{
+		"name":    job.name,
+		"command": strings.Join(append(append([]string(nil), job.before...), strings.Join(append(append([]string(nil), job.secrets...), job.target), " ")), " && "),
+	}
+
+	if job.workingDirectory != "" {
+		command["working_directory"] = job.workingDirectory
+	}
+
+	run := map[string]interface{}{
+		"run": command,
+	}
+
+	spec := jobSpec{
+		Docker: []dockerSpec{
+			{
+				Image: o.BuildContainer,
+			},
+		},
+		Steps: o.steps(append(append([]interface{}(nil), job.preSteps...), run)),
+	}
+
+	if len(env) > 0 {
+		spec.Environment = env
+	}
+
+	return spec
+}
+
+// workflowJob returns the job entry of the workflow.
+//
+// CircleCI doesn't run jobs on tags unless tags filter is set, and jobs required by the tag jobs
+// should run on tags as well, so every job matches release tags.
+func (job *Job) workflowJob(jobNames map[string]struct{}) workflowJobSpec {
+	requires := []string{}
+
+	for _, require := range job.requires {
+		if _, ok := jobNames[require]; ok {
+			requires = append(requires, require)
+		}
+	}
+
+	spec := workflowJobSpec{
+		Requires: requires,
+		Filters: filtersSpec{
+			Tags: &filterSpec{
+				Only: tagFilter,
+			},
+		},
+	}
+
+	switch {
+	case job.onlyOnTag:
+		spec.Filters.Branches = &filterSpec{Ignore: "/.*/"}
+	case job.branch != "":
+		spec.Filters.Branches = &filterSpec{Only: job.branch}
+		spec.Filters.Tags = nil
+	}
+
+	return spec
+}
@@ -0,0 +1,196 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package codeowners implements output to .github/CODEOWNERS.
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = ".github/CODEOWNERS"
+
+	// sentinel separates generated rules from the rules added manually.
+	//
+	// Everything below the sentinel is preserved on regeneration.
+	sentinel = "# Rules below this line are preserved by kres."
+)
+
+// Output implements .github/CODEOWNERS generation.
+type Output struct {
+	output.FileAdapter
+	output.ComponentAdapter
+
+	enabled bool
+
+	defaultOwners []string
+	rules         map[string][]string
+}
+
+// NewOutput creates new .github/CODEOWNERS output.
+func NewOutput() *Output {
+	output := &Output{
+		rules: map[string][]string{},
+	}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileCodeOwners(o)
+}
+
+// Enable should be called to enable config generation.
+func (o *Output) Enable() {
+	o.enabled = true
+}
+
+// DefaultOwners sets owners of all the files not matched by other rules.
+func (o *Output) DefaultOwners(owners ...string) *Output {
+	o.defaultOwners = append([]string(nil), owners...)
+
+	return o
+}
+
+// Rule sets owners of the files matching the pattern.
+//
+// Patterns of the monorepo components are limited to the component directory.
+func (o *Output) Rule(pattern string, owners ...string) *Output {
+	if o.ComponentDir() != "." {
+		if strings.HasPrefix(pattern, "/") {
+			pattern = "/" + o.ComponentPath(strings.TrimPrefix(pattern, "/"))
+		} else {
+			pattern = "/" + o.ComponentPath("**/"+pattern)
+		}
+	}
+
+	o.rules[pattern] = append([]string(nil), owners...)
+
+	return o
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	if !o.enabled {
+		return nil
+	}
+
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.codeowners(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) codeowners(w io.Writer) error {
+	// rules added to the file manually are preserved
+	manual, err := o.manualRules()
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	if len(o.defaultOwners) > 0 {
+		if _, err = fmt.Fprintf(w, "* %s\n", strings.Join(o.defaultOwners, " ")); err != nil {
+			return err
+		}
+	}
+
+	patterns := make([]string, 0, len(o.rules))
+
+	for pattern := range o.rules {
+		patterns = append(patterns, pattern)
+	}
+
+	// last matching rule takes precedence, sorting puts parent directories before their subdirectories
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		if len(o.rules[pattern]) == 0 {
+			continue
+		}
+
+		if _, err = fmt.Fprintf(w, "%s %s\n", pattern, strings.Join(o.rules[pattern], " ")); err != nil {
+			return err
+		}
+	}
+
+	if _, err = fmt.Fprintf(w, "\n%s\n", sentinel); err != nil {
+		return err
+	}
+
+	for _, line := range manual {
+		if _, err = fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *Output) manualRules() ([]string, error) {
+	f, err := os.Open(o.Path(filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	var (
+		lines []string
+		found bool
+	)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if found {
+			lines = append(lines, scanner.Text())
+
+			continue
+		}
+
+		found = strings.TrimSpace(scanner.Text()) == sentinel
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", filename, err)
+	}
+
+	return lines, nil
+}
+
+// Compiler is implemented by project blocks which support .github/CODEOWNERS generate.
+type Compiler interface {
+	CompileCodeOwners(*Output) error
+}
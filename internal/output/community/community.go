@@ -0,0 +1,247 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package community implements output to the GitHub community health files: FUNDING.yml, issue and pull request templates.
+package community
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	fundingFilename             = ".github/FUNDING.yml"
+	issueTemplateDir            = ".github/ISSUE_TEMPLATE"
+	pullRequestTemplateFilename = ".github/pull_request_template.md"
+
+	// generatedMarker is a part of the preamble identifying files generated by kres.
+	generatedMarker = "THIS FILE WAS AUTOMATICALLY GENERATED"
+)
+
+// IssueTemplate is a Markdown issue template.
+type IssueTemplate struct {
+	// Name of the template shown in the template chooser.
+	Name string `yaml:"name"`
+	// About is the description of the template shown in the template chooser.
+	About string `yaml:"about"`
+	// Title is the default title of the issue.
+	Title string `yaml:"title"`
+	// Labels are added to the issues created from the template.
+	Labels []string `yaml:"labels"`
+	// Body is the Markdown body of the issue.
+	Body string `yaml:"body"`
+}
+
+// Output implements community health files generation.
+//
+// Every file is generated only if it's configured, hand-written files (without the kres preamble)
+// are not overwritten unless Overwrite is called.
+type Output struct {
+	output.FileAdapter
+
+	overwrite bool
+
+	funding             map[string][]string
+	issueTemplates      map[string]IssueTemplate
+	pullRequestTemplate string
+}
+
+// NewOutput creates new community health files output.
+func NewOutput() *Output {
+	output := &Output{
+		issueTemplates: map[string]IssueTemplate{},
+	}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileCommunity(o)
+}
+
+// Overwrite replaces the community health files even if they were written manually.
+func (o *Output) Overwrite() {
+	o.overwrite = true
+}
+
+// Funding enables .github/FUNDING.yml with the sponsor handles per platform (e.g. `github`, `open_collective`).
+func (o *Output) Funding(handles map[string][]string) *Output {
+	o.funding = handles
+
+	return o
+}
+
+// IssueTemplate enables .github/ISSUE_TEMPLATE/<name>.md.
+func (o *Output) IssueTemplate(name string, template IssueTemplate) *Output {
+	o.issueTemplates[name] = template
+
+	return o
+}
+
+// PullRequestTemplate enables .github/pull_request_template.md with the body.
+func (o *Output) PullRequestTemplate(body string) *Output {
+	o.pullRequestTemplate = body
+
+	return o
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	var filenames []string
+
+	if len(o.funding) > 0 {
+		filenames = append(filenames, fundingFilename)
+	}
+
+	names := make([]string, 0, len(o.issueTemplates))
+
+	for name := range o.issueTemplates {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		filenames = append(filenames, path.Join(issueTemplateDir, name+".md"))
+	}
+
+	if o.pullRequestTemplate != "" {
+		filenames = append(filenames, pullRequestTemplateFilename)
+	}
+
+	if o.overwrite {
+		return filenames
+	}
+
+	result := filenames[:0]
+
+	for _, filename := range filenames {
+		if !o.handWritten(filename) {
+			result = append(result, filename)
+		}
+	}
+
+	return result
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch {
+	case filename == fundingFilename:
+		return o.fundingFile(w)
+	case filename == pullRequestTemplateFilename:
+		return o.pullRequestTemplateFile(w)
+	case path.Dir(filename) == issueTemplateDir:
+		template, ok := o.issueTemplates[strings.TrimSuffix(path.Base(filename), ".md")]
+		if !ok {
+			panic("unexpected filename: " + filename)
+		}
+
+		return o.issueTemplateFile(w, template)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+// handWritten checks if the file exists and wasn't generated by kres.
+func (o *Output) handWritten(filename string) bool {
+	contents, err := ioutil.ReadFile(o.Path(filename))
+	if err != nil {
+		// missing (or unreadable) file is generated
+		return !os.IsNotExist(err)
+	}
+
+	return !strings.Contains(string(contents), generatedMarker)
+}
+
+func (o *Output) fundingFile(w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	// GitHub accepts a list of handles for `github` only, other platforms take a single handle (or URLs for `custom`)
+	funding := map[string]interface{}{}
+
+	for platform, handles := range o.funding {
+		switch {
+		case platform == "github" || platform == "custom":
+			funding[platform] = handles
+		case len(handles) == 1:
+			funding[platform] = handles[0]
+		default:
+			return fmt.Errorf("funding: platform %q supports a single handle, got %v", platform, handles)
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(funding); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// issueTemplateFile writes the template with the front matter, preamble is a part of the front matter,
+// as GitHub requires the front matter to start the file.
+func (o *Output) issueTemplateFile(w io.Writer, template IssueTemplate) error {
+	if template.Name == "" || template.About == "" {
+		return fmt.Errorf("issue templates require name and about")
+	}
+
+	frontMatter := struct {
+		Name   string `yaml:"name"`
+		About  string `yaml:"about"`
+		Title  string `yaml:"title,omitempty"`
+		Labels string `yaml:"labels,omitempty"`
+	}{
+		Name:   template.Name,
+		About:  template.About,
+		Title:  template.Title,
+		Labels: strings.Join(template.Labels, ", "),
+	}
+
+	marshaled, err := yaml.Marshal(frontMatter)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "---\n%s%s---\n\n%s\n", output.Preamble("# "), marshaled, strings.TrimSpace(template.Body))
+
+	return err
+}
+
+func (o *Output) pullRequestTemplateFile(w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("<!-- ", " -->"))); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%s\n", strings.TrimSpace(o.pullRequestTemplate))
+
+	return err
+}
+
+// Compiler is implemented by project blocks which support community health files generation.
+type Compiler interface {
+	CompileCommunity(*Output) error
+}
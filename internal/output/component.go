@@ -0,0 +1,96 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package output
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ComponentWriter is implemented by the outputs generated once at the repository root (CI configuration, .github).
+//
+// Every component of a monorepo is compiled into the same output, Component is called with the directory
+// of the component (relative to the repository root) before the component is compiled.
+type ComponentWriter interface {
+	Component(dir string)
+}
+
+// ComponentAdapter keeps track of the component being compiled.
+//
+// Outputs embedding ComponentAdapter rename CI steps (jobs) of the components outside of the repository root,
+// so that the names are unique across the components, and run them in the component directory.
+type ComponentAdapter struct {
+	dir string
+
+	// names of the steps of the component (before renaming)
+	names map[string]struct{}
+}
+
+// Component implements ComponentWriter.
+func (adapter *ComponentAdapter) Component(dir string) {
+	adapter.dir = path.Clean(filepath.ToSlash(dir))
+	adapter.names = nil
+}
+
+// ComponentDir returns the directory of the component, "." for the repository root.
+func (adapter *ComponentAdapter) ComponentDir() string {
+	if adapter.dir == "" {
+		return "."
+	}
+
+	return adapter.dir
+}
+
+// ComponentPath converts the path relative to the component directory to the path relative to the repository root.
+func (adapter *ComponentAdapter) ComponentPath(p string) string {
+	if adapter.ComponentDir() == "." {
+		return p
+	}
+
+	joined := path.Join(adapter.dir, filepath.ToSlash(p))
+
+	// trailing slash matches only directories in the path patterns
+	if strings.HasSuffix(p, "/") {
+		joined += "/"
+	}
+
+	return joined
+}
+
+// ComponentName prefixes the name with the component directory (e.g. `services-foo-lint`).
+func (adapter *ComponentAdapter) ComponentName(name string) string {
+	if adapter.ComponentDir() == "." {
+		return name
+	}
+
+	return strings.ReplaceAll(adapter.dir, "/", "-") + "-" + name
+}
+
+// ComponentStep registers the step of the component, and returns the step name and dependencies renamed with ComponentName.
+//
+// Only the dependencies on the steps of the same component are renamed, other dependencies (e.g. common setup steps)
+// are kept as is.
+func (adapter *ComponentAdapter) ComponentStep(name string, depends []string) (string, []string) {
+	if adapter.ComponentDir() == "." {
+		return name, depends
+	}
+
+	renamed := append(depends[:0:0], depends...)
+
+	for i, dep := range renamed {
+		if _, ok := adapter.names[dep]; ok {
+			renamed[i] = adapter.ComponentName(dep)
+		}
+	}
+
+	if adapter.names == nil {
+		adapter.names = map[string]struct{}{}
+	}
+
+	adapter.names[name] = struct{}{}
+
+	return adapter.ComponentName(name), renamed
+}
@@ -0,0 +1,282 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package conform implements output to .conform.yaml.
+package conform
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = ".conform.yaml"
+
+	// sentinel separates generated policies from the policies added manually.
+	//
+	// Everything below the sentinel is preserved on regeneration, so manual policies
+	// should be list items of the same indentation as the generated ones.
+	sentinel = "  # Policies below this line are preserved by kres."
+)
+
+// Output implements .conform.yaml generation.
+//
+// conform reads the config at the repository root only, and the policies apply to the whole repository:
+// the commit policy is taken from the first monorepo component which sets it, and identical license
+// policies of the components are merged.
+type Output struct {
+	output.FileAdapter
+	output.ComponentAdapter
+
+	enabled bool
+
+	commit          *commitSpec
+	commitComponent string
+	licenses        []licenseSpec
+}
+
+// NewOutput creates new .conform.yaml output.
+func NewOutput() *Output {
+	output := &Output{}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileConform(o)
+}
+
+// Enable should be called to enable config generation.
+func (o *Output) Enable() {
+	o.enabled = true
+}
+
+// commitPolicy returns the commit policy, or nil if it was set by another component.
+func (o *Output) commitPolicy() *commitSpec {
+	if o.commit == nil {
+		o.commit = &commitSpec{
+			Header: headerSpec{
+				Imperative:            true,
+				Case:                  "lower",
+				InvalidLastCharacters: ".",
+			},
+		}
+		o.commitComponent = o.ComponentDir()
+	}
+
+	if o.commitComponent != o.ComponentDir() {
+		return nil
+	}
+
+	return o.commit
+}
+
+// Conventional enforces conventional commits format with the allowed types and scopes.
+//
+// Scopes are regular expressions.
+func (o *Output) Conventional(types, scopes []string) *Output {
+	if commit := o.commitPolicy(); commit != nil {
+		commit.Conventional = &conventionalSpec{
+			Types:  append([]string(nil), types...),
+			Scopes: append([]string(nil), scopes...),
+		}
+	}
+
+	return o
+}
+
+// HeaderLength sets the maximum length of the commit message header.
+func (o *Output) HeaderLength(length int) *Output {
+	if commit := o.commitPolicy(); commit != nil {
+		commit.Header.Length = length
+	}
+
+	return o
+}
+
+// DCO enforces Developer Certificate of Origin sign-off.
+func (o *Output) DCO(enabled bool) *Output {
+	if commit := o.commitPolicy(); commit != nil {
+		commit.DCO = enabled
+	}
+
+	return o
+}
+
+// LicenseHeader enforces license header in the files with matching suffixes.
+//
+// conform matches the files by suffix across the repository, so the policy is added once for all the components.
+func (o *Output) LicenseHeader(header string, includeSuffixes, excludeSuffixes []string) *Output {
+	license := licenseSpec{
+		SkipPaths:       []string{".git/", "testdata/"},
+		IncludeSuffixes: append([]string(nil), includeSuffixes...),
+		ExcludeSuffixes: append([]string(nil), excludeSuffixes...),
+		Header:          header,
+	}
+
+	for _, existing := range o.licenses {
+		if reflect.DeepEqual(existing, license) {
+			return o
+		}
+	}
+
+	o.licenses = append(o.licenses, license)
+
+	return o
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	if !o.enabled {
+		return nil
+	}
+
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.conform(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) conform(w io.Writer) error {
+	// policies added to the file manually are preserved
+	manual, err := o.manualPolicies()
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	var policies []policySpec
+
+	if o.commit != nil {
+		policies = append(policies, policySpec{Type: "commit", Spec: o.commit})
+	}
+
+	for i := range o.licenses {
+		policies = append(policies, policySpec{Type: "license", Spec: &o.licenses[i]})
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err = encoder.Encode(struct {
+		Policies []policySpec `yaml:"policies"`
+	}{
+		Policies: policies,
+	}); err != nil {
+		return err
+	}
+
+	if err = encoder.Close(); err != nil {
+		return err
+	}
+
+	if _, err = fmt.Fprintln(w, sentinel); err != nil {
+		return err
+	}
+
+	for _, line := range manual {
+		if _, err = fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *Output) manualPolicies() ([]string, error) {
+	f, err := os.Open(o.Path(filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	var (
+		lines []string
+		found bool
+	)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if found {
+			lines = append(lines, scanner.Text())
+
+			continue
+		}
+
+		found = strings.TrimSpace(scanner.Text()) == strings.TrimSpace(sentinel)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", filename, err)
+	}
+
+	return lines, nil
+}
+
+type policySpec struct {
+	Type string      `yaml:"type"`
+	Spec interface{} `yaml:"spec"`
+}
+
+type commitSpec struct {
+	DCO          bool              `yaml:"dco"`
+	Header       headerSpec        `yaml:"header"`
+	Conventional *conventionalSpec `yaml:"conventional,omitempty"`
+}
+
+type headerSpec struct {
+	Length                int    `yaml:"length,omitempty"`
+	Imperative            bool   `yaml:"imperative"`
+	Case                  string `yaml:"case"`
+	InvalidLastCharacters string `yaml:"invalidLastCharacters"`
+}
+
+type conventionalSpec struct {
+	Types  []string `yaml:"types"`
+	Scopes []string `yaml:"scopes"`
+}
+
+type licenseSpec struct {
+	SkipPaths       []string `yaml:"skipPaths"`
+	IncludeSuffixes []string `yaml:"includeSuffixes"`
+	ExcludeSuffixes []string `yaml:"excludeSuffixes,omitempty"`
+	Header          string   `yaml:"header"`
+}
+
+// Compiler is implemented by project blocks which support .conform.yaml generation.
+type Compiler interface {
+	CompileConform(*Output) error
+}
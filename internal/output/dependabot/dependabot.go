@@ -0,0 +1,192 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package dependabot implements output to .github/dependabot.yml.
+package dependabot
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = ".github/dependabot.yml"
+)
+
+// Package ecosystems.
+const (
+	EcosystemGoMod         = "gomod"
+	EcosystemDocker        = "docker"
+	EcosystemGitHubActions = "github-actions"
+)
+
+// Output implements .github/dependabot.yml generation.
+type Output struct {
+	output.FileAdapter
+	output.ComponentAdapter
+
+	enabled bool
+
+	updates []Update
+}
+
+// Update is a dependabot update entry.
+type Update struct {
+	PackageEcosystem string   `yaml:"package-ecosystem"`
+	Directory        string   `yaml:"directory"`
+	Schedule         Schedule `yaml:"schedule"`
+	Reviewers        []string `yaml:"reviewers,omitempty"`
+}
+
+// Schedule is a dependabot update schedule.
+type Schedule struct {
+	Interval string `yaml:"interval"`
+}
+
+// NewOutput creates new .github/dependabot.yml output.
+func NewOutput() *Output {
+	output := &Output{}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileDependabot(o)
+}
+
+// Enable should be called to enable config generation.
+func (o *Output) Enable() {
+	o.enabled = true
+}
+
+// Update appends an update entry.
+//
+// Directories of the monorepo components are relative to the repository root, GitHub Actions workflows
+// are always at the repository root, so the entry is added once.
+func (o *Output) Update(update Update) *Output {
+	if update.PackageEcosystem != EcosystemGitHubActions {
+		update.Directory = "/" + o.ComponentPath(strings.TrimPrefix(update.Directory, "/"))
+	}
+
+	for _, existing := range o.updates {
+		if existing.PackageEcosystem == update.PackageEcosystem && existing.Directory == update.Directory {
+			return o
+		}
+	}
+
+	o.updates = append(o.updates, update)
+
+	return o
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	if !o.enabled {
+		return nil
+	}
+
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.config(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) config(w io.Writer) error {
+	updates := make([]interface{}, 0, len(o.updates))
+
+	generated := map[string]struct{}{}
+
+	for _, update := range o.updates {
+		updates = append(updates, update)
+
+		generated[update.PackageEcosystem+":"+update.Directory] = struct{}{}
+	}
+
+	// entries added to the file manually are preserved
+	existing, err := o.existingUpdates()
+	if err != nil {
+		return err
+	}
+
+	for _, update := range existing {
+		ecosystem, _ := update["package-ecosystem"].(string)
+		directory, _ := update["directory"].(string)
+
+		if _, ok := generated[ecosystem+":"+directory]; ok {
+			continue
+		}
+
+		updates = append(updates, update)
+	}
+
+	if _, err = w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	config := struct {
+		Version int           `yaml:"version"`
+		Updates []interface{} `yaml:"updates"`
+	}{
+		Version: 2,
+		Updates: updates,
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err = enc.Encode(config); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+func (o *Output) existingUpdates() ([]map[string]interface{}, error) {
+	contents, err := ioutil.ReadFile(o.Path(filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var config struct {
+		Updates []map[string]interface{} `yaml:"updates"`
+	}
+
+	if err = yaml.Unmarshal(contents, &config); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", filename, err)
+	}
+
+	return config.Updates, nil
+}
+
+// Compiler is implemented by project blocks which support .github/dependabot.yml generate.
+type Compiler interface {
+	CompileDependabot(*Output) error
+}
@@ -0,0 +1,176 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around the changes in the unified diff.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// UnifiedDiff returns unified diff between the lines of from and to files.
+//
+// Empty string is returned if there are no differences.
+func UnifiedDiff(fromName, toName string, from, to []string) string {
+	ops := diffLines(from, to)
+
+	var (
+		b            strings.Builder
+		fromN, toN   int
+		fromAt, toAt = make([]int, len(ops)+1), make([]int, len(ops)+1)
+	)
+
+	// line numbers in from and to before each op
+	for i, op := range ops {
+		fromAt[i], toAt[i] = fromN, toN
+
+		if op.kind != '+' {
+			fromN++
+		}
+
+		if op.kind != '-' {
+			toN++
+		}
+	}
+
+	fromAt[len(ops)], toAt[len(ops)] = fromN, toN
+
+	for i := 0; i < len(ops); {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+
+		if i == len(ops) {
+			break
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+
+		// hunk is extended while changes are close enough to share the context
+		end := i
+
+		for {
+			for end < len(ops) && ops[end].kind != ' ' {
+				end++
+			}
+
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+
+			if next == len(ops) || next-end > 2*diffContext {
+				end += diffContext
+				if end > len(ops) {
+					end = len(ops)
+				}
+
+				break
+			}
+
+			end = next
+		}
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+		}
+
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			hunkRange(fromAt[start], fromAt[end]-fromAt[start]),
+			hunkRange(toAt[start], toAt[end]-toAt[start]),
+		)
+
+		for _, op := range ops[start:end] {
+			fmt.Fprintf(&b, "%c%s\n", op.kind, op.line)
+		}
+
+		i = end
+	}
+
+	return b.String()
+}
+
+// hunkRange formats the range of the hunk, empty ranges refer to the line before.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// diffLines computes the edit script based on the longest common subsequence.
+func diffLines(from, to []string) []diffOp {
+	lcs := make([][]int, len(from)+1)
+
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			switch {
+			case from[i] == to[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(from)+len(to))
+
+	i, j := 0, 0
+
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			ops = append(ops, diffOp{' ', from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', from[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', to[j]})
+			j++
+		}
+	}
+
+	for ; i < len(from); i++ {
+		ops = append(ops, diffOp{'-', from[i]})
+	}
+
+	for ; j < len(to); j++ {
+		ops = append(ops, diffOp{'+', to[j]})
+	}
+
+	return ops
+}
+
+// splitLines splits contents into lines, trailing newline doesn't produce an empty line.
+func splitLines(contents []byte) []string {
+	if len(contents) == 0 {
+		return nil
+	}
+
+	return strings.Split(string(bytes.TrimSuffix(contents, []byte("\n"))), "\n")
+}
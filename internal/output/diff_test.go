@@ -0,0 +1,79 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package output_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		from, to []string
+		expected string
+	}{
+		{
+			name:     "equal",
+			from:     []string{"a", "b"},
+			to:       []string{"a", "b"},
+			expected: "",
+		},
+		{
+			name: "new file",
+			to:   []string{"a", "b"},
+			expected: `--- a
++++ b
+@@ -0,0 +1,2 @@
++a
++b
+`,
+		},
+		{
+			name: "change in the middle",
+			from: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"},
+			to:   []string{"1", "2", "3", "4", "x", "6", "7", "8", "9"},
+			expected: `--- a
++++ b
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++x
+ 6
+ 7
+ 8
+`,
+		},
+		{
+			name: "separate hunks",
+			from: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"},
+			to:   []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"},
+			expected: `--- a
++++ b
+@@ -1,3 +1,4 @@
++0
+ 1
+ 2
+ 3
+@@ -7,4 +8,3 @@
+ 7
+ 8
+ 9
+-10
+`,
+		},
+	} {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, output.UnifiedDiff("a", "b", tt.from, tt.to))
+		})
+	}
+}
@@ -17,19 +17,24 @@ import (
 )
 
 const (
-	dockerfile   = "Dockerfile"
-	dockerignore = ".dockerignore"
-	syntax       = "docker/dockerfile-upstream:1.1.7-experimental"
+	dockerfile = "Dockerfile"
+	syntax     = "docker/dockerfile-upstream:1.1.7-experimental"
 )
 
-// Output implements Dockerfile and .dockerignore generation.
+// Output implements Dockerfile generation.
 type Output struct {
 	output.FileAdapter
 
 	args   []*step.ArgStep
 	stages map[string]*Stage
 
-	allowedLocalPaths []string
+	duplicateStages []string
+	stageAppends    []stageAppend
+}
+
+type stageAppend struct {
+	stage string
+	steps []step.Step
 }
 
 // NewOutput creates new dockerfile output.
@@ -54,7 +59,7 @@ func (o *Output) Compile(node interface{}) error {
 
 // Filenames implements output.FileWriter interface.
 func (o *Output) Filenames() []string {
-	return []string{dockerfile, dockerignore}
+	return []string{dockerfile}
 }
 
 // GenerateFile implements output.FileWriter interface.
@@ -62,8 +67,6 @@ func (o *Output) GenerateFile(filename string, w io.Writer) error {
 	switch filename {
 	case dockerfile:
 		return o.dockerfile(w)
-	case dockerignore:
-		return o.dockerignore(w)
 	default:
 		panic("unexpected filename: " + filename)
 	}
@@ -77,26 +80,50 @@ func (o *Output) Stage(name string) *Stage {
 		o.stages = make(map[string]*Stage)
 	}
 
+	if _, exists := o.stages[name]; exists {
+		o.duplicateStages = append(o.duplicateStages, name)
+	}
+
 	o.stages[name] = stage
 
 	return stage
 }
 
-// Arg appends new arg.
-func (o *Output) Arg(arg *step.ArgStep) *Output {
-	o.args = append(o.args, arg)
+// AppendToStage appends steps to the stage which might be defined by some other node.
+//
+// Steps are appended when the Dockerfile is generated, so the order of compilation doesn't matter.
+func (o *Output) AppendToStage(name string, steps ...step.Step) *Output {
+	o.stageAppends = append(o.stageAppends, stageAppend{
+		stage: name,
+		steps: steps,
+	})
 
 	return o
 }
 
-// AllowLocalPath adds path to the list of paths to be copied into the context.
-func (o *Output) AllowLocalPath(paths ...string) *Output {
-	o.allowedLocalPaths = append(o.allowedLocalPaths, paths...)
+// Arg appends new arg.
+func (o *Output) Arg(arg *step.ArgStep) *Output {
+	o.args = append(o.args, arg)
 
 	return o
 }
 
 func (o *Output) dockerfile(w io.Writer) error {
+	if len(o.duplicateStages) > 0 {
+		return fmt.Errorf("duplicate Dockerfile stages: %v", o.duplicateStages)
+	}
+
+	for _, appended := range o.stageAppends {
+		stage, exists := o.stages[appended.stage]
+		if !exists {
+			return fmt.Errorf("stage %q referenced, but not defined", appended.stage)
+		}
+
+		stage.steps = append(stage.steps, appended.steps...)
+	}
+
+	o.stageAppends = nil
+
 	if _, err := fmt.Fprintf(w, "# syntax = %s\n\n", syntax); err != nil {
 		return err
 	}
@@ -136,24 +163,6 @@ func (o *Output) dockerfile(w io.Writer) error {
 	return nil
 }
 
-func (o *Output) dockerignore(w io.Writer) error {
-	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
-		return err
-	}
-
-	if _, err := fmt.Fprintln(w, "**"); err != nil {
-		return err
-	}
-
-	for _, path := range o.allowedLocalPaths {
-		if _, err := fmt.Fprintf(w, "!%s\n", path); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
 // Compiler is implemented by project blocks which support Dockerfile generate.
 type Compiler interface {
 	CompileDockerfile(*Output) error
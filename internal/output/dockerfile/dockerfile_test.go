@@ -62,6 +62,47 @@ WORKDIR /src
 `, buf.String())
 }
 
+func (suite *DockerfileSuite) TestAppendToStage() {
+	output := &dockerfile.Output{}
+
+	output.AppendToStage("build", step.Copy("/lib", "/usr/lib").From("cgo"))
+
+	output.Stage("build").From("setup").Step(step.WorkDir("/src"))
+
+	output.Stage("cgo").From("alpine").Step(step.Script("make lib"))
+
+	output.Stage("setup").From("scratch")
+
+	var buf bytes.Buffer
+
+	err := output.GenerateFile("Dockerfile", &buf)
+	suite.Require().NoError(err)
+
+	suite.Assert().Contains(buf.String(), `FROM setup AS build
+WORKDIR /src
+COPY --from=cgo /lib /usr/lib
+`)
+	suite.Assert().Less(strings.Index(buf.String(), "AS cgo"), strings.Index(buf.String(), "AS build"))
+}
+
+func (suite *DockerfileSuite) TestStageErrors() {
+	output := &dockerfile.Output{}
+
+	output.Stage("build").From("scratch")
+	output.AppendToStage("missing", step.WorkDir("/src"))
+
+	var buf bytes.Buffer
+
+	suite.Assert().EqualError(output.GenerateFile("Dockerfile", &buf), `stage "missing" referenced, but not defined`)
+
+	output = &dockerfile.Output{}
+
+	output.Stage("build").From("scratch")
+	output.Stage("build").From("alpine")
+
+	suite.Assert().EqualError(output.GenerateFile("Dockerfile", &buf), "duplicate Dockerfile stages: [build]")
+}
+
 func TestDockerfileSuite(t *testing.T) {
 	suite.Run(t, new(DockerfileSuite))
 }
@@ -0,0 +1,38 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package step
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// CmdStep implements Dockerfile CMD step.
+type CmdStep struct {
+	args []string
+}
+
+// Cmd creates new CmdStep.
+func Cmd(args ...string) *CmdStep {
+	return &CmdStep{
+		args: args,
+	}
+}
+
+// Step implements Step interface.
+func (step *CmdStep) Step() {}
+
+// Generate implements Step interface.
+func (step *CmdStep) Generate(w io.Writer) error {
+	res, err := json.Marshal(step.args)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "CMD %s\n", string(res))
+
+	return err
+}
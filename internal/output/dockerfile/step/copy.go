@@ -11,9 +11,10 @@ import (
 
 // CopyStep implements Dockerfile COPY step.
 type CopyStep struct {
-	from string
-	src  string
-	dst  string
+	from  string
+	chown string
+	src   string
+	dst   string
 }
 
 // Copy creates new CopyStep.
@@ -34,6 +35,13 @@ func (step *CopyStep) From(stage string) *CopyStep {
 	return step
 }
 
+// Chown sets --chown argument, owner is `user:group` (names or ids).
+func (step *CopyStep) Chown(owner string) *CopyStep {
+	step.chown = owner
+
+	return step
+}
+
 // Depends implements StageDependencies.
 func (step *CopyStep) Depends() []string {
 	if step.from == "" {
@@ -50,6 +58,10 @@ func (step *CopyStep) Generate(w io.Writer) error {
 		fromClause = fmt.Sprintf("--from=%s ", step.from)
 	}
 
+	if step.chown != "" {
+		fromClause = fmt.Sprintf("--chown=%s %s", step.chown, fromClause)
+	}
+
 	_, err := fmt.Fprintf(w, "COPY %s%s %s\n", fromClause, step.src, step.dst)
 
 	return err
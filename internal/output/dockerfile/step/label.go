@@ -0,0 +1,38 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package step
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LabelStep implements Dockerfile LABEL step.
+type LabelStep struct {
+	labels []string
+}
+
+// Label creates new LabelStep.
+func Label(name, value string) *LabelStep {
+	return (&LabelStep{}).Label(name, value)
+}
+
+// Label appends another label to the step.
+func (step *LabelStep) Label(name, value string) *LabelStep {
+	step.labels = append(step.labels, fmt.Sprintf("%s=%q", name, value))
+
+	return step
+}
+
+// Step implements Step interface.
+func (step *LabelStep) Step() {}
+
+// Generate implements Step interface.
+func (step *LabelStep) Generate(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "LABEL %s\n", strings.Join(step.labels, " \\\n\t"))
+
+	return err
+}
@@ -60,6 +60,31 @@ func (step *RunStep) MountCache(target string) *RunStep {
 	return step
 }
 
+// MountCacheWithID mounts cache with the specified id at target path.
+//
+// Cache is shared between all the mounts with the same id, regardless of the target path.
+func (step *RunStep) MountCacheWithID(target, id string) *RunStep {
+	step.mounts = append(step.mounts, fmt.Sprintf("type=cache,target=%s,id=%s", target, id))
+
+	return step
+}
+
+// MountSecret mounts BuildKit secret at specified target path.
+//
+// Secrets are never stored in the image layers.
+func (step *RunStep) MountSecret(id, target string) *RunStep {
+	step.mounts = append(step.mounts, fmt.Sprintf("type=secret,id=%s,target=%s", id, target))
+
+	return step
+}
+
+// MountSSH forwards SSH agent socket from the build client.
+func (step *RunStep) MountSSH() *RunStep {
+	step.mounts = append(step.mounts, "type=ssh")
+
+	return step
+}
+
 // Step implements Step interface.
 func (step *RunStep) Step() {}
 
@@ -26,6 +26,10 @@ func TestGenerate(t *testing.T) {
 			step.Copy("/src", "/dst").From("somestage"),
 			"COPY --from=somestage /src /dst\n",
 		},
+		{
+			step.Copy("/src", "/dst").From("somestage").Chown("1000:1000"),
+			"COPY --chown=1000:1000 --from=somestage /src /dst\n",
+		},
 		{
 			step.Env("GO111MODULE", "on"),
 			"ENV GO111MODULE on\n",
@@ -54,6 +58,18 @@ func TestGenerate(t *testing.T) {
 			step.Run("go", "build", "./...").MountCache("/root/go/.cache"),
 			"RUN --mount=type=cache,target=/root/go/.cache go build ./...\n",
 		},
+		{
+			step.Run("go", "mod", "download").MountCacheWithID("/go/pkg/mod", "example.com/project/go-mod"),
+			"RUN --mount=type=cache,target=/go/pkg/mod,id=example.com/project/go-mod go mod download\n",
+		},
+		{
+			step.Run("go", "mod", "download").MountSecret("netrc", "/root/.netrc"),
+			"RUN --mount=type=secret,id=netrc,target=/root/.netrc go mod download\n",
+		},
+		{
+			step.Run("go", "mod", "download").MountSSH(),
+			"RUN --mount=type=ssh go mod download\n",
+		},
 		{
 			step.Script("curl http://example.com/ | tar xzf -").MountCache("/root/go/.cache"),
 			"RUN --mount=type=cache,target=/root/go/.cache curl http://example.com/ | tar xzf -\n",
@@ -70,6 +86,14 @@ func TestGenerate(t *testing.T) {
 			step.Entrypoint("/bldr", "frontend"),
 			"ENTRYPOINT [\"/bldr\",\"frontend\"]\n",
 		},
+		{
+			step.Cmd("--config", "/etc/app.yaml"),
+			"CMD [\"--config\",\"/etc/app.yaml\"]\n",
+		},
+		{
+			step.Label("org.opencontainers.image.source", "https://github.com/talos-systems/kres").Label("org.opencontainers.image.revision", "${COMMIT}"),
+			"LABEL org.opencontainers.image.source=\"https://github.com/talos-systems/kres\" \\\n\torg.opencontainers.image.revision=\"${COMMIT}\"\n",
+		},
 	} {
 		var buf bytes.Buffer
 
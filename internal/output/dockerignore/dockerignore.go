@@ -0,0 +1,107 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package dockerignore implements output to .dockerignore.
+package dockerignore
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = ".dockerignore"
+)
+
+// Output implements .dockerignore generation.
+//
+// Everything is excluded from the build context by default, only allowed paths are sent.
+type Output struct {
+	output.FileAdapter
+
+	allowedLocalPaths []string
+	ignoredLocalPaths []string
+}
+
+// NewOutput creates new .dockerignore output.
+func NewOutput() *Output {
+	output := &Output{}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileDockerignore(o)
+}
+
+// AllowLocalPath adds path to the list of paths to be copied into the context.
+func (o *Output) AllowLocalPath(paths ...string) *Output {
+	o.allowedLocalPaths = append(o.allowedLocalPaths, paths...)
+
+	return o
+}
+
+// IgnoreLocalPath adds path to the list of paths excluded from the context.
+//
+// Ignored paths take precedence over allowed paths.
+func (o *Output) IgnoreLocalPath(paths ...string) *Output {
+	o.ignoredLocalPaths = append(o.ignoredLocalPaths, paths...)
+
+	return o
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.dockerignore(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) dockerignore(w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(w, "**"); err != nil {
+		return err
+	}
+
+	for _, path := range o.allowedLocalPaths {
+		if _, err := fmt.Fprintf(w, "!%s\n", path); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range o.ignoredLocalPaths {
+		if _, err := fmt.Fprintf(w, "%s\n", path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Compiler is implemented by project blocks which support .dockerignore generate.
+type Compiler interface {
+	CompileDockerignore(*Output) error
+}
@@ -7,11 +7,14 @@ package drone
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"strings"
 
 	"github.com/drone/drone-yaml/yaml"
 	"github.com/drone/drone-yaml/yaml/pretty"
 
+	"github.com/talos-systems/kres/internal/dag"
 	"github.com/talos-systems/kres/internal/output"
 )
 
@@ -22,14 +25,40 @@ const (
 // Output implements Drone project config generation.
 type Output struct {
 	output.FileAdapter
+	output.ComponentAdapter
 
 	manifest *yaml.Manifest
 
 	defaultPipeline *yaml.Pipeline
 	notifyPipeline  *yaml.Pipeline
 
+	// platformPipelines run steps which require runners of the specific platform.
+	platformPipelines map[string]*yaml.Pipeline
+
+	// nativePipelines run steps directly on the runners of the specific platform (without Docker).
+	nativePipelines map[string]*yaml.Pipeline
+
+	// current is the pipeline steps are appended to.
+	current *yaml.Pipeline
+
 	standardMounts []*yaml.VolumeMount
 
+	environment map[string]string
+
+	gitLFS bool
+
+	namespace    string
+	nodeSelector map[string]string
+	resources    Resources
+
+	// PathFilters adds `paths` conditions to the steps, so that steps are skipped if the sources
+	// they depend on were not changed.
+	//
+	// Path conditions require the paths changed conversion extension on the Drone server.
+	// Drone combines conditions with AND, so the paths conditions apply to all events,
+	// including pushes to the default branch.
+	PathFilters bool
+
 	PipelineType       string
 	NotifySlackChannel string
 	BuildContainer     string
@@ -66,10 +95,62 @@ func NewOutput() *Output {
 		},
 	}
 
-	output.defaultPipeline = &yaml.Pipeline{
-		Name: "default",
+	output.defaultPipeline = output.pipeline("default", "linux/amd64")
+	output.current = output.defaultPipeline
+
+	output.notifyPipeline = &yaml.Pipeline{
+		Name: "notify",
 		Type: output.PipelineType,
 		Kind: "pipeline",
+		Clone: yaml.Clone{
+			Disable: true,
+		},
+		Trigger: yaml.Conditions{
+			Status: yaml.Condition{
+				Include: []string{"success", "failure"},
+			},
+		},
+		Steps: []*yaml.Container{
+			{
+				Name:  "slack",
+				Image: "plugins/slack",
+				Settings: map[string]*yaml.Parameter{
+					"webhook": {
+						Secret: "slack_webhook",
+					},
+					"channel": {
+						Value: output.NotifySlackChannel,
+					},
+					"link_names": {
+						Value: true,
+					},
+					"template": {
+						Value: notifyTemplate,
+					},
+				},
+				When: yaml.Conditions{
+					Status: yaml.Condition{
+						Include: []string{"success", "failure"},
+					},
+				},
+			},
+		},
+		DependsOn: []string{"default"},
+	}
+
+	output.manifest.Resources = append(output.manifest.Resources, output.defaultPipeline, output.notifyPipeline)
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// pipeline creates a build pipeline for the runners of the platform.
+func (o *Output) pipeline(name, platform string) *yaml.Pipeline {
+	pipeline := &yaml.Pipeline{
+		Name: name,
+		Type: o.PipelineType,
+		Kind: "pipeline",
 		Volumes: []*yaml.Volume{
 			{
 				Name: "outer-docker-socket",
@@ -99,13 +180,13 @@ func NewOutput() *Output {
 		Steps: []*yaml.Container{
 			{
 				Name:  "setup-ci",
-				Image: output.BuildContainer,
+				Image: o.BuildContainer,
 				Pull:  "always",
 				Commands: []string{
 					"sleep 5",
 					"git fetch --tags",
 					"install-ci-key",
-					"docker buildx create --driver docker-container --platform linux/amd64 --name local --use unix:///var/outer-run/docker.sock",
+					fmt.Sprintf("docker buildx create --driver docker-container --platform %s --name local --use unix:///var/outer-run/docker.sock", platform),
 					"docker buildx inspect --bootstrap",
 				},
 				Environment: map[string]*yaml.Variable{
@@ -113,13 +194,13 @@ func NewOutput() *Output {
 						Secret: "ssh_key",
 					},
 				},
-				Volumes: output.standardMounts,
+				Volumes: o.standardMounts,
 			},
 		},
 		Services: []*yaml.Container{
 			{
 				Name:       "docker",
-				Image:      output.DockerImage,
+				Image:      o.DockerImage,
 				Entrypoint: []string{"dockerd"},
 				Privileged: true,
 				Commands: []string{
@@ -129,59 +210,99 @@ func NewOutput() *Output {
 					"--log-level=error",
 					"--insecure-registry=http://registry.ci.svc:5000",
 				},
-				Volumes: output.standardMounts,
+				Volumes: o.standardMounts,
 			},
 		},
 	}
 
-	output.notifyPipeline = &yaml.Pipeline{
-		Name: "notify",
-		Type: output.PipelineType,
+	return pipeline
+}
+
+// platformPipeline returns the pipeline for the runners of the platform (`os/arch`).
+//
+// Platform pipelines run after the default one, as steps can't depend on the steps of other pipelines.
+func (o *Output) platformPipeline(platform string) (*yaml.Pipeline, error) {
+	if pipeline, ok := o.platformPipelines[platform]; ok {
+		return pipeline, nil
+	}
+
+	parts := strings.SplitN(platform, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("runner platform should be in os/arch format: %q", platform)
+	}
+
+	pipeline := o.pipeline("default-"+strings.ReplaceAll(parts[1], "/", "-"), platform)
+	pipeline.Platform = yaml.Platform{
+		OS:   parts[0],
+		Arch: parts[1],
+	}
+	pipeline.DependsOn = []string{o.defaultPipeline.Name}
+
+	if o.platformPipelines == nil {
+		o.platformPipelines = map[string]*yaml.Pipeline{}
+	}
+
+	o.platformPipelines[platform] = pipeline
+
+	// platform pipelines are placed before the notify pipeline, which waits for them as well
+	o.manifest.Resources = append(o.manifest.Resources[:len(o.manifest.Resources)-1], pipeline, o.notifyPipeline)
+	o.notifyPipeline.DependsOn = append(o.notifyPipeline.DependsOn, pipeline.Name)
+
+	return pipeline, nil
+}
+
+// nativePipeline returns the exec pipeline for the runners of the platform (`os/arch`).
+//
+// Native pipelines don't depend on the default one, as they don't use the build images.
+func (o *Output) nativePipeline(platform string) (*yaml.Pipeline, error) {
+	if pipeline, ok := o.nativePipelines[platform]; ok {
+		return pipeline, nil
+	}
+
+	parts := strings.SplitN(platform, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("runner platform should be in os/arch format: %q", platform)
+	}
+
+	pipeline := &yaml.Pipeline{
+		Name: fmt.Sprintf("native-%s-%s", parts[0], parts[1]),
+		Type: "exec",
 		Kind: "pipeline",
-		Clone: yaml.Clone{
-			Disable: true,
-		},
-		Trigger: yaml.Conditions{
-			Status: yaml.Condition{
-				Include: []string{"success", "failure"},
-			},
-		},
-		Steps: []*yaml.Container{
-			{
-				Name:  "slack",
-				Image: "plugins/slack",
-				Settings: map[string]*yaml.Parameter{
-					"webhook": {
-						Secret: "slack_webhook",
-					},
-					"channel": {
-						Value: output.NotifySlackChannel,
-					},
-					"link_names": {
-						Value: true,
-					},
-					"template": {
-						Value: notifyTemplate,
-					},
-				},
-				When: yaml.Conditions{
-					Status: yaml.Condition{
-						Include: []string{"success", "failure"},
-					},
-				},
-			},
+		Platform: yaml.Platform{
+			OS:   parts[0],
+			Arch: parts[1],
 		},
-		DependsOn: []string{"default"},
 	}
 
-	output.manifest.Resources = append(output.manifest.Resources, output.defaultPipeline, output.notifyPipeline)
+	if o.nativePipelines == nil {
+		o.nativePipelines = map[string]*yaml.Pipeline{}
+	}
 
-	output.FileAdapter.FileWriter = output
+	o.nativePipelines[platform] = pipeline
 
-	return output
+	o.manifest.Resources = append(o.manifest.Resources[:len(o.manifest.Resources)-1], pipeline, o.notifyPipeline)
+	o.notifyPipeline.DependsOn = append(o.notifyPipeline.DependsOn, pipeline.Name)
+
+	return pipeline, nil
+}
+
+// NativeStep appends a step run directly on the runners of the platform (`os/arch`) with the exec runner.
+//
+// Native steps run without the build container and Docker, e.g. to run tests on Windows.
+func (o *Output) NativeStep(platform string, step *Step) error {
+	pipeline, err := o.nativePipeline(platform)
+	if err != nil {
+		return err
+	}
+
+	o.component(step)
+
+	pipeline.Steps = append(pipeline.Steps, &step.container)
+
+	return nil
 }
 
-// Step appends a step to the default pipeline.
+// Step appends a step to the default pipeline (or to the pipeline of the node runner platform).
 func (o *Output) Step(step *Step) {
 	if step.container.Image == "" {
 		step.container.Image = o.BuildContainer
@@ -193,7 +314,51 @@ func (o *Output) Step(step *Step) {
 
 	step.container.Volumes = append(step.container.Volumes, o.standardMounts...)
 
-	o.defaultPipeline.Steps = append(o.defaultPipeline.Steps, &step.container)
+	o.component(step)
+
+	o.current.Steps = append(o.current.Steps, &step.container)
+}
+
+// component renames the step of the monorepo component, and runs the step commands in the component directory.
+func (o *Output) component(step *Step) {
+	step.container.Name, step.container.DependsOn = o.ComponentStep(step.container.Name, step.container.DependsOn)
+
+	if dir := o.ComponentDir(); dir != "." && len(step.container.Commands) > 0 {
+		step.container.Commands = append([]string{"cd " + dir}, step.container.Commands...)
+	}
+}
+
+// GitLFS pulls Git LFS objects in the setup step of every build pipeline.
+func (o *Output) GitLFS() {
+	o.gitLFS = true
+}
+
+// Environment sets an environment variable for all the steps of the default pipeline.
+//
+// Variables set on the step itself take precedence.
+func (o *Output) Environment(name, value string) {
+	if o.environment == nil {
+		o.environment = make(map[string]string)
+	}
+
+	o.environment[name] = value
+}
+
+// Service appends a service container to the default pipeline (or to the pipeline of the node runner platform).
+//
+// Services are reachable from the steps via localhost.
+func (o *Output) Service(name, image string, environment map[string]string) {
+	service := &yaml.Container{
+		Name:        o.ComponentName(name),
+		Image:       image,
+		Environment: make(map[string]*yaml.Variable, len(environment)),
+	}
+
+	for k, v := range environment {
+		service.Environment[k] = &yaml.Variable{Value: v}
+	}
+
+	o.current.Services = append(o.current.Services, service)
 }
 
 // Compile implements output.Writer interface.
@@ -204,7 +369,42 @@ func (o *Output) Compile(node interface{}) error {
 		return nil
 	}
 
-	return compiler.CompileDrone(o)
+	if runner, ok := node.(output.RunnerPlatform); ok && runner.RunnerPlatform() != "" && runner.RunnerPlatform() != "linux/amd64" {
+		pipeline, err := o.platformPipeline(runner.RunnerPlatform())
+		if err != nil {
+			return err
+		}
+
+		o.current = pipeline
+
+		defer func() {
+			o.current = o.defaultPipeline
+		}()
+	}
+
+	if !o.PathFilters {
+		return compiler.CompileDrone(o)
+	}
+
+	firstStep := len(o.current.Steps)
+
+	if err := compiler.CompileDrone(o); err != nil {
+		return err
+	}
+
+	if node, ok := node.(dag.Node); ok {
+		paths := dag.GatherSourcePaths(node)
+
+		for i := range paths {
+			paths[i] = o.ComponentPath(paths[i])
+		}
+
+		for _, step := range o.current.Steps[firstStep:] {
+			step.When.Paths.Include = append(step.When.Paths.Include, paths...)
+		}
+	}
+
+	return nil
 }
 
 // Filenames implements output.FileWriter interface.
@@ -225,10 +425,54 @@ func (o *Output) GenerateFile(filename string, w io.Writer) error {
 func (o *Output) drone(w io.Writer) error {
 	preamble := output.Preamble("# ")
 
+	pipelines := []*yaml.Pipeline{o.defaultPipeline}
+
+	for _, resource := range o.manifest.Resources {
+		if pipeline, ok := resource.(*yaml.Pipeline); ok && pipeline != o.defaultPipeline && pipeline != o.notifyPipeline {
+			pipelines = append(pipelines, pipeline)
+		}
+	}
+
+	for _, pipeline := range pipelines {
+		for _, step := range pipeline.Steps {
+			for name, value := range o.environment {
+				if _, ok := step.Environment[name]; !ok {
+					step.Environment[name] = &yaml.Variable{Value: value}
+				}
+			}
+		}
+	}
+
+	if o.gitLFS {
+		for _, pipeline := range pipelines {
+			for _, step := range pipeline.Steps {
+				if step.Name == "setup-ci" {
+					step.Commands = append(step.Commands, "git lfs pull")
+				}
+			}
+		}
+	}
+
+	if len(pipelines) > 1 {
+		for _, pipeline := range pipelines {
+			filterDependencies(pipeline)
+		}
+	}
+
 	var buf bytes.Buffer
 
 	pretty.Print(&buf, o.manifest)
 
+	if o.hasKubernetesSettings() {
+		patched, err := o.applyKubernetesSettings(buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		buf.Reset()
+		buf.Write(patched)
+	}
+
 	firstLine, err := buf.ReadString('\n')
 	if err != nil {
 		return err
@@ -249,6 +493,38 @@ func (o *Output) drone(w io.Writer) error {
 	return nil
 }
 
+// filterDependencies drops dependencies on the steps of other pipelines.
+//
+// Platform pipelines run once the default pipeline is finished, steps of the default pipeline
+// lose dependencies on the steps moved to the platform pipelines.
+func filterDependencies(pipeline *yaml.Pipeline) {
+	steps := make(map[string]struct{}, len(pipeline.Steps))
+
+	for _, step := range pipeline.Steps {
+		steps[step.Name] = struct{}{}
+	}
+
+	for _, step := range pipeline.Steps[1:] {
+		if len(step.DependsOn) == 0 {
+			continue
+		}
+
+		depends := []string{}
+
+		for _, dep := range step.DependsOn {
+			if _, ok := steps[dep]; ok {
+				depends = append(depends, dep)
+			}
+		}
+
+		if len(depends) == 0 {
+			depends = []string{"setup-ci"}
+		}
+
+		step.DependsOn = depends
+	}
+}
+
 // Compiler is implemented by project blocks which support Drone config generation.
 type Compiler interface {
 	CompileDrone(*Output) error
@@ -0,0 +1,186 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package drone
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Resources are compute resources of the Kubernetes runner step containers.
+type Resources struct {
+	Requests *ResourceList `yaml:"requests,omitempty"`
+	Limits   *ResourceList `yaml:"limits,omitempty"`
+}
+
+// ResourceList is CPU (in millicores, e.g. 1000) and memory (e.g. 2GiB) of the container.
+type ResourceList struct {
+	CPU    int    `yaml:"cpu,omitempty"`
+	Memory string `yaml:"memory,omitempty"`
+}
+
+// Namespace sets the Kubernetes namespace the pipelines run in.
+func (o *Output) Namespace(namespace string) {
+	o.namespace = namespace
+}
+
+// NodeSelector pins the pipelines to the nodes with the label.
+func (o *Output) NodeSelector(label, value string) {
+	if o.nodeSelector == nil {
+		o.nodeSelector = make(map[string]string)
+	}
+
+	o.nodeSelector[label] = value
+}
+
+// Resources sets requests and limits of every step and service.
+func (o *Output) Resources(resources Resources) {
+	o.resources = resources
+}
+
+func (o *Output) hasKubernetesSettings() bool {
+	return o.namespace != "" || len(o.nodeSelector) > 0 || o.resources.Requests != nil || o.resources.Limits != nil
+}
+
+// applyKubernetesSettings injects the Kubernetes runner settings into the rendered pipelines.
+//
+// drone-yaml doesn't support the Kubernetes runner specific fields, so pipelines are patched
+// after rendering.
+func (o *Output) applyKubernetesSettings(rendered []byte) ([]byte, error) {
+	decoder := yamlv3.NewDecoder(bytes.NewReader(rendered))
+
+	var out bytes.Buffer
+
+	out.WriteString("---\n")
+
+	encoder := yamlv3.NewEncoder(&out)
+	encoder.SetIndent(2)
+
+	for {
+		var doc yamlv3.Node
+
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, err
+		}
+
+		if len(doc.Content) > 0 && mappingValue(doc.Content[0], "kind") == "pipeline" && mappingValue(doc.Content[0], "type") == o.PipelineType {
+			if err := o.patchPipeline(doc.Content[0]); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := encoder.Encode(&doc); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+func (o *Output) patchPipeline(pipeline *yamlv3.Node) error {
+	var settings []*yamlv3.Node
+
+	if o.namespace != "" {
+		metadata, err := encodeNode(map[string]string{"namespace": o.namespace})
+		if err != nil {
+			return err
+		}
+
+		settings = append(settings, scalarNode("metadata"), metadata)
+	}
+
+	if len(o.nodeSelector) > 0 {
+		selector := &yamlv3.Node{Kind: yamlv3.MappingNode}
+
+		labels := make([]string, 0, len(o.nodeSelector))
+
+		for label := range o.nodeSelector {
+			labels = append(labels, label)
+		}
+
+		sort.Strings(labels)
+
+		for _, label := range labels {
+			selector.Content = append(selector.Content, scalarNode(label), scalarNode(o.nodeSelector[label]))
+		}
+
+		settings = append(settings, scalarNode("node_selector"), selector)
+	}
+
+	// settings follow the pipeline name
+	for i := 0; i+1 < len(pipeline.Content); i += 2 {
+		if pipeline.Content[i].Value == "name" {
+			pipeline.Content = append(pipeline.Content[:i+2], append(settings, pipeline.Content[i+2:]...)...)
+
+			break
+		}
+	}
+
+	if o.resources.Requests == nil && o.resources.Limits == nil {
+		return nil
+	}
+
+	for _, key := range []string{"steps", "services"} {
+		containers := mappingNode(pipeline, key)
+		if containers == nil {
+			continue
+		}
+
+		for _, container := range containers.Content {
+			resources, err := encodeNode(o.resources)
+			if err != nil {
+				return err
+			}
+
+			container.Content = append(container.Content, scalarNode("resources"), resources)
+		}
+	}
+
+	return nil
+}
+
+func encodeNode(value interface{}) (*yamlv3.Node, error) {
+	var node yamlv3.Node
+
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+
+	return &node, nil
+}
+
+func scalarNode(value string) *yamlv3.Node {
+	return &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: value}
+}
+
+func mappingNode(mapping *yamlv3.Node, key string) *yamlv3.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func mappingValue(mapping *yamlv3.Node, key string) string {
+	if node := mappingNode(mapping, key); node != nil {
+		return node.Value
+	}
+
+	return ""
+}
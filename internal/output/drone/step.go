@@ -29,6 +29,35 @@ func MakeStep(target string, args ...string) *Step {
 	}
 }
 
+// CustomStep creates a step which runs specified commands.
+func CustomStep(name string, commands ...string) *Step {
+	return &Step{
+		container: yaml.Container{
+			Name:        name,
+			Commands:    commands,
+			Environment: make(map[string]*yaml.Variable),
+		},
+	}
+}
+
+// PluginStep creates a step which runs Drone plugin image.
+func PluginStep(name, image string) *Step {
+	return &Step{
+		container: yaml.Container{
+			Name:        name,
+			Image:       image,
+			Environment: make(map[string]*yaml.Variable),
+		},
+	}
+}
+
+// Image sets the image the step is run in.
+func (step *Step) Image(image string) *Step {
+	step.container.Image = image
+
+	return step
+}
+
 // Name provides a name to a step.
 func (step *Step) Name(name string) *Step {
 	step.container.Name = name
@@ -50,6 +79,35 @@ func (step *Step) EnvironmentFromSecret(name, secretName string) *Step {
 	return step
 }
 
+// Setting sets the plugin setting.
+func (step *Step) Setting(name string, value interface{}) *Step {
+	if step.container.Settings == nil {
+		step.container.Settings = make(map[string]*yaml.Parameter)
+	}
+
+	step.container.Settings[name] = &yaml.Parameter{Value: value}
+
+	return step
+}
+
+// SettingFromSecret sets the plugin setting from secret.
+func (step *Step) SettingFromSecret(name, secretName string) *Step {
+	if step.container.Settings == nil {
+		step.container.Settings = make(map[string]*yaml.Parameter)
+	}
+
+	step.container.Settings[name] = &yaml.Parameter{Secret: secretName}
+
+	return step
+}
+
+// BeforeCommands prepends commands to the step.
+func (step *Step) BeforeCommands(commands ...string) *Step {
+	step.container.Commands = append(append([]string(nil), commands...), step.container.Commands...)
+
+	return step
+}
+
 // DependsOn appends to a list of step dependencies.
 func (step *Step) DependsOn(depends ...string) *Step {
 	step.container.DependsOn = append(step.container.DependsOn, depends...)
@@ -71,6 +129,21 @@ func (step *Step) OnlyOnPullRequest() *Step {
 	return step
 }
 
+// OnlyOnTag adds condition to run step only on tag events.
+func (step *Step) OnlyOnTag() *Step {
+	step.container.When.Event.Include = append(step.container.When.Event.Include, "tag")
+
+	return step
+}
+
+// OnlyOnCron adds condition to run step only on the cron job with the specified name.
+func (step *Step) OnlyOnCron(job string) *Step {
+	step.container.When.Event.Include = append(step.container.When.Event.Include, "cron")
+	step.container.When.Cron.Include = append(step.container.When.Cron.Include, job)
+
+	return step
+}
+
 // OnlyOnMaster adds condition to run step only on master branch.
 func (step *Step) OnlyOnMaster() *Step {
 	step.container.When.Branch.Include = append(step.container.When.Branch.Include, "master")
@@ -102,3 +175,21 @@ func (step *Step) DockerLogin() *Step {
 
 	return step
 }
+
+// DockerLoginRegistry sets up login to the specified registry with credentials from the secrets.
+func (step *Step) DockerLoginRegistry(registry, usernameSecret, passwordSecret string) *Step {
+	usernameVariable, passwordVariable := strings.ToUpper(usernameSecret), strings.ToUpper(passwordSecret)
+
+	step.container.Commands = append([]string{
+		fmt.Sprintf(`docker login --username "$${%s}" --password "$${%s}" %s`, usernameVariable, passwordVariable, registry),
+	}, step.container.Commands...)
+
+	step.container.Environment[usernameVariable] = &yaml.Variable{
+		Secret: usernameSecret,
+	}
+	step.container.Environment[passwordVariable] = &yaml.Variable{
+		Secret: passwordSecret,
+	}
+
+	return step
+}
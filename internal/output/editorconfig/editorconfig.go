@@ -0,0 +1,196 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package editorconfig implements output to .editorconfig.
+package editorconfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = ".editorconfig"
+
+	// sentinel separates generated sections from the sections added manually.
+	//
+	// Everything below the sentinel is preserved on regeneration, manual sections
+	// take precedence as they come later in the file.
+	sentinel = "# Sections below this line are preserved by kres."
+)
+
+// Output implements .editorconfig generation.
+type Output struct {
+	output.FileAdapter
+
+	enabled bool
+
+	sections []*Section
+}
+
+// Section is a set of properties applied to the files matching the glob.
+type Section struct {
+	glob string
+
+	keys       []string
+	properties map[string]string
+}
+
+// Set sets the property value.
+func (section *Section) Set(key, value string) *Section {
+	if _, exists := section.properties[key]; !exists {
+		section.keys = append(section.keys, key)
+	}
+
+	section.properties[key] = value
+
+	return section
+}
+
+// NewOutput creates new .editorconfig output.
+func NewOutput() *Output {
+	output := &Output{}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileEditorConfig(o)
+}
+
+// Enable should be called to enable config generation.
+func (o *Output) Enable() {
+	o.enabled = true
+}
+
+// Section returns the section for the glob, sections are written in the order of creation.
+func (o *Output) Section(glob string) *Section {
+	for _, section := range o.sections {
+		if section.glob == glob {
+			return section
+		}
+	}
+
+	section := &Section{
+		glob:       glob,
+		properties: map[string]string{},
+	}
+
+	o.sections = append(o.sections, section)
+
+	return section
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	if !o.enabled {
+		return nil
+	}
+
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.editorconfig(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) editorconfig(w io.Writer) error {
+	// sections added to the file manually are preserved
+	manual, err := o.manualSections()
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	if _, err = fmt.Fprintln(w, "root = true"); err != nil {
+		return err
+	}
+
+	for _, section := range o.sections {
+		if _, err = fmt.Fprintf(w, "\n[%s]\n", section.glob); err != nil {
+			return err
+		}
+
+		for _, key := range section.keys {
+			if _, err = fmt.Fprintf(w, "%s = %s\n", key, section.properties[key]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err = fmt.Fprintf(w, "\n%s\n", sentinel); err != nil {
+		return err
+	}
+
+	for _, line := range manual {
+		if _, err = fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *Output) manualSections() ([]string, error) {
+	f, err := os.Open(o.Path(filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	var (
+		lines []string
+		found bool
+	)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if found {
+			lines = append(lines, scanner.Text())
+
+			continue
+		}
+
+		found = strings.TrimSpace(scanner.Text()) == sentinel
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", filename, err)
+	}
+
+	return lines, nil
+}
+
+// Compiler is implemented by project blocks which support .editorconfig generation.
+type Compiler interface {
+	CompileEditorConfig(*Output) error
+}
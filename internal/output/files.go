@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -27,66 +28,60 @@ type FilePermissionsWriter interface {
 }
 
 // FileAdapter implements Writer via FileWriter.
+//
+// Files are written relative to the root directory (current directory by default).
 type FileAdapter struct {
 	FileWriter
-}
-
-// Generate implements outout.Writer.
-//
-//nolint: gocognit
-func (adapter *FileAdapter) Generate() error {
-	// buffer the output before writing it down
-	buffers := map[string]*bytes.Buffer{}
 
-	for _, filename := range adapter.FileWriter.Filenames() {
-		buf := bytes.NewBuffer(nil)
+	root string
+}
 
-		dir := filepath.Dir(filename)
+// Root sets the directory files are written to.
+func (adapter *FileAdapter) Root(root string) {
+	adapter.root = root
+}
 
-		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
-			if err := os.MkdirAll(dir, 0o755); err != nil {
-				return err
-			}
-		}
+// Path returns the path of the file on disk.
+func (adapter *FileAdapter) Path(filename string) string {
+	return filepath.Join(adapter.root, filename)
+}
 
-		if err := adapter.FileWriter.GenerateFile(filename, buf); err != nil {
-			return err
-		}
+// Change is a difference between the generated file and the file on disk.
+type Change struct {
+	Filename string
+	// Diff is a unified diff from the file on disk to the generated contents.
+	Diff string
+}
 
-		buffers[filename] = buf
+// Generate implements outout.Writer.
+func (adapter *FileAdapter) Generate() error {
+	buffers, err := adapter.render()
+	if err != nil {
+		return err
 	}
 
 	// write everything back to the filesystem
-	for _, filename := range adapter.FileWriter.Filenames() {
-		filename := filename
-
-		var oldContents []string
-
-		if err := func() error {
-			f, err := os.Open(filename)
-			if err != nil {
-				if os.IsNotExist(err) {
-					return nil
-				}
-
-				return err
-			}
-
-			defer f.Close() //nolint: errcheck
-
-			oldContents, err = splitIgnoringPreamble(f)
+	for _, name := range adapter.FileWriter.Filenames() {
+		contents := buffers[name]
+		filename := adapter.Path(name)
 
-			return err
-		}(); err != nil {
+		changed, err := adapter.changed(filename, contents)
+		if err != nil {
 			return err
 		}
 
-		if newContents, err := splitIgnoringPreamble(bytes.NewReader(buffers[filename].Bytes())); err != nil {
-			return err
-		} else if strings.Join(oldContents, "\n") == strings.Join(newContents, "\n") {
+		if !changed {
 			continue // skip as no changes
 		}
 
+		dir := filepath.Dir(filename)
+
+		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+		}
+
 		if err := func() error {
 			f, err := os.Create(filename)
 			if err != nil {
@@ -95,7 +90,7 @@ func (adapter *FileAdapter) Generate() error {
 
 			defer f.Close() //nolint: errcheck
 
-			_, err = buffers[filename].WriteTo(f)
+			_, err = f.Write(contents)
 
 			return err
 		}(); err != nil {
@@ -103,7 +98,7 @@ func (adapter *FileAdapter) Generate() error {
 		}
 
 		if permsWriter, implements := adapter.FileWriter.(FilePermissionsWriter); implements {
-			perms := permsWriter.Permissions(filename)
+			perms := permsWriter.Permissions(name)
 
 			if perms == 0 {
 				perms = 0o644
@@ -118,6 +113,97 @@ func (adapter *FileAdapter) Generate() error {
 	return nil
 }
 
+// Diff implements output.Writer.
+//
+// Files are rendered to memory and compared with the files on disk, nothing is written.
+func (adapter *FileAdapter) Diff() ([]Change, error) {
+	buffers, err := adapter.render()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+
+	for _, name := range adapter.FileWriter.Filenames() {
+		contents := buffers[name]
+		filename := adapter.Path(name)
+
+		changed, err := adapter.changed(filename, contents)
+		if err != nil {
+			return nil, err
+		}
+
+		if !changed {
+			continue
+		}
+
+		oldContents, err := ioutil.ReadFile(filename)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		from := "a/" + filepath.ToSlash(filepath.Clean(filename))
+		if oldContents == nil {
+			from = "/dev/null"
+		}
+
+		changes = append(changes, Change{
+			Filename: filename,
+			Diff:     UnifiedDiff(from, "b/"+filepath.ToSlash(filepath.Clean(filename)), splitLines(oldContents), splitLines(contents)),
+		})
+	}
+
+	return changes, nil
+}
+
+// render buffers the output before writing it down.
+func (adapter *FileAdapter) render() (map[string][]byte, error) {
+	buffers := map[string][]byte{}
+
+	for _, filename := range adapter.FileWriter.Filenames() {
+		var buf bytes.Buffer
+
+		if err := adapter.FileWriter.GenerateFile(filename, &buf); err != nil {
+			return nil, err
+		}
+
+		buffers[filename] = buf.Bytes()
+	}
+
+	return buffers, nil
+}
+
+// changed compares the file on disk with the new contents, preamble is ignored.
+func (adapter *FileAdapter) changed(filename string, contents []byte) (bool, error) {
+	var oldContents []string
+
+	if err := func() error {
+		f, err := os.Open(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		defer f.Close() //nolint: errcheck
+
+		oldContents, err = splitIgnoringPreamble(f)
+
+		return err
+	}(); err != nil {
+		return false, err
+	}
+
+	newContents, err := splitIgnoringPreamble(bytes.NewReader(contents))
+	if err != nil {
+		return false, err
+	}
+
+	return strings.Join(oldContents, "\n") != strings.Join(newContents, "\n"), nil
+}
+
 func splitIgnoringPreamble(r io.Reader) ([]string, error) {
 	var contents []string
 
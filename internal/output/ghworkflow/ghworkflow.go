@@ -0,0 +1,370 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package ghworkflow implements output to GitHub Actions workflows.
+package ghworkflow
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = ".github/workflows/ci.yaml"
+
+	// buildxCacheArgs are the default CI_ARGS: builds run in buildx, so the layer cache of every job
+	// is stored in the GitHub Actions cache.
+	buildxCacheArgs = "--cache-from=type=gha,scope=${{ github.job }} --cache-to=type=gha,scope=${{ github.job }},mode=max"
+)
+
+// Output implements GitHub Actions workflow generation.
+type Output struct {
+	output.FileAdapter
+	output.ComponentAdapter
+
+	jobs []*Job
+
+	gitLFS bool
+
+	env map[string]string
+
+	paths      map[string]struct{}
+	unfiltered bool
+
+	// WorkflowDispatch enables manual workflow runs.
+	WorkflowDispatch bool
+	// PathFilters runs the workflow on pull requests only if the sources the jobs depend on were changed.
+	//
+	// GitHub Actions doesn't support path filters for the jobs, so the workflow is filtered as a whole.
+	// Pushes to the default branch always run the workflow.
+	PathFilters bool
+	// DefaultBranch is the branch to run workflow on pushes to.
+	DefaultBranch string
+	RunsOn        string
+	// RunnerLabels are labels of the runners for the platforms, e.g. `linux/arm64: [self-hosted, ARM64]`.
+	//
+	// Self-hosted runners with default labels (`self-hosted`, OS and architecture) are used for other platforms.
+	RunnerLabels map[string][]string
+}
+
+// NewOutput creates new GitHub Actions workflow output.
+func NewOutput() *Output {
+	output := &Output{
+		DefaultBranch: "master",
+		RunsOn:        "ubuntu-latest",
+	}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// GitLFS checks out Git LFS objects in every job.
+func (o *Output) GitLFS() {
+	o.gitLFS = true
+}
+
+// Environment sets an environment variable for all the jobs of the workflow.
+func (o *Output) Environment(name, value string) {
+	if o.env == nil {
+		o.env = make(map[string]string)
+	}
+
+	o.env[name] = value
+}
+
+// Job appends a job to the workflow.
+func (o *Output) Job(job *Job) {
+	o.component(job)
+
+	o.jobs = append(o.jobs, job)
+}
+
+// component renames the job of the monorepo component, and runs the job in the component directory.
+//
+// Artifacts are renamed as well, as artifact names are shared by all the jobs of the workflow run,
+// and artifact paths are relative to the repository root.
+func (o *Output) component(job *Job) {
+	job.name, job.needs = o.ComponentStep(job.name, job.needs)
+
+	dir := o.ComponentDir()
+	if dir == "." {
+		return
+	}
+
+	job.workingDirectory = dir
+
+	for _, steps := range [][]stepSpec{job.preSteps, job.postSteps} {
+		for _, step := range steps {
+			if !strings.HasPrefix(step.Uses, "actions/upload-artifact@") && !strings.HasPrefix(step.Uses, "actions/download-artifact@") {
+				continue
+			}
+
+			step.With["name"] = o.ComponentName(step.With["name"])
+			step.With["path"] = o.ComponentPath(step.With["path"])
+		}
+	}
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	firstJob := len(o.jobs)
+
+	if err := compiler.CompileGitHubWorkflow(o); err != nil {
+		return err
+	}
+
+	if runner, ok := node.(output.RunnerPlatform); ok && runner.RunnerPlatform() != "" && runner.RunnerPlatform() != "linux/amd64" {
+		labels, err := o.runnerLabels(runner.RunnerPlatform())
+		if err != nil {
+			return err
+		}
+
+		for _, job := range o.jobs[firstJob:] {
+			if len(job.runsOn) == 0 {
+				job.runsOn = labels
+			}
+		}
+	}
+
+	if !o.PathFilters {
+		return nil
+	}
+
+	if node, ok := node.(dag.Node); ok && len(o.jobs) > firstJob {
+		paths := dag.GatherSourcePaths(node)
+
+		for i := range paths {
+			paths[i] = o.ComponentPath(paths[i])
+		}
+
+		o.addPaths(paths)
+	}
+
+	return nil
+}
+
+// HostedRunner returns the label of the GitHub-hosted runners of the operating system (GOOS).
+func HostedRunner(goos string) (string, error) {
+	runner, ok := map[string]string{
+		"linux":   "ubuntu-latest",
+		"windows": "windows-latest",
+		"darwin":  "macos-latest",
+	}[goos]
+	if !ok {
+		return "", fmt.Errorf("no GitHub-hosted runners for %q", goos)
+	}
+
+	return runner, nil
+}
+
+// runnerLabels returns labels of the self-hosted runners for the platform (`os/arch`).
+//
+// GitHub-hosted runners are amd64 only, other platforms require self-hosted runners.
+func (o *Output) runnerLabels(platform string) ([]string, error) {
+	if labels, ok := o.RunnerLabels[platform]; ok {
+		return labels, nil
+	}
+
+	parts := strings.SplitN(platform, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("runner platform should be in os/arch format: %q", platform)
+	}
+
+	// default labels of the self-hosted runners
+	arch, ok := map[string]string{
+		"amd64": "X64",
+		"arm64": "ARM64",
+		"arm":   "ARM",
+	}[parts[1]]
+	if !ok {
+		return nil, fmt.Errorf("no runner labels for platform %q", platform)
+	}
+
+	return []string{"self-hosted", strings.ToUpper(parts[0][:1]) + parts[0][1:], arch}, nil
+}
+
+// addPaths records source paths of the jobs, jobs without source paths should always run.
+func (o *Output) addPaths(paths []string) {
+	if len(paths) == 0 {
+		o.unfiltered = true
+
+		return
+	}
+
+	if o.paths == nil {
+		o.paths = make(map[string]struct{})
+	}
+
+	for _, path := range paths {
+		o.paths[path] = struct{}{}
+	}
+}
+
+// pullRequestPaths returns path filters for pull requests, nil if the workflow should always run.
+func (o *Output) pullRequestPaths() []string {
+	if !o.PathFilters || o.unfiltered || len(o.paths) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(o.paths))
+
+	for path := range o.paths {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.workflow(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) workflow(w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	doc := &yaml.Node{
+		Kind: yaml.MappingNode,
+	}
+
+	appendValue := func(parent *yaml.Node, key string, value interface{}) error {
+		var valueNode yaml.Node
+
+		if err := valueNode.Encode(value); err != nil {
+			return err
+		}
+
+		parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &valueNode)
+
+		return nil
+	}
+
+	if err := appendValue(doc, "name", "default"); err != nil {
+		return err
+	}
+
+	on := map[string]interface{}{
+		"push": map[string][]string{
+			"branches": {o.DefaultBranch},
+			"tags":     {"v*"},
+		},
+		"pull_request": map[string]interface{}{},
+	}
+
+	if paths := o.pullRequestPaths(); paths != nil {
+		on["pull_request"] = map[string][]string{
+			"paths": paths,
+		}
+	}
+
+	if o.WorkflowDispatch {
+		on["workflow_dispatch"] = map[string]interface{}{}
+	}
+
+	if err := appendValue(doc, "on", on); err != nil {
+		return err
+	}
+
+	// jobs with the remote build cache override CI_ARGS
+	env := map[string]string{
+		"CI_ARGS": buildxCacheArgs,
+	}
+
+	for name, value := range o.env {
+		env[name] = value
+	}
+
+	if err := appendValue(doc, "env", env); err != nil {
+		return err
+	}
+
+	jobs := &yaml.Node{
+		Kind: yaml.MappingNode,
+	}
+
+	// nodes might implement Compiler without producing a job (e.g. toolchain), skip them in needs
+	jobNames := make(map[string]struct{}, len(o.jobs))
+
+	for _, job := range o.jobs {
+		jobNames[job.name] = struct{}{}
+	}
+
+	for _, job := range o.jobs {
+		if err := appendValue(jobs, job.name, job.compile(o, jobNames)); err != nil {
+			return err
+		}
+	}
+
+	doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "jobs"}, jobs)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// commonSteps are prepended to every job.
+func (o *Output) commonSteps() []stepSpec {
+	checkout := map[string]string{
+		"fetch-depth": "0",
+	}
+
+	if o.gitLFS {
+		checkout["lfs"] = "true"
+	}
+
+	return []stepSpec{
+		{
+			Name: "checkout",
+			Uses: "actions/checkout@v2",
+			With: checkout,
+		},
+		{
+			Name: "set up buildx",
+			Uses: "docker/setup-buildx-action@v1",
+		},
+		{
+			// buildx gha cache requires the runtime token and cache URL
+			Name: "expose GitHub runtime",
+			Uses: "crazy-max/ghaction-github-runtime@v1",
+		},
+	}
+}
+
+// Compiler is implemented by project blocks which support GitHub Actions workflow generation.
+type Compiler interface {
+	CompileGitHubWorkflow(*Output) error
+}
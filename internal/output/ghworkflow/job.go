@@ -0,0 +1,241 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ghworkflow
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Job is a workflow job.
+type Job struct {
+	name string
+
+	target     string
+	native     bool
+	runsOn     []string
+	needs      []string
+	conditions []string
+	env        map[string]string
+
+	// workingDirectory is the directory of the monorepo component the job runs in.
+	workingDirectory string
+
+	preSteps  []stepSpec
+	postSteps []stepSpec
+}
+
+type jobSpec struct {
+	RunsOn interface{} `yaml:"runs-on"`
+	Needs  []string    `yaml:"needs,omitempty"`
+	If     string      `yaml:"if,omitempty"`
+	Steps  []stepSpec  `yaml:"steps"`
+}
+
+type stepSpec struct {
+	Name string            `yaml:"name"`
+	Uses string            `yaml:"uses,omitempty"`
+	With map[string]string `yaml:"with,omitempty"`
+	Env  map[string]string `yaml:"env,omitempty"`
+	Run  string            `yaml:"run,omitempty"`
+
+	WorkingDirectory string `yaml:"working-directory,omitempty"`
+}
+
+// MakeJob creates a job which calls make target.
+func MakeJob(target string, args ...string) *Job {
+	return &Job{
+		name:   target,
+		target: strings.TrimSpace(fmt.Sprintf("make %s %s", target, strings.Join(args, " "))),
+		env:    make(map[string]string),
+	}
+}
+
+// CommandJob creates a job which runs the command directly on the runner (without Docker).
+func CommandJob(name, command string) *Job {
+	return &Job{
+		name:   name,
+		target: command,
+		native: true,
+		env:    make(map[string]string),
+	}
+}
+
+// Name provides a name to a job.
+func (job *Job) Name(name string) *Job {
+	job.name = name
+
+	return job
+}
+
+// Environment appends an environment variable to the job.
+func (job *Job) Environment(name, value string) *Job {
+	job.env[name] = value
+
+	return job
+}
+
+// EnvironmentFromSecret appends an environment variable from secret to the job.
+func (job *Job) EnvironmentFromSecret(name, secretName string) *Job {
+	job.env[name] = fmt.Sprintf("${{ secrets.%s }}", secretName)
+
+	return job
+}
+
+// Needs appends to a list of job dependencies.
+func (job *Job) Needs(needs ...string) *Job {
+	job.needs = append(job.needs, needs...)
+
+	return job
+}
+
+// RunsOn sets labels of the runners the job runs on.
+func (job *Job) RunsOn(labels ...string) *Job {
+	job.runsOn = labels
+
+	return job
+}
+
+// SetupGo installs Go of the specified version on the runner.
+func (job *Job) SetupGo(version string) *Job {
+	job.preSteps = append(job.preSteps, stepSpec{
+		Name: "set up Go",
+		Uses: "actions/setup-go@v2",
+		With: map[string]string{
+			"go-version": version,
+		},
+	})
+
+	return job
+}
+
+// ExceptPullRequest adds condition to skip job on PRs.
+func (job *Job) ExceptPullRequest() *Job {
+	job.conditions = append(job.conditions, "github.event_name != 'pull_request'")
+
+	return job
+}
+
+// OnlyOnPullRequest adds condition to run job only on PRs.
+func (job *Job) OnlyOnPullRequest() *Job {
+	job.conditions = append(job.conditions, "github.event_name == 'pull_request'")
+
+	return job
+}
+
+// OnlyOnBranch adds condition to run job only on the specified branch.
+func (job *Job) OnlyOnBranch(branch string) *Job {
+	job.conditions = append(job.conditions, fmt.Sprintf("github.ref == 'refs/heads/%s'", branch))
+
+	return job
+}
+
+// OnlyOnTag adds condition to run job only on tags.
+func (job *Job) OnlyOnTag() *Job {
+	job.conditions = append(job.conditions, "startsWith(github.ref, 'refs/tags/')")
+
+	return job
+}
+
+// DockerLogin sets up login to registry.
+func (job *Job) DockerLogin() *Job {
+	job.preSteps = append(job.preSteps, stepSpec{
+		Name: "login to registry",
+		Uses: "docker/login-action@v1",
+		With: map[string]string{
+			"username": "${{ secrets.DOCKER_USERNAME }}",
+			"password": "${{ secrets.DOCKER_PASSWORD }}",
+		},
+	})
+
+	return job
+}
+
+// DockerLoginRegistry sets up login to the specified registry with credentials from the secrets.
+func (job *Job) DockerLoginRegistry(registry, usernameSecret, passwordSecret string) *Job {
+	job.preSteps = append(job.preSteps, stepSpec{
+		Name: fmt.Sprintf("login to %s", registry),
+		Uses: "docker/login-action@v1",
+		With: map[string]string{
+			"registry": registry,
+			"username": fmt.Sprintf("${{ secrets.%s }}", usernameSecret),
+			"password": fmt.Sprintf("${{ secrets.%s }}", passwordSecret),
+		},
+	})
+
+	return job
+}
+
+// UploadArtifact uploads the path as job artifact after the build.
+func (job *Job) UploadArtifact(name, path string) *Job {
+	job.postSteps = append(job.postSteps, stepSpec{
+		Name: fmt.Sprintf("upload %s", name),
+		Uses: "actions/upload-artifact@v2",
+		With: map[string]string{
+			"name": name,
+			"path": path,
+		},
+	})
+
+	return job
+}
+
+// DownloadArtifact downloads the artifact produced by other job to the path.
+func (job *Job) DownloadArtifact(name, path string) *Job {
+	job.preSteps = append(job.preSteps, stepSpec{
+		Name: fmt.Sprintf("download %s", name),
+		Uses: "actions/download-artifact@v2",
+		With: map[string]string{
+			"name": name,
+			"path": path,
+		},
+	})
+
+	return job
+}
+
+func (job *Job) compile(o *Output, jobNames map[string]struct{}) jobSpec {
+	steps := o.commonSteps()
+
+	// native jobs don't build images, so only the sources are checked out
+	if job.native {
+		steps = steps[:1]
+	}
+
+	steps = append(steps, job.preSteps...)
+
+	run := stepSpec{
+		Name:             job.name,
+		Run:              job.target,
+		WorkingDirectory: job.workingDirectory,
+	}
+
+	if len(job.env) > 0 {
+		run.Env = job.env
+	}
+
+	steps = append(steps, run)
+	steps = append(steps, job.postSteps...)
+
+	needs := []string{}
+
+	for _, need := range job.needs {
+		if _, ok := jobNames[need]; ok {
+			needs = append(needs, need)
+		}
+	}
+
+	var runsOn interface{} = o.RunsOn
+	if len(job.runsOn) > 0 {
+		runsOn = job.runsOn
+	}
+
+	return jobSpec{
+		RunsOn: runsOn,
+		Needs:  needs,
+		If:     strings.Join(job.conditions, " && "),
+		Steps:  steps,
+	}
+}
@@ -0,0 +1,223 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package gitlab implements output to .gitlab-ci.yml.
+package gitlab
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = ".gitlab-ci.yml"
+)
+
+// Output implements GitLab CI config generation.
+type Output struct {
+	output.FileAdapter
+	output.ComponentAdapter
+
+	stages    []string
+	jobs      []*Job
+	variables map[string]string
+
+	gitLFS bool
+
+	BuildContainer string
+	DockerImage    string
+}
+
+// NewOutput creates new .gitlab-ci.yml output.
+func NewOutput() *Output {
+	output := &Output{
+		BuildContainer: "autonomy/build-container:latest",
+		DockerImage:    "docker:19.03-dind",
+	}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Job appends a job to the pipeline.
+//
+// Stage of the job is registered in the order of appearance.
+func (o *Output) Job(job *Job) {
+	found := false
+
+	for _, stage := range o.stages {
+		if stage == job.spec.Stage {
+			found = true
+
+			break
+		}
+	}
+
+	if !found {
+		o.stages = append(o.stages, job.spec.Stage)
+	}
+
+	o.component(job)
+
+	o.jobs = append(o.jobs, job)
+}
+
+// component renames the job of the monorepo component, and runs the job script in the component directory.
+//
+// Artifact paths are relative to the repository root.
+func (o *Output) component(job *Job) {
+	job.name, job.spec.Needs = o.ComponentStep(job.name, job.spec.Needs)
+
+	dir := o.ComponentDir()
+	if dir == "." {
+		return
+	}
+
+	job.spec.Script = append([]string{"cd " + dir}, job.spec.Script...)
+
+	if job.spec.Artifacts == nil {
+		return
+	}
+
+	for i := range job.spec.Artifacts.Paths {
+		job.spec.Artifacts.Paths[i] = o.ComponentPath(job.spec.Artifacts.Paths[i])
+	}
+
+	if job.spec.Artifacts.Reports != nil {
+		for i := range job.spec.Artifacts.Reports.JUnit {
+			job.spec.Artifacts.Reports.JUnit[i] = o.ComponentPath(job.spec.Artifacts.Reports.JUnit[i])
+		}
+	}
+}
+
+// Variable sets a global variable.
+func (o *Output) Variable(name, value string) {
+	if o.variables == nil {
+		o.variables = make(map[string]string)
+	}
+
+	o.variables[name] = value
+}
+
+// GitLFS pulls Git LFS objects before every job.
+func (o *Output) GitLFS() {
+	o.gitLFS = true
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileGitLab(o)
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.gitlab(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) gitlab(w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	doc := &yaml.Node{
+		Kind: yaml.MappingNode,
+	}
+
+	appendValue := func(key string, value interface{}) error {
+		var valueNode yaml.Node
+
+		if err := valueNode.Encode(value); err != nil {
+			return err
+		}
+
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &valueNode)
+
+		return nil
+	}
+
+	if err := appendValue("stages", o.stages); err != nil {
+		return err
+	}
+
+	beforeScript := []string{
+		"git fetch --tags",
+	}
+
+	if o.gitLFS {
+		beforeScript = append(beforeScript, "git lfs pull")
+	}
+
+	beforeScript = append(beforeScript,
+		"docker buildx create --driver docker-container --platform linux/amd64 --name local --use",
+		"docker buildx inspect --bootstrap",
+	)
+
+	if err := appendValue("default", defaultSpec{
+		Image:        o.BuildContainer,
+		Services:     []string{o.DockerImage},
+		BeforeScript: beforeScript,
+	}); err != nil {
+		return err
+	}
+
+	variables := map[string]string{
+		"DOCKER_HOST":        "tcp://docker:2375",
+		"DOCKER_TLS_CERTDIR": "",
+		"GIT_DEPTH":          "0",
+	}
+
+	for name, value := range o.variables {
+		variables[name] = value
+	}
+
+	if err := appendValue("variables", variables); err != nil {
+		return err
+	}
+
+	for _, job := range o.jobs {
+		if err := appendValue(job.name, job.compile()); err != nil {
+			return err
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+type defaultSpec struct {
+	Image        string   `yaml:"image"`
+	Services     []string `yaml:"services"`
+	BeforeScript []string `yaml:"before_script"`
+}
+
+// Compiler is implemented by project blocks which support GitLab CI config generation.
+type Compiler interface {
+	CompileGitLab(*Output) error
+}
@@ -0,0 +1,169 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gitlab_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/output"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+)
+
+type GitLabSuite struct {
+	suite.Suite
+}
+
+func (suite *GitLabSuite) SetupSuite() {
+	output.PreambleTimestamp, _ = time.Parse(time.RFC3339, strings.ReplaceAll(time.RFC3339, "07:00", "")) //nolint: errcheck
+	output.PreambleCreator = "test"
+}
+
+type pipeline struct {
+	Stages  []string `yaml:"stages"`
+	Default struct {
+		Image        string   `yaml:"image"`
+		Services     []string `yaml:"services"`
+		BeforeScript []string `yaml:"before_script"`
+	} `yaml:"default"`
+	Variables map[string]string `yaml:"variables"`
+	Jobs      map[string]job    `yaml:",inline"`
+}
+
+type job struct {
+	Stage     string            `yaml:"stage"`
+	Variables map[string]string `yaml:"variables"`
+	Script    []string          `yaml:"script"`
+	Needs     []string          `yaml:"needs"`
+	Artifacts struct {
+		Paths   []string `yaml:"paths"`
+		Reports struct {
+			JUnit []string `yaml:"junit"`
+		} `yaml:"reports"`
+	} `yaml:"artifacts"`
+	Rules []struct {
+		If   string `yaml:"if"`
+		When string `yaml:"when"`
+	} `yaml:"rules"`
+}
+
+func (suite *GitLabSuite) generate(out *gitlab.Output) pipeline {
+	var buf bytes.Buffer
+
+	suite.Require().NoError(out.GenerateFile(".gitlab-ci.yml", &buf))
+
+	suite.Assert().True(strings.HasPrefix(buf.String(), `# THIS FILE WAS AUTOMATICALLY GENERATED, PLEASE DO NOT EDIT.
+#
+# Generated on 2006-01-02T15:04:05Z by test.
+`))
+
+	var result pipeline
+
+	suite.Require().NoError(yaml.Unmarshal(buf.Bytes(), &result))
+
+	return result
+}
+
+func (suite *GitLabSuite) TestGenerateFile() {
+	out := gitlab.NewOutput()
+	out.Variable("GO_VERSION", "1.14")
+	out.GitLFS()
+
+	out.Job(gitlab.MakeJob("lint").Stage("verify"))
+	out.Job(gitlab.MakeJob("unit-tests").
+		Stage("test").
+		Needs("lint").
+		Artifacts("_out/coverage.txt").
+		JUnitReports("_out/junit.xml"))
+	out.Job(gitlab.MakeJob("image-kres", "IMAGE_LOAD=false").Stage("publish").Needs("lint"))
+	out.Job(gitlab.MakeJob("image-kres-push").
+		Name("push-kres").
+		Stage("publish").
+		ExceptMergeRequest().
+		DockerLogin().
+		Needs("image-kres", "unit-tests"))
+
+	result := suite.generate(out)
+
+	// stages are listed in the order of appearance
+	suite.Assert().Equal([]string{"verify", "test", "publish"}, result.Stages)
+
+	suite.Assert().Equal("autonomy/build-container:latest", result.Default.Image)
+	suite.Assert().Equal([]string{"docker:19.03-dind"}, result.Default.Services)
+	suite.Assert().Equal([]string{
+		"git fetch --tags",
+		"git lfs pull",
+		"docker buildx create --driver docker-container --platform linux/amd64 --name local --use",
+		"docker buildx inspect --bootstrap",
+	}, result.Default.BeforeScript)
+
+	suite.Assert().Equal(map[string]string{
+		"DOCKER_HOST":        "tcp://docker:2375",
+		"DOCKER_TLS_CERTDIR": "",
+		"GIT_DEPTH":          "0",
+		"GO_VERSION":         "1.14",
+	}, result.Variables)
+
+	suite.Require().Len(result.Jobs, 4)
+
+	lint := result.Jobs["lint"]
+	suite.Assert().Equal("verify", lint.Stage)
+	suite.Assert().Equal([]string{"make lint"}, lint.Script)
+	suite.Assert().Empty(lint.Needs)
+	suite.Assert().Empty(lint.Rules)
+
+	unitTests := result.Jobs["unit-tests"]
+	suite.Assert().Equal([]string{"lint"}, unitTests.Needs)
+	suite.Assert().Equal([]string{"_out/coverage.txt"}, unitTests.Artifacts.Paths)
+	suite.Assert().Equal([]string{"_out/junit.xml"}, unitTests.Artifacts.Reports.JUnit)
+
+	suite.Assert().Equal([]string{"make image-kres IMAGE_LOAD=false"}, result.Jobs["image-kres"].Script)
+
+	push := result.Jobs["push-kres"]
+	suite.Assert().Equal("publish", push.Stage)
+	suite.Assert().Equal([]string{
+		`docker login --username "${DOCKER_USERNAME}" --password "${DOCKER_PASSWORD}"`,
+		"make image-kres-push",
+	}, push.Script)
+	suite.Assert().Equal([]string{"image-kres", "unit-tests"}, push.Needs)
+	suite.Require().Len(push.Rules, 2)
+	suite.Assert().Equal(`$CI_PIPELINE_SOURCE == "merge_request_event"`, push.Rules[0].If)
+	suite.Assert().Equal("never", push.Rules[0].When)
+	suite.Assert().Equal("on_success", push.Rules[1].When)
+}
+
+func (suite *GitLabSuite) TestComponent() {
+	out := gitlab.NewOutput()
+
+	out.Component("services/foo")
+	out.Job(gitlab.MakeJob("lint").Stage("lint"))
+	out.Job(gitlab.MakeJob("unit-tests").Stage("test").Needs("lint").Artifacts("_out/coverage.txt"))
+
+	out.Component(".")
+	out.Job(gitlab.MakeJob("lint").Stage("lint"))
+
+	result := suite.generate(out)
+
+	suite.Assert().Equal([]string{"lint", "test"}, result.Stages)
+	suite.Require().Len(result.Jobs, 3)
+
+	suite.Assert().Equal([]string{"cd services/foo", "make lint"}, result.Jobs["services-foo-lint"].Script)
+
+	unitTests := result.Jobs["services-foo-unit-tests"]
+	suite.Assert().Equal([]string{"cd services/foo", "make unit-tests"}, unitTests.Script)
+	suite.Assert().Equal([]string{"services-foo-lint"}, unitTests.Needs)
+	suite.Assert().Equal([]string{"services/foo/_out/coverage.txt"}, unitTests.Artifacts.Paths)
+
+	suite.Assert().Equal([]string{"make lint"}, result.Jobs["lint"].Script)
+}
+
+func TestGitLabSuite(t *testing.T) {
+	suite.Run(t, new(GitLabSuite))
+}
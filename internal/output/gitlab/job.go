@@ -0,0 +1,202 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gitlab
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Job is a pipeline job.
+type Job struct {
+	name string
+	spec jobSpec
+
+	exceptMergeRequest  bool
+	onlyOnMergeRequest  bool
+	onlyOnDefaultBranch bool
+	onlyOnTag           bool
+}
+
+type jobSpec struct {
+	Stage     string            `yaml:"stage"`
+	Variables map[string]string `yaml:"variables,omitempty"`
+	Script    []string          `yaml:"script"`
+	Needs     []string          `yaml:"needs"`
+	Artifacts *artifactsSpec    `yaml:"artifacts,omitempty"`
+	Rules     []ruleSpec        `yaml:"rules,omitempty"`
+}
+
+type artifactsSpec struct {
+	Paths   []string     `yaml:"paths"`
+	Reports *reportsSpec `yaml:"reports,omitempty"`
+}
+
+type reportsSpec struct {
+	JUnit []string `yaml:"junit"`
+}
+
+type ruleSpec struct {
+	If   string `yaml:"if,omitempty"`
+	When string `yaml:"when"`
+}
+
+// MakeJob creates a job which calls make target.
+func MakeJob(target string, args ...string) *Job {
+	return &Job{
+		name: target,
+		spec: jobSpec{
+			Script: []string{
+				strings.TrimSpace(fmt.Sprintf("make %s %s", target, strings.Join(args, " "))),
+			},
+			Variables: make(map[string]string),
+			Needs:     []string{},
+		},
+	}
+}
+
+// Name provides a name to a job.
+func (job *Job) Name(name string) *Job {
+	job.name = name
+
+	return job
+}
+
+// Stage sets the stage of the job.
+func (job *Job) Stage(stage string) *Job {
+	job.spec.Stage = stage
+
+	return job
+}
+
+// Variable appends an environment variable to the job.
+func (job *Job) Variable(name, value string) *Job {
+	job.spec.Variables[name] = value
+
+	return job
+}
+
+// Needs appends to a list of job dependencies.
+func (job *Job) Needs(needs ...string) *Job {
+	job.spec.Needs = append(job.spec.Needs, needs...)
+
+	return job
+}
+
+// Artifacts appends paths to the list of job artifacts passed to dependent jobs.
+func (job *Job) Artifacts(paths ...string) *Job {
+	if job.spec.Artifacts == nil {
+		job.spec.Artifacts = &artifactsSpec{}
+	}
+
+	job.spec.Artifacts.Paths = append(job.spec.Artifacts.Paths, paths...)
+
+	return job
+}
+
+// JUnitReports appends paths to the list of JUnit XML reports shown in merge requests and pipelines.
+func (job *Job) JUnitReports(paths ...string) *Job {
+	if job.spec.Artifacts == nil {
+		job.spec.Artifacts = &artifactsSpec{}
+	}
+
+	if job.spec.Artifacts.Reports == nil {
+		job.spec.Artifacts.Reports = &reportsSpec{}
+	}
+
+	job.spec.Artifacts.Reports.JUnit = append(job.spec.Artifacts.Reports.JUnit, paths...)
+
+	return job
+}
+
+// ExceptMergeRequest adds condition to skip job on merge requests.
+func (job *Job) ExceptMergeRequest() *Job {
+	job.exceptMergeRequest = true
+
+	return job
+}
+
+// OnlyOnMergeRequest adds condition to run job only on merge requests.
+func (job *Job) OnlyOnMergeRequest() *Job {
+	job.onlyOnMergeRequest = true
+
+	return job
+}
+
+// OnlyOnDefaultBranch adds condition to run job only on the default branch.
+func (job *Job) OnlyOnDefaultBranch() *Job {
+	job.onlyOnDefaultBranch = true
+
+	return job
+}
+
+// OnlyOnTag adds condition to run job only on tags.
+func (job *Job) OnlyOnTag() *Job {
+	job.onlyOnTag = true
+
+	return job
+}
+
+// DockerLogin sets up login to registry.
+//
+// DOCKER_USERNAME and DOCKER_PASSWORD should be set as CI/CD variables of the project.
+func (job *Job) DockerLogin() *Job {
+	job.spec.Script = append([]string{
+		`docker login --username "${DOCKER_USERNAME}" --password "${DOCKER_PASSWORD}"`,
+	}, job.spec.Script...)
+
+	return job
+}
+
+// DockerLoginRegistry sets up login to the specified registry.
+//
+// Credentials are taken from the CI/CD variables with the specified names.
+func (job *Job) DockerLoginRegistry(registry, usernameVariable, passwordVariable string) *Job {
+	job.spec.Script = append([]string{
+		fmt.Sprintf(`docker login --username "${%s}" --password "${%s}" %s`, usernameVariable, passwordVariable, registry),
+	}, job.spec.Script...)
+
+	return job
+}
+
+func (job *Job) compile() jobSpec {
+	spec := job.spec
+
+	if job.exceptMergeRequest {
+		spec.Rules = append(spec.Rules, ruleSpec{
+			If:   `$CI_PIPELINE_SOURCE == "merge_request_event"`,
+			When: "never",
+		})
+	}
+
+	if job.onlyOnMergeRequest {
+		spec.Rules = append(spec.Rules, ruleSpec{
+			If:   `$CI_PIPELINE_SOURCE != "merge_request_event"`,
+			When: "never",
+		})
+	}
+
+	if job.onlyOnDefaultBranch {
+		spec.Rules = append(spec.Rules, ruleSpec{
+			If:   `$CI_COMMIT_BRANCH != $CI_DEFAULT_BRANCH`,
+			When: "never",
+		})
+	}
+
+	if job.onlyOnTag {
+		spec.Rules = append(spec.Rules, ruleSpec{
+			If:   `$CI_COMMIT_TAG == null`,
+			When: "never",
+		})
+	}
+
+	if len(spec.Rules) > 0 {
+		spec.Rules = append(spec.Rules, ruleSpec{
+			When: "on_success",
+		})
+	}
+
+	return spec
+}
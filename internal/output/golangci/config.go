@@ -4,6 +4,19 @@
 
 package golangci
 
+// defaultDisabledLinters are disabled unless the list of enabled linters is set explicitly.
+var defaultDisabledLinters = []string{
+	"gas",
+	"typecheck",
+	"gochecknoglobals",
+	"gochecknoinits",
+	"funlen",
+	"godox",
+	"gomnd",
+	"goerr113",
+	"nestif",
+}
+
 const config = `
 # options for analysis running
 run:
@@ -116,25 +129,12 @@ linters-settings:
     extra-rules: false
 
 linters:
-  enable-all: true
-  disable:
-    - gas
-    - typecheck
-    - gochecknoglobals
-    - gochecknoinits
-    - funlen
-    - godox
-    - gomnd
-    - goerr113
-    - nestif
-  disable-all: false
-  fast: false
+%[2]s  fast: false
 
 
 issues:
   exclude: []
-  exclude-rules: []
-  exclude-use-default: false
+%[3]s  exclude-use-default: false
   exclude-case-sensitive: false
   max-issues-per-linter: 10
   max-same-issues: 3
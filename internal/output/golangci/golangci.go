@@ -8,6 +8,8 @@ package golangci
 import (
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 
 	"github.com/talos-systems/kres/internal/output"
 )
@@ -22,6 +24,21 @@ type Output struct {
 
 	enabled       bool
 	canonicalPath string
+
+	enableLinters  []string
+	disableLinters []string
+
+	excludeRules []ExcludeRule
+}
+
+// ExcludeRule excludes issues reported for the files matching the path.
+type ExcludeRule struct {
+	// Path is a regular expression matched against the file path relative to the project root.
+	Path string
+	// Linters are the linters the issues are excluded for, issues of all linters are excluded if empty.
+	Linters []string
+	// Text is a regular expression matched against the issue text.
+	Text string
 }
 
 // NewOutput creates new Makefile output.
@@ -56,6 +73,74 @@ func (o *Output) CanonicalPath(path string) {
 	o.canonicalPath = path
 }
 
+// Linters sets linters to enable and disable.
+//
+// If the linters to enable are set, only these linters are enabled, otherwise all the linters
+// are enabled except for the default disabled ones and the linters to disable.
+func (o *Output) Linters(enable, disable []string) {
+	o.enableLinters = append([]string(nil), enable...)
+	o.disableLinters = append([]string(nil), disable...)
+}
+
+// ExcludeRules appends rules excluding issues.
+func (o *Output) ExcludeRules(rules ...ExcludeRule) {
+	o.excludeRules = append(o.excludeRules, rules...)
+}
+
+// renderExcludeRules renders the exclude-rules of the issues section of the config.
+func (o *Output) renderExcludeRules() string {
+	if len(o.excludeRules) == 0 {
+		return "  exclude-rules: []\n"
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("  exclude-rules:\n")
+
+	for _, rule := range o.excludeRules {
+		fmt.Fprintf(&sb, "    - path: %s\n", strconv.Quote(rule.Path))
+
+		if len(rule.Linters) > 0 {
+			sb.WriteString("      linters:\n")
+
+			for _, linter := range rule.Linters {
+				fmt.Fprintf(&sb, "        - %s\n", linter)
+			}
+		}
+
+		if rule.Text != "" {
+			fmt.Fprintf(&sb, "      text: %s\n", strconv.Quote(rule.Text))
+		}
+	}
+
+	return sb.String()
+}
+
+// linters renders the linters section of the config.
+func (o *Output) linters() string {
+	var sb strings.Builder
+
+	if len(o.enableLinters) > 0 {
+		sb.WriteString("  disable-all: true\n  enable:\n")
+
+		for _, linter := range o.enableLinters {
+			fmt.Fprintf(&sb, "    - %s\n", linter)
+		}
+
+		return sb.String()
+	}
+
+	sb.WriteString("  enable-all: true\n  disable:\n")
+
+	for _, linter := range append(append([]string(nil), defaultDisabledLinters...), o.disableLinters...) {
+		fmt.Fprintf(&sb, "    - %s\n", linter)
+	}
+
+	sb.WriteString("  disable-all: false\n")
+
+	return sb.String()
+}
+
 // Filenames implements output.FileWriter interface.
 func (o *Output) Filenames() []string {
 	if !o.enabled {
@@ -80,7 +165,7 @@ func (o *Output) config(w io.Writer) error {
 		return err
 	}
 
-	if _, err := fmt.Fprintf(w, config, o.canonicalPath); err != nil {
+	if _, err := fmt.Fprintf(w, config, o.canonicalPath, o.linters(), o.renderExcludeRules()); err != nil {
 		return err
 	}
 
@@ -0,0 +1,143 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package goreleaser implements output to .goreleaser.yaml.
+package goreleaser
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = ".goreleaser.yaml"
+)
+
+// Output implements .goreleaser.yaml generation.
+type Output struct {
+	output.FileAdapter
+
+	enabled bool
+
+	builds   []Build
+	archive  Archive
+	checksum Checksum
+}
+
+// Build is a goreleaser build entry.
+type Build struct {
+	ID      string   `yaml:"id"`
+	Main    string   `yaml:"main"`
+	Binary  string   `yaml:"binary"`
+	Env     []string `yaml:"env,omitempty"`
+	Flags   []string `yaml:"flags,omitempty"`
+	Ldflags []string `yaml:"ldflags,omitempty"`
+	// Targets are GOOS_GOARCH pairs.
+	Targets []string `yaml:"targets"`
+}
+
+// Archive is a goreleaser archive settings.
+type Archive struct {
+	NameTemplate string `yaml:"name_template,omitempty"`
+	Format       string `yaml:"format,omitempty"`
+}
+
+// Checksum is a goreleaser checksum settings.
+type Checksum struct {
+	NameTemplate string `yaml:"name_template,omitempty"`
+	Algorithm    string `yaml:"algorithm,omitempty"`
+	Disable      bool   `yaml:"disable,omitempty"`
+}
+
+// NewOutput creates new .goreleaser.yaml output.
+func NewOutput() *Output {
+	output := &Output{}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileGoreleaser(o)
+}
+
+// Enable should be called to enable config generation.
+func (o *Output) Enable() {
+	o.enabled = true
+}
+
+// Build appends a build entry.
+func (o *Output) Build(build Build) {
+	o.builds = append(o.builds, build)
+}
+
+// Archive sets archive settings.
+func (o *Output) Archive(archive Archive) {
+	o.archive = archive
+}
+
+// Checksum sets checksum settings.
+func (o *Output) Checksum(checksum Checksum) {
+	o.checksum = checksum
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	if !o.enabled {
+		return nil
+	}
+
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.config(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) config(w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	config := struct {
+		Builds   []Build   `yaml:"builds"`
+		Archives []Archive `yaml:"archives"`
+		Checksum Checksum  `yaml:"checksum"`
+	}{
+		Builds:   o.builds,
+		Archives: []Archive{o.archive},
+		Checksum: o.checksum,
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(config); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// Compiler is implemented by project blocks which support .goreleaser.yaml generate.
+type Compiler interface {
+	CompileGoreleaser(*Output) error
+}
@@ -14,6 +14,7 @@ const (
 	VariableGroupCommon = "common variables"
 	VariableGroupDocker = "docker build settings"
 	VariableGroupHelp   = "help menu"
+	VariableGroupExtra  = "extra variables"
 )
 
 // VariableGroup is a way to group nicely variables in Makefile.
@@ -23,12 +24,33 @@ type VariableGroup struct {
 }
 
 // Variable appends variable to the group.
+//
+// Variable is defined once if several nodes share it, the first definition wins.
 func (group *VariableGroup) Variable(variable *Variable) *VariableGroup {
+	for _, v := range group.variables {
+		if v.name == variable.name {
+			return group
+		}
+	}
+
 	group.variables = append(group.variables, variable)
 
 	return group
 }
 
+// Push appends extra value (+=) to the variable already defined in the group.
+//
+// Push is a no-op if the variable is not defined.
+func (group *VariableGroup) Push(name, line string) *VariableGroup {
+	for _, v := range group.variables {
+		if v.name == name {
+			v.Push(line)
+		}
+	}
+
+	return group
+}
+
 // Generate renders group to output.
 func (group *VariableGroup) Generate(w io.Writer) error {
 	if _, err := fmt.Fprintf(w, "# %s\n\n", group.description); err != nil {
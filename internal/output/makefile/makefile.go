@@ -6,6 +6,7 @@
 package makefile
 
 import (
+	"fmt"
 	"io"
 	"sort"
 
@@ -24,11 +25,16 @@ type Output struct {
 	variableGroupOrder []string
 
 	targets []*Target
+	aliases map[string][]string
+
+	defaultGoal string
 }
 
 // NewOutput creates new Makefile output.
 func NewOutput() *Output {
-	output := &Output{}
+	output := &Output{
+		aliases: map[string][]string{},
+	}
 
 	output.FileAdapter.FileWriter = output
 
@@ -61,6 +67,30 @@ func (o *Output) Target(name string) *Target {
 	return target
 }
 
+// Alias creates target running the targets one by one, it stops on the first failure.
+//
+// Targets are verified to be defined in the Makefile when it's generated.
+func (o *Output) Alias(name string, targets ...string) *Target {
+	target := o.Target(name).Phony()
+
+	for _, dependency := range targets {
+		target.Script("@$(MAKE) " + dependency)
+	}
+
+	o.aliases[name] = append([]string(nil), targets...)
+
+	return target
+}
+
+// DefaultGoal sets the target run by `make` without arguments.
+//
+// By default, the first target (`all`) is run.
+func (o *Output) DefaultGoal(target string) *Output {
+	o.defaultGoal = target
+
+	return o
+}
+
 // Compile implements output.Writer interface.
 func (o *Output) Compile(node interface{}) error {
 	compiler, implements := node.(Compiler)
@@ -88,6 +118,10 @@ func (o *Output) GenerateFile(filename string, w io.Writer) error {
 }
 
 func (o *Output) makefile(w io.Writer) error {
+	if err := o.verifyAliases(); err != nil {
+		return err
+	}
+
 	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
 		return err
 	}
@@ -98,6 +132,12 @@ func (o *Output) makefile(w io.Writer) error {
 		}
 	}
 
+	if o.defaultGoal != "" {
+		if _, err := fmt.Fprintf(w, ".DEFAULT_GOAL := %s\n\n", o.defaultGoal); err != nil {
+			return err
+		}
+	}
+
 	sort.SliceStable(o.targets, func(i, j int) bool {
 		return o.targets[i].name == "all"
 	})
@@ -111,6 +151,37 @@ func (o *Output) makefile(w io.Writer) error {
 	return nil
 }
 
+// verifyAliases checks that the aliases refer to the defined targets.
+func (o *Output) verifyAliases() error {
+	defined := make(map[string]int, len(o.targets))
+
+	for _, target := range o.targets {
+		defined[target.name]++
+	}
+
+	names := make([]string, 0, len(o.aliases))
+
+	for name := range o.aliases {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if defined[name] > 1 {
+			return fmt.Errorf("alias %q conflicts with the generated target", name)
+		}
+
+		for _, target := range o.aliases[name] {
+			if defined[target] == 0 {
+				return fmt.Errorf("alias %q refers to unknown target %q", name, target)
+			}
+		}
+	}
+
+	return nil
+}
+
 // Compiler is implemented by project blocks which support Dockerfile generate.
 type Compiler interface {
 	CompileMakefile(*Output) error
@@ -31,7 +31,8 @@ func (suite *MakefileSuite) TestGenerateFile() {
 	output.VariableGroup(makefile.VariableGroupCommon).
 		Variable(makefile.SimpleVariable("FOO", "bar")).
 		Variable(makefile.RecursiveVariable("BLA", "bla")).
-		Variable(makefile.OverridableVariable("DEFAULT", "unknown"))
+		Variable(makefile.OverridableVariable("DEFAULT", "unknown")).
+		Variable(makefile.OverridableVariable("DEFAULT", "shadowed"))
 
 	output.VariableGroup(makefile.VariableGroupDocker).
 		Variable(makefile.SimpleVariable("BUILD", "docker buildx build").Export()).
@@ -92,6 +93,35 @@ build:  ## build everything
 `, buf.String())
 }
 
+func (suite *MakefileSuite) TestDefaultGoal() {
+	output := &makefile.Output{}
+
+	output.DefaultGoal("help")
+
+	output.Target("all").
+		Depends("foo")
+
+	output.Target("help").
+		Description("This help menu.")
+
+	var buf bytes.Buffer
+
+	err := output.GenerateFile("Makefile", &buf)
+	suite.Require().NoError(err)
+
+	suite.Assert().Equal(`# THIS FILE WAS AUTOMATICALLY GENERATED, PLEASE DO NOT EDIT.
+#
+# Generated on 2006-01-02T15:04:05Z by test.
+
+.DEFAULT_GOAL := help
+
+all: foo
+
+help:  ## This help menu.
+
+`, buf.String())
+}
+
 func TestMakefileSuite(t *testing.T) {
 	suite.Run(t, new(MakefileSuite))
 }
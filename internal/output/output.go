@@ -8,5 +8,13 @@ package output
 // Writer is an interface which should be implemented by outputs.
 type Writer interface {
 	Generate() error
+	Diff() ([]Change, error)
 	Compile(interface{}) error
+	Root(string)
+}
+
+// RunnerPlatform is implemented by the nodes which should run on CI runners of the specific platform.
+type RunnerPlatform interface {
+	// RunnerPlatform returns `os/arch` of the runners, empty for the default (linux/amd64) runners.
+	RunnerPlatform() string
 }
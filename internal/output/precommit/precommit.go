@@ -0,0 +1,207 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package precommit implements output to .pre-commit-config.yaml.
+package precommit
+
+import (
+	"io"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = ".pre-commit-config.yaml"
+)
+
+// Output implements .pre-commit-config.yaml generation.
+//
+// Hooks run Makefile targets, so that the checks are defined once for CI and local runs.
+//
+// pre-commit reads the config at the repository root only, so the hooks of the monorepo components
+// are prefixed with the component directory, run the targets in it, and match only the files of the component.
+type Output struct {
+	output.FileAdapter
+	output.ComponentAdapter
+
+	enabled bool
+
+	hooks     map[string]*Hook
+	overrides map[string]bool
+}
+
+// NewOutput creates new .pre-commit-config.yaml output.
+func NewOutput() *Output {
+	output := &Output{
+		hooks:     map[string]*Hook{},
+		overrides: map[string]bool{},
+	}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompilePreCommit(o)
+}
+
+// Enable should be called to enable config generation.
+func (o *Output) Enable() {
+	o.enabled = true
+}
+
+// Hook registers a hook which runs the Makefile target.
+func (o *Output) Hook(id, target string) *Hook {
+	id = o.ComponentName(id)
+
+	hook := &Hook{
+		spec: hookSpec{
+			ID:       id,
+			Name:     id,
+			Entry:    "make " + target,
+			Language: "system",
+		},
+		enabled: true,
+	}
+
+	if dir := o.ComponentDir(); dir != "." {
+		hook.spec.Entry = "make -C " + dir + " " + target
+		hook.spec.Files = "^" + regexp.QuoteMeta(dir+"/")
+	}
+
+	o.hooks[id] = hook
+
+	return hook
+}
+
+// Override enables or disables the hook of the component regardless of its default.
+func (o *Output) Override(id string, enabled bool) {
+	o.overrides[o.ComponentName(id)] = enabled
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	if !o.enabled {
+		return nil
+	}
+
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.config(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) config(w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(o.hooks))
+
+	for id := range o.hooks {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	hooks := []hookSpec{}
+
+	for _, id := range ids {
+		enabled := o.hooks[id].enabled
+
+		if override, ok := o.overrides[id]; ok {
+			enabled = override
+		}
+
+		if enabled {
+			hooks = append(hooks, o.hooks[id].spec)
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(configSpec{
+		Repos: []repoSpec{
+			{
+				Repo:  "local",
+				Hooks: hooks,
+			},
+		},
+	}); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// Hook is a local pre-commit hook.
+type Hook struct {
+	spec    hookSpec
+	enabled bool
+}
+
+// Name sets the name of the hook shown in the pre-commit output.
+func (hook *Hook) Name(name string) *Hook {
+	hook.spec.Name = name
+
+	return hook
+}
+
+// Types limits the hooks to run only if the files of the types are committed (e.g. go).
+func (hook *Hook) Types(types ...string) *Hook {
+	hook.spec.Types = append(hook.spec.Types, types...)
+
+	return hook
+}
+
+// Optional disables the hook unless it's enabled explicitly.
+func (hook *Hook) Optional() *Hook {
+	hook.enabled = false
+
+	return hook
+}
+
+type configSpec struct {
+	Repos []repoSpec `yaml:"repos"`
+}
+
+type repoSpec struct {
+	Repo  string     `yaml:"repo"`
+	Hooks []hookSpec `yaml:"hooks"`
+}
+
+type hookSpec struct {
+	ID            string   `yaml:"id"`
+	Name          string   `yaml:"name"`
+	Entry         string   `yaml:"entry"`
+	Language      string   `yaml:"language"`
+	PassFilenames bool     `yaml:"pass_filenames"`
+	Files         string   `yaml:"files,omitempty"`
+	Types         []string `yaml:"types,omitempty"`
+}
+
+// Compiler is implemented by project blocks which support .pre-commit-config.yaml generation.
+type Compiler interface {
+	CompilePreCommit(*Output) error
+}
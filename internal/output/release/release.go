@@ -9,6 +9,10 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/talos-systems/kres/internal/output"
 )
@@ -34,7 +38,23 @@ function changelog {
 }
 
 function release-notes {
-  git-chglog --output ${1} -c ./hack/git-chglog/config.yaml "${2}"
+  if [ $# -ne 2 ]; then
+    echo 1>&2 "Usage: $0 release-notes <output> <tag>"
+    exit 1
+  fi
+
+  local chglog="git-chglog"
+
+  if ! command -v git-chglog > /dev/null; then
+    chglog="docker run --rm -v ${PWD}:/src -w /src ${GIT_CHGLOG_IMAGE:-quay.io/git-chglog/git-chglog:0.15.0}"
+  fi
+
+  # commits since the last tag are listed under the next tag if it doesn't exist yet
+  if git rev-parse -q --verify "refs/tags/${2}" > /dev/null; then
+    ${chglog} --output ${1} -c ./hack/git-chglog/config.yaml "${2}"
+  else
+    ${chglog} --output ${1} -c ./hack/git-chglog/config.yaml --next-tag "${2}" "${2}"
+  fi
 }
 
 function cherry-pick {
@@ -66,20 +86,23 @@ then
 else
   cat <<EOF
 Usage:
-  commit:       Create the official release commit message.
-  cherry-pick:  Cherry-pick a commit into a release branch.
-  changelog:    Update the specified CHANGELOG.
+  commit:         Create the official release commit message.
+  cherry-pick:    Cherry-pick a commit into a release branch.
+  changelog:      Update the specified CHANGELOG.
+  release-notes:  Generate release notes for the tag.
 EOF
 
   exit 1
 fi`
 
-const configStr = `style: github
-template: CHANGELOG.tpl.md
+const configHeaderStr = `style: github
+template: %s
 info:
   title: CHANGELOG
-  repository_url: https://github.com/talos-systems/talos
-options:
+  repository_url: %s
+options:`
+
+const configCommitsStr = `
   commits:
     # filters:
     #   Type:
@@ -92,7 +115,9 @@ options:
     #   feat: Features
     #   fix: Bug Fixes
     #   perf: Performance Improvements
-    #   refactor: Code Refactoring
+    #   refactor: Code Refactoring`
+
+const configFooterStr = `
   header:
     pattern: "^(\\w*)(?:\\(([\\w\\$\\.\\-\\*\\s]*)\\))?\\:\\s(.*)$"
     pattern_maps:
@@ -111,7 +136,7 @@ const templateStr = `{{ range .Versions }}
 ### {{ .Title }}
 
 {{ range .Commits -}}
-* {{ if .Scope }}**{{ .Scope }}:** {{ end }}{{ .Subject }}
+* %s{{ .Subject }}
 {{ end }}
 {{ end -}}
 
@@ -126,14 +151,21 @@ const templateStr = `{{ range .Versions }}
 {{ end -}}
 {{ end -}}`
 
-// Output implements .gitignore generation.
+// Output implements release scripts and changelog config generation.
 type Output struct {
 	output.FileAdapter
+
+	repositoryURL  string
+	customTemplate string
+	typeHeadings   map[string]string
+	scopeHeadings  map[string]string
 }
 
-// NewOutput creates new Makefile output.
+// NewOutput creates new release output.
 func NewOutput() *Output {
-	output := &Output{}
+	output := &Output{
+		repositoryURL: "https://github.com/talos-systems/talos",
+	}
 
 	output.FileAdapter.FileWriter = output
 
@@ -151,8 +183,42 @@ func (o *Output) Compile(node interface{}) error {
 	return compiler.CompileRelease(o)
 }
 
+// RepositoryURL sets the repository URL used for the links in the changelog.
+func (o *Output) RepositoryURL(url string) *Output {
+	o.repositoryURL = url
+
+	return o
+}
+
+// Template sets the path to the custom changelog template (relative to the project root).
+//
+// Default template is not generated when custom template is used.
+func (o *Output) Template(path string) *Output {
+	o.customTemplate = path
+
+	return o
+}
+
+// TypeHeadings sets the commit types included into the changelog and their headings.
+func (o *Output) TypeHeadings(headings map[string]string) *Output {
+	o.typeHeadings = headings
+
+	return o
+}
+
+// ScopeHeadings sets the headings the commit scopes are rendered with.
+func (o *Output) ScopeHeadings(headings map[string]string) *Output {
+	o.scopeHeadings = headings
+
+	return o
+}
+
 // Filenames implements output.FileWriter interface.
 func (o *Output) Filenames() []string {
+	if o.customTemplate != "" {
+		return []string{release, config}
+	}
+
 	return []string{release, config, template}
 }
 
@@ -200,25 +266,105 @@ func (o *Output) config(w io.Writer) error {
 		return err
 	}
 
-	if _, err := fmt.Fprintf(w, "%s\n", configStr); err != nil {
+	// template path is relative to the config
+	templatePath := filepath.Base(template)
+
+	if o.customTemplate != "" {
+		path, err := filepath.Rel(filepath.Dir(config), o.customTemplate)
+		if err != nil {
+			return err
+		}
+
+		templatePath = filepath.ToSlash(path)
+	}
+
+	if _, err := fmt.Fprintf(w, configHeaderStr, templatePath, o.repositoryURL); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "%s%s\n", o.commitsConfig(), configFooterStr); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// commitsConfig returns commit filters and group titles, all commit types are included by default.
+func (o *Output) commitsConfig() string {
+	if len(o.typeHeadings) == 0 {
+		return configCommitsStr
+	}
+
+	types := make([]string, 0, len(o.typeHeadings))
+
+	for typ := range o.typeHeadings {
+		types = append(types, typ)
+	}
+
+	sort.Strings(types)
+
+	var b strings.Builder
+
+	b.WriteString("\n  commits:\n    filters:\n      Type:\n")
+
+	for _, typ := range types {
+		fmt.Fprintf(&b, "        - %s\n", typ)
+	}
+
+	b.WriteString("  commit_groups:\n    title_maps:")
+
+	for _, typ := range types {
+		fmt.Fprintf(&b, "\n      %s: %s", typ, strconv.Quote(o.typeHeadings[typ]))
+	}
+
+	return b.String()
+}
+
 func (o *Output) template(w io.Writer) error {
 	if _, err := w.Write([]byte(output.Preamble("<!-- ", " -->"))); err != nil {
 		return err
 	}
 
-	if _, err := fmt.Fprintf(w, "%s\n", templateStr); err != nil {
+	if _, err := fmt.Fprintf(w, templateStr+"\n", o.scopeTemplate()); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// scopeTemplate renders the commit scope, scopes with headings are replaced.
+func (o *Output) scopeTemplate() string {
+	scopes := make([]string, 0, len(o.scopeHeadings))
+
+	for scope := range o.scopeHeadings {
+		scopes = append(scopes, scope)
+	}
+
+	sort.Strings(scopes)
+
+	var b strings.Builder
+
+	for i, scope := range scopes {
+		if i == 0 {
+			b.WriteString("{{ if ")
+		} else {
+			b.WriteString("{{ else if ")
+		}
+
+		fmt.Fprintf(&b, "eq .Scope %s }}**%s:** ", strconv.Quote(scope), o.scopeHeadings[scope])
+	}
+
+	if len(scopes) == 0 {
+		b.WriteString("{{ if .Scope }}")
+	} else {
+		b.WriteString("{{ else if .Scope }}")
+	}
+
+	b.WriteString("**{{ .Scope }}:** {{ end }}")
+
+	return b.String()
+}
+
 // Compiler is implemented by project blocks which support Dockerfile generate.
 type Compiler interface {
 	CompileRelease(*Output) error
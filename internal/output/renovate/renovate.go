@@ -0,0 +1,179 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package renovate implements output to renovate.json.
+package renovate
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = "renovate.json"
+
+	// JSON doesn't support comments, and timestamp would cause the file to be rewritten every time.
+	description = "THIS FILE WAS AUTOMATICALLY GENERATED, PLEASE DO NOT EDIT."
+
+	// the file is rewritten on every generation, so custom settings should go to the kres config.
+	customRules = "Custom presets and package rules should be set in .kres.yaml (common.Renovate extends and packageRules)."
+)
+
+// PackageRule is a Renovate package rule.
+type PackageRule map[string]interface{}
+
+// Output implements renovate.json generation.
+type Output struct {
+	output.FileAdapter
+
+	enabled bool
+
+	extends         []string
+	enabledManagers []string
+	schedule        []string
+	timezone        string
+	packageRules    []PackageRule
+}
+
+// NewOutput creates new renovate.json output.
+func NewOutput() *Output {
+	output := &Output{}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileRenovate(o)
+}
+
+// Enable should be called to enable config generation.
+func (o *Output) Enable() {
+	o.enabled = true
+}
+
+// Extend appends presets to extend.
+//
+// The config is shared by the monorepo components, so presets which are already present are skipped.
+func (o *Output) Extend(presets ...string) *Output {
+	o.extends = appendUnique(o.extends, presets...)
+
+	return o
+}
+
+// EnableManager appends package managers to the list of enabled ones.
+func (o *Output) EnableManager(managers ...string) *Output {
+	o.enabledManagers = appendUnique(o.enabledManagers, managers...)
+
+	return o
+}
+
+// Schedule sets the update schedule.
+func (o *Output) Schedule(timezone string, schedule ...string) *Output {
+	o.timezone = timezone
+	o.schedule = schedule
+
+	return o
+}
+
+// PackageRule appends package rules, rules which are already present are skipped.
+func (o *Output) PackageRule(rules ...PackageRule) *Output {
+	for _, rule := range rules {
+		if !o.hasPackageRule(rule) {
+			o.packageRules = append(o.packageRules, rule)
+		}
+	}
+
+	return o
+}
+
+func (o *Output) hasPackageRule(rule PackageRule) bool {
+	for _, existing := range o.packageRules {
+		if reflect.DeepEqual(existing, rule) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendUnique(values []string, newValues ...string) []string {
+	for _, value := range newValues {
+		if !contains(values, value) {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func contains(values []string, value string) bool {
+	for _, existing := range values {
+		if existing == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	if !o.enabled {
+		return nil
+	}
+
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.config(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) config(w io.Writer) error {
+	config := struct {
+		Schema          string        `json:"$schema"`
+		Description     []string      `json:"description"`
+		Extends         []string      `json:"extends,omitempty"`
+		EnabledManagers []string      `json:"enabledManagers,omitempty"`
+		Timezone        string        `json:"timezone,omitempty"`
+		Schedule        []string      `json:"schedule,omitempty"`
+		PackageRules    []PackageRule `json:"packageRules,omitempty"`
+	}{
+		Schema:          "https://docs.renovatebot.com/renovate-schema.json",
+		Description:     []string{description, customRules},
+		Extends:         o.extends,
+		EnabledManagers: o.enabledManagers,
+		Timezone:        o.timezone,
+		Schedule:        o.schedule,
+		PackageRules:    o.packageRules,
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(config)
+}
+
+// Compiler is implemented by project blocks which support renovate.json generate.
+type Compiler interface {
+	CompileRenovate(*Output) error
+}
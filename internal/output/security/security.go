@@ -0,0 +1,282 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package security implements output to SECURITY.md and CodeQL analysis workflow.
+package security
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	policyFilename = "SECURITY.md"
+	codeqlFilename = ".github/workflows/codeql.yaml"
+
+	// generatedMarker is a part of the preamble identifying files generated by kres.
+	generatedMarker = "THIS FILE WAS AUTOMATICALLY GENERATED"
+)
+
+// CodeQL languages.
+const (
+	LanguageGo         = "go"
+	LanguageJavaScript = "javascript"
+	LanguagePython     = "python"
+)
+
+// Output implements SECURITY.md and CodeQL workflow generation.
+//
+// Hand-written SECURITY.md (without the kres preamble) is not overwritten unless Overwrite is called.
+type Output struct {
+	output.FileAdapter
+	output.ComponentAdapter
+
+	enabled   bool
+	overwrite bool
+
+	contact           string
+	disclosureDays    int
+	supportedVersions string
+
+	languages []string
+	paths     []string
+
+	// unrestricted is set if any of the components is analyzed as a whole.
+	unrestricted bool
+
+	// DefaultBranch is the branch CodeQL analysis runs on pushes to.
+	DefaultBranch string
+}
+
+// NewOutput creates new security output.
+func NewOutput() *Output {
+	output := &Output{
+		DefaultBranch: "master",
+	}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileSecurity(o)
+}
+
+// Enable should be called to enable SECURITY.md generation.
+func (o *Output) Enable() {
+	o.enabled = true
+}
+
+// Overwrite replaces SECURITY.md even if it was written manually.
+func (o *Output) Overwrite() {
+	o.overwrite = true
+}
+
+// Policy sets the contact vulnerabilities are reported to and the disclosure details.
+func (o *Output) Policy(contact string, disclosureDays int, supportedVersions string) *Output {
+	o.contact = contact
+	o.disclosureDays = disclosureDays
+	o.supportedVersions = supportedVersions
+
+	return o
+}
+
+// CodeQL enables CodeQL analysis workflow for the languages, analysis is limited to the paths (if any).
+//
+// Languages and paths of the monorepo components are merged, the component is analyzed as a whole
+// if no paths are set.
+func (o *Output) CodeQL(languages []string, paths []string) *Output {
+	for _, language := range languages {
+		if !contains(o.languages, language) {
+			o.languages = append(o.languages, language)
+		}
+	}
+
+	if len(paths) == 0 {
+		if o.ComponentDir() == "." {
+			o.unrestricted = true
+		}
+
+		paths = []string{"."}
+	}
+
+	for _, p := range paths {
+		if p = o.ComponentPath(p); p != "." && !contains(o.paths, p) {
+			o.paths = append(o.paths, p)
+		}
+	}
+
+	return o
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	var filenames []string
+
+	if o.enabled && (o.overwrite || !o.handWritten()) {
+		filenames = append(filenames, policyFilename)
+	}
+
+	if len(o.languages) > 0 {
+		filenames = append(filenames, codeqlFilename)
+	}
+
+	return filenames
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case policyFilename:
+		return o.policy(w)
+	case codeqlFilename:
+		return o.codeql(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+// handWritten checks if SECURITY.md exists and wasn't generated by kres.
+func (o *Output) handWritten() bool {
+	contents, err := ioutil.ReadFile(o.Path(policyFilename))
+	if err != nil {
+		// missing (or unreadable) file is generated
+		return !os.IsNotExist(err)
+	}
+
+	return !strings.Contains(string(contents), generatedMarker)
+}
+
+func (o *Output) policy(w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("<!-- ", " -->"))); err != nil {
+		return err
+	}
+
+	supported := o.supportedVersions
+	if supported == "" {
+		supported = "Security fixes are provided for the latest release."
+	}
+
+	policy := fmt.Sprintf(`# Security Policy
+
+## Supported Versions
+
+%s
+
+## Reporting a Vulnerability
+
+Please do not report security vulnerabilities through public issues.
+Report them to %s instead, including the affected versions and the steps to reproduce the issue.
+
+Reports are acknowledged within a few business days, the details of the vulnerability are disclosed
+once the fix is released or %d days after the report, whichever comes first.
+`, supported, o.contact, o.disclosureDays)
+
+	_, err := io.WriteString(w, policy)
+
+	return err
+}
+
+func (o *Output) codeql(w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	initWith := map[string]string{
+		"languages": "${{ matrix.language }}",
+	}
+
+	if len(o.paths) > 0 && !o.unrestricted {
+		config, err := yaml.Marshal(map[string][]string{"paths": o.paths})
+		if err != nil {
+			return err
+		}
+
+		initWith["config"] = string(config)
+	}
+
+	workflow := map[string]interface{}{
+		"name": "codeql",
+		"on": map[string]interface{}{
+			"push": map[string][]string{
+				"branches": {o.DefaultBranch},
+			},
+			"pull_request": map[string][]string{
+				"branches": {o.DefaultBranch},
+			},
+			"schedule": []map[string]string{
+				{"cron": "30 1 * * 1"},
+			},
+		},
+		"permissions": map[string]string{
+			"actions":         "read",
+			"contents":        "read",
+			"security-events": "write",
+		},
+		"jobs": map[string]interface{}{
+			"analyze": map[string]interface{}{
+				"runs-on": "ubuntu-latest",
+				"strategy": map[string]interface{}{
+					"fail-fast": false,
+					"matrix": map[string][]string{
+						"language": o.languages,
+					},
+				},
+				"steps": []interface{}{
+					map[string]string{"uses": "actions/checkout@v2"},
+					map[string]interface{}{
+						"uses": "github/codeql-action/init@v2",
+						"with": initWith,
+					},
+					map[string]string{"uses": "github/codeql-action/autobuild@v2"},
+					map[string]interface{}{
+						"uses": "github/codeql-action/analyze@v2",
+						"with": map[string]string{
+							"category": "/language:${{ matrix.language }}",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(workflow); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// Compiler is implemented by project blocks which support security policy generation.
+type Compiler interface {
+	CompileSecurity(*Output) error
+}
@@ -0,0 +1,106 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package tekton
+
+type param struct {
+	Name        string
+	Variable    string
+	Default     string
+	Description string
+}
+
+func (p param) spec() paramSpec {
+	return paramSpec{
+		Name:        p.Name,
+		Type:        "string",
+		Description: p.Description,
+		Default:     p.Default,
+	}
+}
+
+type resource struct {
+	APIVersion string       `yaml:"apiVersion"`
+	Kind       string       `yaml:"kind"`
+	Metadata   metadataSpec `yaml:"metadata"`
+	Spec       interface{}  `yaml:"spec"`
+}
+
+type metadataSpec struct {
+	Name string `yaml:"name"`
+}
+
+type paramSpec struct {
+	Name        string `yaml:"name"`
+	Type        string `yaml:"type"`
+	Description string `yaml:"description,omitempty"`
+	Default     string `yaml:"default,omitempty"`
+}
+
+type paramValueSpec struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type workspaceSpec struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	MountPath   string `yaml:"mountPath,omitempty"`
+	Optional    bool   `yaml:"optional,omitempty"`
+}
+
+type workspaceBindingSpec struct {
+	Name      string `yaml:"name"`
+	Workspace string `yaml:"workspace"`
+}
+
+type taskRefSpec struct {
+	Name string `yaml:"name"`
+}
+
+type pipelineSpec struct {
+	Params     []paramSpec        `yaml:"params"`
+	Workspaces []workspaceSpec    `yaml:"workspaces"`
+	Tasks      []pipelineTaskSpec `yaml:"tasks"`
+}
+
+type pipelineTaskSpec struct {
+	Name       string                 `yaml:"name"`
+	TaskRef    taskRefSpec            `yaml:"taskRef"`
+	RunAfter   []string               `yaml:"runAfter,omitempty"`
+	Params     []paramValueSpec       `yaml:"params,omitempty"`
+	Workspaces []workspaceBindingSpec `yaml:"workspaces"`
+}
+
+type taskSpec struct {
+	Params     []paramSpec     `yaml:"params,omitempty"`
+	Workspaces []workspaceSpec `yaml:"workspaces"`
+	Steps      []stepSpec      `yaml:"steps"`
+	Sidecars   []stepSpec      `yaml:"sidecars,omitempty"`
+}
+
+type stepSpec struct {
+	Name            string               `yaml:"name"`
+	Image           string               `yaml:"image"`
+	WorkingDir      string               `yaml:"workingDir,omitempty"`
+	Env             []envSpec            `yaml:"env,omitempty"`
+	Command         []string             `yaml:"command,omitempty"`
+	Args            []string             `yaml:"args,omitempty"`
+	Script          string               `yaml:"script,omitempty"`
+	SecurityContext *securityContextSpec `yaml:"securityContext,omitempty"`
+}
+
+type envSpec struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type securityContextSpec struct {
+	Privileged     bool                `yaml:"privileged,omitempty"`
+	SeccompProfile *seccompProfileSpec `yaml:"seccompProfile,omitempty"`
+}
+
+type seccompProfileSpec struct {
+	Type string `yaml:"type"`
+}
@@ -0,0 +1,240 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package tekton
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Task is a pipeline task, it is compiled into a separate Task resource.
+type Task struct {
+	name     string
+	target   string
+	args     []string
+	runAfter []string
+	dir      string
+
+	image        bool
+	platforms    []string
+	destinations []string
+	buildArgs    []string
+}
+
+// MakeTask creates a task which calls make target.
+func MakeTask(target string, args ...string) *Task {
+	return &Task{
+		name:   target,
+		target: target,
+		args:   args,
+	}
+}
+
+// ImageTask creates a task which builds the Dockerfile target as an image and pushes it.
+//
+// The image is built with the executor configured for the output.
+func ImageTask(target string) *Task {
+	return &Task{
+		name:   target,
+		target: target,
+		image:  true,
+	}
+}
+
+// Name provides a name to a task.
+func (task *Task) Name(name string) *Task {
+	task.name = name
+
+	return task
+}
+
+// RunAfter appends to a list of task dependencies.
+func (task *Task) RunAfter(names ...string) *Task {
+	task.runAfter = append(task.runAfter, names...)
+
+	return task
+}
+
+// Platforms sets the platforms the image is built for.
+func (task *Task) Platforms(platforms ...string) *Task {
+	task.platforms = append(task.platforms, platforms...)
+
+	return task
+}
+
+// Destination appends image references the image is pushed to.
+func (task *Task) Destination(images ...string) *Task {
+	task.destinations = append(task.destinations, images...)
+
+	return task
+}
+
+// BuildArgs appends Dockerfile build arguments.
+//
+// Build arguments are only passed if they are backed by a pipeline parameter.
+func (task *Task) BuildArgs(args ...string) *Task {
+	task.buildArgs = append(task.buildArgs, args...)
+
+	return task
+}
+
+// source returns the directory of the task sources in the source workspace.
+func (task *Task) source() string {
+	if task.dir == "" {
+		return "$(workspaces.source.path)"
+	}
+
+	return "$(workspaces.source.path)/" + task.dir
+}
+
+func (task *Task) compile(o *Output) (taskSpec, error) {
+	source := task.source()
+
+	spec := taskSpec{
+		Workspaces: []workspaceSpec{
+			{Name: WorkspaceSource, MountPath: "/workspace/source"},
+			{Name: WorkspaceGoModCache, Optional: true},
+		},
+	}
+
+	for _, p := range o.params {
+		spec.Params = append(spec.Params, paramSpec{Name: p.Name, Type: "string", Description: p.Description})
+	}
+
+	env := []envSpec{}
+
+	names := make([]string, 0, len(o.env))
+
+	for name := range o.env {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		env = append(env, envSpec{Name: name, Value: o.env[name]})
+	}
+
+	if !task.image {
+		args := append([]string{"make", task.target}, task.args...)
+
+		for _, p := range o.params {
+			args = append(args, fmt.Sprintf(`%s="$(params.%s)"`, p.Variable, p.Name))
+		}
+
+		spec.Steps = []stepSpec{
+			{
+				Name:       "make",
+				Image:      o.BuildContainer,
+				WorkingDir: source,
+				Env: append([]envSpec{
+					{Name: "DOCKER_HOST", Value: "tcp://localhost:2375"},
+					{Name: "GOMODCACHE", Value: "$(workspaces.go-mod-cache.path)"},
+				}, env...),
+				Script: strings.Join([]string{
+					"#!/bin/sh",
+					"set -e",
+					"docker buildx create --driver docker-container --name local --use",
+					"docker buildx inspect --bootstrap",
+					strings.Join(args, " "),
+				}, "\n") + "\n",
+			},
+		}
+
+		spec.Sidecars = []stepSpec{
+			{
+				Name:            "docker",
+				Image:           o.DockerImage,
+				Env:             []envSpec{{Name: "DOCKER_TLS_CERTDIR", Value: ""}},
+				SecurityContext: &securityContextSpec{Privileged: true},
+			},
+		}
+
+		return spec, nil
+	}
+
+	buildArgs := []string{}
+
+	for _, arg := range task.buildArgs {
+		for _, p := range o.params {
+			if p.Variable == arg {
+				buildArgs = append(buildArgs, fmt.Sprintf("%s=$(params.%s)", arg, p.Name))
+			}
+		}
+	}
+
+	// registry credentials are provided by the service account of the pipeline run
+	env = append([]envSpec{{Name: "DOCKER_CONFIG", Value: "/tekton/home/.docker"}}, env...)
+
+	switch o.Executor {
+	case ExecutorKaniko:
+		args := []string{
+			"--dockerfile=" + source + "/Dockerfile",
+			"--context=dir://" + source,
+			"--target=" + task.target,
+		}
+
+		switch len(task.platforms) {
+		case 0:
+		case 1:
+			args = append(args, "--custom-platform="+task.platforms[0])
+		default:
+			return spec, fmt.Errorf("kaniko executor doesn't support multi-platform builds of %q, use buildkit instead", task.name)
+		}
+
+		for _, arg := range buildArgs {
+			args = append(args, "--build-arg="+arg)
+		}
+
+		for _, destination := range task.destinations {
+			args = append(args, "--destination="+destination)
+		}
+
+		spec.Steps = []stepSpec{
+			{
+				Name:       "build-and-push",
+				Image:      o.KanikoImage,
+				WorkingDir: source,
+				Env:        env,
+				Args:       args,
+			},
+		}
+	case ExecutorBuildKit:
+		args := []string{
+			"build",
+			"--frontend=dockerfile.v0",
+			"--local=context=" + source,
+			"--local=dockerfile=" + source,
+			"--opt=target=" + task.target,
+		}
+
+		if len(task.platforms) > 0 {
+			args = append(args, "--opt=platform="+strings.Join(task.platforms, ","))
+		}
+
+		for _, arg := range buildArgs {
+			args = append(args, "--opt=build-arg:"+arg)
+		}
+
+		args = append(args, fmt.Sprintf(`--output=type=image,"name=%s",push=true`, strings.Join(task.destinations, ",")))
+
+		spec.Steps = []stepSpec{
+			{
+				Name:       "build-and-push",
+				Image:      o.BuildKitImage,
+				WorkingDir: source,
+				Env:        append([]envSpec{{Name: "BUILDKITD_FLAGS", Value: "--oci-worker-no-process-sandbox"}}, env...),
+				Command:    []string{"buildctl-daemonless.sh"},
+				Args:       args,
+				SecurityContext: &securityContextSpec{
+					SeccompProfile: &seccompProfileSpec{Type: "Unconfined"},
+				},
+			},
+		}
+	}
+
+	return spec, nil
+}
@@ -0,0 +1,257 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package tekton implements output to Tekton Pipeline and Task resources.
+package tekton
+
+import (
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/output"
+)
+
+const (
+	filename = ".tekton/pipeline.yaml"
+
+	apiVersion = "tekton.dev/v1beta1"
+
+	fetchSourceTask = "fetch-source"
+)
+
+// Names of the workspaces shared by the tasks of the pipeline.
+const (
+	WorkspaceSource      = "source"
+	WorkspaceGoModCache  = "go-mod-cache"
+	workspaceCloneOutput = "output"
+)
+
+// Supported image build executors.
+const (
+	ExecutorKaniko   = "kaniko"
+	ExecutorBuildKit = "buildkit"
+)
+
+// Output implements Tekton Pipeline generation.
+//
+// Every task is written as a separate Task resource, Pipeline clones the source
+// into the shared workspace and runs the tasks in the order of their dependencies.
+type Output struct {
+	output.FileAdapter
+	output.ComponentAdapter
+
+	tasks  []*Task
+	params []param
+	env    map[string]string
+
+	// Name of the Pipeline resource.
+	Name string
+	// Executor builds and pushes images, one of kaniko or buildkit.
+	Executor string
+
+	BuildContainer string
+	DockerImage    string
+	GitCloneTask   string
+	KanikoImage    string
+	BuildKitImage  string
+}
+
+// NewOutput creates new Tekton pipeline output.
+func NewOutput() *Output {
+	output := &Output{
+		Name:     "ci",
+		Executor: ExecutorKaniko,
+
+		BuildContainer: "autonomy/build-container:latest",
+		DockerImage:    "docker:19.03-dind",
+		GitCloneTask:   "git-clone",
+		KanikoImage:    "gcr.io/kaniko-project/executor:v1.9.1",
+		BuildKitImage:  "moby/buildkit:v0.10.6-rootless",
+	}
+
+	output.FileAdapter.FileWriter = output
+
+	return output
+}
+
+// Task appends a task to the pipeline.
+//
+// Tasks of the monorepo components are renamed and run in the component directory of the source workspace.
+func (o *Output) Task(task *Task) {
+	task.name, task.runAfter = o.ComponentStep(task.name, task.runAfter)
+
+	if dir := o.ComponentDir(); dir != "." {
+		task.dir = dir
+	}
+
+	o.tasks = append(o.tasks, task)
+}
+
+// Param declares a pipeline parameter passed to every task as a Makefile variable.
+//
+// Parameters without default value are required to start the pipeline.
+func (o *Output) Param(name, variable, defaultValue, description string) {
+	p := param{
+		Name:        name,
+		Variable:    variable,
+		Default:     defaultValue,
+		Description: description,
+	}
+
+	for i := range o.params {
+		if o.params[i].Name == name {
+			o.params[i] = p
+
+			return
+		}
+	}
+
+	o.params = append(o.params, p)
+}
+
+// Environment sets an environment variable for all the tasks.
+func (o *Output) Environment(name, value string) {
+	if o.env == nil {
+		o.env = make(map[string]string)
+	}
+
+	o.env[name] = value
+}
+
+// Compile implements output.Writer interface.
+func (o *Output) Compile(node interface{}) error {
+	compiler, implements := node.(Compiler)
+
+	if !implements {
+		return nil
+	}
+
+	return compiler.CompileTekton(o)
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	return []string{filename}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case filename:
+		return o.pipeline(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) pipeline(w io.Writer) error {
+	switch o.Executor {
+	case ExecutorKaniko, ExecutorBuildKit:
+	default:
+		return fmt.Errorf("unsupported Tekton image build executor %q", o.Executor)
+	}
+
+	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	known := map[string]bool{}
+
+	for _, task := range o.tasks {
+		known[task.name] = true
+	}
+
+	pipeline := pipelineSpec{
+		Workspaces: []workspaceSpec{
+			{Name: WorkspaceSource, Description: "Source checkout shared by the tasks."},
+			{Name: WorkspaceGoModCache, Description: "Go module cache.", Optional: true},
+		},
+		Tasks: []pipelineTaskSpec{
+			{
+				Name:    fetchSourceTask,
+				TaskRef: taskRefSpec{Name: o.GitCloneTask},
+				Params: []paramValueSpec{
+					{Name: "url", Value: "$(params.git-url)"},
+					{Name: "revision", Value: "$(params.git-revision)"},
+					{Name: "depth", Value: "0"},
+				},
+				Workspaces: []workspaceBindingSpec{
+					{Name: workspaceCloneOutput, Workspace: WorkspaceSource},
+				},
+			},
+		},
+	}
+
+	pipeline.Params = append(pipeline.Params,
+		paramSpec{Name: "git-url", Type: "string", Description: "Repository URL to clone from."},
+		paramSpec{Name: "git-revision", Type: "string", Description: "Revision to check out.", Default: "main"},
+	)
+
+	for _, p := range o.params {
+		pipeline.Params = append(pipeline.Params, p.spec())
+	}
+
+	for _, task := range o.tasks {
+		spec, err := task.compile(o)
+		if err != nil {
+			return err
+		}
+
+		if err := encoder.Encode(resource{
+			APIVersion: apiVersion,
+			Kind:       "Task",
+			Metadata:   metadataSpec{Name: task.name},
+			Spec:       spec,
+		}); err != nil {
+			return err
+		}
+
+		pipelineTask := pipelineTaskSpec{
+			Name:    task.name,
+			TaskRef: taskRefSpec{Name: task.name},
+			Workspaces: []workspaceBindingSpec{
+				{Name: WorkspaceSource, Workspace: WorkspaceSource},
+				{Name: WorkspaceGoModCache, Workspace: WorkspaceGoModCache},
+			},
+		}
+
+		for _, p := range o.params {
+			pipelineTask.Params = append(pipelineTask.Params, paramValueSpec{Name: p.Name, Value: fmt.Sprintf("$(params.%s)", p.Name)})
+		}
+
+		for _, name := range task.runAfter {
+			// dependencies which don't produce tasks are skipped
+			if known[name] {
+				pipelineTask.RunAfter = append(pipelineTask.RunAfter, name)
+			}
+		}
+
+		if len(pipelineTask.RunAfter) == 0 {
+			pipelineTask.RunAfter = []string{fetchSourceTask}
+		}
+
+		pipeline.Tasks = append(pipeline.Tasks, pipelineTask)
+	}
+
+	if err := encoder.Encode(resource{
+		APIVersion: apiVersion,
+		Kind:       "Pipeline",
+		Metadata:   metadataSpec{Name: o.Name},
+		Spec:       pipeline,
+	}); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// Compiler is implemented by project blocks which support Tekton pipeline generation.
+type Compiler interface {
+	CompileTekton(*Output) error
+}
@@ -12,31 +12,86 @@ import (
 	"github.com/talos-systems/kres/internal/dag"
 	"github.com/talos-systems/kres/internal/project"
 	"github.com/talos-systems/kres/internal/project/common"
+	"github.com/talos-systems/kres/internal/project/custom"
 	"github.com/talos-systems/kres/internal/project/meta"
+	"github.com/talos-systems/kres/internal/project/service"
 )
 
 type (
 	detector func(string, *meta.Options) (bool, error)
-	builder  func(*meta.Options, []dag.Node) ([]dag.Node, error)
+	builder  func(*meta.Options, []dag.Node, *common.Lint, *service.CodeCov) ([]dag.Node, error)
 )
 
 // Build the project type and structure based on project type.
 func Build(meta *meta.Options) (*project.Contents, error) {
 	proj := &project.Contents{}
 
+	rootPath := meta.Root
+	if rootPath == "" {
+		rootPath = "."
+	}
+
 	inputs := []dag.Node{common.NewBuild(meta), common.NewDocker(meta)}
 	outputs := []dag.Node{}
 
+	// common lint target is shared by all project types
+	lint := common.NewLint(meta)
+
+	// coverage is uploaded from all project types at once
+	coverage := service.NewCodeCov(meta)
+
+	if _, err := DetectDockerfiles(rootPath, meta); err != nil {
+		return nil, err
+	}
+
+	lint.AddInput(common.NewDockerfileLint(meta))
+
+	if _, err := DetectGitLFS(rootPath, meta); err != nil {
+		return nil, err
+	}
+
+	markdown, err := DetectMarkdown(rootPath, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if markdown {
+		lint.AddInput(common.NewMarkdownLint(meta))
+	}
+
 	for _, projectType := range []struct {
 		detect detector
 		build  builder
+		native bool
 	}{
+		{
+			// C libraries are built first, as they might be linked by the other projects
+			detect: DetectCMake,
+			build:  BuildCMake,
+			native: true,
+		},
 		{
 			detect: DetectGolang,
 			build:  BuildGolang,
 		},
+		{
+			detect: DetectRust,
+			build:  BuildRust,
+		},
+		{
+			detect: DetectJS,
+			build:  BuildJS,
+		},
+		{
+			detect: DetectPython,
+			build:  BuildPython,
+		},
+		{
+			detect: DetectHelm,
+			build:  BuildHelm,
+		},
 	} {
-		ok, err := projectType.detect(".", meta)
+		ok, err := projectType.detect(rootPath, meta)
 		if err != nil {
 			return nil, err
 		}
@@ -45,12 +100,29 @@ func Build(meta *meta.Options) (*project.Contents, error) {
 			continue
 		}
 
-		newOutputs, err := projectType.build(meta, inputs)
+		newOutputs, err := projectType.build(meta, inputs, lint, coverage)
 		if err != nil {
 			return nil, err
 		}
 
 		outputs = append(outputs, newOutputs...)
+
+		// native libraries are injected into the toolchains of the following project types (e.g. for cgo)
+		if projectType.native {
+			for _, output := range newOutputs {
+				if _, ok := output.(common.ToolchainBuilder); ok {
+					inputs = append(inputs, output)
+				}
+			}
+		}
+	}
+
+	if len(coverage.Inputs()) > 0 {
+		outputs = append(outputs, coverage)
+	}
+
+	if len(lint.Inputs()) > 0 {
+		outputs = append([]dag.Node{lint}, outputs...)
 	}
 
 	all := common.NewAll(meta)
@@ -58,10 +130,87 @@ func Build(meta *meta.Options) (*project.Contents, error) {
 
 	rekres := common.NewReKres(meta)
 
+	renovate := common.NewRenovate(meta)
+
+	dependabot := common.NewDependabot(meta)
+
+	codeOwners := common.NewCodeOwners(meta)
+
+	security := common.NewSecurity(meta)
+	community := common.NewCommunity(meta)
+
+	editorConfig := common.NewEditorConfig(meta)
+
+	// license header policies follow the license header checks
+	conform := common.NewConform(meta)
+
+	for _, input := range lint.Inputs() {
+		if license, ok := input.(*common.LicenseHeader); ok {
+			conform.AddInput(license)
+		}
+	}
+
+	// release notes are published once everything is built
+	releaseNotes := common.NewReleaseNotes(meta)
+	releaseNotes.AddInput(outputs...)
+
+	variables := common.NewVariables(meta)
+
+	gitLFS := common.NewGitLFS(meta)
+
+	// Kubernetes runner settings of the Drone pipelines
+	droneSettings := common.NewDrone(meta)
+
+	// pre-commit hooks are registered by the linters themselves
+	preCommit := common.NewPreCommit(meta)
+
 	makeHelp := common.NewMakeHelp(meta)
 
+	// SBOM is generated from built images, so it's not part of `all`
+	sbom := common.NewSBOM(meta)
+
+	// images are signed on releases only
+	sign := common.NewSign(meta)
+
+	// draft release is created on tags once everything is built and pushed
+	release := common.NewRelease(meta)
+	release.AddInput(releaseNotes, sbom)
+
+	scans := []dag.Node{}
+
+	for _, output := range outputs {
+		if _, ok := output.(common.Executable); ok {
+			release.AddInput(output)
+		}
+
+		if image, ok := output.(*common.Image); ok {
+			sbom.AddInput(image)
+			sign.AddInput(image)
+			release.AddInput(image)
+
+			scans = append(scans, common.NewImageScan(meta, image))
+		}
+	}
+
 	proj.AddTarget(outputs...)
-	proj.AddTarget(rekres, all, makeHelp)
+	proj.AddTarget(scans...)
+	proj.AddTarget(rekres, all, makeHelp, renovate, dependabot, codeOwners, security, community, editorConfig, conform, preCommit, releaseNotes, release, variables, gitLFS, droneSettings)
+
+	// custom nodes are provided by the plugins registered in the binary
+	customNodes, err := custom.Nodes(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	proj.AddTarget(customNodes...)
+
+	if len(sbom.Inputs()) > 0 {
+		proj.AddTarget(sbom)
+	}
+
+	if meta.CosignKey != "" && len(sign.Inputs()) > 0 {
+		proj.AddTarget(sign)
+	}
 
 	return proj, nil
 }
@@ -80,3 +229,26 @@ func directoryExists(rootPath, name string) (bool, error) {
 
 	return st.IsDir(), nil
 }
+
+func fileExists(rootPath, name string) (bool, error) {
+	st, err := os.Stat(filepath.Join(rootPath, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return !st.IsDir(), nil
+}
+
+func contains(list []string, item string) bool {
+	for _, x := range list {
+		if x == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,226 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/project/cmake"
+	"github.com/talos-systems/kres/internal/project/common"
+	"github.com/talos-systems/kres/internal/project/meta"
+	"github.com/talos-systems/kres/internal/project/service"
+)
+
+// cmakeProjectName matches the name in the `project()` command.
+var cmakeProjectName = regexp.MustCompile(`(?im)^\s*project\s*\(\s*([A-Za-z0-9_.+-]+)`)
+
+// DetectCMake checks if the project at rootPath contains C/C++ projects built with CMake.
+//
+// CMake project is either at the root of the project, or in the top-level directories
+// (e.g. a C library linked with cgo).
+//
+//nolint: gocognit
+func DetectCMake(rootPath string, options *meta.Options) (bool, error) {
+	root, err := fileExists(rootPath, "CMakeLists.txt")
+	if err != nil {
+		return false, err
+	}
+
+	entries, err := ioutil.ReadDir(rootPath)
+	if err != nil {
+		return false, err
+	}
+
+	if root {
+		project, err := cmakeProject(rootPath, ".")
+		if err != nil {
+			return true, err
+		}
+
+		options.CMakeProjects = append(options.CMakeProjects, project)
+		options.CMakeSourceFiles = append(options.CMakeSourceFiles, "CMakeLists.txt")
+
+		// sources of the root project are spread across the top-level files and directories
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), ".") || excluded(options.ExcludePaths, entry.Name()) {
+				continue
+			}
+
+			if !entry.IsDir() {
+				if isCSource(entry.Name()) {
+					options.CMakeSourceFiles = append(options.CMakeSourceFiles, entry.Name())
+				}
+
+				continue
+			}
+
+			sources, err := hasCSources(filepath.Join(rootPath, entry.Name()))
+			if err != nil {
+				return true, err
+			}
+
+			if sources {
+				options.CMakeDirectories = append(options.CMakeDirectories, entry.Name())
+			}
+		}
+	} else {
+		for _, entry := range entries {
+			if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || excluded(options.ExcludePaths, entry.Name()) {
+				continue
+			}
+
+			exists, err := fileExists(rootPath, filepath.Join(entry.Name(), "CMakeLists.txt"))
+			if err != nil {
+				return false, err
+			}
+
+			if !exists {
+				continue
+			}
+
+			project, err := cmakeProject(rootPath, entry.Name())
+			if err != nil {
+				return true, err
+			}
+
+			options.CMakeProjects = append(options.CMakeProjects, project)
+			options.CMakeDirectories = append(options.CMakeDirectories, entry.Name())
+		}
+	}
+
+	if len(options.CMakeProjects) == 0 {
+		return false, nil
+	}
+
+	for _, config := range []struct {
+		name    string
+		enabled *bool
+	}{
+		{".clang-format", &options.ClangFormat},
+		{".clang-tidy", &options.ClangTidy},
+	} {
+		exists, err := fileExists(rootPath, config.name)
+		if err != nil {
+			return true, err
+		}
+
+		if exists {
+			*config.enabled = true
+
+			options.CMakeSourceFiles = append(options.CMakeSourceFiles, config.name)
+		}
+	}
+
+	for _, dir := range options.CMakeDirectories {
+		if !contains(options.Directories, dir) {
+			options.Directories = append(options.Directories, dir)
+		}
+	}
+
+	options.SourceFiles = append(options.SourceFiles, options.CMakeSourceFiles...)
+
+	return true, nil
+}
+
+// BuildCMake builds project structure for CMake projects.
+func BuildCMake(meta *meta.Options, inputs []dag.Node, lint *common.Lint, coverage *service.CodeCov) ([]dag.Node, error) {
+	// toolchain as the root of the tree
+	toolchain := cmake.NewToolchain(meta)
+	toolchain.AddInput(inputs...)
+
+	clangFormat := cmake.NewClangFormat(meta)
+	clangFormat.AddInput(toolchain)
+
+	clangTidy := cmake.NewClangTidy(meta)
+	clangTidy.AddInput(toolchain)
+
+	// linters are skipped unless configured
+	lint.AddInput(clangFormat, clangTidy)
+
+	unitTests := cmake.NewUnitTests(meta)
+	unitTests.AddInput(toolchain)
+
+	outputs := []dag.Node{unitTests}
+
+	for _, project := range meta.CMakeProjects {
+		build := cmake.NewBuild(meta, project)
+		build.AddInput(toolchain)
+
+		outputs = append(outputs, build)
+	}
+
+	return outputs, nil
+}
+
+// cmakeProject reads the name of the CMake project in the directory.
+func cmakeProject(rootPath, dir string) (meta.CMakeProject, error) {
+	project := meta.CMakeProject{
+		Name:      path.Base(dir),
+		Directory: dir,
+	}
+
+	if dir == "." {
+		project.Name = path.Base(filepath.ToSlash(filepath.Clean(rootPath)))
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(rootPath, dir, "CMakeLists.txt"))
+	if err != nil {
+		return project, err
+	}
+
+	if matches := cmakeProjectName.FindSubmatch(contents); matches != nil {
+		project.Name = string(matches[1])
+	}
+
+	project.Name = strings.ToLower(project.Name)
+
+	return project, nil
+}
+
+// hasCSources checks if the directory tree contains C/C++ sources or CMake files.
+func hasCSources(dir string) (bool, error) {
+	found := false
+
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if found {
+			return filepath.SkipDir
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		found = isCSource(info.Name())
+
+		return nil
+	})
+
+	return found, err
+}
+
+// isCSource checks if the file is a C/C++ source, header or CMake file.
+func isCSource(name string) bool {
+	if name == "CMakeLists.txt" || strings.HasSuffix(name, ".cmake") {
+		return true
+	}
+
+	for _, ext := range cmake.SourceExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,52 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// DetectDockerfiles finds custom Dockerfiles in the project at rootPath.
+func DetectDockerfiles(rootPath string, options *meta.Options) (bool, error) {
+	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			switch {
+			case path == rootPath:
+			case strings.HasPrefix(info.Name(), "."), info.Name() == "node_modules", info.Name() == "vendor", info.Name() == options.ArtifactsPath:
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasPrefix(info.Name(), "Dockerfile") {
+			return nil
+		}
+
+		file, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			return err
+		}
+
+		file = filepath.ToSlash(file)
+
+		// generated Dockerfile is always linted
+		if file != "Dockerfile" {
+			options.Dockerfiles = append(options.Dockerfiles, file)
+		}
+
+		return nil
+	})
+
+	return len(options.Dockerfiles) > 0, err
+}
@@ -0,0 +1,77 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// excludePaths removes detected sources matching meta.ExcludePaths.
+//
+// Paths nested in the directories which are not excluded are left as is, they are
+// excluded from the build context instead.
+func excludePaths(options *meta.Options) error {
+	if len(options.ExcludePaths) == 0 {
+		return nil
+	}
+
+	for i, pattern := range options.ExcludePaths {
+		pattern = path.Clean(pattern)
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+
+		options.ExcludePaths[i] = pattern
+	}
+
+	options.Directories = filterExcluded(options.ExcludePaths, options.Directories)
+	options.GoDirectories = filterExcluded(options.ExcludePaths, options.GoDirectories)
+	options.SourceFiles = filterExcluded(options.ExcludePaths, options.SourceFiles)
+	options.GoSourceFiles = filterExcluded(options.ExcludePaths, options.GoSourceFiles)
+	options.GoTestdataDirectories = filterExcluded(options.ExcludePaths, options.GoTestdataDirectories)
+	options.GoEmbedPaths = filterExcluded(options.ExcludePaths, options.GoEmbedPaths)
+	options.GoToolsFiles = filterExcluded(options.ExcludePaths, options.GoToolsFiles)
+
+	commands := options.Commands[:0]
+
+	for _, command := range options.Commands {
+		if !excluded(options.ExcludePaths, command.Path) {
+			commands = append(commands, command)
+		}
+	}
+
+	options.Commands = commands
+
+	return nil
+}
+
+func filterExcluded(patterns, paths []string) []string {
+	result := make([]string, 0, len(paths))
+
+	for _, p := range paths {
+		if !excluded(patterns, p) {
+			result = append(result, p)
+		}
+	}
+
+	return result
+}
+
+// excluded checks if the path or any of its parent directories matches the patterns.
+func excluded(patterns []string, p string) bool {
+	for dir := path.Clean(p); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, dir); matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -5,10 +5,15 @@
 package auto
 
 import (
+	"bufio"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"golang.org/x/mod/modfile"
@@ -23,9 +28,207 @@ import (
 
 // DetectGolang check if project at rootPath is Go-based project.
 //
+// If go.work is present, every module listed in the workspace is detected, single module
+// at rootPath is detected otherwise.
+//
 //nolint: gocognit,gocyclo
 func DetectGolang(rootPath string, options *meta.Options) (bool, error) {
-	gomodPath := filepath.Join(rootPath, "go.mod")
+	moduleDirs, err := detectGoWorkspace(rootPath, options)
+	if err != nil {
+		return true, err
+	}
+
+	if moduleDirs == nil {
+		moduleDirs = []string{"."}
+	}
+
+	for _, moduleDir := range moduleDirs {
+		ok, err := detectGoModule(rootPath, moduleDir, options)
+		if err != nil {
+			return true, err
+		}
+
+		if !ok {
+			if options.GoWorkspace {
+				return true, fmt.Errorf("go.work: module %q doesn't have go.mod", moduleDir)
+			}
+
+			return false, nil
+		}
+	}
+
+	// config overrides are applied on top of the detected modules
+	if err := applyOverrides(rootPath, options); err != nil {
+		return true, err
+	}
+
+	// testdata is detected after the overrides, as it's only needed outside of the Go directories
+	if err := detectTestdata(rootPath, options); err != nil {
+		return true, err
+	}
+
+	// vendoring is not supported for Go workspaces
+	if !options.GoWorkspace {
+		if _, err := os.Stat(filepath.Join(rootPath, "vendor", "modules.txt")); err == nil {
+			options.GoVendor = true
+			options.Directories = append(options.Directories, "vendor")
+		} else if !os.IsNotExist(err) {
+			return true, err
+		}
+	}
+
+	if err := excludePaths(options); err != nil {
+		return true, err
+	}
+
+	if _, err := DetectProtobuf(rootPath, options); err != nil {
+		return true, err
+	}
+
+	for _, dir := range options.GoDirectories {
+		tags, err := testBuildTags(filepath.Join(rootPath, dir))
+		if err != nil {
+			return true, err
+		}
+
+		for _, tag := range tags {
+			if !contains(options.GoTestBuildTags, tag) {
+				options.GoTestBuildTags = append(options.GoTestBuildTags, tag)
+			}
+		}
+
+		goos, err := buildOS(filepath.Join(rootPath, dir))
+		if err != nil {
+			return true, err
+		}
+
+		options.GoBuildOS = appendMissing(options.GoBuildOS, goos...)
+
+		targets, err := fuzzTargets(rootPath, dir)
+		if err != nil {
+			return true, err
+		}
+
+		options.GoFuzzTargets = append(options.GoFuzzTargets, targets...)
+
+		if !options.GoGenerate {
+			if options.GoGenerate, err = hasGoGenerate(filepath.Join(rootPath, dir)); err != nil {
+				return true, err
+			}
+		}
+	}
+
+	for _, file := range options.GoSourceFiles {
+		goos, err := buildOS(filepath.Join(rootPath, file))
+		if err != nil {
+			return true, err
+		}
+
+		options.GoBuildOS = appendMissing(options.GoBuildOS, goos...)
+
+		if !options.GoGenerate {
+			if options.GoGenerate, err = hasGoGenerate(filepath.Join(rootPath, file)); err != nil {
+				return true, err
+			}
+		}
+	}
+
+	sort.Strings(options.GoBuildOS)
+
+	return true, nil
+}
+
+// detectGoWorkspace parses go.work at rootPath and returns directories of the workspace modules.
+//
+// If there's no go.work, nil is returned.
+func detectGoWorkspace(rootPath string, options *meta.Options) ([]string, error) {
+	goworkPath := filepath.Join(rootPath, "go.work")
+
+	contents, err := ioutil.ReadFile(goworkPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	// go.work shares the syntax with go.mod: `use` directives are skipped by the lax parser,
+	// but they are still available in the syntax tree
+	workFile, err := modfile.ParseLax(goworkPath, contents, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if workFile.Go != nil {
+		options.GoVersion = workFile.Go.Version
+	}
+
+	var moduleDirs []string
+
+	addModuleDir := func(token string) error {
+		if strings.HasPrefix(token, `"`) {
+			var err error
+
+			if token, err = strconv.Unquote(token); err != nil {
+				return err
+			}
+		}
+
+		moduleDirs = append(moduleDirs, path.Clean(filepath.ToSlash(token)))
+
+		return nil
+	}
+
+	for _, stmt := range workFile.Syntax.Stmt {
+		switch stmt := stmt.(type) {
+		case *modfile.Line:
+			if len(stmt.Token) == 2 && stmt.Token[0] == "use" {
+				if err := addModuleDir(stmt.Token[1]); err != nil {
+					return nil, err
+				}
+			}
+		case *modfile.LineBlock:
+			if len(stmt.Token) != 1 || stmt.Token[0] != "use" {
+				continue
+			}
+
+			for _, line := range stmt.Line {
+				if len(line.Token) == 1 {
+					if err := addModuleDir(line.Token[0]); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+
+	if len(moduleDirs) == 0 {
+		return nil, fmt.Errorf("go.work doesn't use any modules")
+	}
+
+	options.GoWorkspace = true
+	options.GoWorkspaceFiles = append(options.GoWorkspaceFiles, "go.work")
+
+	if _, err := os.Stat(filepath.Join(rootPath, "go.work.sum")); err == nil {
+		options.GoWorkspaceFiles = append(options.GoWorkspaceFiles, "go.work.sum")
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	options.SourceFiles = append(options.SourceFiles, options.GoWorkspaceFiles...)
+
+	return moduleDirs, nil
+}
+
+// detectGoModule detects Go module in moduleDir (relative to rootPath).
+//
+// All the detected paths are relative to rootPath.
+//
+//nolint: gocognit,gocyclo
+func detectGoModule(rootPath, moduleDir string, options *meta.Options) (bool, error) {
+	modulePath := filepath.Join(rootPath, moduleDir)
+	gomodPath := filepath.Join(modulePath, "go.mod")
 
 	gomod, err := os.Open(gomodPath)
 	if err != nil {
@@ -43,23 +246,60 @@ func DetectGolang(rootPath string, options *meta.Options) (bool, error) {
 		return true, err
 	}
 
-	options.CanonicalPath = modfile.ModulePath(contents)
+	module := meta.GoModule{
+		Directory:     moduleDir,
+		CanonicalPath: modfile.ModulePath(contents),
+		SourceFiles:   []string{path.Join(moduleDir, "go.mod")},
+	}
+
+	// the first module defines the canonical path of the project
+	if options.CanonicalPath == "" {
+		options.CanonicalPath = module.CanonicalPath
+	}
+
+	if !contains(options.PackageManagers, "gomod") {
+		options.PackageManagers = append(options.PackageManagers, "gomod")
+	}
+
+	modFile, err := modfile.ParseLax(gomodPath, contents, nil)
+	if err != nil {
+		return true, err
+	}
+
+	// Go version of the workspace takes precedence
+	if modFile.Go != nil && !options.GoWorkspace {
+		options.GoVersion = modFile.Go.Version
+	}
+
+	// OpenAPI docs are generated with the swag version required by the module
+	for _, require := range modFile.Require {
+		if require.Mod.Path == swagModule && options.GoSwaggerModule == "" {
+			options.GoSwaggerModule = moduleDir
+			options.GoSwaggerVersion = require.Mod.Version
+		}
+	}
+
+	// modules hosted outside of well-known public hosts are assumed to be private
+	if host := strings.SplitN(module.CanonicalPath, "/", 2)[0]; strings.Contains(host, ".") && !contains(publicGoHosts, host) && !contains(options.GoPrivate, host) {
+		options.GoPrivate = append(options.GoPrivate, host)
+	}
+
+	goDirectories := []string{}
 
 	for _, srcDir := range []string{"src", "internal", "pkg", "cmd"} {
-		exists, err := directoryExists(rootPath, srcDir)
+		exists, err := directoryExists(modulePath, srcDir)
 		if err != nil {
 			return true, err
 		}
 
 		if exists {
-			options.Directories = append(options.Directories, srcDir)
-			options.GoDirectories = append(options.GoDirectories, srcDir)
+			goDirectories = append(goDirectories, path.Join(moduleDir, srcDir))
 		}
 	}
 
-	if len(options.GoDirectories) == 0 {
+	if len(goDirectories) == 0 {
 		// no standard directories found, assume any directory with `.go` files is a source directory
-		topLevel, err := ioutil.ReadDir(rootPath)
+		topLevel, err := ioutil.ReadDir(modulePath)
 		if err != nil {
 			return true, err
 		}
@@ -69,77 +309,168 @@ func DetectGolang(rootPath string, options *meta.Options) (bool, error) {
 				continue
 			}
 
-			result, err := hasGoFiles(filepath.Join(rootPath, item.Name()))
+			result, err := hasGoFiles(filepath.Join(modulePath, item.Name()))
 			if err != nil {
 				return true, err
 			}
 
 			if result {
-				options.Directories = append(options.Directories, item.Name())
-				options.GoDirectories = append(options.GoDirectories, item.Name())
+				goDirectories = append(goDirectories, path.Join(moduleDir, item.Name()))
 			}
 		}
 	}
 
+	// generated docs are a Go package (docs might be embedded), so they're built along with the sources
+	if options.GoSwaggerModule == moduleDir && !contains(goDirectories, path.Join(moduleDir, golang.SwaggerOutput)) {
+		exists, err := directoryExists(modulePath, golang.SwaggerOutput)
+		if err != nil {
+			return true, err
+		}
+
+		if exists {
+			goDirectories = append(goDirectories, path.Join(moduleDir, golang.SwaggerOutput))
+		}
+	}
+
+	options.Directories = append(options.Directories, goDirectories...)
+	options.GoDirectories = append(options.GoDirectories, goDirectories...)
+
 	{
-		res, err := hasGoFiles(rootPath)
+		res, err := hasGoFiles(modulePath)
 		if err != nil {
 			return true, err
 		}
 
 		if res {
-			contents, err := ioutil.ReadDir(rootPath)
+			contents, err := ioutil.ReadDir(modulePath)
 			if err != nil {
 				return true, err
 			}
 
 			for _, item := range contents {
 				if !item.IsDir() && strings.HasSuffix(item.Name(), ".go") {
-					options.SourceFiles = append(options.SourceFiles, item.Name())
-					options.GoSourceFiles = append(options.GoSourceFiles, item.Name())
+					options.SourceFiles = append(options.SourceFiles, path.Join(moduleDir, item.Name()))
+					options.GoSourceFiles = append(options.GoSourceFiles, path.Join(moduleDir, item.Name()))
 				}
 			}
 		}
 	}
 
-	options.SourceFiles = append(options.SourceFiles, "go.mod", "go.sum")
+	// packages in GoDirectories can only embed files from their own directories,
+	// so only the root package might reference files which are not copied with the sources
+	embedPaths, err := goEmbedPaths(modulePath)
+	if err != nil {
+		return true, err
+	}
+
+	for _, embedPath := range embedPaths {
+		if contains(goDirectories, path.Join(moduleDir, embedPath)) {
+			continue
+		}
+
+		st, err := os.Stat(filepath.Join(modulePath, embedPath))
+		if err != nil {
+			return true, err
+		}
+
+		if st.IsDir() {
+			options.Directories = append(options.Directories, path.Join(moduleDir, embedPath))
+		} else {
+			options.SourceFiles = append(options.SourceFiles, path.Join(moduleDir, embedPath))
+		}
+
+		options.GoEmbedPaths = append(options.GoEmbedPaths, path.Join(moduleDir, embedPath))
+	}
+
+	if err := detectGoTools(rootPath, moduleDir, goDirectories, options); err != nil {
+		return true, err
+	}
+
+	options.SourceFiles = append(options.SourceFiles, path.Join(moduleDir, "go.mod"), path.Join(moduleDir, "go.sum"))
+
+	if _, err := os.Stat(filepath.Join(modulePath, "go.sum")); err == nil {
+		module.SourceFiles = append(module.SourceFiles, path.Join(moduleDir, "go.sum"))
+	} else if !os.IsNotExist(err) {
+		return true, err
+	}
+
+	var versionPackage string
 
 	for _, candidate := range []string{"pkg/version", "internal/version"} {
-		exists, err := directoryExists(rootPath, candidate)
+		exists, err := directoryExists(modulePath, candidate)
 		if err != nil {
 			return true, err
 		}
 
 		if exists {
-			options.VersionPackage = path.Join(options.CanonicalPath, candidate)
+			versionPackage = path.Join(module.CanonicalPath, candidate)
 		}
 	}
 
+	if options.VersionPackage == "" {
+		options.VersionPackage = versionPackage
+	}
+
 	{
-		cmdExists, err := directoryExists(rootPath, "cmd")
+		cmdExists, err := directoryExists(modulePath, "cmd")
 		if err != nil {
 			return true, err
 		}
 
 		if cmdExists {
-			dirs, err := ioutil.ReadDir(filepath.Join(rootPath, "cmd"))
+			dirs, err := ioutil.ReadDir(filepath.Join(modulePath, "cmd"))
 			if err != nil {
 				return true, err
 			}
 
 			for _, dir := range dirs {
 				if dir.IsDir() {
-					options.Commands = append(options.Commands, dir.Name())
+					name := commandName(options.Commands, moduleDir, dir.Name())
+
+					options.Commands = append(options.Commands, meta.Command{
+						Name:        name,
+						Path:        path.Join(moduleDir, "cmd", dir.Name()),
+						OutputName:  name,
+						InstallPath: "/",
+					})
 				}
 			}
 		}
 	}
 
+	options.GoModules = append(options.GoModules, module)
+
 	return true, nil
 }
 
+// commandName returns unique name for the command, commands of workspace modules are prefixed
+// with the module directory name on conflicts.
+func commandName(commands []meta.Command, moduleDir, name string) string {
+	for _, command := range commands {
+		if command.Name == name {
+			return path.Base(moduleDir) + "-" + name
+		}
+	}
+
+	return name
+}
+
+// swagModule is the module of the swag OpenAPI docs generator.
+const swagModule = "github.com/swaggo/swag"
+
+var publicGoHosts = []string{
+	"github.com",
+	"gitlab.com",
+	"bitbucket.org",
+	"golang.org",
+	"gopkg.in",
+	"go.uber.org",
+	"k8s.io",
+	"sigs.k8s.io",
+}
+
 // BuildGolang builds project structure for Go project.
-func BuildGolang(meta *meta.Options, inputs []dag.Node) ([]dag.Node, error) {
+func BuildGolang(meta *meta.Options, inputs []dag.Node, lint *common.Lint, coverage *service.CodeCov) ([]dag.Node, error) {
 	// toolchain as the root of the tree
 	toolchain := golang.NewToolchain(meta)
 	toolchain.AddInput(inputs...)
@@ -147,38 +478,588 @@ func BuildGolang(meta *meta.Options, inputs []dag.Node) ([]dag.Node, error) {
 	// linters
 	golangciLint := golang.NewGolangciLint(meta)
 	gofumpt := golang.NewGofumpt(meta)
+	gofmt := golang.NewGofmt(meta)
+	goimports := golang.NewGoimports(meta)
+	staticcheck := golang.NewStaticcheck(meta)
+	vulnCheck := golang.NewVulnCheck(meta)
+	importGuard := golang.NewImportGuard(meta)
+	licenseHeader := common.NewLicenseHeader(meta)
 
 	// linters are input to the toolchain as they inject into toolchain build
-	toolchain.AddInput(golangciLint, gofumpt)
+	toolchain.AddInput(golangciLint, gofumpt, goimports, staticcheck, vulnCheck)
+
+	// common lint target, staticcheck and import guard are skipped unless enabled in the config,
+	// either gofumpt or gofmt is run depending on the formatter
+	lint.AddInput(toolchain, golangciLint, gofumpt, gofmt, goimports, staticcheck, vulnCheck, importGuard, licenseHeader)
+
+	// go.mod and go.sum are verified to be tidy as part of lint
+	modTidy := golang.NewModTidy(meta, toolchain)
+	modTidy.AddInput(toolchain)
+
+	lint.AddInput(modTidy)
+
+	// vendored dependencies are verified as part of lint, skipped unless vendoring is enabled in the toolchain config
+	vendorCheck := golang.NewVendorCheck(meta, toolchain)
+	vendorCheck.AddInput(toolchain)
+
+	lint.AddInput(vendorCheck)
+
+	// protobuf code generation injects code generators into toolchain build as well
+	if len(meta.ProtobufFiles) > 0 {
+		protobuf := golang.NewProtobuf(meta)
+		toolchain.AddInput(protobuf)
+
+		lint.AddInput(golang.NewProtobufCheck(meta, protobuf))
+	}
+
+	// go:generate directives are verified as part of lint, generators are installed into the toolchain
+	if meta.GoGenerate {
+		generate := golang.NewGenerate(meta)
+		toolchain.AddInput(generate)
+
+		generateCheck := golang.NewGenerateCheck(meta, generate)
+		generateCheck.AddInput(toolchain)
+
+		lint.AddInput(generateCheck)
+	}
 
-	// common lint target
-	lint := common.NewLint(meta)
-	lint.AddInput(toolchain, golangciLint, gofumpt)
+	// OpenAPI docs are verified to be up to date as part of lint, swag is installed into the toolchain
+	if meta.GoSwaggerModule != "" {
+		swagger := golang.NewSwagger(meta)
+		toolchain.AddInput(swagger)
+
+		swaggerCheck := golang.NewSwaggerCheck(meta, swagger)
+		swaggerCheck.AddInput(toolchain)
+
+		lint.AddInput(swaggerCheck)
+	}
 
 	// unit-tests
+	// gotestsum is installed into the toolchain, unit-tests are run via gotestsum if it's enabled
+	gotestsum := golang.NewGotestsum(meta)
+	toolchain.AddInput(gotestsum)
+
 	unitTests := golang.NewUnitTests(meta)
 	unitTests.AddInput(toolchain)
 
-	coverage := service.NewCodeCov(meta)
-	coverage.InputPath = "coverage.txt"
 	coverage.AddInput(unitTests)
 
-	outputs := []dag.Node{lint, unitTests, coverage}
+	// coverage threshold is checked if configured
+	coverageThreshold := golang.NewCoverageThreshold(meta)
+	coverageThreshold.AddInput(unitTests)
+
+	outputs := []dag.Node{unitTests, coverageThreshold}
+
+	// integration tests are enabled if there are tests guarded with `integration` build tag
+	if contains(meta.GoTestBuildTags, "integration") {
+		integrationTests := golang.NewIntegrationTests(meta)
+		integrationTests.AddInput(toolchain)
+
+		coverage.AddInputPath(golang.IntegrationTestsCoverageFile)
+		coverage.AddInput(integrationTests)
+
+		outputs = append(outputs, integrationTests)
+	}
+
+	// fuzzing is enabled if there are native fuzz targets
+	if len(meta.GoFuzzTargets) > 0 {
+		fuzz := golang.NewFuzz(meta)
+		fuzz.AddInput(toolchain)
+
+		outputs = append(outputs, fuzz)
+	}
+
+	// benchmarks are only run in CI if enabled explicitly
+	benchmarks := golang.NewBenchmarks(meta)
+	benchmarks.AddInput(toolchain)
+
+	outputs = append(outputs, benchmarks)
+
+	// scan Go modules for vulnerabilities, works for projects without images as well
+	outputs = append(outputs, common.NewFilesystemScan(meta, toolchain))
+
+	// released versions are indexed by pkg.go.dev once fetched via the module proxy
+	godoc := golang.NewGoDoc(meta)
+	godoc.AddInput(toolchain)
+
+	outputs = append(outputs, godoc)
+
+	// binary releases are built from the same commands
+	releaser := golang.NewGoReleaser(meta)
+	upload := golang.NewGitHubRelease(meta)
+
+	// commands listed in image groups share a single image
+	groups, err := imageGroups(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	builds := map[string]*golang.Build{}
 
 	// process commands
 	for _, cmd := range meta.Commands {
-		build := golang.NewBuild(meta, cmd, filepath.Join("cmd", cmd))
+		build := golang.NewBuild(meta, cmd.Name, cmd.Path)
+		build.OutputName = cmd.OutputName
+		build.InstallPath = cmd.InstallPath
 		build.AddInput(toolchain)
 
-		image := common.NewImage(meta, cmd)
-		image.AddInput(build, common.NewFHS(meta), common.NewCACerts(meta), lint, wrap.Drone(unitTests))
+		releaser.AddInput(build)
+		upload.AddInput(build)
+
+		builds[cmd.Name] = build
+
+		outputs = append(outputs, build)
+
+		if _, grouped := groups[cmd.Name]; grouped || cmd.BinaryOnly {
+			continue
+		}
 
-		outputs = append(outputs, build, image)
+		// images inherit default platforms from meta.Platforms
+		image := common.NewImage(meta, cmd.Name)
+		image.AddInput(build, common.NewFHS(meta), common.NewCACerts(meta), lint)
+		image.AddInput(wrap.CI(unitTests)...)
+
+		outputs = append(outputs, image)
+	}
+
+	imageNames := make([]string, 0, len(meta.ImageGroups))
+
+	for name := range meta.ImageGroups {
+		imageNames = append(imageNames, name)
+	}
+
+	sort.Strings(imageNames)
+
+	for _, name := range imageNames {
+		// entrypoint follows the first command of the group
+		image := common.NewImage(meta, name)
+
+		for _, cmd := range meta.ImageGroups[name] {
+			image.AddInput(builds[cmd])
+		}
+
+		image.AddInput(common.NewFHS(meta), common.NewCACerts(meta), lint)
+		image.AddInput(wrap.CI(unitTests)...)
+
+		outputs = append(outputs, image)
+	}
+
+	if len(releaser.Inputs()) > 0 {
+		outputs = append(outputs, releaser, upload)
+	}
+
+	// reproducibility is checked on demand, as every command is built twice
+	if len(meta.Commands) > 0 {
+		reproCheck := golang.NewReproCheck(meta)
+
+		for _, cmd := range meta.Commands {
+			reproCheck.AddInput(builds[cmd.Name])
+		}
+
+		outputs = append(outputs, reproCheck)
 	}
 
 	return outputs, nil
 }
 
+// imageGroups maps grouped commands to the names of their images.
+func imageGroups(meta *meta.Options) (map[string]string, error) {
+	groups := map[string]string{}
+
+	for name, commands := range meta.ImageGroups {
+		if len(commands) == 0 {
+			return nil, fmt.Errorf("image group %q has no commands", name)
+		}
+
+		for _, cmd := range commands {
+			found := false
+
+			for _, command := range meta.Commands {
+				if command.Name == cmd {
+					found = true
+
+					if command.BinaryOnly {
+						return nil, fmt.Errorf("image group %q: command %q is built without an image", name, cmd)
+					}
+
+					break
+				}
+			}
+
+			if !found {
+				return nil, fmt.Errorf("image group %q: unknown command %q", name, cmd)
+			}
+
+			if group, exists := groups[cmd]; exists {
+				return nil, fmt.Errorf("command %q is listed in image groups %q and %q", cmd, group, name)
+			}
+
+			groups[cmd] = name
+		}
+	}
+
+	for name := range meta.ImageGroups {
+		for _, command := range meta.Commands {
+			if _, grouped := groups[command.Name]; command.Name == name && !grouped {
+				return nil, fmt.Errorf("image group %q conflicts with the image of command %q", name, name)
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// detectTestdata detects `testdata` directories next to the Go test files which are not copied
+// with the Go directories, as `go test` runs the tests in the package directory.
+func detectTestdata(rootPath string, options *meta.Options) error {
+	return filepath.Walk(rootPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(rootPath, p)
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if rel != "." && (strings.HasPrefix(info.Name(), ".") || info.Name() == "vendor" || info.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+
+		// nested modules which are not part of the workspace are not built
+		if rel != "." && !isGoModuleDir(options, rel) {
+			nested, err := fileExists(p, "go.mod")
+			if err != nil {
+				return err
+			}
+
+			if nested {
+				return filepath.SkipDir
+			}
+		}
+
+		// Go directories are copied with their testdata
+		for _, dir := range options.GoDirectories {
+			if rel == dir || strings.HasPrefix(rel, dir+"/") {
+				return filepath.SkipDir
+			}
+		}
+
+		if info.Name() != "testdata" {
+			return nil
+		}
+
+		hasTests, err := hasGoTestFiles(filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+
+		if hasTests && !contains(options.GoTestdataDirectories, rel) {
+			options.GoTestdataDirectories = append(options.GoTestdataDirectories, rel)
+			options.Directories = append(options.Directories, rel)
+		}
+
+		// nested testdata belongs to the same fixtures
+		return filepath.SkipDir
+	})
+}
+
+// isGoModuleDir checks whether dir is a root of the detected Go module.
+func isGoModuleDir(options *meta.Options, dir string) bool {
+	for _, module := range options.GoModules {
+		if module.Directory == dir {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasGoTestFiles checks whether path contains Go test files.
+func hasGoTestFiles(path string) (bool, error) {
+	contents, err := ioutil.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+
+	for _, item := range contents {
+		if !item.IsDir() && strings.HasSuffix(item.Name(), "_test.go") {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// testBuildTags returns build tags used in Go test files under path.
+func testBuildTags(path string) ([]string, error) {
+	var tags []string
+
+	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !strings.HasSuffix(info.Name(), "_test.go") {
+			return nil
+		}
+
+		fileTags, err := buildTags(path)
+		if err != nil {
+			return err
+		}
+
+		tags = append(tags, fileTags...)
+
+		return nil
+	})
+
+	return tags, err
+}
+
+var fuzzFuncRegexp = regexp.MustCompile(`^func (Fuzz[A-Za-z0-9_]*)\(\w+ \*testing\.F\)`)
+
+// fuzzTargets returns native fuzz targets defined in Go test files under dir.
+func fuzzTargets(rootPath, dir string) ([]meta.GoFuzzTarget, error) {
+	var targets []meta.GoFuzzTarget
+
+	err := filepath.Walk(filepath.Join(rootPath, dir), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !strings.HasSuffix(info.Name(), "_test.go") {
+			return nil
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		pkg, err := filepath.Rel(rootPath, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+
+		for _, line := range strings.Split(string(contents), "\n") {
+			if matches := fuzzFuncRegexp.FindStringSubmatch(line); matches != nil {
+				targets = append(targets, meta.GoFuzzTarget{
+					Package: "./" + filepath.ToSlash(pkg),
+					Name:    matches[1],
+				})
+			}
+		}
+
+		return nil
+	})
+
+	return targets, err
+}
+
+// hasGoGenerate checks whether Go files under path (or the file itself) contain `//go:generate` directives.
+func hasGoGenerate(path string) (bool, error) {
+	found := false
+
+	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if found || info.IsDir() || !strings.HasSuffix(info.Name(), ".go") {
+			return nil
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range strings.Split(string(contents), "\n") {
+			if strings.HasPrefix(line, "//go:generate ") {
+				found = true
+
+				break
+			}
+		}
+
+		return nil
+	})
+
+	return found, err
+}
+
+// goEmbedPaths returns top-level paths (relative to dir) referenced by `//go:embed` directives
+// in the Go files of dir.
+func goEmbedPaths(dir string) ([]string, error) {
+	contents, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+
+	for _, item := range contents {
+		if item.IsDir() || !strings.HasSuffix(item.Name(), ".go") {
+			continue
+		}
+
+		source, err := ioutil.ReadFile(filepath.Join(dir, item.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range strings.Split(string(source), "\n") {
+			if !strings.HasPrefix(line, "//go:embed ") {
+				continue
+			}
+
+			for _, pattern := range strings.Fields(strings.TrimPrefix(line, "//go:embed ")) {
+				if strings.HasPrefix(pattern, `"`) || strings.HasPrefix(pattern, "`") {
+					if pattern, err = strconv.Unquote(pattern); err != nil {
+						return nil, fmt.Errorf("%s: invalid go:embed pattern: %w", item.Name(), err)
+					}
+				}
+
+				matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(pattern, "all:"))))
+				if err != nil {
+					return nil, fmt.Errorf("%s: invalid go:embed pattern: %w", item.Name(), err)
+				}
+
+				for _, match := range matches {
+					rel, err := filepath.Rel(dir, match)
+					if err != nil {
+						return nil, err
+					}
+
+					topLevel := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+
+					if !contains(paths, topLevel) {
+						paths = append(paths, topLevel)
+					}
+				}
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// buildTags parses build constraints in the header of the Go file.
+func buildTags(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	var tags []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+
+		var expr string
+
+		switch {
+		case strings.HasPrefix(line, "// +build "):
+			expr = strings.TrimPrefix(line, "// +build ")
+		case strings.HasPrefix(line, "//go:build "):
+			expr = strings.TrimPrefix(line, "//go:build ")
+		default:
+			continue
+		}
+
+		tags = append(tags, strings.FieldsFunc(expr, func(r rune) bool {
+			return strings.ContainsRune(" ,!()&|", r)
+		})...)
+	}
+
+	return tags, scanner.Err()
+}
+
+// knownOS are GOOS values recognized in build constraints and file names, see `go tool dist list`.
+var knownOS = []string{
+	"aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios", "js",
+	"linux", "netbsd", "openbsd", "plan9", "solaris", "windows",
+}
+
+// knownArch are GOARCH values recognized in file names.
+var knownArch = []string{
+	"386", "amd64", "arm", "arm64", "mips", "mips64", "mips64le", "mipsle",
+	"ppc64", "ppc64le", "riscv64", "s390x", "wasm",
+}
+
+// buildOS returns GOOS values Go files under path are constrained to.
+func buildOS(path string) ([]string, error) {
+	var result []string
+
+	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if info.Name() == "testdata" || info.Name() == "vendor" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasSuffix(info.Name(), ".go") {
+			return nil
+		}
+
+		// file name suffixes: name_GOOS.go, name_GOOS_GOARCH.go (with optional _test)
+		parts := strings.Split(strings.TrimSuffix(strings.TrimSuffix(info.Name(), ".go"), "_test"), "_")
+
+		switch n := len(parts); {
+		case n >= 2 && contains(knownOS, parts[n-1]):
+			result = appendMissing(result, parts[n-1])
+		case n >= 3 && contains(knownArch, parts[n-1]) && contains(knownOS, parts[n-2]):
+			result = appendMissing(result, parts[n-2])
+		}
+
+		tags, err := buildTags(path)
+		if err != nil {
+			return err
+		}
+
+		for _, tag := range tags {
+			if contains(knownOS, tag) {
+				result = appendMissing(result, tag)
+			}
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// appendMissing appends the values which are not in the slice yet.
+func appendMissing(slice []string, values ...string) []string {
+	for _, value := range values {
+		if !contains(slice, value) {
+			slice = append(slice, value)
+		}
+	}
+
+	return slice
+}
+
 func hasGoFiles(path string) (bool, error) {
 	contents, err := ioutil.ReadDir(path)
 	if err != nil {
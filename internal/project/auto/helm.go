@@ -0,0 +1,122 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/project/common"
+	"github.com/talos-systems/kres/internal/project/helm"
+	"github.com/talos-systems/kres/internal/project/meta"
+	"github.com/talos-systems/kres/internal/project/service"
+)
+
+// DetectHelm checks if project at rootPath contains Helm charts.
+func DetectHelm(rootPath string, options *meta.Options) (bool, error) {
+	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			switch {
+			case path == rootPath:
+			case strings.HasPrefix(info.Name(), "."), info.Name() == "node_modules", info.Name() == "vendor", info.Name() == options.ArtifactsPath:
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if info.Name() != "Chart.yaml" {
+			return nil
+		}
+
+		dir, err := filepath.Rel(rootPath, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+
+		dir = filepath.ToSlash(dir)
+
+		options.HelmCharts = append(options.HelmCharts, dir)
+
+		// chart templates are not Go sources, but they should be in the build context
+		if dir == "." {
+			// chart at the repository root, allowing "." would allow the whole repository
+			return allowRootChart(rootPath, options)
+		}
+
+		if !contains(options.Directories, dir) {
+			options.Directories = append(options.Directories, dir)
+		}
+
+		// subcharts are packaged with the parent chart
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if len(options.HelmCharts) == 0 {
+		return false, nil
+	}
+
+	options.PackageManagers = append(options.PackageManagers, "helmv3")
+
+	return true, nil
+}
+
+// helmChartEntries are the files and directories of the chart (besides Chart.yaml).
+var helmChartEntries = []string{"Chart.lock", "values.yaml", "values.schema.json", ".helmignore", "templates", "charts", "crds"}
+
+// allowRootChart adds the entries of the chart at the repository root to the build context.
+func allowRootChart(rootPath string, options *meta.Options) error {
+	options.SourceFiles = append(options.SourceFiles, "Chart.yaml")
+
+	for _, entry := range helmChartEntries {
+		info, err := os.Stat(filepath.Join(rootPath, entry))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return err
+		}
+
+		if info.IsDir() {
+			if !contains(options.Directories, entry) {
+				options.Directories = append(options.Directories, entry)
+			}
+		} else {
+			options.SourceFiles = append(options.SourceFiles, entry)
+		}
+	}
+
+	// subcharts are packaged with the chart
+	return filepath.SkipDir
+}
+
+// BuildHelm builds project structure for Helm charts.
+func BuildHelm(meta *meta.Options, inputs []dag.Node, lint *common.Lint, coverage *service.CodeCov) ([]dag.Node, error) {
+	toolchain := helm.NewToolchain(meta)
+	toolchain.AddInput(inputs...)
+
+	helmLint := helm.NewLint(meta)
+	helmLint.AddInput(toolchain)
+
+	template := helm.NewTemplate(meta)
+	template.AddInput(toolchain)
+
+	lint.AddInput(helmLint, template)
+
+	pkg := helm.NewPackage(meta)
+	pkg.AddInput(toolchain)
+
+	return []dag.Node{pkg}, nil
+}
@@ -0,0 +1,139 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/project/common"
+	"github.com/talos-systems/kres/internal/project/js"
+	"github.com/talos-systems/kres/internal/project/meta"
+	"github.com/talos-systems/kres/internal/project/service"
+)
+
+// DetectJS check if project at rootPath contains JavaScript/TypeScript frontend.
+//
+// package.json is looked up at rootPath and in well-known frontend directories.
+func DetectJS(rootPath string, options *meta.Options) (bool, error) {
+	var root string
+
+	for _, candidate := range []string{".", "frontend", "web", "ui"} {
+		_, err := os.Stat(filepath.Join(rootPath, candidate, "package.json"))
+		if err == nil {
+			root = candidate
+
+			break
+		}
+
+		if !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+
+	if root == "" {
+		return false, nil
+	}
+
+	f, err := os.Open(filepath.Join(rootPath, root, "package.json"))
+	if err != nil {
+		return true, err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	var pkg struct {
+		Name string `json:"name"`
+	}
+
+	if err = json.NewDecoder(f).Decode(&pkg); err != nil {
+		return true, err
+	}
+
+	options.JSRoot = root
+	options.JSPackageName = pkg.Name
+	options.JSPackageManager = js.NPM
+	options.PackageManagers = append(options.PackageManagers, "npm")
+	options.JSSourceFiles = append(options.JSSourceFiles, "package.json")
+
+	for _, lock := range []struct {
+		file           string
+		packageManager string
+	}{
+		{"yarn.lock", js.Yarn},
+		{"pnpm-lock.yaml", js.PNPM},
+		{"package-lock.json", js.NPM},
+	} {
+		_, err = os.Stat(filepath.Join(rootPath, root, lock.file))
+		if err == nil {
+			options.JSPackageManager = lock.packageManager
+			options.JSSourceFiles = append(options.JSSourceFiles, lock.file)
+
+			break
+		}
+
+		if !os.IsNotExist(err) {
+			return true, err
+		}
+	}
+
+	if root == "." {
+		for _, srcDir := range []string{"src", "public"} {
+			exists, err := directoryExists(rootPath, srcDir)
+			if err != nil {
+				return true, err
+			}
+
+			if exists {
+				options.JSDirectories = append(options.JSDirectories, srcDir)
+			}
+		}
+
+		options.SourceFiles = append(options.SourceFiles, options.JSSourceFiles...)
+	} else {
+		options.JSDirectories = append(options.JSDirectories, root)
+	}
+
+	for _, dir := range options.JSDirectories {
+		if !contains(options.Directories, dir) {
+			options.Directories = append(options.Directories, dir)
+		}
+	}
+
+	return true, nil
+}
+
+// BuildJS builds project structure for JavaScript/TypeScript project.
+func BuildJS(meta *meta.Options, inputs []dag.Node, lint *common.Lint, coverage *service.CodeCov) ([]dag.Node, error) {
+	// toolchain as the root of the tree
+	toolchain := js.NewToolchain(meta)
+	toolchain.AddInput(inputs...)
+
+	install := js.NewInstall(meta)
+	install.AddInput(toolchain)
+
+	eslint := js.NewESLint(meta)
+	eslint.AddInput(install)
+
+	lint.AddInput(install, eslint)
+
+	name := path.Base(meta.JSPackageName)
+	if name == "." || name == "/" {
+		name = "frontend"
+	}
+
+	build := js.NewBuild(meta, name)
+	build.AddInput(install)
+
+	// image with static assets, to be consumed by other images
+	image := common.NewImage(meta, name)
+	image.Entrypoint = ""
+	image.AddInput(build, lint)
+
+	return []dag.Node{build, image}, nil
+}
@@ -0,0 +1,81 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// DetectGitLFS checks if the project at rootPath stores files in Git LFS.
+//
+// Files are stored in LFS if any of the .gitattributes files sets the LFS filter.
+func DetectGitLFS(rootPath string, options *meta.Options) (bool, error) {
+	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			switch {
+			case path == rootPath:
+			case strings.HasPrefix(info.Name(), "."), info.Name() == "node_modules", info.Name() == "vendor", info.Name() == options.ArtifactsPath:
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if info.Name() != ".gitattributes" {
+			return nil
+		}
+
+		lfs, err := lfsAttributes(path)
+		if err != nil {
+			return err
+		}
+
+		options.GitLFS = options.GitLFS || lfs
+
+		return nil
+	})
+
+	return options.GitLFS, err
+}
+
+// lfsAttributes checks if any pattern in the attributes file uses the LFS filter.
+func lfsAttributes(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		// first field is the pattern, the rest are attributes
+		for i := 1; i < len(fields); i++ {
+			if fields[i] == "filter=lfs" {
+				return true, nil
+			}
+		}
+	}
+
+	return false, scanner.Err()
+}
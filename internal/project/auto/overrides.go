@@ -0,0 +1,114 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Overrides of the detected project options, loaded from the `auto.Overrides` document of `.kres.yaml`.
+//
+// Overrides are applied on top of the detection results, so the config wins over the detection
+// (paths matching `--exclude` are still excluded).
+type Overrides struct {
+	// CanonicalPath replaces the module path detected from go.mod.
+	CanonicalPath string `yaml:"canonicalPath"`
+	// VersionPackage replaces the detected version package (`pkg/version` or `internal/version`).
+	VersionPackage string `yaml:"versionPackage"`
+	// Directories are Go source directories (relative to the project root) which are not detected automatically.
+	Directories []string `yaml:"directories"`
+	// ExcludeCommands are names of the detected commands (directories under `cmd/`) which are not built.
+	ExcludeCommands []string `yaml:"excludeCommands"`
+	// BinaryOnlyCommands are names of the detected commands which are built as binaries without images (e.g. dev tools).
+	BinaryOnlyCommands []string `yaml:"binaryOnlyCommands"`
+	// ExcludeTestdata are glob patterns of the test fixtures excluded from the build context (e.g. large files),
+	// they are appended to the `--exclude` patterns.
+	ExcludeTestdata []string `yaml:"excludeTestdata"`
+}
+
+// applyOverrides loads Overrides from the config and applies them to the detected options.
+func applyOverrides(rootPath string, options *meta.Options) error {
+	if options.Config == nil {
+		return nil
+	}
+
+	var overrides Overrides
+
+	if err := options.Config.Load(&overrides); err != nil {
+		return err
+	}
+
+	if overrides.CanonicalPath != "" {
+		options.CanonicalPath = overrides.CanonicalPath
+	}
+
+	if overrides.VersionPackage != "" {
+		options.VersionPackage = overrides.VersionPackage
+	}
+
+	for _, dir := range overrides.Directories {
+		dir = path.Clean(dir)
+
+		exists, err := directoryExists(rootPath, dir)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return fmt.Errorf("overrides: directory %q doesn't exist", dir)
+		}
+
+		if !contains(options.Directories, dir) {
+			options.Directories = append(options.Directories, dir)
+		}
+
+		if !contains(options.GoDirectories, dir) {
+			options.GoDirectories = append(options.GoDirectories, dir)
+		}
+	}
+
+	for _, name := range overrides.ExcludeCommands {
+		found := false
+		commands := options.Commands[:0]
+
+		for _, command := range options.Commands {
+			if command.Name == name {
+				found = true
+
+				continue
+			}
+
+			commands = append(commands, command)
+		}
+
+		options.Commands = commands
+
+		if !found {
+			options.Warn("overrides: command %q is not detected", name)
+		}
+	}
+
+	options.ExcludePaths = append(options.ExcludePaths, overrides.ExcludeTestdata...)
+
+	for _, name := range overrides.BinaryOnlyCommands {
+		found := false
+
+		for i := range options.Commands {
+			if options.Commands[i].Name == name {
+				options.Commands[i].BinaryOnly = true
+				found = true
+			}
+		}
+
+		if !found {
+			options.Warn("overrides: command %q is not detected", name)
+		}
+	}
+
+	return nil
+}
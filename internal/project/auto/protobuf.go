@@ -0,0 +1,147 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// bufGenConfig is a subset of buf.gen.yaml used for detection.
+type bufGenConfig struct {
+	Plugins []struct {
+		Out string `yaml:"out"`
+	} `yaml:"plugins"`
+}
+
+// DetectProtobuf checks if Go project at rootPath contains protobuf definitions.
+//
+// Generated Go code is placed next to the .proto files (paths=source_relative), unless
+// buf.gen.yaml is present, in which case plugin output directories are used.
+func DetectProtobuf(rootPath string, options *meta.Options) (bool, error) {
+	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			switch {
+			case path == rootPath:
+			case strings.HasPrefix(info.Name(), "."), info.Name() == "node_modules", info.Name() == "vendor", info.Name() == options.ArtifactsPath:
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasSuffix(info.Name(), ".proto") {
+			return nil
+		}
+
+		file, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			return err
+		}
+
+		file = filepath.ToSlash(file)
+
+		options.ProtobufFiles = append(options.ProtobufFiles, file)
+
+		// top-level files are copied one by one into the build context
+		if !strings.Contains(file, "/") {
+			options.SourceFiles = append(options.SourceFiles, file)
+			options.GoSourceFiles = append(options.GoSourceFiles, file)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if len(options.ProtobufFiles) == 0 {
+		return false, nil
+	}
+
+	var outputs []string
+
+	for _, file := range options.ProtobufFiles {
+		outputs = append(outputs, topLevelDirectory(file))
+	}
+
+	for _, name := range []string{"buf.yaml", "buf.gen.yaml"} {
+		contents, err := ioutil.ReadFile(filepath.Join(rootPath, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return true, err
+		}
+
+		options.ProtobufBuf = true
+		options.SourceFiles = append(options.SourceFiles, name)
+		options.GoSourceFiles = append(options.GoSourceFiles, name)
+
+		if name != "buf.gen.yaml" {
+			continue
+		}
+
+		var config bufGenConfig
+
+		if err = yaml.Unmarshal(contents, &config); err != nil {
+			return true, err
+		}
+
+		for _, plugin := range config.Plugins {
+			if plugin.Out != "" {
+				outputs = append(outputs, topLevelDirectory(plugin.Out))
+			}
+		}
+	}
+
+	for _, dir := range outputs {
+		// files on the top level are already covered by the build context
+		if dir == "." {
+			continue
+		}
+
+		if !contains(options.ProtobufDirectories, dir) {
+			options.ProtobufDirectories = append(options.ProtobufDirectories, dir)
+		}
+
+		if !contains(options.Directories, dir) {
+			options.Directories = append(options.Directories, dir)
+		}
+
+		if !contains(options.GoDirectories, dir) {
+			options.GoDirectories = append(options.GoDirectories, dir)
+		}
+	}
+
+	return true, nil
+}
+
+// topLevelDirectory returns first path element of the relative file path.
+func topLevelDirectory(file string) string {
+	file = path.Clean(strings.TrimPrefix(filepath.ToSlash(file), "./"))
+
+	if !strings.Contains(file, "/") {
+		if strings.HasSuffix(file, ".proto") {
+			return "."
+		}
+
+		return file
+	}
+
+	return strings.SplitN(file, "/", 2)[0]
+}
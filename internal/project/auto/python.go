@@ -0,0 +1,290 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/project/common"
+	"github.com/talos-systems/kres/internal/project/meta"
+	"github.com/talos-systems/kres/internal/project/python"
+	"github.com/talos-systems/kres/internal/project/service"
+	"github.com/talos-systems/kres/internal/project/wrap"
+)
+
+// pyProject is a subset of pyproject.toml used for detection.
+type pyProject struct {
+	Name     string
+	Sections map[string]bool
+}
+
+// DetectPython check if project at rootPath contains Python project.
+//
+// pyproject.toml or setup.py is looked up at rootPath and in service directories (services/*).
+//
+//nolint: gocognit,gocyclo
+func DetectPython(rootPath string, options *meta.Options) (bool, error) {
+	candidates := []string{"."}
+
+	services, err := filepath.Glob(filepath.Join(rootPath, "services", "*"))
+	if err != nil {
+		return false, err
+	}
+
+	for _, serviceDir := range services {
+		dir, err := filepath.Rel(rootPath, serviceDir)
+		if err != nil {
+			return false, err
+		}
+
+		candidates = append(candidates, filepath.ToSlash(dir))
+	}
+
+	var root string
+
+	for _, candidate := range candidates {
+		for _, file := range []string{"pyproject.toml", "setup.py"} {
+			_, err := os.Stat(filepath.Join(rootPath, candidate, file))
+			if err == nil {
+				root = candidate
+
+				break
+			}
+
+			if !os.IsNotExist(err) {
+				return false, err
+			}
+		}
+
+		if root != "" {
+			break
+		}
+	}
+
+	if root == "" {
+		return false, nil
+	}
+
+	options.PythonRoot = root
+	options.PythonPackageManager = python.Pip
+
+	for _, file := range []string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "poetry.lock", "pdm.lock"} {
+		_, err := os.Stat(filepath.Join(rootPath, root, file))
+		if err == nil {
+			options.PythonSourceFiles = append(options.PythonSourceFiles, file)
+
+			continue
+		}
+
+		if !os.IsNotExist(err) {
+			return true, err
+		}
+	}
+
+	project := pyProject{Sections: map[string]bool{}}
+
+	if contains(options.PythonSourceFiles, "pyproject.toml") {
+		if project, err = parsePyProject(filepath.Join(rootPath, root, "pyproject.toml")); err != nil {
+			return true, err
+		}
+	}
+
+	if project.Name == "" && contains(options.PythonSourceFiles, "setup.py") {
+		if project.Name, err = setupPyName(filepath.Join(rootPath, root, "setup.py")); err != nil {
+			return true, err
+		}
+	}
+
+	options.PythonPackageName = project.Name
+
+	switch {
+	case contains(options.PythonSourceFiles, "poetry.lock") || project.Sections["tool.poetry"]:
+		options.PythonPackageManager = python.Poetry
+		options.PackageManagers = append(options.PackageManagers, "poetry")
+	case contains(options.PythonSourceFiles, "pdm.lock") || project.Sections["tool.pdm"]:
+		options.PythonPackageManager = python.PDM
+		options.PackageManagers = append(options.PackageManagers, "pep621")
+	default:
+		if contains(options.PythonSourceFiles, "requirements.txt") {
+			options.PackageManagers = append(options.PackageManagers, "pip_requirements")
+		}
+
+		if contains(options.PythonSourceFiles, "setup.py") {
+			options.PackageManagers = append(options.PackageManagers, "pip_setup")
+		}
+	}
+
+	if options.PythonFlake8, err = hasFlake8Config(filepath.Join(rootPath, root)); err != nil {
+		return true, err
+	}
+
+	if root == "." {
+		srcDirs := []string{"src", "tests"}
+
+		if project.Name != "" {
+			srcDirs = append(srcDirs, pythonModuleName(project.Name))
+		}
+
+		for _, srcDir := range srcDirs {
+			exists, err := directoryExists(rootPath, srcDir)
+			if err != nil {
+				return true, err
+			}
+
+			if exists {
+				options.PythonDirectories = append(options.PythonDirectories, srcDir)
+			}
+		}
+
+		options.SourceFiles = append(options.SourceFiles, options.PythonSourceFiles...)
+	} else {
+		options.PythonDirectories = append(options.PythonDirectories, root)
+	}
+
+	for _, dir := range options.PythonDirectories {
+		if !contains(options.Directories, dir) {
+			options.Directories = append(options.Directories, dir)
+		}
+	}
+
+	return true, nil
+}
+
+// BuildPython builds project structure for Python project.
+func BuildPython(meta *meta.Options, inputs []dag.Node, lint *common.Lint, coverage *service.CodeCov) ([]dag.Node, error) {
+	// toolchain as the root of the tree
+	toolchain := python.NewToolchain(meta)
+	toolchain.AddInput(inputs...)
+
+	install := python.NewInstall(meta)
+	install.AddInput(toolchain)
+
+	// linters
+	var linter dag.Node
+
+	if meta.PythonFlake8 {
+		linter = python.NewFlake8(meta)
+	} else {
+		linter = python.NewRuff(meta)
+	}
+
+	linter.AddInput(install)
+
+	mypy := python.NewMyPy(meta)
+	mypy.AddInput(install)
+
+	lint.AddInput(install, linter, mypy)
+
+	// unit-tests
+	unitTests := python.NewUnitTests(meta)
+	unitTests.AddInput(install)
+
+	coverage.AddInputPath(python.UnitTestsCoverageFile)
+	coverage.AddInput(unitTests)
+
+	name := meta.PythonPackageName
+	if name == "" {
+		name = path.Base(meta.PythonRoot)
+	}
+
+	if name == "." || name == "/" {
+		name = "app"
+	}
+
+	build := python.NewBuild(meta, name)
+	build.AddInput(toolchain)
+
+	// image contains the whole Python environment with the project installed
+	image := common.NewImage(meta, name)
+	image.Entrypoint = "python"
+	image.EntrypointArgs = []string{"-m", pythonModuleName(name)}
+	image.AddInput(build, lint)
+	image.AddInput(wrap.CI(unitTests)...)
+
+	return []dag.Node{unitTests, build, image}, nil
+}
+
+// parsePyProject extracts project name and the list of sections from pyproject.toml.
+//
+// Name is taken from `[project]` (PEP 621) or `[tool.poetry]` sections.
+func parsePyProject(path string) (pyProject, error) {
+	project := pyProject{Sections: map[string]bool{}}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return project, err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	err = scanTOML(f, func(table, key, value string) error {
+		if table != "" {
+			project.Sections[table] = true
+		}
+
+		var err error
+
+		if key == "name" && (table == "project" || table == "tool.poetry") {
+			project.Name, err = parseTOMLString(value)
+		}
+
+		return err
+	})
+
+	return project, err
+}
+
+var setupPyNameRegexp = regexp.MustCompile(`\bname\s*=\s*["']([^"']+)["']`)
+
+// setupPyName extracts project name from the setup() call in setup.py.
+func setupPyName(path string) (string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	if matches := setupPyNameRegexp.FindSubmatch(contents); matches != nil {
+		return string(matches[1]), nil
+	}
+
+	return "", nil
+}
+
+// hasFlake8Config checks whether flake8 is configured in one of the supported config files.
+func hasFlake8Config(path string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(path, ".flake8")); err == nil {
+		return true, nil
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+
+	for _, file := range []string{"setup.cfg", "tox.ini"} {
+		contents, err := ioutil.ReadFile(filepath.Join(path, file))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return false, err
+		}
+
+		if strings.Contains(string(contents), "[flake8]") {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// pythonModuleName converts distribution name to the name of the top-level module.
+func pythonModuleName(name string) string {
+	return strings.ToLower(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
@@ -0,0 +1,176 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/project/common"
+	"github.com/talos-systems/kres/internal/project/meta"
+	"github.com/talos-systems/kres/internal/project/rust"
+	"github.com/talos-systems/kres/internal/project/service"
+)
+
+// cargoManifest is a subset of Cargo.toml used for detection.
+type cargoManifest struct {
+	PackageName      string
+	WorkspaceMembers []string
+}
+
+// DetectRust check if project at rootPath is Rust-based project.
+//
+//nolint: gocognit
+func DetectRust(rootPath string, options *meta.Options) (bool, error) {
+	cargoPath := filepath.Join(rootPath, "Cargo.toml")
+
+	cargo, err := os.Open(cargoPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	defer cargo.Close() //nolint: errcheck
+
+	manifest, err := parseCargoManifest(cargo)
+	if err != nil {
+		return true, err
+	}
+
+	options.RustPackage = manifest.PackageName
+	options.PackageManagers = append(options.PackageManagers, "cargo")
+
+	// Go module path takes precedence for mixed projects, as it's used for import grouping
+	if options.CanonicalPath == "" {
+		options.CanonicalPath = manifest.PackageName
+	}
+
+	options.RustSourceFiles = append(options.RustSourceFiles, "Cargo.toml")
+
+	if _, err := os.Stat(filepath.Join(rootPath, "Cargo.lock")); err == nil {
+		options.RustSourceFiles = append(options.RustSourceFiles, "Cargo.lock")
+	} else if !os.IsNotExist(err) {
+		return true, err
+	}
+
+	rustDirectories := []string{}
+
+	{
+		srcExists, err := directoryExists(rootPath, "src")
+		if err != nil {
+			return true, err
+		}
+
+		if srcExists {
+			res, err := hasRustFiles(filepath.Join(rootPath, "src"))
+			if err != nil {
+				return true, err
+			}
+
+			if res {
+				rustDirectories = append(rustDirectories, "src")
+			}
+		}
+	}
+
+	for _, member := range manifest.WorkspaceMembers {
+		matches, err := filepath.Glob(filepath.Join(rootPath, member))
+		if err != nil {
+			return true, err
+		}
+
+		for _, match := range matches {
+			st, err := os.Stat(match)
+			if err != nil {
+				return true, err
+			}
+
+			if !st.IsDir() {
+				continue
+			}
+
+			dir, err := filepath.Rel(rootPath, match)
+			if err != nil {
+				return true, err
+			}
+
+			rustDirectories = append(rustDirectories, filepath.ToSlash(dir))
+		}
+	}
+
+	for _, dir := range rustDirectories {
+		options.RustDirectories = append(options.RustDirectories, dir)
+
+		if !contains(options.Directories, dir) {
+			options.Directories = append(options.Directories, dir)
+		}
+	}
+
+	options.SourceFiles = append(options.SourceFiles, options.RustSourceFiles...)
+
+	return true, nil
+}
+
+// BuildRust builds project structure for Rust project.
+func BuildRust(meta *meta.Options, inputs []dag.Node, lint *common.Lint, coverage *service.CodeCov) ([]dag.Node, error) {
+	// toolchain as the root of the tree
+	toolchain := rust.NewToolchain(meta)
+	toolchain.AddInput(inputs...)
+
+	outputs := []dag.Node{}
+
+	// virtual workspaces don't have a top-level package to build
+	if meta.RustPackage != "" {
+		build := rust.NewBuild(meta, meta.RustPackage)
+		build.AddInput(toolchain)
+
+		outputs = append(outputs, build)
+	}
+
+	return outputs, nil
+}
+
+// parseCargoManifest extracts `[package] name` and `[workspace] members` from
+// Cargo.toml.
+func parseCargoManifest(r io.Reader) (cargoManifest, error) {
+	var manifest cargoManifest
+
+	err := scanTOML(r, func(table, key, value string) error {
+		var err error
+
+		switch {
+		case table == "package" && key == "name":
+			manifest.PackageName, err = parseTOMLString(value)
+		case table == "workspace" && key == "members":
+			manifest.WorkspaceMembers, err = parseTOMLStringArray(value)
+		}
+
+		return err
+	})
+
+	return manifest, err
+}
+
+func hasRustFiles(path string) (bool, error) {
+	contents, err := ioutil.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+
+	for _, item := range contents {
+		if !item.IsDir() && strings.HasSuffix(item.Name(), ".rs") {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,128 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/talos-systems/kres/internal/project/auto"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+func TestDetectRust(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		manifest string
+		members  []string
+
+		expectedPackage     string
+		expectedDirectories []string
+	}{
+		{
+			name: "package",
+			manifest: `[package]
+name = "foo" # comment
+version = "0.1.0"
+`,
+			expectedPackage: "foo",
+		},
+		{
+			name: "literal string",
+			manifest: `[package]
+name = 'foo'
+`,
+			expectedPackage: "foo",
+		},
+		{
+			name: "dotted key",
+			manifest: `package.name = "foo"
+package.version.workspace = true
+`,
+			expectedPackage: "foo",
+		},
+		{
+			name: "inline table",
+			manifest: `package = { name = "foo", version = "0.1.0" }
+`,
+			expectedPackage: "foo",
+		},
+		{
+			name: "workspace",
+			manifest: `[workspace]
+members = ["crates/a", 'crates/b']
+`,
+			members:             []string{"crates/a", "crates/b"},
+			expectedDirectories: []string{"crates/a", "crates/b"},
+		},
+		{
+			name: "multi-line array",
+			manifest: `[workspace]
+members = [
+    "crates/*", # all crates
+    "tools/[x]",
+]
+resolver = "2"
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+`,
+			members:             []string{"crates/a", "crates/b"},
+			expectedDirectories: []string{"crates/a", "crates/b"},
+		},
+		{
+			name: "workspace with root package",
+			manifest: `[package]
+name = "foo"
+
+[workspace]
+members = ["crates/a"]
+
+[[bin]]
+name = "bar"
+`,
+			members:             []string{"crates/a"},
+			expectedPackage:     "foo",
+			expectedDirectories: []string{"crates/a"},
+		},
+	} {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := t.TempDir()
+
+			require.NoError(t, ioutil.WriteFile(filepath.Join(rootPath, "Cargo.toml"), []byte(tt.manifest), 0o644))
+
+			for _, member := range tt.members {
+				require.NoError(t, os.MkdirAll(filepath.Join(rootPath, member), 0o755))
+			}
+
+			var options meta.Options
+
+			detected, err := auto.DetectRust(rootPath, &options)
+			require.NoError(t, err)
+			assert.True(t, detected)
+
+			assert.Equal(t, tt.expectedPackage, options.RustPackage)
+			assert.Equal(t, tt.expectedDirectories, options.RustDirectories)
+		})
+	}
+}
+
+func TestDetectRustInvalidManifest(t *testing.T) {
+	rootPath := t.TempDir()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(rootPath, "Cargo.toml"), []byte("[package]\nname = foo\n"), 0o644))
+
+	var options meta.Options
+
+	_, err := auto.DetectRust(rootPath, &options)
+	assert.Error(t, err)
+}
@@ -0,0 +1,287 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// scanTOML calls fn for every key of the TOML document with the raw value, tables are reported with an empty key.
+//
+// Only a subset of TOML used by the project manifests is supported: tables, dotted keys, basic and literal strings,
+// arrays and inline tables (possibly spanning multiple lines). Keys of the inline tables are reported as the keys
+// of the nested table. Values are parsed with parseTOMLString and parseTOMLStringArray.
+func scanTOML(r io.Reader, fn func(table, key, value string) error) error {
+	var (
+		table, key string
+		pending    strings.Builder
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(stripTOMLComment(scanner.Text()))
+
+		// value spanning multiple lines
+		if pending.Len() > 0 {
+			pending.WriteString(" ")
+			pending.WriteString(line)
+
+			if tomlDepth(pending.String()) > 0 {
+				continue
+			}
+
+			value := pending.String()
+			pending.Reset()
+
+			if err := emitTOML(table, key, value, fn); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			table = tomlKey(strings.Trim(line, "[]"))
+
+			if err := fn(table, "", ""); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		idx := tomlIndex(line, '=')
+		if idx < 0 {
+			continue
+		}
+
+		var value string
+
+		key, value = strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+1:])
+
+		if tomlDepth(value) > 0 {
+			pending.WriteString(value)
+
+			continue
+		}
+
+		if err := emitTOML(table, key, value, fn); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// emitTOML reports the key of the table, dotted keys and inline tables are resolved to the nested tables.
+func emitTOML(table, key, value string, fn func(table, key, value string) error) error {
+	path := tomlKey(key)
+	if table != "" {
+		path = table + "." + path
+	}
+
+	if strings.HasPrefix(value, "{") {
+		if err := fn(path, "", ""); err != nil {
+			return err
+		}
+
+		for _, item := range splitTOMLList(strings.TrimSuffix(strings.TrimPrefix(value, "{"), "}")) {
+			idx := tomlIndex(item, '=')
+			if idx < 0 {
+				return fmt.Errorf("invalid inline table %s", value)
+			}
+
+			if err := emitTOML(path, item[:idx], strings.TrimSpace(item[idx+1:]), fn); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if idx := strings.LastIndex(path, "."); idx >= 0 {
+		table, key = path[:idx], path[idx+1:]
+	} else {
+		table, key = "", path
+	}
+
+	return fn(table, key, value)
+}
+
+// tomlKey normalizes the (dotted) key: whitespace around the dots and quotes are removed.
+func tomlKey(key string) string {
+	parts := strings.Split(key, ".")
+
+	for i := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(parts[i]), `"'`)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// outsideTOMLStrings calls fn for the characters outside of the string literals until fn returns false.
+func outsideTOMLStrings(s string, fn func(i int, c rune) bool) {
+	var (
+		quote   rune
+		escaped bool
+	)
+
+	for i, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"', c == '\'':
+			quote = c
+		default:
+			if !fn(i, c) {
+				return
+			}
+		}
+	}
+}
+
+// tomlIndex returns the index of the first c outside of the string literals, or -1.
+func tomlIndex(s string, c rune) int {
+	result := -1
+
+	outsideTOMLStrings(s, func(i int, r rune) bool {
+		if r == c {
+			result = i
+
+			return false
+		}
+
+		return true
+	})
+
+	return result
+}
+
+// tomlDepth returns the number of arrays and inline tables not closed in s.
+func tomlDepth(s string) int {
+	depth := 0
+
+	outsideTOMLStrings(s, func(_ int, c rune) bool {
+		switch c {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		}
+
+		return true
+	})
+
+	return depth
+}
+
+// splitTOMLList splits the items of the array or the inline table.
+func splitTOMLList(s string) []string {
+	var (
+		items []string
+		depth int
+		start int
+	)
+
+	outsideTOMLStrings(s, func(i int, c rune) bool {
+		switch c {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, s[start:i])
+				start = i + 1
+			}
+		}
+
+		return true
+	})
+
+	items = append(items, s[start:])
+
+	result := make([]string, 0, len(items))
+
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func stripTOMLComment(line string) string {
+	if idx := tomlIndex(line, '#'); idx >= 0 {
+		return line[:idx]
+	}
+
+	return line
+}
+
+// parseTOMLString parses basic ("...") or literal ('...') string.
+func parseTOMLString(value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	switch {
+	case strings.HasPrefix(value, "'"):
+		if end := strings.IndexByte(value[1:], '\''); end >= 0 {
+			return value[1 : end+1], nil
+		}
+	case strings.HasPrefix(value, `"`):
+		escaped := false
+
+		for i := 1; i < len(value); i++ {
+			switch {
+			case escaped:
+				escaped = false
+			case value[i] == '\\':
+				escaped = true
+			case value[i] == '"':
+				return strconv.Unquote(value[:i+1])
+			}
+		}
+	}
+
+	return "", fmt.Errorf("invalid TOML string %s", value)
+}
+
+// parseTOMLStringArray parses the array of strings.
+func parseTOMLStringArray(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("invalid TOML array %s", value)
+	}
+
+	items := splitTOMLList(value[1 : len(value)-1])
+	result := make([]string, 0, len(items))
+
+	for _, item := range items {
+		s, err := parseTOMLString(item)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, s)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,107 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// goToolsDirectories are directories which might contain the file pinning the tools (`tools.go`).
+var goToolsDirectories = []string{".", "tools", "internal/tools", "hack/tools"}
+
+// detectGoTools detects the tools package of the module in moduleDir: Go files guarded with `tools`
+// build tag (or named `tools.go`) which blank import the tools to pin their versions in go.mod.
+//
+// Files outside of already detected Go directories are added to the source files.
+func detectGoTools(rootPath, moduleDir string, goDirectories []string, options *meta.Options) error {
+	for _, dir := range goToolsDirectories {
+		items, err := ioutil.ReadDir(filepath.Join(rootPath, moduleDir, dir))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return err
+		}
+
+		for _, item := range items {
+			if item.IsDir() || !strings.HasSuffix(item.Name(), ".go") || strings.HasSuffix(item.Name(), "_test.go") {
+				continue
+			}
+
+			filePath := filepath.Join(rootPath, moduleDir, dir, item.Name())
+
+			tags, err := buildTags(filePath)
+			if err != nil {
+				return err
+			}
+
+			if !contains(tags, "tools") && item.Name() != "tools.go" {
+				continue
+			}
+
+			tools, err := blankImports(filePath)
+			if err != nil {
+				return err
+			}
+
+			if len(tools) == 0 {
+				continue
+			}
+
+			for _, tool := range tools {
+				if !contains(options.GoTools, tool) {
+					options.GoTools = append(options.GoTools, tool)
+				}
+			}
+
+			file := path.Join(moduleDir, dir, item.Name())
+
+			// files at the root of the module are already detected as Go source files
+			if dir == "." || contains(goDirectories, path.Join(moduleDir, strings.SplitN(dir, "/", 2)[0])) {
+				continue
+			}
+
+			options.SourceFiles = append(options.SourceFiles, file)
+			options.GoToolsFiles = append(options.GoToolsFiles, file)
+		}
+	}
+
+	return nil
+}
+
+// blankImports returns the packages imported for side effects (`import _ "pkg"`) by the Go file.
+func blankImports(filePath string) ([]string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), filePath, nil, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []string
+
+	for _, spec := range f.Imports {
+		if spec.Name == nil || spec.Name.Name != "_" {
+			continue
+		}
+
+		pkg, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		packages = append(packages, pkg)
+	}
+
+	return packages, nil
+}
@@ -0,0 +1,68 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmake
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// ClangFormat checks formatting of C/C++ sources with clang-format.
+//
+// Check is enabled if .clang-format config is present.
+type ClangFormat struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Package provides clang-format in the toolchain image.
+	Package string `yaml:"package"`
+}
+
+// NewClangFormat builds ClangFormat node.
+func NewClangFormat(meta *meta.Options) *ClangFormat {
+	return &ClangFormat{
+		BaseNode: dag.NewBaseNode("lint-clang-format"),
+
+		meta: meta,
+
+		Package: "clang-extra-tools",
+	}
+}
+
+// LinterEnabled implements common.OptionalLinter.
+func (lint *ClangFormat) LinterEnabled() bool {
+	return lint.meta.ClangFormat
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (lint *ClangFormat) CompileDockerfile(output *dockerfile.Output) error {
+	if !lint.LinterEnabled() {
+		return nil
+	}
+
+	output.Stage(lint.Name()).
+		Description("runs clang-format").
+		From("cmake-toolchain").
+		Step(step.Script("apk --update --no-cache add " + lint.Package)).
+		Step(step.Script(findSources(".", true) + " | xargs -0 -r clang-format --dry-run --Werror"))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (lint *ClangFormat) CompileMakefile(output *makefile.Output) error {
+	if !lint.LinterEnabled() {
+		return nil
+	}
+
+	output.Target(lint.Name()).Description("Runs clang-format linter.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
@@ -0,0 +1,82 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmake
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// ClangTidy runs clang-tidy over C/C++ sources with the compilation database exported by CMake.
+//
+// Check is enabled if .clang-tidy config is present.
+type ClangTidy struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Package provides clang-tidy in the toolchain image.
+	Package string `yaml:"package"`
+}
+
+// NewClangTidy builds ClangTidy node.
+func NewClangTidy(meta *meta.Options) *ClangTidy {
+	return &ClangTidy{
+		BaseNode: dag.NewBaseNode("lint-clang-tidy"),
+
+		meta: meta,
+
+		Package: "clang-extra-tools",
+	}
+}
+
+// LinterEnabled implements common.OptionalLinter.
+func (lint *ClangTidy) LinterEnabled() bool {
+	return lint.meta.ClangTidy
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (lint *ClangTidy) CompileDockerfile(output *dockerfile.Output) error {
+	if !lint.LinterEnabled() {
+		return nil
+	}
+
+	commands := make([]string, 0, len(lint.meta.CMakeProjects))
+
+	for _, project := range lint.meta.CMakeProjects {
+		buildDir := path.Join(buildPath, "tidy", project.Name)
+
+		// headers are checked as part of the sources including them
+		commands = append(commands, fmt.Sprintf("cmake -S %s -B %s -DCMAKE_EXPORT_COMPILE_COMMANDS=ON \\\n\t&& %s | xargs -0 -r clang-tidy -p %s --warnings-as-errors='*'",
+			project.Directory, buildDir, findSources(project.Directory, false), buildDir))
+	}
+
+	output.Stage(lint.Name()).
+		Description("runs clang-tidy").
+		From("cmake-toolchain").
+		Step(step.Script("apk --update --no-cache add " + lint.Package)).
+		Step(step.Script(strings.Join(commands, " \\\n\t&& ")))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (lint *ClangTidy) CompileMakefile(output *makefile.Output) error {
+	if !lint.LinterEnabled() {
+		return nil
+	}
+
+	output.Target(lint.Name()).Description("Runs clang-tidy linter.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
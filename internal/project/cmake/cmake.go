@@ -0,0 +1,32 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package cmake provides building blocks for C/C++ projects built with CMake.
+package cmake
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SourceExtensions are extensions of C/C++ sources and headers.
+var SourceExtensions = []string{".c", ".cc", ".cpp", ".cxx", ".h", ".hh", ".hpp", ".hxx"}
+
+// buildPath is the directory CMake build trees are placed into.
+const buildPath = "/build"
+
+// findSources returns the command listing C/C++ files (NUL-separated) in the directory.
+func findSources(dir string, headers bool) string {
+	patterns := make([]string, 0, len(SourceExtensions))
+
+	for _, ext := range SourceExtensions {
+		if !headers && strings.HasPrefix(ext, ".h") {
+			continue
+		}
+
+		patterns = append(patterns, fmt.Sprintf("-name '*%s'", ext))
+	}
+
+	return fmt.Sprintf(`find %s -type f \( %s \) -print0`, dir, strings.Join(patterns, " -o "))
+}
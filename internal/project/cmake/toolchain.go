@@ -0,0 +1,91 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmake
+
+import (
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Toolchain provides C/C++ compiler and CMake.
+//
+// Toolchain runs on the build platform, libraries are linked into the Go toolchain which runs there as well.
+type Toolchain struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Image is the base image of the toolchain, it should match the libc of the Go toolchain (Alpine).
+	Image string `yaml:"image"`
+	// Packages are installed into the base image with apk.
+	Packages []string `yaml:"packages"`
+}
+
+// NewToolchain builds Toolchain with default values.
+func NewToolchain(meta *meta.Options) *Toolchain {
+	meta.BuildArgs = append(meta.BuildArgs, "CMAKE_TOOLCHAIN")
+
+	return &Toolchain{
+		BaseNode: dag.NewBaseNode("cmake-toolchain"),
+
+		meta: meta,
+
+		Image:    "docker.io/alpine:3.14",
+		Packages: []string{"build-base", "cmake"},
+	}
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (toolchain *Toolchain) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupDocker).
+		Variable(makefile.OverridableVariable("CMAKE_TOOLCHAIN", toolchain.Image))
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (toolchain *Toolchain) CompileDockerfile(output *dockerfile.Output) error {
+	output.Arg(step.Arg("CMAKE_TOOLCHAIN"))
+
+	stage := output.Stage(toolchain.Name()).
+		Description("C/C++ toolchain and sources").
+		From("--platform=${BUILDPLATFORM} ${CMAKE_TOOLCHAIN}")
+
+	if len(toolchain.Packages) > 0 {
+		stage.Step(step.Script("apk --update --no-cache add " + strings.Join(toolchain.Packages, " ")))
+	}
+
+	stage.Step(step.WorkDir("/src"))
+
+	for _, file := range toolchain.meta.CMakeSourceFiles {
+		stage.Step(step.Copy("./"+file, "./"+file))
+	}
+
+	for _, directory := range toolchain.meta.CMakeDirectories {
+		stage.Step(step.Copy("./"+directory, "./"+directory))
+	}
+
+	return nil
+}
+
+// SourcePaths implements dag.Node.
+func (toolchain *Toolchain) SourcePaths() []string {
+	paths := append([]string(nil), toolchain.meta.CMakeSourceFiles...)
+
+	for _, directory := range toolchain.meta.CMakeDirectories {
+		paths = append(paths, directory+"/**")
+	}
+
+	return paths
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (toolchain *Toolchain) SkipAsMakefileDependency() {
+}
@@ -0,0 +1,106 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmake
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// UnitTests builds CMake projects with tests enabled and runs them with ctest.
+type UnitTests struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Args are extra arguments of ctest.
+	Args []string `yaml:"args"`
+}
+
+// NewUnitTests initializes UnitTests.
+func NewUnitTests(meta *meta.Options) *UnitTests {
+	return &UnitTests{
+		BaseNode: dag.NewBaseNode("unit-tests-cmake"),
+
+		meta: meta,
+	}
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (tests *UnitTests) CompileDockerfile(output *dockerfile.Output) error {
+	commands := make([]string, 0, len(tests.meta.CMakeProjects))
+
+	for _, project := range tests.meta.CMakeProjects {
+		buildDir := path.Join(buildPath, "tests", project.Name)
+
+		commands = append(commands, fmt.Sprintf("cmake -S %s -B %s -DBUILD_TESTING=ON \\\n\t&& cmake --build %s --parallel \\\n\t&& (cd %s && ctest --output-on-failure%s)",
+			project.Directory, buildDir, buildDir, buildDir, argsSuffix(tests.Args)))
+	}
+
+	output.Stage(tests.Name()).
+		Description("runs CMake projects tests").
+		From("cmake-toolchain").
+		Step(step.Script(strings.Join(commands, " \\\n\t&& ")).
+			MountCache(path.Join(buildPath, "tests")))
+
+	return nil
+}
+
+// argsSuffix joins extra arguments of the command.
+func argsSuffix(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	return " " + strings.Join(args, " ")
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (tests *UnitTests) CompileMakefile(output *makefile.Output) error {
+	output.Target(tests.Name()).
+		Description("Performs CMake projects tests.").
+		Script("@$(MAKE) target-$@").
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (tests *UnitTests) CompileDrone(output *drone.Output) error {
+	output.Step(drone.MakeStep(tests.Name()).
+		DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*drone.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (tests *UnitTests) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	output.Job(ghworkflow.MakeJob(tests.Name()).
+		Needs(dag.GatherMatchingInputNames(tests, dag.Implements((*ghworkflow.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (tests *UnitTests) CompileGitLab(output *gitlab.Output) error {
+	output.Job(gitlab.MakeJob(tests.Name()).
+		Stage(tests.meta.GitLabStages.Test).
+		Needs(dag.GatherMatchingInputNames(tests, dag.Implements((*gitlab.Compiler)(nil)))...),
+	)
+
+	return nil
+}
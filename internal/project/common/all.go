@@ -5,12 +5,18 @@
 package common
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/talos-systems/kres/internal/dag"
 	"github.com/talos-systems/kres/internal/output/makefile"
 	"github.com/talos-systems/kres/internal/project/meta"
 )
 
-// All builds Makefile `all` target.
+// All builds Makefile `all` target and the aliases from meta.MakeAliases.
+//
+// `all` depends on lint, tests and builds (in that order), unless it's redefined as an alias.
 type All struct {
 	dag.BaseNode
 
@@ -28,8 +34,25 @@ func NewAll(meta *meta.Options) *All {
 
 // CompileMakefile implements makefile.Compiler.
 func (all *All) CompileMakefile(output *makefile.Output) error {
-	output.Target("all").
-		Depends(dag.GatherMatchingInputNames(all, dag.Not(dag.Implements((*makefile.SkipAsMakefileDependency)(nil))))...)
+	if _, redefined := all.meta.MakeAliases["all"]; !redefined {
+		output.Target("all").
+			Depends(dag.GatherMatchingInputNames(all, dag.Not(dag.Implements((*makefile.SkipAsMakefileDependency)(nil))))...)
+	}
+
+	names := make([]string, 0, len(all.meta.MakeAliases))
+
+	for name := range all.meta.MakeAliases {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		targets := all.meta.MakeAliases[name]
+
+		output.Alias(name, targets...).
+			Description(fmt.Sprintf("Runs %s.", strings.Join(targets, ", ")))
+	}
 
 	return nil
 }
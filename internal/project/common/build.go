@@ -5,10 +5,13 @@
 package common
 
 import (
+	"strings"
+
 	"github.com/talos-systems/kres/internal/dag"
-	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
 	"github.com/talos-systems/kres/internal/output/gitignore"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/meta"
 )
 
@@ -23,23 +26,48 @@ type Build struct {
 
 // NewBuild initializes Build.
 func NewBuild(meta *meta.Options) *Build {
-	meta.BuildArgs = append(meta.BuildArgs, "ARTIFACTS", "SHA", "TAG")
+	meta.BuildArgs = append(meta.BuildArgs, "ARTIFACTS", "SHA", "TAG", "COMMIT", "BUILD_DATE", "BUILDER")
+	meta.ArtifactsPath = "_out"
 
 	return &Build{
 		BaseNode: dag.NewBaseNode("build"),
 
 		meta: meta,
 
-		ArtifactsPath: "_out",
+		ArtifactsPath: meta.ArtifactsPath,
 	}
 }
 
-// CompileDockerfile implements dockerfile.Compiler.
-func (build *Build) CompileDockerfile(output *dockerfile.Output) error {
+// SourcePaths implements dag.Node.
+//
+// Changes to the build instructions affect every target.
+func (build *Build) SourcePaths() []string {
+	return []string{".kres.yaml", "Makefile", "Dockerfile", ".dockerignore"}
+}
+
+// CompileDockerignore implements dockerignore.Compiler.
+func (build *Build) CompileDockerignore(output *dockerignore.Output) error {
 	output.
 		AllowLocalPath(build.meta.Directories...).
 		AllowLocalPath(build.meta.SourceFiles...)
 
+	// version package is required to inject version info
+	if build.meta.VersionPackage != "" && strings.HasPrefix(build.meta.VersionPackage, build.meta.CanonicalPath+"/") {
+		output.AllowLocalPath(strings.TrimPrefix(build.meta.VersionPackage, build.meta.CanonicalPath+"/"))
+	}
+
+	output.IgnoreLocalPath(".git", build.ArtifactsPath, "**/*.test")
+
+	// excluded paths might be nested in the allowed directories
+	output.IgnoreLocalPath(build.meta.ExcludePaths...)
+
+	return nil
+}
+
+// CompileTekton implements tekton.Compiler.
+func (build *Build) CompileTekton(output *tekton.Output) error {
+	output.Param("version", "TAG", "", "Version the artifacts and images are tagged with.")
+
 	return nil
 }
 
@@ -49,6 +77,9 @@ func (build *Build) CompileMakefile(output *makefile.Output) error {
 		Variable(makefile.SimpleVariable("SHA", "$(shell git describe --match=none --always --abbrev=8 --dirty)")).
 		Variable(makefile.SimpleVariable("TAG", "$(shell git describe --tag --always --dirty)")).
 		Variable(makefile.SimpleVariable("BRANCH", "$(shell git rev-parse --abbrev-ref HEAD)")).
+		Variable(makefile.SimpleVariable("COMMIT", "$(shell git rev-parse HEAD)")).
+		Variable(makefile.SimpleVariable("BUILD_DATE", "$(shell date -u +%Y-%m-%dT%H:%M:%SZ)")).
+		Variable(makefile.OverridableVariable("BUILDER", "$(USER)")).
 		Variable(makefile.SimpleVariable("ARTIFACTS", build.ArtifactsPath))
 
 	output.Target("clean").
@@ -9,14 +9,16 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
-	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
 	"github.com/talos-systems/kres/internal/output/gitignore"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/common"
 )
 
 func TestBuildInterfaces(t *testing.T) {
 	assert.Implements(t, (*makefile.Compiler)(nil), new(common.Build))
-	assert.Implements(t, (*dockerfile.Compiler)(nil), new(common.Build))
+	assert.Implements(t, (*dockerignore.Compiler)(nil), new(common.Build))
 	assert.Implements(t, (*gitignore.Compiler)(nil), new(common.Build))
+	assert.Implements(t, (*tekton.Compiler)(nil), new(common.Build))
 }
@@ -0,0 +1,89 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"path"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/codeowners"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// CodeOwners provides .github/CODEOWNERS with the owners of the project directories.
+//
+// Rules are generated for the Go source directories and commands, owners are taken
+// from the directory assignments (the closest parent directory wins) falling back to the default owners.
+// Rules added manually below the sentinel comment are preserved on regeneration.
+type CodeOwners struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Paths maps additional CODEOWNERS patterns to their owners.
+	Paths map[string][]string `yaml:"paths"`
+}
+
+// NewCodeOwners initializes CodeOwners.
+func NewCodeOwners(meta *meta.Options) *CodeOwners {
+	return &CodeOwners{
+		BaseNode: dag.NewBaseNode("codeowners"),
+
+		meta: meta,
+	}
+}
+
+// CompileCodeOwners implements codeowners.Compiler.
+func (c *CodeOwners) CompileCodeOwners(output *codeowners.Output) error {
+	if len(c.meta.CodeOwners) == 0 && len(c.meta.DirectoryOwners) == 0 && len(c.Paths) == 0 {
+		return nil
+	}
+
+	output.Enable()
+	output.DefaultOwners(c.meta.CodeOwners...)
+
+	directories := append([]string(nil), c.meta.GoDirectories...)
+
+	for _, command := range c.meta.Commands {
+		directories = append(directories, command.Path)
+	}
+
+	for dir := range c.meta.DirectoryOwners {
+		directories = append(directories, dir)
+	}
+
+	for _, dir := range directories {
+		dir = path.Clean(strings.Trim(dir, "/"))
+
+		if dir == "." {
+			continue
+		}
+
+		output.Rule("/"+dir+"/", c.owners(dir)...)
+	}
+
+	for pattern, owners := range c.Paths {
+		output.Rule(pattern, owners...)
+	}
+
+	return nil
+}
+
+// owners returns the owners of the directory or of its closest parent directory.
+func (c *CodeOwners) owners(dir string) []string {
+	for ; dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if owners, ok := c.meta.DirectoryOwners[dir]; ok {
+			return owners
+		}
+
+		// assignments might be specified with the trailing slash
+		if owners, ok := c.meta.DirectoryOwners[dir+"/"]; ok {
+			return owners
+		}
+	}
+
+	return c.meta.CodeOwners
+}
@@ -0,0 +1,59 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/community"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Community provides GitHub community health files: FUNDING.yml, issue and pull request templates.
+//
+// FUNDING.yml is generated if the sponsor handles are set, templates are generated if configured.
+type Community struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Funding enables .github/FUNDING.yml with the sponsor handles.
+	Funding bool `yaml:"funding"`
+	// IssueTemplates are written to .github/ISSUE_TEMPLATE/<key>.md, e.g. `bug_report`.
+	IssueTemplates map[string]community.IssueTemplate `yaml:"issueTemplates"`
+	// PullRequestTemplate is the body of .github/pull_request_template.md.
+	PullRequestTemplate string `yaml:"pullRequestTemplate"`
+}
+
+// NewCommunity initializes Community.
+func NewCommunity(meta *meta.Options) *Community {
+	return &Community{
+		BaseNode: dag.NewBaseNode("community"),
+
+		meta: meta,
+
+		Funding: true,
+	}
+}
+
+// CompileCommunity implements community.Compiler.
+func (c *Community) CompileCommunity(output *community.Output) error {
+	if c.meta.CommunityOverwrite {
+		output.Overwrite()
+	}
+
+	if c.Funding && len(c.meta.Funding) > 0 {
+		output.Funding(c.meta.Funding)
+	}
+
+	for name, template := range c.IssueTemplates {
+		output.IssueTemplate(name, template)
+	}
+
+	if c.PullRequestTemplate != "" {
+		output.PullRequestTemplate(c.PullRequestTemplate)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/conform"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Conform enforces commit message policies and license headers with conform.
+//
+// License header policies follow the LicenseHeader inputs, so that both checks agree.
+// Commits of pull requests are checked in CI against the target branch.
+type Conform struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Enabled generates .conform.yaml and the commit checks in CI.
+	Enabled bool `yaml:"enabled"`
+	// Types are allowed conventional commit types (feat and fix are always allowed).
+	Types []string `yaml:"types"`
+	// Scopes are regular expressions of the allowed conventional commit scopes.
+	Scopes []string `yaml:"scopes"`
+	// HeaderLength is the maximum length of the commit message header.
+	HeaderLength int `yaml:"headerLength"`
+	// DCO requires Developer Certificate of Origin sign-off.
+	DCO bool `yaml:"dco"`
+	// Image is used if conform is not installed.
+	Image string `yaml:"image"`
+}
+
+// NewConform initializes Conform.
+func NewConform(meta *meta.Options) *Conform {
+	return &Conform{
+		BaseNode: dag.NewBaseNode("conform"),
+
+		meta: meta,
+
+		Types:        []string{"chore", "docs", "perf", "refactor", "style", "test", "release"},
+		Scopes:       []string{".*"},
+		HeaderLength: 89,
+		DCO:          true,
+		Image:        "ghcr.io/talos-systems/conform:v0.1.0-alpha.22",
+	}
+}
+
+// CompileConform implements conform.Compiler.
+func (c *Conform) CompileConform(output *conform.Output) error {
+	if !c.Enabled {
+		return nil
+	}
+
+	output.Enable()
+	output.
+		Conventional(c.Types, c.Scopes).
+		HeaderLength(c.HeaderLength).
+		DCO(c.DCO)
+
+	for _, input := range c.Inputs() {
+		license, ok := input.(*LicenseHeader)
+		if !ok {
+			continue
+		}
+
+		// conform matches files by suffix only
+		var exclude []string
+
+		for _, pattern := range license.Exclude {
+			if strings.HasPrefix(pattern, "*.") {
+				exclude = append(exclude, strings.TrimPrefix(pattern, "*"))
+			}
+		}
+
+		for _, glob := range license.Globs {
+			if !strings.HasPrefix(glob, "*.") {
+				continue
+			}
+
+			output.LicenseHeader(strings.Join(license.lines(glob), "\n")+"\n", []string{strings.TrimPrefix(glob, "*")}, exclude)
+		}
+	}
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (c *Conform) CompileMakefile(output *makefile.Output) error {
+	if !c.Enabled {
+		return nil
+	}
+
+	output.VariableGroup(makefile.VariableGroupCommon).
+		Variable(makefile.OverridableVariable("CONFORM_IMAGE", c.Image)).
+		Variable(makefile.OverridableVariable("CONFORM_ARGS", ""))
+
+	script := `@if command -v conform > /dev/null; then conform enforce $(CONFORM_ARGS); else docker run --rm -v $(PWD):/src -w /src $(CONFORM_IMAGE) enforce $(CONFORM_ARGS); fi`
+
+	// .conform.yaml is generated at the repository root, so conform of the monorepo components runs there
+	if filepath.Clean(c.meta.Root) != "." {
+		script = `@cd "$$(git rev-parse --show-toplevel)" && if command -v conform > /dev/null; then conform enforce $(CONFORM_ARGS); else docker run --rm -v "$$PWD":/src -w /src $(CONFORM_IMAGE) enforce $(CONFORM_ARGS); fi`
+	}
+
+	output.Target("conform").
+		Description("Verifies commit messages and license headers with conform.").
+		Script(script).
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (c *Conform) CompileDrone(output *drone.Output) error {
+	if !c.Enabled {
+		return nil
+	}
+
+	output.Step(drone.MakeStep("conform", `CONFORM_ARGS="--base-branch=origin/${DRONE_TARGET_BRANCH}"`).
+		OnlyOnPullRequest().
+		DependsOn("setup-ci"),
+	)
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (c *Conform) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	if !c.Enabled {
+		return nil
+	}
+
+	output.Job(ghworkflow.MakeJob("conform", `CONFORM_ARGS="--base-branch=origin/${{ github.base_ref }}"`).
+		OnlyOnPullRequest(),
+	)
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (c *Conform) CompileGitLab(output *gitlab.Output) error {
+	if !c.Enabled {
+		return nil
+	}
+
+	output.Job(gitlab.MakeJob("conform", `CONFORM_ARGS="--base-branch=origin/${CI_MERGE_REQUEST_TARGET_BRANCH_NAME}"`).
+		Stage(c.meta.GitLabStages.Lint).
+		OnlyOnMergeRequest(),
+	)
+
+	return nil
+}
@@ -0,0 +1,73 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dependabot"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Dependabot provides Dependabot config for Go modules, Dockerfile and GitHub Actions.
+//
+// Ecosystems added to the config manually are preserved on regeneration.
+type Dependabot struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Enabled bool `yaml:"enabled"`
+}
+
+// NewDependabot initializes Dependabot.
+func NewDependabot(meta *meta.Options) *Dependabot {
+	return &Dependabot{
+		BaseNode: dag.NewBaseNode("dependabot"),
+
+		meta: meta,
+	}
+}
+
+// CompileDependabot implements dependabot.Compiler.
+func (d *Dependabot) CompileDependabot(output *dependabot.Output) error {
+	if !d.Enabled {
+		return nil
+	}
+
+	output.Enable()
+
+	ecosystems := []string{}
+
+	for _, manager := range d.meta.PackageManagers {
+		if manager == "gomod" {
+			ecosystems = append(ecosystems, dependabot.EcosystemGoMod)
+		}
+	}
+
+	// Dockerfile is always generated
+	ecosystems = append(ecosystems, dependabot.EcosystemDocker)
+
+	if d.meta.GitHubActions {
+		ecosystems = append(ecosystems, dependabot.EcosystemGitHubActions)
+	}
+
+	interval := d.meta.DependabotSchedule
+	if interval == "" {
+		interval = "weekly"
+	}
+
+	for _, ecosystem := range ecosystems {
+		output.Update(dependabot.Update{
+			PackageEcosystem: ecosystem,
+			Directory:        "/",
+			Schedule: dependabot.Schedule{
+				Interval: interval,
+			},
+			Reviewers: d.meta.DependabotReviewers,
+		})
+	}
+
+	return nil
+}
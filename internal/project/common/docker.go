@@ -6,9 +6,12 @@ package common
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/buildkite"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/meta"
 )
 
@@ -28,6 +31,31 @@ func NewDocker(meta *meta.Options) *Docker {
 	}
 }
 
+// CompileTekton implements tekton.Compiler.
+func (docker *Docker) CompileTekton(output *tekton.Output) error {
+	output.Param("registry", "REGISTRY", "docker.io", "Registry images are pushed to.")
+	output.Param("username", "USERNAME", "autonomy", "Username (organization) images are pushed under.")
+
+	return nil
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+func (docker *Docker) CompileBuildkite(output *buildkite.Output) error {
+	tags := make([]string, 0, len(docker.meta.BuildkiteAgents))
+
+	for tag := range docker.meta.BuildkiteAgents {
+		tags = append(tags, tag)
+	}
+
+	sort.Strings(tags)
+
+	for _, tag := range tags {
+		output.Agent(tag, docker.meta.BuildkiteAgents[tag])
+	}
+
+	return nil
+}
+
 // CompileMakefile implements makefile.Compiler.
 func (docker *Docker) CompileMakefile(output *makefile.Output) error {
 	buildArgs := makefile.RecursiveVariable("COMMON_ARGS", "--file=Dockerfile").
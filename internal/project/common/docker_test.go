@@ -9,10 +9,14 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/talos-systems/kres/internal/output/buildkite"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/common"
 )
 
 func TestDockerInterfaces(t *testing.T) {
 	assert.Implements(t, (*makefile.Compiler)(nil), new(common.Docker))
+	assert.Implements(t, (*tekton.Compiler)(nil), new(common.Docker))
+	assert.Implements(t, (*buildkite.Compiler)(nil), new(common.Docker))
 }
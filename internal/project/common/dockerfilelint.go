@@ -0,0 +1,96 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/precommit"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// DockerfileLint runs hadolint over the generated and custom Dockerfiles.
+//
+// Rules might be disabled for a single instruction with `# hadolint ignore=DL3008` comments.
+type DockerfileLint struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Image string `yaml:"image"`
+	// Ignore is a list of rules disabled for all Dockerfiles.
+	Ignore []string `yaml:"ignore"`
+}
+
+// NewDockerfileLint initializes DockerfileLint.
+func NewDockerfileLint(meta *meta.Options) *DockerfileLint {
+	meta.BuildArgs = append(meta.BuildArgs, "HADOLINT_IMAGE")
+
+	return &DockerfileLint{
+		BaseNode: dag.NewBaseNode("lint-dockerfile"),
+
+		meta: meta,
+
+		Image: "docker.io/hadolint/hadolint:v1.22.1-alpine",
+		// generated Dockerfile doesn't pin packages and tools, and uses shell pipes
+		Ignore: []string{"DL3003", "DL3006", "DL3007", "DL3018", "DL4006", "SC2046", "SC2086"},
+	}
+}
+
+func (lint *DockerfileLint) dockerfiles() []string {
+	return append([]string{"Dockerfile"}, lint.meta.Dockerfiles...)
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (lint *DockerfileLint) CompileDockerfile(output *dockerfile.Output) error {
+	output.Arg(step.Arg("HADOLINT_IMAGE"))
+
+	stage := output.Stage("lint-dockerfile").
+		Description("runs hadolint").
+		From("--platform=${BUILDPLATFORM} ${HADOLINT_IMAGE}").
+		Step(step.WorkDir("/src"))
+
+	for _, file := range lint.dockerfiles() {
+		stage.Step(step.Copy("./"+file, "./"+file))
+	}
+
+	args := []string{}
+
+	for _, rule := range lint.Ignore {
+		args = append(args, "--ignore", rule)
+	}
+
+	stage.Step(step.Run("hadolint", append(args, lint.dockerfiles()...)...))
+
+	return nil
+}
+
+// CompileDockerignore implements dockerignore.Compiler.
+func (lint *DockerfileLint) CompileDockerignore(output *dockerignore.Output) error {
+	output.AllowLocalPath(lint.dockerfiles()...)
+
+	return nil
+}
+
+// CompilePreCommit implements precommit.Compiler.
+func (lint *DockerfileLint) CompilePreCommit(output *precommit.Output) error {
+	output.Hook("hadolint", lint.Name()).Types("dockerfile")
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (lint *DockerfileLint) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupDocker).
+		Variable(makefile.OverridableVariable("HADOLINT_IMAGE", lint.Image))
+
+	output.Target("lint-dockerfile").Description("Runs hadolint over Dockerfiles.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
@@ -0,0 +1,61 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"sort"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Drone configures scheduling of the Drone pipelines on the Kubernetes runner.
+//
+// Settings apply to every pipeline, and the resources to every step of the pipelines.
+type Drone struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Namespace is the Kubernetes namespace the pipelines run in.
+	Namespace string `yaml:"namespace"`
+	// NodeSelector pins the pipelines to the nodes with the labels.
+	NodeSelector map[string]string `yaml:"nodeSelector"`
+	// Resources are requests and limits of every step.
+	Resources drone.Resources `yaml:"resources"`
+}
+
+// NewDrone initializes Drone.
+func NewDrone(meta *meta.Options) *Drone {
+	return &Drone{
+		BaseNode: dag.NewBaseNode("drone"),
+
+		meta: meta,
+	}
+}
+
+// CompileDrone implements drone.Compiler.
+func (settings *Drone) CompileDrone(output *drone.Output) error {
+	if settings.Namespace != "" {
+		output.Namespace(settings.Namespace)
+	}
+
+	labels := make([]string, 0, len(settings.NodeSelector))
+
+	for label := range settings.NodeSelector {
+		labels = append(labels, label)
+	}
+
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		output.NodeSelector(label, settings.NodeSelector[label])
+	}
+
+	output.Resources(settings.Resources)
+
+	return nil
+}
@@ -0,0 +1,112 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"sort"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/editorconfig"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// EditorConfig provides .editorconfig with the formatting settings for the detected languages.
+//
+// Defaults might be overridden via the options (`kres gen --editorconfig`), sections added manually
+// below the sentinel comment are preserved on regeneration.
+type EditorConfig struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Enabled bool `yaml:"enabled"`
+}
+
+// NewEditorConfig initializes EditorConfig.
+func NewEditorConfig(meta *meta.Options) *EditorConfig {
+	return &EditorConfig{
+		BaseNode: dag.NewBaseNode("editorconfig"),
+
+		meta: meta,
+
+		Enabled: true,
+	}
+}
+
+// CompileEditorConfig implements editorconfig.Compiler.
+func (e *EditorConfig) CompileEditorConfig(output *editorconfig.Output) error {
+	if !e.Enabled {
+		return nil
+	}
+
+	output.Enable()
+
+	output.Section("*").
+		Set("charset", "utf-8").
+		Set("end_of_line", "lf").
+		Set("insert_final_newline", "true").
+		Set("trim_trailing_whitespace", "true")
+
+	// Go sources and Makefile are always generated by kres
+	output.Section("*.go").
+		Set("indent_style", "tab")
+
+	output.Section("Makefile").
+		Set("indent_style", "tab")
+
+	output.Section("*.{yml,yaml,json}").
+		Set("indent_style", "space").
+		Set("indent_size", "2")
+
+	if e.meta.JSRoot != "" {
+		output.Section("*.{js,jsx,ts,tsx,vue,css,scss,html}").
+			Set("indent_style", "space").
+			Set("indent_size", "2")
+	}
+
+	if e.meta.PythonRoot != "" {
+		output.Section("*.py").
+			Set("indent_style", "space").
+			Set("indent_size", "4")
+	}
+
+	if len(e.meta.RustDirectories) > 0 {
+		output.Section("*.rs").
+			Set("indent_style", "space").
+			Set("indent_size", "4")
+	}
+
+	// trailing double space is a line break in Markdown
+	output.Section("*.md").
+		Set("trim_trailing_whitespace", "false")
+
+	globs := make([]string, 0, len(e.meta.EditorConfig))
+
+	for glob := range e.meta.EditorConfig {
+		globs = append(globs, glob)
+	}
+
+	sort.Strings(globs)
+
+	for _, glob := range globs {
+		properties := e.meta.EditorConfig[glob]
+
+		keys := make([]string, 0, len(properties))
+
+		for key := range properties {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		section := output.Section(glob)
+
+		for _, key := range keys {
+			section.Set(key, properties[key])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// GitLFS pulls Git LFS objects in CI, so that the build context contains the files instead of LFS pointers.
+type GitLFS struct {
+	dag.BaseNode
+
+	meta *meta.Options
+}
+
+// NewGitLFS initializes GitLFS.
+func NewGitLFS(meta *meta.Options) *GitLFS {
+	return &GitLFS{
+		BaseNode: dag.NewBaseNode("git-lfs"),
+
+		meta: meta,
+	}
+}
+
+// CompileDrone implements drone.Compiler.
+func (lfs *GitLFS) CompileDrone(output *drone.Output) error {
+	if lfs.meta.GitLFS {
+		output.GitLFS()
+	}
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (lfs *GitLFS) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	if lfs.meta.GitLFS {
+		output.GitLFS()
+	}
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (lfs *GitLFS) CompileGitLab(output *gitlab.Output) error {
+	if lfs.meta.GitLFS {
+		output.GitLFS()
+	}
+
+	return nil
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+func (lfs *GitLFS) CompileAzurePipelines(output *azurepipelines.Output) error {
+	if lfs.meta.GitLFS {
+		output.GitLFS()
+	}
+
+	return nil
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+func (lfs *GitLFS) CompileBuildkite(output *buildkite.Output) error {
+	if lfs.meta.GitLFS {
+		output.GitLFS()
+	}
+
+	return nil
+}
+
+// CompileCircleCI implements circleci.Compiler.
+func (lfs *GitLFS) CompileCircleCI(output *circleci.Output) error {
+	if lfs.meta.GitLFS {
+		output.GitLFS()
+	}
+
+	return nil
+}
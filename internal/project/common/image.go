@@ -6,95 +6,725 @@ package common
 
 import (
 	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
 
 	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
 	"github.com/talos-systems/kres/internal/output/dockerfile"
 	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
 	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/meta"
 )
 
+// Positions of the custom image stages.
+const (
+	StagePositionBeforeBuild = "before-build"
+	StagePositionAfterBuild  = "after-build"
+	StagePositionFinal       = "final"
+)
+
+// Base images with well-known names.
+const (
+	BaseImageScratch    = "scratch"
+	BaseImageDistroless = "distroless"
+	BaseImageAlpine     = "alpine"
+)
+
+// baseImagePreset is a well-known base image with the platforms it's published for.
+type baseImagePreset struct {
+	image     string
+	platforms []string
+}
+
+var baseImagePresets = map[string]baseImagePreset{
+	BaseImageDistroless: {
+		image:     "gcr.io/distroless/base-debian11:latest",
+		platforms: []string{"linux/amd64", "linux/arm64", "linux/arm/v7", "linux/ppc64le", "linux/s390x"},
+	},
+	BaseImageAlpine: {
+		image:     "docker.io/library/alpine:3.14",
+		platforms: []string{"linux/386", "linux/amd64", "linux/arm64", "linux/arm/v6", "linux/arm/v7", "linux/ppc64le", "linux/s390x"},
+	},
+}
+
+// ImageStage is a user-provided stage merged into the generated Dockerfile.
+//
+// Result of the stage (Source path) is copied into the stage defined by Position:
+//
+//   - before-build: into the stage Into (defaults to `base`), e.g. to provide CGO dependencies
+//   - after-build: into the image right after build artifacts
+//   - final: into the image after custom commands
+type ImageStage struct {
+	Name        string   `yaml:"name"`
+	Position    string   `yaml:"position"`
+	From        string   `yaml:"from"`
+	Into        string   `yaml:"into"`
+	Steps       []string `yaml:"steps"`
+	Source      string   `yaml:"source"`
+	Destination string   `yaml:"destination"`
+}
+
+// Executable is implemented by the build blocks which produce the binary the image runs.
+type Executable interface {
+	// ExecutablePath is the absolute path of the binary in the image.
+	ExecutablePath() string
+}
+
 // Image provides common image build target.
 type Image struct {
 	dag.BaseNode
 
 	meta *meta.Options
 
+	scans []*Scan
+
+	// BaseImage is the base of the final image: scratch (default), distroless, alpine or any image reference.
+	//
+	// FHS and CA certificates are only injected into scratch images, other base images provide them.
 	BaseImage      string   `yaml:"baseImage"`
 	ImageName      string   `yaml:"imageName"`
 	Entrypoint     string   `yaml:"entrypoint"`
 	EntrypointArgs []string `yaml:"entrypointArgs"`
+
+	// EntrypointScript is a local script (relative to the project root) installed into the image root as the entrypoint.
+	//
+	// The script is expected to exec the binary after the setup, EntrypointArgs are passed to the script.
+	EntrypointScript string `yaml:"entrypointScript"`
+	// Cmd are the default arguments of the entrypoint (CMD), which might be overridden when the container is run.
+	Cmd []string `yaml:"cmd"`
+
 	CustomCommands []string `yaml:"customCommands"`
+	Platforms      []string `yaml:"platforms"`
 	PushLatest     bool     `yaml:"pushLatest"`
+	SBOM           bool     `yaml:"sbom"`
+
+	// Registries the image is pushed to, defaults to $(REGISTRY).
+	//
+	// Image is built once and pushed to every registry, so the manifest digest is the same.
+	Registries []string `yaml:"registries"`
+
+	Stages []ImageStage `yaml:"stages"`
+
+	// Links are absolute paths of the symlinks to the entrypoint binary, e.g. `/bin/sh`.
+	//
+	// Links allow busybox-style binaries to dispatch on the name they are invoked with.
+	Links []string `yaml:"links"`
+
+	// Description is shown for the target in `make help`, defaults to `Builds image for <imageName>.`
+	Description string `yaml:"description"`
+
+	// Labels are set on the image, they override OCI annotations with the same names.
+	Labels map[string]string `yaml:"labels"`
+	// OCIAnnotations enables default `org.opencontainers.image.*` labels (source, revision, created and licenses).
+	OCIAnnotations bool `yaml:"ociAnnotations"`
+
+	// Tags the image is tagged (and pushed) with, templates might refer to `{{.Version}}` (`$(TAG)`),
+	// `{{.ShortSHA}}` (`$(SHA)`) and `{{.Branch}}` (`$(BRANCH)` with slashes replaced by dashes).
+	Tags []string `yaml:"tags"`
+	// ReleaseTags are added to Tags for release builds (the commit is tagged with `v*`), e.g. `latest`.
+	ReleaseTags []string `yaml:"releaseTags"`
+}
+
+// imageTagData is the data of the image tag templates.
+type imageTagData struct {
+	Version  string
+	ShortSHA string
+	Branch   string
 }
 
 // NewImage initializes Image.
 func NewImage(meta *meta.Options, name string) *Image {
+	platforms := append([]string(nil), meta.Platforms...)
+	if len(platforms) == 0 {
+		platforms = []string{"linux/amd64"}
+	}
+
 	return &Image{
 		BaseNode: dag.NewBaseNode("image-" + name),
 
 		meta: meta,
 
-		BaseImage:  "scratch",
+		BaseImage:  BaseImageScratch,
 		ImageName:  name,
 		Entrypoint: "/" + name,
+		Platforms:  platforms,
 		PushLatest: true,
+
+		OCIAnnotations: true,
+
+		Tags: []string{"{{.Version}}"},
 	}
 }
 
 // CompileDrone implements drone.Compiler.
 func (image *Image) CompileDrone(output *drone.Output) error {
-	output.Step(drone.MakeStep(image.Name()).
-		DependsOn(dag.GatherMatchingInputNames(image, dag.Implements((*drone.Compiler)(nil)))...),
+	output.Step(image.droneCache(drone.MakeStep(image.Name(), "IMAGE_LOAD=false").
+		DependsOn(dag.GatherMatchingInputNames(image, dag.Implements((*drone.Compiler)(nil)))...), false),
 	)
 
-	output.Step(drone.MakeStep(image.Name()).
-		Name(fmt.Sprintf("push-%s", image.ImageName)).
-		Environment("PUSH", "true").
+	output.Step(image.droneCache(image.droneLogin(drone.MakeStep(image.pushTarget()).
+		Name(image.pushStep()).
 		ExceptPullRequest().
-		DockerLogin().
-		DependsOn(image.Name()),
+		DependsOn(image.pushDependencies()...)), true),
 	)
 
 	if image.PushLatest {
-		output.Step(drone.MakeStep(image.Name(), "TAG=latest").
+		output.Step(image.droneCache(image.droneLogin(drone.MakeStep(image.pushTarget(), "TAG=latest").
 			Name(fmt.Sprintf("push-%s-latest", image.ImageName)).
-			Environment("PUSH", "true").
 			OnlyOnMaster().
 			ExceptPullRequest().
-			DockerLogin().
-			DependsOn(fmt.Sprintf("push-%s", image.ImageName)),
+			DependsOn(image.pushStep())), true),
+		)
+	}
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (image *Image) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	output.Job(image.githubCache(ghworkflow.MakeJob(image.Name(), "IMAGE_LOAD=false").
+		Needs(dag.GatherMatchingInputNames(image, dag.Implements((*ghworkflow.Compiler)(nil)))...), false),
+	)
+
+	output.Job(image.githubCache(image.githubLogin(ghworkflow.MakeJob(image.pushTarget()).
+		Name(image.pushStep()).
+		ExceptPullRequest().
+		Needs(image.pushDependencies()...)), true),
+	)
+
+	if image.PushLatest {
+		output.Job(image.githubCache(image.githubLogin(ghworkflow.MakeJob(image.pushTarget(), "TAG=latest").
+			Name(fmt.Sprintf("push-%s-latest", image.ImageName)).
+			OnlyOnBranch(output.DefaultBranch).
+			ExceptPullRequest().
+			Needs(image.pushStep())), true),
+		)
+	}
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (image *Image) CompileGitLab(output *gitlab.Output) error {
+	output.Job(image.gitlabCache(gitlab.MakeJob(image.Name(), "IMAGE_LOAD=false").
+		Stage(image.meta.GitLabStages.Build).
+		Needs(dag.GatherMatchingInputNames(image, dag.Implements((*gitlab.Compiler)(nil)))...), false),
+	)
+
+	output.Job(image.gitlabCache(image.gitlabLogin(gitlab.MakeJob(image.pushTarget()).
+		Name(image.pushStep()).
+		Stage(image.meta.GitLabStages.Build).
+		ExceptMergeRequest().
+		Needs(image.pushDependencies()...)), true),
+	)
+
+	if image.PushLatest {
+		output.Job(image.gitlabCache(image.gitlabLogin(gitlab.MakeJob(image.pushTarget(), "TAG=latest").
+			Name(fmt.Sprintf("push-%s-latest", image.ImageName)).
+			Stage(image.meta.GitLabStages.Build).
+			OnlyOnDefaultBranch().
+			ExceptMergeRequest().
+			Needs(image.pushStep())), true),
+		)
+	}
+
+	return nil
+}
+
+// CompileTekton implements tekton.Compiler.
+func (image *Image) CompileTekton(output *tekton.Output) error {
+	registries := image.Registries
+	if len(registries) == 0 {
+		registries = []string{"$(params.registry)"}
+	}
+
+	task := tekton.ImageTask(image.Name()).
+		Platforms(image.Platforms...).
+		BuildArgs(image.meta.BuildArgs...).
+		RunAfter(dag.GatherMatchingInputNames(image, dag.Implements((*tekton.Compiler)(nil)))...)
+
+	for _, registry := range registries {
+		task.Destination(fmt.Sprintf("%s/$(params.username)/%s:$(params.version)", registry, image.ImageName))
+	}
+
+	output.Task(task)
+
+	return nil
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+func (image *Image) CompileAzurePipelines(output *azurepipelines.Output) error {
+	output.Job(image.azureCache(azurepipelines.MakeJob(image.Name(), "IMAGE_LOAD=false").
+		DependsOn(dag.GatherMatchingInputNames(image, dag.Implements((*azurepipelines.Compiler)(nil)))...), false),
+	)
+
+	output.Job(image.azureCache(image.azureLogin(azurepipelines.MakeJob(image.pushTarget()).
+		Name(image.pushStep()).
+		OnlyOnTag().
+		DependsOn(image.pushDependencies()...)), true),
+	)
+
+	if image.PushLatest {
+		// tag push stage doesn't run on branches, so latest image only waits for the build
+		output.Job(image.azureCache(image.azureLogin(azurepipelines.MakeJob(image.pushTarget(), "TAG=latest").
+			Name(fmt.Sprintf("push-%s-latest", image.ImageName)).
+			OnlyOnBranch(output.DefaultBranch).
+			DependsOn(image.pushDependencies()...)), true),
+		)
+	}
+
+	return nil
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+func (image *Image) CompileBuildkite(output *buildkite.Output) error {
+	output.Step(image.buildkiteCache(buildkite.MakeStep(image.Name(), "IMAGE_LOAD=false").
+		DependsOn(dag.GatherMatchingInputNames(image, dag.Implements((*buildkite.Compiler)(nil)))...), false),
+	)
+
+	output.Step(image.buildkiteCache(image.buildkiteLogin(buildkite.MakeStep(image.pushTarget()).
+		Name(image.pushStep()).
+		OnlyOnTag().
+		DependsOn(image.pushDependencies()...)), true),
+	)
+
+	if image.PushLatest {
+		// tag push step doesn't run on branches, so latest image only waits for the build
+		output.Step(image.buildkiteCache(image.buildkiteLogin(buildkite.MakeStep(image.pushTarget(), "TAG=latest").
+			Name(fmt.Sprintf("push-%s-latest", image.ImageName)).
+			OnlyOnBranch(output.DefaultBranch).
+			DependsOn(image.pushDependencies()...)), true),
 		)
 	}
 
 	return nil
 }
 
+// CompileCircleCI implements circleci.Compiler.
+func (image *Image) CompileCircleCI(output *circleci.Output) error {
+	output.Job(image.circleciCache(circleci.MakeJob(image.Name(), "IMAGE_LOAD=false").
+		Requires(dag.GatherMatchingInputNames(image, dag.Implements((*circleci.Compiler)(nil)))...), false),
+	)
+
+	output.Job(image.circleciCache(image.circleciLogin(circleci.MakeJob(image.pushTarget()).
+		Name(image.pushStep()).
+		OnlyOnTag().
+		Requires(image.pushDependencies()...)), true),
+	)
+
+	if image.PushLatest {
+		// tag push job doesn't run on branches, so latest image only waits for the build
+		output.Job(image.circleciCache(image.circleciLogin(circleci.MakeJob(image.pushTarget(), "TAG=latest").
+			Name(fmt.Sprintf("push-%s-latest", image.ImageName)).
+			OnlyOnBranch(output.DefaultBranch).
+			Requires(image.pushDependencies()...)), true),
+		)
+	}
+
+	return nil
+}
+
+func (image *Image) droneLogin(step *drone.Step) *drone.Step {
+	if len(image.Registries) == 0 {
+		return step.DockerLogin()
+	}
+
+	image.registryCredentials(func(registry, username, password string) {
+		step.DockerLoginRegistry(registry, username, password)
+	})
+
+	return step
+}
+
+func (image *Image) githubLogin(job *ghworkflow.Job) *ghworkflow.Job {
+	if len(image.Registries) == 0 {
+		return job.DockerLogin()
+	}
+
+	image.registryCredentials(func(registry, username, password string) {
+		job.DockerLoginRegistry(registry, strings.ToUpper(username), strings.ToUpper(password))
+	})
+
+	return job
+}
+
+func (image *Image) azureLogin(job *azurepipelines.Job) *azurepipelines.Job {
+	if len(image.Registries) == 0 {
+		return job.DockerLogin()
+	}
+
+	image.registryCredentials(func(registry, username, password string) {
+		job.DockerLoginRegistry(registry, strings.ToUpper(username), strings.ToUpper(password))
+	})
+
+	return job
+}
+
+func (image *Image) buildkiteLogin(step *buildkite.Step) *buildkite.Step {
+	if len(image.Registries) == 0 {
+		return step.DockerLogin()
+	}
+
+	image.registryCredentials(func(registry, username, password string) {
+		step.DockerLoginRegistry(registry, strings.ToUpper(username), strings.ToUpper(password))
+	})
+
+	return step
+}
+
+func (image *Image) circleciLogin(job *circleci.Job) *circleci.Job {
+	if len(image.Registries) == 0 {
+		return job.DockerLogin()
+	}
+
+	image.registryCredentials(func(registry, username, password string) {
+		job.DockerLoginRegistry(registry, strings.ToUpper(username), strings.ToUpper(password))
+	})
+
+	return job
+}
+
+func (image *Image) gitlabLogin(job *gitlab.Job) *gitlab.Job {
+	if len(image.Registries) == 0 {
+		return job.DockerLogin()
+	}
+
+	image.registryCredentials(func(registry, username, password string) {
+		job.DockerLoginRegistry(registry, strings.ToUpper(username), strings.ToUpper(password))
+	})
+
+	return job
+}
+
+// cacheArgs returns buildx arguments to use the remote build cache (if configured).
+//
+// Cache is exported only by the push steps, so that builds of pull requests don't pollute it.
+func (image *Image) cacheArgs(export bool) string {
+	cache := image.meta.BuildCache
+
+	var backend string
+
+	switch cache.Type {
+	case meta.BuildCacheRegistry:
+		backend = fmt.Sprintf("type=registry,ref=%s:%s", cache.Ref, image.ImageName)
+	case meta.BuildCacheS3:
+		backend = fmt.Sprintf("type=s3,bucket=%s,region=%s,name=%s", cache.Ref, cache.Region, image.ImageName)
+
+		if cache.Endpoint != "" {
+			backend += ",endpoint_url=" + cache.Endpoint
+		}
+	default:
+		return ""
+	}
+
+	args := "--cache-from=" + backend
+
+	if export {
+		args += " --cache-to=" + backend + ",mode=max"
+	}
+
+	return args
+}
+
+func (image *Image) droneCache(step *drone.Step, export bool) *drone.Step {
+	args := image.cacheArgs(export)
+	if args == "" {
+		return step
+	}
+
+	step.Environment("CI_ARGS", args)
+
+	if image.meta.BuildCache.Type == meta.BuildCacheS3 {
+		step.EnvironmentFromSecret("AWS_ACCESS_KEY_ID", image.meta.BuildCache.AccessKeySecret).
+			EnvironmentFromSecret("AWS_SECRET_ACCESS_KEY", image.meta.BuildCache.SecretKeySecret)
+	}
+
+	return step
+}
+
+func (image *Image) githubCache(job *ghworkflow.Job, export bool) *ghworkflow.Job {
+	args := image.cacheArgs(export)
+	if args == "" {
+		return job
+	}
+
+	job.Environment("CI_ARGS", args)
+
+	if image.meta.BuildCache.Type == meta.BuildCacheS3 {
+		job.EnvironmentFromSecret("AWS_ACCESS_KEY_ID", strings.ToUpper(image.meta.BuildCache.AccessKeySecret)).
+			EnvironmentFromSecret("AWS_SECRET_ACCESS_KEY", strings.ToUpper(image.meta.BuildCache.SecretKeySecret))
+	}
+
+	return job
+}
+
+func (image *Image) azureCache(job *azurepipelines.Job, export bool) *azurepipelines.Job {
+	args := image.cacheArgs(export)
+	if args == "" {
+		return job
+	}
+
+	job.Environment("CI_ARGS", args)
+
+	if image.meta.BuildCache.Type == meta.BuildCacheS3 {
+		job.EnvironmentFromSecret("AWS_ACCESS_KEY_ID", strings.ToUpper(image.meta.BuildCache.AccessKeySecret)).
+			EnvironmentFromSecret("AWS_SECRET_ACCESS_KEY", strings.ToUpper(image.meta.BuildCache.SecretKeySecret))
+	}
+
+	return job
+}
+
+func (image *Image) buildkiteCache(step *buildkite.Step, export bool) *buildkite.Step {
+	args := image.cacheArgs(export)
+	if args == "" {
+		return step
+	}
+
+	step.Environment("CI_ARGS", args)
+
+	if image.meta.BuildCache.Type == meta.BuildCacheS3 {
+		step.EnvironmentFromVariable("AWS_ACCESS_KEY_ID", strings.ToUpper(image.meta.BuildCache.AccessKeySecret)).
+			EnvironmentFromVariable("AWS_SECRET_ACCESS_KEY", strings.ToUpper(image.meta.BuildCache.SecretKeySecret))
+	}
+
+	return step
+}
+
+func (image *Image) circleciCache(job *circleci.Job, export bool) *circleci.Job {
+	args := image.cacheArgs(export)
+	if args == "" {
+		return job
+	}
+
+	job.Environment("CI_ARGS", args)
+
+	if image.meta.BuildCache.Type == meta.BuildCacheS3 {
+		job.EnvironmentFromSecret("AWS_ACCESS_KEY_ID", strings.ToUpper(image.meta.BuildCache.AccessKeySecret)).
+			EnvironmentFromSecret("AWS_SECRET_ACCESS_KEY", strings.ToUpper(image.meta.BuildCache.SecretKeySecret))
+	}
+
+	return job
+}
+
+func (image *Image) gitlabCache(job *gitlab.Job, export bool) *gitlab.Job {
+	args := image.cacheArgs(export)
+	if args == "" {
+		return job
+	}
+
+	job.Variable("CI_ARGS", args)
+
+	if image.meta.BuildCache.Type == meta.BuildCacheS3 {
+		job.Variable("AWS_ACCESS_KEY_ID", "${"+strings.ToUpper(image.meta.BuildCache.AccessKeySecret)+"}").
+			Variable("AWS_SECRET_ACCESS_KEY", "${"+strings.ToUpper(image.meta.BuildCache.SecretKeySecret)+"}")
+	}
+
+	return job
+}
+
+// registryCredentials calls fn with the names of the credential secrets for each registry.
+//
+// Single registry uses default credentials (docker_username, docker_password), with several
+// registries credentials are per registry, e.g. docker_username_ghcr_io.
+func (image *Image) registryCredentials(fn func(registry, username, password string)) {
+	if len(image.Registries) == 1 {
+		fn(image.Registries[0], "docker_username", "docker_password")
+
+		return
+	}
+
+	for _, registry := range image.Registries {
+		suffix := strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+				return r
+			}
+
+			return '_'
+		}, strings.ToLower(registry))
+
+		fn(registry, "docker_username_"+suffix, "docker_password_"+suffix)
+	}
+}
+
+// pushDependencies returns names of the steps which should succeed before the image is pushed.
+func (image *Image) pushDependencies() []string {
+	depends := []string{image.Name()}
+
+	for _, scan := range image.scans {
+		if scan.Enabled {
+			depends = append(depends, scan.Name())
+		}
+	}
+
+	return depends
+}
+
 // CompileMakefile implements makefile.Compiler.
 func (image *Image) CompileMakefile(output *makefile.Output) error {
+	registries := image.Registries
+	if len(registries) == 0 {
+		registries = []string{"$(REGISTRY)"}
+	}
+
+	// single build is tagged for every registry, so all of them get the same manifest
+	tags, err := image.tagArgs(registries, image.Tags)
+	if err != nil {
+		return err
+	}
+
+	if len(image.ReleaseTags) > 0 {
+		releaseTags, err := image.tagArgs(registries, image.ReleaseTags)
+		if err != nil {
+			return err
+		}
+
+		output.VariableGroup(makefile.VariableGroupDocker).
+			Variable(makefile.OverridableVariable("IMAGE_RELEASE",
+				"$(shell git describe --tags --exact-match --match='v*' >/dev/null 2>&1 && echo true || echo false)"))
+
+		tags = append(tags, fmt.Sprintf("$(if $(filter true,$(IMAGE_RELEASE)),%s)", strings.Join(releaseTags, " ")))
+	}
+
+	description := image.Description
+	if description == "" {
+		description = fmt.Sprintf("Builds image for %s.", image.ImageName)
+	}
+
+	platform := image.platformVariable()
+
+	// `platforms: []` in the config falls back to the default platform
+	platforms := image.Platforms
+	if len(platforms) == 0 {
+		platforms = []string{"linux/amd64"}
+	}
+
+	output.VariableGroup(makefile.VariableGroupDocker).
+		Variable(makefile.SimpleVariable("COMMA", ",")).
+		Variable(makefile.OverridableVariable("IMAGE_LOAD", "$(if $(filter true,$(PUSH)),false,true)")).
+		Variable(makefile.OverridableVariable(platform, strings.Join(platforms, ",")))
+
+	// multi-platform builds can't be loaded into the local Docker daemon, so they stay in the build cache,
+	// platforms might be overridden, so the check is done by make
+	tags = append(tags, fmt.Sprintf("$(if $(and $(filter true,$(IMAGE_LOAD)),$(if $(findstring $(COMMA),$(%s)),,true)),--load)", platform))
+
 	output.Target(image.Name()).
-		Description(fmt.Sprintf("Builds image for %s.", image.ImageName)).
-		Script(fmt.Sprintf(`@$(MAKE) target-$@ TARGET_ARGS="--tag=$(REGISTRY)/$(USERNAME)/%s:$(TAG)"`, image.ImageName)).
+		Description(description).
+		Script(fmt.Sprintf(`@$(MAKE) target-$@ PLATFORM=$(%s) TARGET_ARGS="%s"`, platform, strings.Join(tags, " "))).
+		Phony()
+
+	output.Target(image.pushTarget()).
+		Description(fmt.Sprintf("Builds and pushes image for %s.", image.ImageName)).
+		Script(fmt.Sprintf("@$(MAKE) %s PUSH=true", image.Name())).
 		Phony()
 
 	return nil
 }
 
+// platformVariable is the Makefile variable holding the platforms the image is built for (e.g. `IMAGE_KRES_PLATFORM`).
+func (image *Image) platformVariable() string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(image.Name())) + "_PLATFORM"
+}
+
+// pushStep is the name of the CI step (job) pushing the image.
+func (image *Image) pushStep() string {
+	return "push-" + image.ImageName
+}
+
+// pushTarget is the Makefile target building and pushing the image.
+//
+// Push requires registry credentials, so the image target only builds the image (and loads it locally).
+func (image *Image) pushTarget() string {
+	return image.Name() + "-push"
+}
+
+// tagArgs renders the tag templates into the build arguments tagging the image for every registry.
+func (image *Image) tagArgs(registries, templates []string) ([]string, error) {
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("image %q: no tags", image.ImageName)
+	}
+
+	data := imageTagData{
+		Version:  "$(TAG)",
+		ShortSHA: "$(SHA)",
+		Branch:   "$(subst /,-,$(BRANCH))",
+	}
+
+	args := make([]string, 0, len(registries)*len(templates))
+
+	for _, tmpl := range templates {
+		t, err := template.New("tag").Option("missingkey=error").Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("image %q: invalid tag %q: %w", image.ImageName, tmpl, err)
+		}
+
+		var tag strings.Builder
+
+		if err = t.Execute(&tag, data); err != nil {
+			return nil, fmt.Errorf("image %q: invalid tag %q: %w", image.ImageName, tmpl, err)
+		}
+
+		for _, registry := range registries {
+			args = append(args, fmt.Sprintf("--tag=%s/$(USERNAME)/%s:%s", registry, image.ImageName, tag.String()))
+		}
+	}
+
+	return args, nil
+}
+
+// entrypoint returns the entrypoint of the image.
+//
+// Default entrypoint follows the binary the image is built from, as it might be renamed
+// or installed to a different path.
+func (image *Image) entrypoint() string {
+	if image.Entrypoint != "/"+image.ImageName {
+		return image.Entrypoint
+	}
+
+	for _, input := range image.Inputs() {
+		if executable, ok := input.(Executable); ok {
+			return executable.ExecutablePath()
+		}
+	}
+
+	return image.Entrypoint
+}
+
 // CompileDockerfile implements dockerfile.Compiler.
 func (image *Image) CompileDockerfile(output *dockerfile.Output) error {
-	inputs := dag.GatherMatchingInputNames(image, dag.Implements((*dockerfile.Compiler)(nil)))
+	baseImage, err := image.baseImage()
+	if err != nil {
+		return err
+	}
+
+	inputs := dag.GatherMatchingInputNames(image, func(node dag.Node) bool {
+		// base images other than scratch already have the root filesystem
+		if inputImage, ok := node.(*InputImage); ok && inputImage.rootfs && baseImage != BaseImageScratch {
+			return false
+		}
+
+		return dag.Implements((*dockerfile.Compiler)(nil))(node)
+	})
 	if len(inputs) == 0 {
 		return fmt.Errorf("no inputs for Image block")
 	}
 
 	stage := output.Stage(image.Name())
 
-	if image.BaseImage == "scratch" {
-		stage.From(image.BaseImage)
+	if baseImage == BaseImageScratch {
+		stage.From(baseImage)
 	} else {
 		output.Stage(fmt.Sprintf("base-%s", image.Name())).
-			From(image.BaseImage)
+			From(baseImage)
 
 		stage.From(fmt.Sprintf("base-%s", image.Name()))
 	}
@@ -103,11 +733,262 @@ func (image *Image) CompileDockerfile(output *dockerfile.Output) error {
 		stage.Step(step.Copy("/", "/").From(input))
 	}
 
+	if err := image.compileLinks(output, stage); err != nil {
+		return err
+	}
+
+	image.compileEntrypointScript(output, stage)
+
+	if err := image.compileStages(output, stage, StagePositionAfterBuild); err != nil {
+		return err
+	}
+
 	for _, command := range image.CustomCommands {
 		stage.Step(step.Script(command))
 	}
 
-	stage.Step(step.Entrypoint(image.Entrypoint, image.EntrypointArgs...))
+	if err := image.compileStages(output, stage, StagePositionFinal); err != nil {
+		return err
+	}
+
+	if err := image.compileStages(output, nil, StagePositionBeforeBuild); err != nil {
+		return err
+	}
+
+	entrypoint := image.entrypoint()
+	if image.EntrypointScript != "" {
+		entrypoint = image.entrypointScriptPath()
+	}
+
+	// images without entrypoint carry only artifacts (e.g. static assets)
+	if entrypoint != "" {
+		stage.Step(step.Entrypoint(entrypoint, image.EntrypointArgs...))
+	}
+
+	if len(image.Cmd) > 0 {
+		stage.Step(step.Cmd(image.Cmd...))
+	}
+
+	image.compileLabels(stage)
+
+	return nil
+}
+
+// compileLabels sets OCI annotations and custom labels on the image.
+//
+// Revision and creation date come from the build args and are set last,
+// so that changing values don't invalidate the cached layers of the image.
+func (image *Image) compileLabels(imageStage *dockerfile.Stage) {
+	labels := map[string]string{}
+
+	if image.OCIAnnotations {
+		labels["org.opencontainers.image.revision"] = "${COMMIT}"
+		labels["org.opencontainers.image.created"] = "${BUILD_DATE}"
+		// LICENSE output is always MPL-2.0
+		labels["org.opencontainers.image.licenses"] = "MPL-2.0"
+
+		// module path is the repository for the projects hosted by well-known providers
+		if first := strings.Split(image.meta.CanonicalPath, "/")[0]; strings.Contains(first, ".") {
+			labels["org.opencontainers.image.source"] = "https://" + image.meta.CanonicalPath
+		}
+	}
+
+	for name, value := range image.Labels {
+		labels[name] = value
+	}
+
+	if len(labels) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(labels))
+
+	for name := range labels {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var (
+		commit, buildDate bool
+		label             *step.LabelStep
+	)
+
+	for _, name := range names {
+		value := labels[name]
+
+		commit = commit || strings.Contains(value, "${COMMIT}")
+		buildDate = buildDate || strings.Contains(value, "${BUILD_DATE}")
+
+		if label == nil {
+			label = step.Label(name, value)
+		} else {
+			label.Label(name, value)
+		}
+	}
+
+	if commit {
+		imageStage.Step(step.Arg("COMMIT"))
+	}
+
+	if buildDate {
+		imageStage.Step(step.Arg("BUILD_DATE"))
+	}
+
+	imageStage.Step(label)
+}
+
+// CompileDockerignore implements dockerignore.Compiler.
+func (image *Image) CompileDockerignore(output *dockerignore.Output) error {
+	if image.EntrypointScript != "" {
+		output.AllowLocalPath(image.EntrypointScript)
+	}
+
+	return nil
+}
+
+// entrypointScriptPath is the path of the entrypoint script in the image.
+func (image *Image) entrypointScriptPath() string {
+	return "/" + path.Base(image.EntrypointScript)
+}
+
+// compileEntrypointScript copies the entrypoint script into the image.
+//
+// The script is made executable in a separate stage, as scratch images don't have a shell to run chmod.
+func (image *Image) compileEntrypointScript(output *dockerfile.Output, imageStage *dockerfile.Stage) {
+	if image.EntrypointScript == "" {
+		return
+	}
+
+	name := fmt.Sprintf("%s-entrypoint", image.Name())
+	script := "/rootfs" + image.entrypointScriptPath()
+
+	output.Stage(name).
+		Description(fmt.Sprintf("entrypoint script of %s", image.ImageName)).
+		From("--platform=${BUILDPLATFORM} " + baseImagePresets[BaseImageAlpine].image).
+		Step(step.Copy("./"+path.Clean(image.EntrypointScript), script)).
+		Step(step.Run("chmod", "+x", script))
+
+	imageStage.Step(step.Copy("/rootfs/", "/").From(name))
+}
+
+// baseImage resolves the base image reference, well-known base images are checked against the target platforms.
+func (image *Image) baseImage() (string, error) {
+	if image.BaseImage == "" {
+		return BaseImageScratch, nil
+	}
+
+	preset, ok := baseImagePresets[image.BaseImage]
+	if !ok {
+		// arbitrary images are used as is
+		return image.BaseImage, nil
+	}
+
+	for _, platform := range image.Platforms {
+		if !platformSupported(platform, preset.platforms) {
+			return "", fmt.Errorf("image %q: base image %s is not available for platform %s", image.ImageName, image.BaseImage, platform)
+		}
+	}
+
+	return preset.image, nil
+}
+
+// platformSupported checks the platform against the list, platform without variant matches any variant.
+func platformSupported(platform string, supported []string) bool {
+	for _, candidate := range supported {
+		if candidate == platform || strings.HasPrefix(candidate, platform+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compileLinks creates symlinks to the entrypoint in a separate stage, as the image might have no shell.
+func (image *Image) compileLinks(output *dockerfile.Output, imageStage *dockerfile.Stage) error {
+	if len(image.Links) == 0 {
+		return nil
+	}
+
+	entrypoint := image.entrypoint()
+	if entrypoint == "" {
+		return fmt.Errorf("image %q: links require an entrypoint", image.ImageName)
+	}
+
+	commands := make([]string, 0, len(image.Links))
+
+	for _, link := range image.Links {
+		if !path.IsAbs(link) {
+			return fmt.Errorf("image %q: link %q should be an absolute path", image.ImageName, link)
+		}
+
+		commands = append(commands, fmt.Sprintf("mkdir -p /rootfs%s && ln -s %s /rootfs%s", path.Dir(link), entrypoint, link))
+	}
+
+	name := fmt.Sprintf("%s-links", image.Name())
+
+	output.Stage(name).
+		Description(fmt.Sprintf("symlinks to the entrypoint of %s", image.ImageName)).
+		// symlinks don't depend on the target platform
+		From("--platform=${BUILDPLATFORM} " + baseImagePresets[BaseImageAlpine].image).
+		Step(step.Script(strings.Join(commands, " \\\n\t&& ")))
+
+	imageStage.Step(step.Copy("/rootfs/", "/").From(name))
+
+	return nil
+}
+
+func (image *Image) compileStages(output *dockerfile.Output, imageStage *dockerfile.Stage, position string) error {
+	for _, custom := range image.Stages {
+		switch custom.Position {
+		case StagePositionBeforeBuild, StagePositionAfterBuild, StagePositionFinal:
+		default:
+			return fmt.Errorf("image %q: stage %q has unsupported position %q", image.ImageName, custom.Name, custom.Position)
+		}
+
+		if custom.Position != position {
+			continue
+		}
+
+		if custom.Name == "" || custom.From == "" {
+			return fmt.Errorf("image %q: custom stage requires both name and from", image.ImageName)
+		}
+
+		// collisions with generated stages are reported by the Dockerfile output
+		name := custom.Name
+
+		stage := output.Stage(name).
+			Description(fmt.Sprintf("custom stage %s for %s", custom.Name, image.ImageName)).
+			From(custom.From)
+
+		for _, command := range custom.Steps {
+			stage.Step(step.Script(command))
+		}
+
+		source, destination := custom.Source, custom.Destination
+		if source == "" {
+			source = "/"
+		}
+
+		if destination == "" {
+			destination = "/"
+		}
+
+		copyStep := step.Copy(source, destination).From(name)
+
+		if position == StagePositionBeforeBuild {
+			into := custom.Into
+			if into == "" {
+				into = "base"
+			}
+
+			output.AppendToStage(into, copyStep)
+
+			continue
+		}
+
+		imageStage.Step(copyStep)
+	}
 
 	return nil
 }
@@ -9,14 +9,28 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
 	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
 	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/common"
 )
 
 func TestImageInterfaces(t *testing.T) {
 	assert.Implements(t, (*makefile.Compiler)(nil), new(common.Image))
 	assert.Implements(t, (*dockerfile.Compiler)(nil), new(common.Image))
+	assert.Implements(t, (*dockerignore.Compiler)(nil), new(common.Image))
 	assert.Implements(t, (*drone.Compiler)(nil), new(common.Image))
+	assert.Implements(t, (*gitlab.Compiler)(nil), new(common.Image))
+	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(common.Image))
+	assert.Implements(t, (*tekton.Compiler)(nil), new(common.Image))
+	assert.Implements(t, (*circleci.Compiler)(nil), new(common.Image))
+	assert.Implements(t, (*buildkite.Compiler)(nil), new(common.Image))
+	assert.Implements(t, (*azurepipelines.Compiler)(nil), new(common.Image))
 }
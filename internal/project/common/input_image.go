@@ -6,9 +6,14 @@ package common
 
 import (
 	"fmt"
+	"path"
+	"regexp"
+	"strings"
 
 	"github.com/talos-systems/kres/internal/dag"
 	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
 	"github.com/talos-systems/kres/internal/project/meta"
 )
 
@@ -18,13 +23,152 @@ type InputImage struct {
 
 	Image   string
 	Version string
+
+	// Directories are created on top of the image contents, e.g. `/etc/app` for the config of the service.
+	Directories []LayoutDirectory `yaml:"directories"`
+	// Files are local files (relative to the project root) seeded into the image, e.g. the default config.
+	Files []LayoutFile `yaml:"files"`
+
+	// rootfs is set for the images providing base root filesystem contents,
+	// which are skipped for images built on top of non-scratch base images.
+	rootfs bool
+}
+
+// LayoutDirectory is a directory created in the input image.
+type LayoutDirectory struct {
+	// Path is the absolute path of the directory.
+	Path string `yaml:"path"`
+	// Mode is the octal permissions of the directory, defaults to `0755`.
+	Mode string `yaml:"mode"`
+	// Owner is `uid:gid` of the directory, defaults to root.
+	Owner string `yaml:"owner"`
+}
+
+// LayoutFile is a file seeded into the input image.
+type LayoutFile struct {
+	// Source is the path of the file relative to the project root.
+	Source string `yaml:"source"`
+	// Path is the absolute path of the file in the image.
+	Path string `yaml:"path"`
+	// Mode is the octal permissions of the file, defaults to `0644`.
+	Mode string `yaml:"mode"`
+	// Owner is `uid:gid` of the file, defaults to root.
+	Owner string `yaml:"owner"`
+}
+
+var (
+	layoutModeRegexp  = regexp.MustCompile(`^0?[0-7]{3}$`)
+	layoutOwnerRegexp = regexp.MustCompile(`^[a-z0-9_][a-z0-9_.-]*(:[a-z0-9_][a-z0-9_.-]*)?$`)
+)
+
+func (inputImage *InputImage) validate() error {
+	validate := func(kind, target, mode, owner string) error {
+		if !path.IsAbs(target) {
+			return fmt.Errorf("%s: %s %q should be an absolute path", inputImage.Name(), kind, target)
+		}
+
+		if mode != "" && !layoutModeRegexp.MatchString(mode) {
+			return fmt.Errorf("%s: invalid mode %q of %s %q", inputImage.Name(), mode, kind, target)
+		}
+
+		if owner != "" && !layoutOwnerRegexp.MatchString(owner) {
+			return fmt.Errorf("%s: invalid owner %q of %s %q", inputImage.Name(), owner, kind, target)
+		}
+
+		return nil
+	}
+
+	for _, dir := range inputImage.Directories {
+		if err := validate("directory", dir.Path, dir.Mode, dir.Owner); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range inputImage.Files {
+		if err := validate("file", file.Path, file.Mode, file.Owner); err != nil {
+			return err
+		}
+
+		if file.Source == "" || path.IsAbs(file.Source) || strings.HasPrefix(path.Clean(file.Source), "../") {
+			return fmt.Errorf("%s: source %q of file %q should be relative to the project root", inputImage.Name(), file.Source, file.Path)
+		}
+	}
+
+	return nil
 }
 
 // CompileDockerfile implements dockerfile.Compiler.
 func (inputImage *InputImage) CompileDockerfile(output *dockerfile.Output) error {
-	output.Stage(inputImage.Name()).
+	if err := inputImage.validate(); err != nil {
+		return err
+	}
+
+	stage := output.Stage(inputImage.Name()).
 		From(fmt.Sprintf("%s:%s", inputImage.Image, inputImage.Version))
 
+	inputImage.compileLayout(output, stage)
+
+	return nil
+}
+
+// compileLayout creates the directories and seeds the files in a separate stage, as the image might have no shell.
+//
+// Permissions are set in the layout stage and preserved by COPY, ownership is set with `COPY --chown`,
+// so that contents of the directories are owned by the same user.
+func (inputImage *InputImage) compileLayout(output *dockerfile.Output, imageStage *dockerfile.Stage) {
+	if len(inputImage.Directories) == 0 && len(inputImage.Files) == 0 {
+		return
+	}
+
+	name := fmt.Sprintf("%s-layout", inputImage.Name())
+
+	layout := output.Stage(name).
+		Description(fmt.Sprintf("filesystem layout of %s", inputImage.Name())).
+		// layout doesn't depend on the target platform
+		From("--platform=${BUILDPLATFORM} " + baseImagePresets[BaseImageAlpine].image)
+
+	if len(inputImage.Directories) > 0 {
+		commands := make([]string, 0, len(inputImage.Directories))
+
+		for _, dir := range inputImage.Directories {
+			mode := dir.Mode
+			if mode == "" {
+				mode = "0755"
+			}
+
+			commands = append(commands, fmt.Sprintf("mkdir -p /rootfs%s && chmod %s /rootfs%s", path.Clean(dir.Path), mode, path.Clean(dir.Path)))
+		}
+
+		layout.Step(step.Script(strings.Join(commands, " \\\n\t&& ")))
+	}
+
+	for _, file := range inputImage.Files {
+		mode := file.Mode
+		if mode == "" {
+			mode = "0644"
+		}
+
+		layout.
+			Step(step.Copy("./"+path.Clean(file.Source), "/rootfs"+path.Clean(file.Path))).
+			Step(step.Run("chmod", mode, "/rootfs"+path.Clean(file.Path)))
+	}
+
+	// directories are copied one by one (parents first), as the ownership might be different
+	for _, dir := range inputImage.Directories {
+		imageStage.Step(step.Copy("/rootfs"+path.Clean(dir.Path), path.Clean(dir.Path)).From(name).Chown(dir.Owner))
+	}
+
+	for _, file := range inputImage.Files {
+		imageStage.Step(step.Copy("/rootfs"+path.Clean(file.Path), path.Clean(file.Path)).From(name).Chown(file.Owner))
+	}
+}
+
+// CompileDockerignore implements dockerignore.Compiler.
+func (inputImage *InputImage) CompileDockerignore(output *dockerignore.Output) error {
+	for _, file := range inputImage.Files {
+		output.AllowLocalPath(file.Source)
+	}
+
 	return nil
 }
 
@@ -35,6 +179,8 @@ func NewFHS(meta *meta.Options) *InputImage {
 
 		Image:   "autonomy/fhs",
 		Version: "v0.2.0-29-gdda8024",
+
+		rootfs: true,
 	}
 }
 
@@ -45,5 +191,7 @@ func NewCACerts(meta *meta.Options) *InputImage {
 
 		Image:   "autonomy/ca-certificates",
 		Version: "v0.2.0-29-gdda8024",
+
+		rootfs: true,
 	}
 }
@@ -10,9 +10,11 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
 	"github.com/talos-systems/kres/internal/project/common"
 )
 
 func TestInputImageInterfaces(t *testing.T) {
 	assert.Implements(t, (*dockerfile.Compiler)(nil), new(common.InputImage))
+	assert.Implements(t, (*dockerignore.Compiler)(nil), new(common.InputImage))
 }
@@ -0,0 +1,146 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/precommit"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// LicenseHeader verifies that source files start with license header.
+//
+// Comment style is picked based on the glob: `//` for Go files, `#` for everything else
+// (shell scripts, Dockerfiles, etc.).
+type LicenseHeader struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Header is the header text without comment markers.
+	Header string `yaml:"header"`
+	// Globs are file name patterns to check.
+	Globs []string `yaml:"globs"`
+	// Exclude are file name patterns to skip (e.g. generated code).
+	Exclude []string `yaml:"exclude"`
+}
+
+// NewLicenseHeader initializes LicenseHeader.
+func NewLicenseHeader(meta *meta.Options) *LicenseHeader {
+	return &LicenseHeader{
+		BaseNode: dag.NewBaseNode("lint-license"),
+
+		meta: meta,
+
+		Header: `This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at http://mozilla.org/MPL/2.0/.`,
+		Globs:   []string{"*.go"},
+		Exclude: []string{"*.pb.go"},
+	}
+}
+
+// CompilePreCommit implements precommit.Compiler.
+func (license *LicenseHeader) CompilePreCommit(output *precommit.Output) error {
+	output.Hook("license", license.Name())
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (license *LicenseHeader) CompileMakefile(output *makefile.Output) error {
+	var check, fix []string
+
+	for _, glob := range license.Globs {
+		find := license.find(glob)
+		if find == "" {
+			continue
+		}
+
+		lines := license.lines(glob)
+
+		// header is compared line by line with file contents
+		matches := fmt.Sprintf(`[ "$$(head -n %d "$$file")" = "$$(printf '%%s\n' %s)" ]`, len(lines), escape(shellquote.Join(lines...)))
+
+		check = append(check, fmt.Sprintf(`@missing=$$(%s | while read -r file; do %s || echo "$$file"; done); \
+	test -z "$$missing" || { echo "Files missing license header (run 'make fix-license'):"; echo "$$missing"; exit 1; }`, find, matches))
+
+		fix = append(fix, fmt.Sprintf(`@%s | while read -r file; do %s || { { printf '%%s\n' %s ''; cat "$$file"; } > "$$file.tmp" && mv "$$file.tmp" "$$file"; }; done`,
+			find, matches, escape(shellquote.Join(lines...))))
+	}
+
+	if len(check) == 0 {
+		check = append(check, "@true")
+		fix = append(fix, "@true")
+	}
+
+	output.Target("lint-license").Description("Verifies source files have license header.").
+		Script(check...)
+
+	output.Target("fix-license").Description("Inserts license header into source files.").
+		Script(fix...).
+		Phony()
+
+	return nil
+}
+
+// lines returns header lines commented according to the file type.
+// Comment returns the header as a comment for the files matching the glob.
+func (license *LicenseHeader) Comment(glob string) string {
+	return strings.Join(license.lines(glob), "\n")
+}
+
+func (license *LicenseHeader) lines(glob string) []string {
+	prefix := "#"
+
+	if strings.HasSuffix(glob, ".go") {
+		prefix = "//"
+	}
+
+	lines := strings.Split(strings.TrimSpace(license.Header), "\n")
+
+	for i := range lines {
+		lines[i] = strings.TrimSpace(prefix + " " + lines[i])
+	}
+
+	return lines
+}
+
+// find returns a command to list files matching the glob.
+func (license *LicenseHeader) find(glob string) string {
+	paths := append([]string(nil), license.meta.GoDirectories...)
+
+	for _, file := range license.meta.GoSourceFiles {
+		if matched, _ := filepath.Match(glob, file); matched {
+			paths = append(paths, file)
+		}
+	}
+
+	if len(paths) == 0 {
+		return ""
+	}
+
+	args := []string{"find"}
+	args = append(args, paths...)
+	args = append(args, "-type", "f", "-name", glob)
+
+	for _, exclude := range license.Exclude {
+		args = append(args, "!", "-name", exclude)
+	}
+
+	return escape(shellquote.Join(args...))
+}
+
+// escape escapes `$` for Makefile.
+func escape(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
@@ -6,8 +6,14 @@ package common
 
 import (
 	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
 	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/meta"
 )
 
@@ -16,6 +22,14 @@ type Lint struct {
 	dag.BaseNode
 
 	meta *meta.Options
+
+	// Description is shown for the target in `make help`.
+	Description string `yaml:"description"`
+}
+
+// OptionalLinter is implemented by linters which are only run if enabled in the config.
+type OptionalLinter interface {
+	LinterEnabled() bool
 }
 
 // NewLint initializes Lint.
@@ -24,13 +38,70 @@ func NewLint(meta *meta.Options) *Lint {
 		BaseNode: dag.NewBaseNode("lint"),
 
 		meta: meta,
+
+		Description: "Run all linters for the project.",
 	}
 }
 
 // CompileDrone implements drone.Compiler.
 func (lint *Lint) CompileDrone(output *drone.Output) error {
 	output.Step(drone.MakeStep("lint").
-		DependsOn(dag.GatherMatchingInputNames(lint, dag.Implements((*drone.Compiler)(nil)))...),
+		DependsOn(dag.GatherMatchingInputNames(lint, enabledLinters(dag.Implements((*drone.Compiler)(nil))))...),
+	)
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (lint *Lint) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	output.Job(ghworkflow.MakeJob("lint").
+		Needs(dag.GatherMatchingInputNames(lint, enabledLinters(dag.Implements((*ghworkflow.Compiler)(nil))))...),
+	)
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (lint *Lint) CompileGitLab(output *gitlab.Output) error {
+	output.Job(gitlab.MakeJob("lint").
+		Stage(lint.meta.GitLabStages.Lint).
+		Needs(dag.GatherMatchingInputNames(lint, enabledLinters(dag.Implements((*gitlab.Compiler)(nil))))...),
+	)
+
+	return nil
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+func (lint *Lint) CompileAzurePipelines(output *azurepipelines.Output) error {
+	output.Job(azurepipelines.MakeJob("lint").
+		DependsOn(dag.GatherMatchingInputNames(lint, enabledLinters(dag.Implements((*azurepipelines.Compiler)(nil))))...),
+	)
+
+	return nil
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+func (lint *Lint) CompileBuildkite(output *buildkite.Output) error {
+	output.Step(buildkite.MakeStep("lint").
+		DependsOn(dag.GatherMatchingInputNames(lint, enabledLinters(dag.Implements((*buildkite.Compiler)(nil))))...),
+	)
+
+	return nil
+}
+
+// CompileCircleCI implements circleci.Compiler.
+func (lint *Lint) CompileCircleCI(output *circleci.Output) error {
+	output.Job(circleci.MakeJob("lint").
+		Requires(dag.GatherMatchingInputNames(lint, enabledLinters(dag.Implements((*circleci.Compiler)(nil))))...),
+	)
+
+	return nil
+}
+
+// CompileTekton implements tekton.Compiler.
+func (lint *Lint) CompileTekton(output *tekton.Output) error {
+	output.Task(tekton.MakeTask("lint").
+		RunAfter(dag.GatherMatchingInputNames(lint, enabledLinters(dag.Implements((*tekton.Compiler)(nil))))...),
 	)
 
 	return nil
@@ -38,9 +109,20 @@ func (lint *Lint) CompileDrone(output *drone.Output) error {
 
 // CompileMakefile implements makefile.Compiler.
 func (lint *Lint) CompileMakefile(output *makefile.Output) error {
-	output.Target("lint").Description("Run all linters for the project.").
-		Depends(dag.GatherMatchingInputNames(lint, dag.Not(dag.Implements((*makefile.SkipAsMakefileDependency)(nil))))...).
+	output.Target("lint").Description(lint.Description).
+		Depends(dag.GatherMatchingInputNames(lint, enabledLinters(dag.Not(dag.Implements((*makefile.SkipAsMakefileDependency)(nil)))))...).
 		Phony()
 
 	return nil
 }
+
+// enabledLinters skips optional linters which are not enabled.
+func enabledLinters(condition dag.NodeCondition) dag.NodeCondition {
+	return func(node dag.Node) bool {
+		if linter, ok := node.(OptionalLinter); ok && !linter.LinterEnabled() {
+			return false
+		}
+
+		return condition(node)
+	}
+}
@@ -9,12 +9,24 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
 	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/common"
 )
 
 func TestLintInterfaces(t *testing.T) {
 	assert.Implements(t, (*makefile.Compiler)(nil), new(common.Lint))
 	assert.Implements(t, (*drone.Compiler)(nil), new(common.Lint))
+	assert.Implements(t, (*gitlab.Compiler)(nil), new(common.Lint))
+	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(common.Lint))
+	assert.Implements(t, (*tekton.Compiler)(nil), new(common.Lint))
+	assert.Implements(t, (*circleci.Compiler)(nil), new(common.Lint))
+	assert.Implements(t, (*buildkite.Compiler)(nil), new(common.Lint))
+	assert.Implements(t, (*azurepipelines.Compiler)(nil), new(common.Lint))
 }
@@ -35,10 +35,13 @@ func (help *MakeHelp) CompileMakefile(output *makefile.Output) error {
 	output.VariableGroup(makefile.VariableGroupHelp).
 		Variable(makefile.MultilineVariable("HELP_MENU_HEADER", help.MenuHeader).Export())
 
+	// `make` without arguments shows the help
+	output.DefaultGoal("help")
+
 	output.Target("help").
 		Description("This help menu.").
 		Script("@echo \"$$HELP_MENU_HEADER\"").
-		Script(`@grep -E '^[a-zA-Z%_-]+:.*?## .*$$' $(MAKEFILE_LIST) | awk 'BEGIN {FS = ":.*?## "}; {printf "\033[36m%-30s\033[0m %s\n", $$1, $$2}'`).
+		Script(`@grep -E '^[a-zA-Z0-9%_.-]+:.*?## .*$$' $(MAKEFILE_LIST) | awk 'BEGIN {FS = ":.*?## "}; {printf "\033[36m%-30s\033[0m %s\n", $$1, $$2}'`).
 		Phony()
 
 	return nil
@@ -0,0 +1,113 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/precommit"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// MarkdownLint runs markdownlint over the Markdown documents.
+//
+// Rules might be disabled for a part of the document with `<!-- markdownlint-disable MD001 -->` comments.
+type MarkdownLint struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Image string `yaml:"image"`
+	// Config is a path to the ruleset file (relative to the project root), default ruleset is used if not set.
+	Config string `yaml:"config"`
+	// Disable is a list of rules disabled on top of the ruleset.
+	Disable []string `yaml:"disable"`
+	// Ignore is a list of globs of the documents which are not linted, e.g. generated docs.
+	Ignore []string `yaml:"ignore"`
+}
+
+// NewMarkdownLint initializes MarkdownLint.
+func NewMarkdownLint(meta *meta.Options) *MarkdownLint {
+	meta.BuildArgs = append(meta.BuildArgs, "MARKDOWNLINT_IMAGE")
+
+	return &MarkdownLint{
+		BaseNode: dag.NewBaseNode("lint-markdown"),
+
+		meta: meta,
+
+		Image: "ghcr.io/igorshubovych/markdownlint-cli:v0.31.1",
+		// generated changelog has anchors, long lines and no top-level heading
+		Disable: []string{"MD013", "MD033", "MD041"},
+	}
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (lint *MarkdownLint) CompileDockerfile(output *dockerfile.Output) error {
+	output.Arg(step.Arg("MARKDOWNLINT_IMAGE"))
+
+	stage := output.Stage("lint-markdown").
+		Description("runs markdownlint").
+		From("--platform=${BUILDPLATFORM} ${MARKDOWNLINT_IMAGE}").
+		Step(step.WorkDir("/src"))
+
+	files := lint.meta.MarkdownFiles
+
+	// --disable is variadic, so it goes last
+	args := []string{"."}
+
+	if lint.Config != "" {
+		files = append([]string{lint.Config}, files...)
+		args = append(args, "--config", lint.Config)
+	}
+
+	for _, file := range files {
+		stage.Step(step.Copy("./"+file, "./"+file))
+	}
+
+	for _, glob := range lint.Ignore {
+		args = append(args, "--ignore", glob)
+	}
+
+	if len(lint.Disable) > 0 {
+		args = append(args, "--disable")
+		args = append(args, lint.Disable...)
+	}
+
+	stage.Step(step.Run("markdownlint", args...))
+
+	return nil
+}
+
+// CompileDockerignore implements dockerignore.Compiler.
+func (lint *MarkdownLint) CompileDockerignore(output *dockerignore.Output) error {
+	output.AllowLocalPath(lint.meta.MarkdownFiles...)
+
+	if lint.Config != "" {
+		output.AllowLocalPath(lint.Config)
+	}
+
+	return nil
+}
+
+// CompilePreCommit implements precommit.Compiler.
+func (lint *MarkdownLint) CompilePreCommit(output *precommit.Output) error {
+	output.Hook("markdownlint", lint.Name()).Types("markdown")
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (lint *MarkdownLint) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupDocker).
+		Variable(makefile.OverridableVariable("MARKDOWNLINT_IMAGE", lint.Image))
+
+	output.Target("lint-markdown").Description("Runs markdownlint over Markdown documents.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
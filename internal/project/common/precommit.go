@@ -0,0 +1,49 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/precommit"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// PreCommit provides .pre-commit-config.yaml which runs the linters before commit.
+//
+// Hooks are registered by the linters of the project and call the same Makefile targets as CI.
+type PreCommit struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Enabled generates .pre-commit-config.yaml.
+	Enabled bool `yaml:"enabled"`
+	// Hooks enables or disables individual hooks by ID (e.g. `golangci-lint: false`, `vulncheck: true`).
+	Hooks map[string]bool `yaml:"hooks"`
+}
+
+// NewPreCommit initializes PreCommit.
+func NewPreCommit(meta *meta.Options) *PreCommit {
+	return &PreCommit{
+		BaseNode: dag.NewBaseNode("pre-commit"),
+
+		meta: meta,
+	}
+}
+
+// CompilePreCommit implements precommit.Compiler.
+func (p *PreCommit) CompilePreCommit(output *precommit.Output) error {
+	if !p.Enabled {
+		return nil
+	}
+
+	output.Enable()
+
+	for id, enabled := range p.Hooks {
+		output.Override(id, enabled)
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Release drafts the GitHub release of the tag with the release notes, binaries and SBOMs attached.
+//
+// Release depends on the builds (Executable inputs), the images, SBOM and ReleaseNotes, binaries are
+// built into $(ARTIFACTS) by the build targets. Release stays a draft until it's published manually,
+// unless AutoPublish is set.
+type Release struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Enabled runs the release on tags in CI.
+	Enabled bool `yaml:"enabled"`
+	// AutoPublish publishes the release once the artifacts are uploaded.
+	AutoPublish bool `yaml:"autoPublish"`
+}
+
+// NewRelease initializes Release.
+func NewRelease(meta *meta.Options) *Release {
+	return &Release{
+		BaseNode: dag.NewBaseNode("github-release"),
+
+		meta: meta,
+	}
+}
+
+// repository returns the GitHub repository of the project, if it's hosted on GitHub.
+func (release *Release) repository() string {
+	parts := strings.Split(release.meta.CanonicalPath, "/")
+	if len(parts) < 3 || parts[0] != "github.com" {
+		return ""
+	}
+
+	return strings.Join(parts[:3], "/")
+}
+
+// artifacts returns the make targets producing the release assets along with the asset paths.
+func (release *Release) artifacts() (targets, assets []string) {
+	for _, input := range release.Inputs() {
+		switch node := input.(type) {
+		case Executable:
+			artifact := "$(ARTIFACTS)" + node.ExecutablePath()
+
+			targets = append(targets, artifact)
+			assets = append(assets, artifact)
+		case *SBOM:
+			images := node.images()
+			if len(images) == 0 {
+				continue
+			}
+
+			targets = append(targets, node.Name())
+
+			for _, image := range images {
+				assets = append(assets, filepath.Join("$(ARTIFACTS)", node.filename(image)))
+			}
+		case *ReleaseNotes:
+			targets = append(targets, node.Name())
+		}
+	}
+
+	return targets, assets
+}
+
+// notes returns the path of the release notes, if the release depends on them.
+func (release *Release) notes() string {
+	for _, input := range release.Inputs() {
+		if notes, ok := input.(*ReleaseNotes); ok {
+			return notes.path()
+		}
+	}
+
+	return ""
+}
+
+// ciDependencies returns the names of the CI steps the release waits for.
+//
+// Images are built from the registry by SBOM, so the release waits for the pushes.
+func (release *Release) ciDependencies() []string {
+	var depends []string
+
+	for _, input := range release.Inputs() {
+		switch node := input.(type) {
+		case *Image:
+			depends = append(depends, node.pushStep())
+		default:
+			depends = append(depends, input.Name())
+		}
+	}
+
+	return depends
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (release *Release) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupCommon).
+		Variable(makefile.OverridableVariable("GH_REPO", release.repository()))
+
+	targets, assets := release.artifacts()
+
+	notes := `--notes ""`
+	if path := release.notes(); path != "" {
+		notes = "--notes-file " + path
+	}
+
+	// release might be already created by the other release steps, notes are updated then
+	script := []string{
+		fmt.Sprintf(`@if gh release view $(TAG) --repo "$(GH_REPO)" >/dev/null 2>&1; then gh release edit $(TAG) --repo "$(GH_REPO)" %s; `+
+			`else gh release create $(TAG) --repo "$(GH_REPO)" --draft --title $(TAG) %s; fi`, notes, notes),
+	}
+
+	if len(assets) > 0 {
+		script = append(script, fmt.Sprintf(`@gh release upload $(TAG) --repo "$(GH_REPO)" --clobber %s`, strings.Join(assets, " ")))
+	}
+
+	if release.AutoPublish {
+		script = append(script, `@gh release edit $(TAG) --repo "$(GH_REPO)" --draft=false`)
+	}
+
+	output.Target(release.Name()).
+		Description("Drafts the GitHub release for $(TAG) with the release notes and artifacts.").
+		Depends(targets...).
+		Script(script...).
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (release *Release) CompileDrone(output *drone.Output) error {
+	if !release.Enabled {
+		return nil
+	}
+
+	output.Step(drone.MakeStep(release.Name()).
+		EnvironmentFromSecret("GITHUB_TOKEN", "github_token").
+		OnlyOnTag().
+		DockerLogin().
+		DependsOn(release.ciDependencies()...),
+	)
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (release *Release) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	if !release.Enabled {
+		return nil
+	}
+
+	output.Job(ghworkflow.MakeJob(release.Name()).
+		EnvironmentFromSecret("GITHUB_TOKEN", "GITHUB_TOKEN").
+		OnlyOnTag().
+		DockerLogin().
+		Needs(release.ciDependencies()...),
+	)
+
+	return nil
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (release *Release) SkipAsMakefileDependency() {
+}
@@ -0,0 +1,114 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/release"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// ReleaseNotes generates release notes from the conventional commits since the last tag.
+//
+// Notes are generated with git-chglog for $(TAG), which is the same version used for the builds.
+type ReleaseNotes struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Enabled attaches release notes to the GitHub release on tags in CI.
+	Enabled bool `yaml:"enabled"`
+	// Template is the path to the custom git-chglog template (relative to the project root).
+	Template string `yaml:"template"`
+	// Types maps commit types to the headings, only listed types are included into the notes.
+	Types map[string]string `yaml:"types"`
+	// Scopes maps commit scopes to the headings commits are prefixed with.
+	Scopes map[string]string `yaml:"scopes"`
+	// GitChglogImage is used if git-chglog is not installed.
+	GitChglogImage string `yaml:"gitChglogImage"`
+}
+
+// NewReleaseNotes initializes ReleaseNotes.
+func NewReleaseNotes(meta *meta.Options) *ReleaseNotes {
+	return &ReleaseNotes{
+		BaseNode: dag.NewBaseNode("release-notes"),
+
+		meta: meta,
+
+		Types: map[string]string{
+			"feat":     "Features",
+			"fix":      "Bug Fixes",
+			"perf":     "Performance Improvements",
+			"refactor": "Code Refactoring",
+		},
+		GitChglogImage: "quay.io/git-chglog/git-chglog:0.15.0",
+	}
+}
+
+func (notes *ReleaseNotes) path() string {
+	return filepath.Join(notes.meta.ArtifactsPath, "RELEASE_NOTES.md")
+}
+
+// CompileRelease implements release.Compiler.
+func (notes *ReleaseNotes) CompileRelease(output *release.Output) error {
+	// only hosted repositories can be linked to
+	if host := strings.SplitN(notes.meta.CanonicalPath, "/", 2)[0]; strings.Contains(host, ".") {
+		output.RepositoryURL("https://" + notes.meta.CanonicalPath)
+	}
+
+	output.
+		Template(notes.Template).
+		TypeHeadings(notes.Types).
+		ScopeHeadings(notes.Scopes)
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (notes *ReleaseNotes) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupCommon).
+		Variable(makefile.OverridableVariable("GIT_CHGLOG_IMAGE", notes.GitChglogImage))
+
+	output.Target("release-notes").
+		Description("Generates release notes for $(TAG) from the commits since the last tag.").
+		Script(
+			"@mkdir -p $(ARTIFACTS)",
+			"@GIT_CHGLOG_IMAGE=$(GIT_CHGLOG_IMAGE) ./hack/release.sh $@ $(ARTIFACTS)/RELEASE_NOTES.md $(TAG)",
+		).
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (notes *ReleaseNotes) CompileDrone(output *drone.Output) error {
+	if !notes.Enabled {
+		return nil
+	}
+
+	output.Step(drone.MakeStep(notes.Name()).
+		OnlyOnTag().
+		DependsOn(dag.GatherMatchingInputNames(notes, dag.Implements((*drone.Compiler)(nil)))...),
+	)
+
+	output.Step(drone.PluginStep("release", "plugins/github-release").
+		SettingFromSecret("api_key", "github_token").
+		Setting("note", output.ComponentPath(notes.path())).
+		Setting("draft", true).
+		OnlyOnTag().
+		DependsOn(notes.Name()),
+	)
+
+	return nil
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (notes *ReleaseNotes) SkipAsMakefileDependency() {
+}
@@ -0,0 +1,77 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/renovate"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Renovate provides Renovate config for the detected package managers.
+//
+// Renovate is enabled by default once any package manager (e.g. Go modules) is detected.
+// Custom presets and rules should be set in the config, so that they survive regeneration.
+type Renovate struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Enabled      bool                   `yaml:"enabled"`
+	Extends      []string               `yaml:"extends"`
+	Schedule     []string               `yaml:"schedule"`
+	Timezone     string                 `yaml:"timezone"`
+	PackageRules []renovate.PackageRule `yaml:"packageRules"`
+}
+
+// NewRenovate initializes Renovate.
+func NewRenovate(meta *meta.Options) *Renovate {
+	return &Renovate{
+		BaseNode: dag.NewBaseNode("renovate"),
+
+		meta: meta,
+
+		Enabled: true,
+
+		// off-hours
+		Schedule: []string{"after 10pm every weekday", "before 5am every weekday", "every weekend"},
+		Timezone: "UTC",
+	}
+}
+
+// CompileRenovate implements renovate.Compiler.
+func (r *Renovate) CompileRenovate(output *renovate.Output) error {
+	if !r.Enabled || len(r.meta.PackageManagers) == 0 {
+		return nil
+	}
+
+	output.Enable()
+
+	output.
+		Extend("config:base").
+		Extend(r.Extends...).
+		EnableManager(r.meta.PackageManagers...).
+		Schedule(r.Timezone, r.Schedule...)
+
+	output.PackageRule(renovate.PackageRule{
+		"matchUpdateTypes": []string{"patch"},
+		"groupName":        "all patch dependencies",
+		"groupSlug":        "all-patch",
+	})
+
+	// Go version is pinned in the toolchain
+	if r.meta.GoVersion != "" {
+		output.PackageRule(renovate.PackageRule{
+			"matchManagers": []string{"gomod"},
+			"matchDepTypes": []string{"golang"},
+			"enabled":       false,
+		})
+	}
+
+	// custom rules go last, so that they take precedence
+	output.PackageRule(r.PackageRules...)
+
+	return nil
+}
@@ -0,0 +1,39 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/talos-systems/kres/internal/output/renovate"
+	"github.com/talos-systems/kres/internal/project/common"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+func TestRenovateInterfaces(t *testing.T) {
+	assert.Implements(t, (*renovate.Compiler)(nil), new(common.Renovate))
+}
+
+func TestRenovateEnabled(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		managers []string
+		expected []string
+	}{
+		{name: "none"},
+		{name: "gomod", managers: []string{"gomod"}, expected: []string{"renovate.json"}},
+	} {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			output := renovate.NewOutput()
+
+			assert.NoError(t, common.NewRenovate(&meta.Options{PackageManagers: tt.managers}).CompileRenovate(output))
+			assert.Equal(t, tt.expected, output.Filenames())
+		})
+	}
+}
@@ -0,0 +1,267 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// SBOM generates software bill of materials for the images it depends on.
+//
+// SBOM is only generated for images with SBOM enabled.
+type SBOM struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Tool is the container image of the SBOM generator.
+	Tool string `yaml:"tool"`
+	// Format is the SBOM output format.
+	Format string `yaml:"format"`
+	// Args is a template of SBOM generator arguments, `.Image` and `.Format` are available.
+	Args string `yaml:"args"`
+}
+
+// NewSBOM initializes SBOM.
+func NewSBOM(meta *meta.Options) *SBOM {
+	return &SBOM{
+		BaseNode: dag.NewBaseNode("sbom"),
+
+		meta: meta,
+
+		Tool:   "docker.io/anchore/syft:v0.10.0",
+		Format: "spdx-json",
+		Args:   "packages {{ .Image }} -o {{ .Format }}",
+	}
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (sbom *SBOM) CompileMakefile(output *makefile.Output) error {
+	images := sbom.images()
+	if len(images) == 0 {
+		return nil
+	}
+
+	output.VariableGroup(makefile.VariableGroupDocker).
+		Variable(makefile.OverridableVariable("SBOM_TOOL", sbom.Tool))
+
+	target := output.Target(sbom.Name()).
+		Description("Generates SBOM for the images.").
+		Script("@mkdir -p $(ARTIFACTS)").
+		Phony()
+
+	for _, image := range images {
+		args, err := sbom.args(image)
+		if err != nil {
+			return err
+		}
+
+		target.Script(fmt.Sprintf("@docker run --rm -v /var/run/docker.sock:/var/run/docker.sock $(SBOM_TOOL) %s > %s",
+			args, filepath.Join("$(ARTIFACTS)", sbom.filename(image))))
+	}
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (sbom *SBOM) CompileDrone(output *drone.Output) error {
+	images := sbom.images()
+	if len(images) == 0 {
+		return nil
+	}
+
+	output.Step(drone.MakeStep(sbom.Name()).
+		ExceptPullRequest().
+		DockerLogin().
+		DependsOn(sbom.ciDependencies(images, dag.Implements((*drone.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (sbom *SBOM) CompileGitLab(output *gitlab.Output) error {
+	images := sbom.images()
+	if len(images) == 0 {
+		return nil
+	}
+
+	output.Job(gitlab.MakeJob(sbom.Name()).
+		Stage(sbom.meta.GitLabStages.Build).
+		ExceptMergeRequest().
+		DockerLogin().
+		Artifacts(sbom.artifacts(images)...).
+		Needs(sbom.ciDependencies(images, dag.Implements((*gitlab.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (sbom *SBOM) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	images := sbom.images()
+	if len(images) == 0 {
+		return nil
+	}
+
+	output.Job(ghworkflow.MakeJob(sbom.Name()).
+		ExceptPullRequest().
+		DockerLogin().
+		UploadArtifact(sbom.Name(), sbom.meta.ArtifactsPath).
+		Needs(sbom.ciDependencies(images, dag.Implements((*ghworkflow.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileCircleCI implements circleci.Compiler.
+//
+// Images are pushed on tags only, so SBOM is generated on tags as well.
+func (sbom *SBOM) CompileCircleCI(output *circleci.Output) error {
+	images := sbom.images()
+	if len(images) == 0 {
+		return nil
+	}
+
+	output.Job(circleci.MakeJob(sbom.Name()).
+		OnlyOnTag().
+		DockerLogin().
+		Requires(sbom.ciDependencies(images, dag.Implements((*circleci.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+//
+// Images are pushed on tags only, so SBOM is generated on tags as well.
+func (sbom *SBOM) CompileBuildkite(output *buildkite.Output) error {
+	images := sbom.images()
+	if len(images) == 0 {
+		return nil
+	}
+
+	output.Step(buildkite.MakeStep(sbom.Name()).
+		OnlyOnTag().
+		DockerLogin().
+		ArtifactPaths(sbom.artifacts(images)...).
+		DependsOn(sbom.ciDependencies(images, dag.Implements((*buildkite.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+//
+// Images are pushed on tags only, so SBOM is generated on tags as well.
+func (sbom *SBOM) CompileAzurePipelines(output *azurepipelines.Output) error {
+	images := sbom.images()
+	if len(images) == 0 {
+		return nil
+	}
+
+	output.Job(azurepipelines.MakeJob(sbom.Name()).
+		OnlyOnTag().
+		DockerLogin().
+		DependsOn(sbom.ciDependencies(images, dag.Implements((*azurepipelines.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileTekton implements tekton.Compiler.
+//
+// Tekton tasks push images with the executor, and the pipeline doesn't pass registry credentials to make.
+func (sbom *SBOM) CompileTekton(output *tekton.Output) error {
+	if len(sbom.images()) > 0 {
+		sbom.meta.Warn("SBOM generation is not supported by Tekton, the step is skipped")
+	}
+
+	return nil
+}
+
+// artifacts returns paths of the generated SBOM files.
+func (sbom *SBOM) artifacts(images []*Image) []string {
+	artifacts := make([]string, 0, len(images))
+
+	for _, image := range images {
+		// $(TAG) is only known to make
+		artifacts = append(artifacts, filepath.Join(sbom.meta.ArtifactsPath, strings.ReplaceAll(sbom.filename(image), "$(TAG)", "*")))
+	}
+
+	return artifacts
+}
+
+// images returns the list of input images with SBOM enabled.
+func (sbom *SBOM) images() []*Image {
+	var images []*Image
+
+	for _, input := range sbom.Inputs() {
+		if image, ok := input.(*Image); ok && image.SBOM {
+			images = append(images, image)
+		}
+	}
+
+	return images
+}
+
+// ciDependencies returns names of the CI steps matching the condition SBOM depends on.
+//
+// SBOM is built from the registry, so images with SBOM enabled are replaced with the steps pushing the images.
+func (sbom *SBOM) ciDependencies(images []*Image, condition dag.NodeCondition) []string {
+	depends := dag.GatherMatchingInputNames(sbom, func(node dag.Node) bool {
+		if image, ok := node.(*Image); ok && image.SBOM {
+			return false
+		}
+
+		return condition(node)
+	})
+
+	for _, image := range images {
+		depends = append(depends, image.pushStep())
+	}
+
+	return depends
+}
+
+func (sbom *SBOM) args(image *Image) (string, error) {
+	tmpl, err := template.New(sbom.Name()).Parse(sbom.Args)
+	if err != nil {
+		return "", fmt.Errorf("error parsing SBOM args template: %w", err)
+	}
+
+	var buf bytes.Buffer
+
+	if err = tmpl.Execute(&buf, struct {
+		Image  string
+		Format string
+	}{
+		Image:  fmt.Sprintf("$(REGISTRY)/$(USERNAME)/%s:$(TAG)", image.ImageName),
+		Format: sbom.Format,
+	}); err != nil {
+		return "", fmt.Errorf("error rendering SBOM args template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+func (sbom *SBOM) filename(image *Image) string {
+	return fmt.Sprintf("%s-$(TAG).%s", image.ImageName, strings.ReplaceAll(sbom.Format, "-", "."))
+}
@@ -0,0 +1,32 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
+	"github.com/talos-systems/kres/internal/project/common"
+)
+
+func TestSBOMInterfaces(t *testing.T) {
+	assert.Implements(t, (*makefile.Compiler)(nil), new(common.SBOM))
+	assert.Implements(t, (*drone.Compiler)(nil), new(common.SBOM))
+	assert.Implements(t, (*circleci.Compiler)(nil), new(common.SBOM))
+	assert.Implements(t, (*buildkite.Compiler)(nil), new(common.SBOM))
+	assert.Implements(t, (*azurepipelines.Compiler)(nil), new(common.SBOM))
+	assert.Implements(t, (*tekton.Compiler)(nil), new(common.SBOM))
+	assert.Implements(t, (*gitlab.Compiler)(nil), new(common.SBOM))
+	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(common.SBOM))
+}
@@ -0,0 +1,210 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Scan runs trivy vulnerability scanner either against the image or the source tree.
+//
+// Scan fails if vulnerabilities of the specified severity are found.
+type Scan struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	image *Image
+
+	Enabled    bool     `yaml:"enabled"`
+	Severity   []string `yaml:"severity"`
+	IgnoreFile string   `yaml:"ignoreFile"`
+	Trivy      string   `yaml:"trivy"`
+}
+
+func newScan(meta *meta.Options, name string) *Scan {
+	return &Scan{
+		BaseNode: dag.NewBaseNode(name),
+
+		meta: meta,
+
+		Severity: []string{"HIGH", "CRITICAL"},
+		Trivy:    "docker.io/aquasec/trivy:0.16.0",
+	}
+}
+
+// NewImageScan initializes Scan of the image.
+//
+// When enabled, image is not pushed until scan succeeds.
+func NewImageScan(meta *meta.Options, image *Image) *Scan {
+	scan := newScan(meta, "scan-"+image.ImageName)
+	scan.image = image
+	scan.AddInput(image)
+
+	image.scans = append(image.scans, scan)
+
+	return scan
+}
+
+// NewFilesystemScan initializes Scan of the source tree (e.g. Go modules).
+func NewFilesystemScan(meta *meta.Options, toolchain dag.Node) *Scan {
+	scan := newScan(meta, "scan-fs")
+	scan.AddInput(toolchain)
+
+	return scan
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (scan *Scan) CompileMakefile(output *makefile.Output) error {
+	if !scan.Enabled {
+		return nil
+	}
+
+	output.VariableGroup(makefile.VariableGroupDocker).
+		Variable(makefile.OverridableVariable("TRIVY", scan.Trivy))
+
+	args := []string{"--exit-code", "1", "--severity", strings.Join(scan.Severity, ",")}
+
+	if scan.IgnoreFile != "" {
+		args = append(args, "--ignorefile", scan.IgnoreFile)
+	}
+
+	trivy := "@docker run --rm -v $(PWD):/src -w /src $(TRIVY)"
+
+	target := output.Target(scan.Name()).
+		Phony()
+
+	if scan.image == nil {
+		target.
+			Description("Scans the source tree for vulnerabilities.").
+			Script(fmt.Sprintf("%s fs %s .", trivy, strings.Join(args, " ")))
+
+		return nil
+	}
+
+	// image is exported for the first platform only, as docker archive can't hold multi-platform images,
+	// platforms might be overridden (or empty), so the platform is picked by make
+	archive := filepath.Join("$(ARTIFACTS)", scan.image.Name()+".tar")
+	platform := fmt.Sprintf("$(or $(firstword $(subst $(COMMA), ,$(%s))),linux/amd64)", scan.image.platformVariable())
+
+	target.
+		Description(fmt.Sprintf("Scans image for %s for vulnerabilities.", scan.image.ImageName)).
+		Script(
+			"@mkdir -p $(ARTIFACTS)",
+			fmt.Sprintf(`@$(MAKE) target-%s PLATFORM=%s TARGET_ARGS="--output=type=docker,dest=%s"`, scan.image.Name(), platform, archive),
+			fmt.Sprintf("%s image --input %s %s", trivy, archive, strings.Join(args, " ")),
+		)
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (scan *Scan) CompileDrone(output *drone.Output) error {
+	if !scan.Enabled {
+		return nil
+	}
+
+	output.Step(drone.MakeStep(scan.Name()).
+		DependsOn(dag.GatherMatchingInputNames(scan, dag.Implements((*drone.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (scan *Scan) CompileGitLab(output *gitlab.Output) error {
+	if !scan.Enabled {
+		return nil
+	}
+
+	output.Job(gitlab.MakeJob(scan.Name()).
+		Stage(scan.meta.GitLabStages.Test).
+		Needs(dag.GatherMatchingInputNames(scan, dag.Implements((*gitlab.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (scan *Scan) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	if !scan.Enabled {
+		return nil
+	}
+
+	output.Job(ghworkflow.MakeJob(scan.Name()).
+		Needs(dag.GatherMatchingInputNames(scan, dag.Implements((*ghworkflow.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileCircleCI implements circleci.Compiler.
+func (scan *Scan) CompileCircleCI(output *circleci.Output) error {
+	if !scan.Enabled {
+		return nil
+	}
+
+	output.Job(circleci.MakeJob(scan.Name()).
+		Requires(dag.GatherMatchingInputNames(scan, dag.Implements((*circleci.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+func (scan *Scan) CompileBuildkite(output *buildkite.Output) error {
+	if !scan.Enabled {
+		return nil
+	}
+
+	output.Step(buildkite.MakeStep(scan.Name()).
+		DependsOn(dag.GatherMatchingInputNames(scan, dag.Implements((*buildkite.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+func (scan *Scan) CompileAzurePipelines(output *azurepipelines.Output) error {
+	if !scan.Enabled {
+		return nil
+	}
+
+	output.Job(azurepipelines.MakeJob(scan.Name()).
+		DependsOn(dag.GatherMatchingInputNames(scan, dag.Implements((*azurepipelines.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileTekton implements tekton.Compiler.
+func (scan *Scan) CompileTekton(output *tekton.Output) error {
+	if !scan.Enabled {
+		return nil
+	}
+
+	output.Task(tekton.MakeTask(scan.Name()).
+		RunAfter(dag.GatherMatchingInputNames(scan, dag.Implements((*tekton.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (scan *Scan) SkipAsMakefileDependency() {
+}
@@ -0,0 +1,61 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
+	"github.com/talos-systems/kres/internal/project/common"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+func TestScanInterfaces(t *testing.T) {
+	assert.Implements(t, (*makefile.Compiler)(nil), new(common.Scan))
+	assert.Implements(t, (*drone.Compiler)(nil), new(common.Scan))
+	assert.Implements(t, (*circleci.Compiler)(nil), new(common.Scan))
+	assert.Implements(t, (*buildkite.Compiler)(nil), new(common.Scan))
+	assert.Implements(t, (*azurepipelines.Compiler)(nil), new(common.Scan))
+	assert.Implements(t, (*tekton.Compiler)(nil), new(common.Scan))
+	assert.Implements(t, (*gitlab.Compiler)(nil), new(common.Scan))
+	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(common.Scan))
+	assert.Implements(t, (*makefile.SkipAsMakefileDependency)(nil), new(common.Scan))
+}
+
+func TestImageScanPlatforms(t *testing.T) {
+	options := &meta.Options{}
+
+	image := common.NewImage(options, "kres")
+
+	// `platforms: []` in the config
+	image.Platforms = nil
+
+	scan := common.NewImageScan(options, image)
+	scan.Enabled = true
+
+	output := makefile.NewOutput()
+
+	require.NoError(t, image.CompileMakefile(output))
+	require.NoError(t, scan.CompileMakefile(output))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, output.GenerateFile("Makefile", &buf))
+
+	assert.Contains(t, buf.String(), "IMAGE_KRES_PLATFORM ?= linux/amd64\n")
+	assert.Contains(t, buf.String(),
+		`@$(MAKE) target-image-kres PLATFORM=$(or $(firstword $(subst $(COMMA), ,$(IMAGE_KRES_PLATFORM))),linux/amd64) TARGET_ARGS="--output=type=docker,dest=$(ARTIFACTS)/image-kres.tar"`)
+}
@@ -0,0 +1,82 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/security"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Security provides SECURITY.md with the vulnerability reporting policy and CodeQL analysis workflow.
+//
+// SECURITY.md is generated if the security contact is set, CodeQL workflow is generated along with
+// GitHub Actions workflow for the languages of the detected projects.
+type Security struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// DisclosureDays is the maximum number of days before the vulnerability details are disclosed.
+	DisclosureDays int `yaml:"disclosureDays"`
+	// SupportedVersions describes the versions security fixes are provided for.
+	SupportedVersions string `yaml:"supportedVersions"`
+	// CodeQL enables CodeQL analysis workflow (if GitHub Actions workflow is generated).
+	CodeQL bool `yaml:"codeql"`
+}
+
+// NewSecurity initializes Security.
+func NewSecurity(meta *meta.Options) *Security {
+	return &Security{
+		BaseNode: dag.NewBaseNode("security"),
+
+		meta: meta,
+
+		DisclosureDays: 90,
+		CodeQL:         true,
+	}
+}
+
+// CompileSecurity implements security.Compiler.
+func (s *Security) CompileSecurity(output *security.Output) error {
+	if s.meta.SecurityContact != "" {
+		output.Enable()
+		output.Policy(s.meta.SecurityContact, s.DisclosureDays, s.SupportedVersions)
+
+		if s.meta.SecurityOverwrite {
+			output.Overwrite()
+		}
+	}
+
+	if !s.CodeQL || !s.meta.GitHubActions {
+		return nil
+	}
+
+	var languages, paths []string
+
+	if len(s.meta.GoModules) > 0 {
+		languages = append(languages, security.LanguageGo)
+		paths = append(paths, s.meta.GoDirectories...)
+	}
+
+	if s.meta.JSPackageManager != "" {
+		languages = append(languages, security.LanguageJavaScript)
+		paths = append(paths, s.meta.JSDirectories...)
+	}
+
+	if s.meta.PythonPackageManager != "" {
+		languages = append(languages, security.LanguagePython)
+		paths = append(paths, s.meta.PythonDirectories...)
+	}
+
+	// sources at the root of the project can't be covered by the directory paths
+	if len(s.meta.GoSourceFiles) > 0 {
+		paths = nil
+	}
+
+	output.CodeQL(languages, paths)
+
+	return nil
+}
@@ -0,0 +1,289 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// CosignKeyless is a special key reference to sign with keyless OIDC flow.
+const CosignKeyless = "keyless"
+
+// Sign signs images with cosign on releases.
+type Sign struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Image is cosign container image.
+	Image string `yaml:"image"`
+}
+
+// NewSign initializes Sign.
+func NewSign(meta *meta.Options) *Sign {
+	return &Sign{
+		BaseNode: dag.NewBaseNode("sign"),
+
+		meta: meta,
+
+		Image: "gcr.io/projectsigstore/cosign:v1.2.1",
+	}
+}
+
+// cosignKMSEnvironment are the environment variables with the credentials of the KMS providers,
+// the first one is required to sign images.
+var cosignKMSEnvironment = map[string][]string{
+	"awskms":     {"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_REGION"},
+	"azurekms":   {"AZURE_CLIENT_SECRET", "AZURE_CLIENT_ID", "AZURE_TENANT_ID"},
+	"gcpkms":     {"GOOGLE_APPLICATION_CREDENTIALS"},
+	"hashivault": {"VAULT_TOKEN", "VAULT_ADDR"},
+}
+
+// kmsEnvironment returns the environment variables with the KMS credentials of the key reference.
+func (sign *Sign) kmsEnvironment() ([]string, error) {
+	scheme := strings.SplitN(sign.meta.CosignKey, "://", 2)[0]
+
+	env, ok := cosignKMSEnvironment[scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported cosign KMS %q", scheme)
+	}
+
+	return env, nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (sign *Sign) CompileMakefile(output *makefile.Output) error {
+	images := sign.images()
+
+	var (
+		args  string
+		check string
+		skip  string
+	)
+
+	env := []string{"COSIGN_EXPERIMENTAL", "COSIGN_PASSWORD"}
+	volumes := []string{"$(HOME)/.docker:/root/.docker:ro", "$(PWD):/src"}
+
+	switch {
+	case sign.meta.CosignKey == CosignKeyless:
+		check = `[ -n "$${COSIGN_EXPERIMENTAL}" ]`
+		skip = "COSIGN_EXPERIMENTAL is not set"
+	case strings.Contains(sign.meta.CosignKey, "://"):
+		kmsEnv, err := sign.kmsEnvironment()
+		if err != nil {
+			return err
+		}
+
+		env = append(env, kmsEnv...)
+
+		// credentials file is mounted at the same path, so that the variable is valid in the container
+		if kmsEnv[0] == "GOOGLE_APPLICATION_CREDENTIALS" {
+			volumes = append(volumes, "$(GOOGLE_APPLICATION_CREDENTIALS):$(GOOGLE_APPLICATION_CREDENTIALS):ro")
+		}
+
+		args = "--key $(COSIGN_KEY)"
+		check = fmt.Sprintf(`[ -n "$${%s}" ]`, kmsEnv[0])
+		skip = kmsEnv[0] + " is not set"
+	default:
+		// key might be outside of the current directory, so its directory is mounted
+		volumes = append(volumes, "$(abspath $(dir $(COSIGN_KEY))):/cosign:ro")
+
+		args = "--key /cosign/$(notdir $(COSIGN_KEY))"
+		check = `[ -f "$(COSIGN_KEY)" ]`
+		skip = "$(COSIGN_KEY) is not found"
+	}
+
+	flags := make([]string, 0, len(volumes)+len(env))
+
+	for _, volume := range volumes {
+		flags = append(flags, "-v "+volume)
+	}
+
+	flags = append(flags, "-w /src")
+
+	for _, name := range env {
+		flags = append(flags, "-e "+name)
+	}
+
+	output.VariableGroup(makefile.VariableGroupDocker).
+		Variable(makefile.OverridableVariable("COSIGN_KEY", sign.meta.CosignKey)).
+		Variable(makefile.OverridableVariable("COSIGN",
+			fmt.Sprintf("docker run --rm %s %s", strings.Join(flags, " "), sign.Image)))
+
+	commands := make([]string, 0, len(images))
+
+	for _, image := range images {
+		commands = append(commands, strings.Join(strings.Fields(fmt.Sprintf("$(COSIGN) sign %s $(REGISTRY)/$(USERNAME)/%s:$(TAG)", args, image.ImageName)), " "))
+	}
+
+	script := strings.Join(commands, " && ")
+
+	// don't block developers who don't have signing credentials
+	script = fmt.Sprintf(`if %s; then %s; else echo "%s, skipping image signing"; fi`, check, script, skip)
+
+	output.Target(sign.Name()).
+		Description("Signs images with cosign.").
+		Script("@" + script).
+		Phony()
+
+	return nil
+}
+
+// ciCredentials passes the signing credentials to the CI step.
+//
+// environment sets the variable, secret passes the CI secret (named as in Drone) as the variable,
+// and writeKey writes the private key file from the COSIGN_PRIVATE_KEY variable.
+func (sign *Sign) ciCredentials(environment, secret func(name, value string), writeKey func(path string)) error {
+	switch {
+	case sign.meta.CosignKey == CosignKeyless:
+		environment("COSIGN_EXPERIMENTAL", "1")
+	case strings.Contains(sign.meta.CosignKey, "://"):
+		kmsEnv, err := sign.kmsEnvironment()
+		if err != nil {
+			return err
+		}
+
+		// KMS credentials are provided via CI secrets
+		for _, name := range kmsEnv {
+			secret(name, strings.ToLower(name))
+		}
+	default:
+		secret("COSIGN_PASSWORD", "cosign_password")
+		secret("COSIGN_PRIVATE_KEY", "cosign_private_key")
+		writeKey(sign.meta.CosignKey)
+	}
+
+	return nil
+}
+
+// pushSteps returns the names of the CI steps pushing the images.
+func (sign *Sign) pushSteps() []string {
+	images := sign.images()
+
+	depends := make([]string, 0, len(images))
+
+	for _, image := range images {
+		depends = append(depends, image.pushStep())
+	}
+
+	return depends
+}
+
+// CompileDrone implements drone.Compiler.
+func (sign *Sign) CompileDrone(output *drone.Output) error {
+	step := drone.MakeStep(sign.Name()).
+		OnlyOnTag().
+		DockerLogin().
+		DependsOn(sign.pushSteps()...)
+
+	if err := sign.ciCredentials(
+		func(name, value string) { step.Environment(name, value) },
+		func(name, secret string) { step.EnvironmentFromSecret(name, secret) },
+		func(path string) { step.BeforeCommands(`printf '%s\n' "$${COSIGN_PRIVATE_KEY}" > ` + path) },
+	); err != nil {
+		return err
+	}
+
+	output.Step(step)
+
+	return nil
+}
+
+// CompileCircleCI implements circleci.Compiler.
+//
+// Secrets should be set as environment variables of the project (or context), named in upper case.
+func (sign *Sign) CompileCircleCI(output *circleci.Output) error {
+	job := circleci.MakeJob(sign.Name()).
+		OnlyOnTag().
+		DockerLogin().
+		Requires(sign.pushSteps()...)
+
+	if err := sign.ciCredentials(
+		func(name, value string) { job.Environment(name, value) },
+		func(name, secret string) { job.EnvironmentFromSecret(name, strings.ToUpper(secret)) },
+		func(path string) { job.BeforeCommands(`printf '%s\n' "${COSIGN_PRIVATE_KEY}" > ` + path) },
+	); err != nil {
+		return err
+	}
+
+	output.Job(job)
+
+	return nil
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+//
+// Secrets should be set in the agent environment, named in upper case.
+func (sign *Sign) CompileBuildkite(output *buildkite.Output) error {
+	step := buildkite.MakeStep(sign.Name()).
+		OnlyOnTag().
+		DockerLogin().
+		DependsOn(sign.pushSteps()...)
+
+	if err := sign.ciCredentials(
+		func(name, value string) { step.Environment(name, value) },
+		func(name, secret string) { step.EnvironmentFromVariable(name, strings.ToUpper(secret)) },
+		func(path string) { step.BeforeCommands(`printf '%s\n' "$${COSIGN_PRIVATE_KEY}" > ` + path) },
+	); err != nil {
+		return err
+	}
+
+	output.Step(step)
+
+	return nil
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+//
+// Secrets should be set as secret variables of the pipeline, named in upper case.
+func (sign *Sign) CompileAzurePipelines(output *azurepipelines.Output) error {
+	job := azurepipelines.MakeJob(sign.Name()).
+		OnlyOnTag().
+		DockerLogin().
+		DependsOn(sign.pushSteps()...)
+
+	if err := sign.ciCredentials(
+		func(name, value string) { job.Environment(name, value) },
+		func(name, secret string) { job.EnvironmentFromSecret(name, strings.ToUpper(secret)) },
+		func(path string) { job.BeforeCommands(`printf '%s\n' "${COSIGN_PRIVATE_KEY}" > ` + path) },
+	); err != nil {
+		return err
+	}
+
+	output.Job(job)
+
+	return nil
+}
+
+// CompileTekton implements tekton.Compiler.
+//
+// Tekton pipeline runs don't distinguish tags, and images are pushed by the executor, so signing is skipped.
+func (sign *Sign) CompileTekton(output *tekton.Output) error {
+	sign.meta.Warn("image signing is not supported by Tekton, the step is skipped")
+
+	return nil
+}
+
+func (sign *Sign) images() []*Image {
+	var images []*Image
+
+	for _, input := range sign.Inputs() {
+		if image, ok := input.(*Image); ok {
+			images = append(images, image)
+		}
+	}
+
+	return images
+}
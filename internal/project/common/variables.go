@@ -0,0 +1,127 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"sort"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Variables injects meta.ExtraVariables into the Makefile and CI configuration.
+type Variables struct {
+	dag.BaseNode
+
+	meta *meta.Options
+}
+
+// NewVariables initializes Variables.
+func NewVariables(meta *meta.Options) *Variables {
+	return &Variables{
+		BaseNode: dag.NewBaseNode("variables"),
+
+		meta: meta,
+	}
+}
+
+// names returns sorted variable names, so that the output is stable.
+func (variables *Variables) names() []string {
+	names := make([]string, 0, len(variables.meta.ExtraVariables))
+
+	for name := range variables.meta.ExtraVariables {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (variables *Variables) CompileMakefile(output *makefile.Output) error {
+	if len(variables.meta.ExtraVariables) == 0 {
+		return nil
+	}
+
+	group := output.VariableGroup(makefile.VariableGroupExtra)
+
+	for _, name := range variables.names() {
+		group.Variable(makefile.OverridableVariable(name, variables.meta.ExtraVariables[name]).Export())
+	}
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (variables *Variables) CompileDrone(output *drone.Output) error {
+	for _, name := range variables.names() {
+		output.Environment(name, variables.meta.ExtraVariables[name])
+	}
+
+	return nil
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+func (variables *Variables) CompileAzurePipelines(output *azurepipelines.Output) error {
+	for _, name := range variables.names() {
+		output.Environment(name, variables.meta.ExtraVariables[name])
+	}
+
+	return nil
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+func (variables *Variables) CompileBuildkite(output *buildkite.Output) error {
+	for _, name := range variables.names() {
+		output.Environment(name, variables.meta.ExtraVariables[name])
+	}
+
+	return nil
+}
+
+// CompileCircleCI implements circleci.Compiler.
+func (variables *Variables) CompileCircleCI(output *circleci.Output) error {
+	for _, name := range variables.names() {
+		output.Environment(name, variables.meta.ExtraVariables[name])
+	}
+
+	return nil
+}
+
+// CompileTekton implements tekton.Compiler.
+func (variables *Variables) CompileTekton(output *tekton.Output) error {
+	for _, name := range variables.names() {
+		output.Environment(name, variables.meta.ExtraVariables[name])
+	}
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (variables *Variables) CompileGitLab(output *gitlab.Output) error {
+	for _, name := range variables.names() {
+		output.Variable(name, variables.meta.ExtraVariables[name])
+	}
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (variables *Variables) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	for _, name := range variables.names() {
+		output.Environment(name, variables.meta.ExtraVariables[name])
+	}
+
+	return nil
+}
@@ -0,0 +1,285 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package custom provides nodes of the types registered via the plugin registry.
+package custom
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
+	"github.com/talos-systems/kres/internal/project/meta"
+	"github.com/talos-systems/kres/pkg/plugin"
+)
+
+// Node adapts plugin.Node to the project.
+type Node struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	node plugin.Node
+
+	// Type is the registered type of the node.
+	Type string `yaml:"type"`
+	// Params are passed to the node factory.
+	Params yaml.Node `yaml:"params"`
+}
+
+// NewNode initializes Node.
+func NewNode(meta *meta.Options, node plugin.Node) *Node {
+	return &Node{
+		BaseNode: dag.NewBaseNode(node.Name()),
+
+		meta: meta,
+
+		node: node,
+	}
+}
+
+// Nodes instantiates custom nodes declared in the config.
+func Nodes(meta *meta.Options) ([]dag.Node, error) {
+	var nodes []dag.Node
+
+	for _, doc := range meta.Config.Documents("custom.Node") {
+		if doc.Name == "" {
+			return nil, fmt.Errorf("custom node requires a name")
+		}
+
+		// type and params are decoded into the node once it's created
+		var spec Node
+
+		if err := doc.Spec.Decode(&spec); err != nil {
+			return nil, fmt.Errorf("error decoding custom node %q: %w", doc.Name, err)
+		}
+
+		factory, ok := plugin.Lookup(spec.Type)
+		if !ok {
+			return nil, fmt.Errorf("custom node %q has unknown type %q (registered types: %s)", doc.Name, spec.Type, strings.Join(plugin.Kinds(), ", "))
+		}
+
+		node, err := factory(doc.Name, &spec.Params)
+		if err != nil {
+			return nil, fmt.Errorf("error creating custom node %q: %w", doc.Name, err)
+		}
+
+		nodes = append(nodes, NewNode(meta, node))
+	}
+
+	return nodes, nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (custom *Node) CompileMakefile(output *makefile.Output) error {
+	for _, target := range custom.node.Targets() {
+		t := output.Target(target.Name).
+			Description(target.Description).
+			Depends(target.Depends...).
+			Script(target.Script...)
+
+		if target.Phony {
+			t.Phony()
+		}
+	}
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (custom *Node) CompileDrone(output *drone.Output) error {
+	for _, s := range custom.node.Steps() {
+		step := drone.MakeStep(s.Target, s.Args...).
+			Name(stepName(s)).
+			DependsOn(s.DependsOn...)
+
+		for name, secret := range s.Secrets {
+			step.EnvironmentFromSecret(name, secret)
+		}
+
+		if s.OnlyOnTag {
+			step.OnlyOnTag()
+		}
+
+		if s.ExceptPullRequest {
+			step.ExceptPullRequest()
+		}
+
+		output.Step(step)
+	}
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (custom *Node) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	for _, s := range custom.node.Steps() {
+		job := ghworkflow.MakeJob(s.Target, s.Args...).
+			Name(stepName(s)).
+			Needs(s.DependsOn...)
+
+		for name, secret := range s.Secrets {
+			job.EnvironmentFromSecret(name, secret)
+		}
+
+		if s.OnlyOnTag {
+			job.OnlyOnTag()
+		}
+
+		if s.ExceptPullRequest {
+			job.ExceptPullRequest()
+		}
+
+		output.Job(job)
+	}
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+//
+// Secrets should be set as CI/CD variables of the project.
+func (custom *Node) CompileGitLab(output *gitlab.Output) error {
+	for _, s := range custom.node.Steps() {
+		job := gitlab.MakeJob(s.Target, s.Args...).
+			Name(stepName(s)).
+			Stage(custom.meta.GitLabStages.Build).
+			Needs(s.DependsOn...)
+
+		if s.OnlyOnTag {
+			job.OnlyOnTag()
+		}
+
+		if s.ExceptPullRequest {
+			job.ExceptMergeRequest()
+		}
+
+		output.Job(job)
+	}
+
+	return nil
+}
+
+// CompileCircleCI implements circleci.Compiler.
+//
+// Secrets should be set as environment variables of the project (or context).
+func (custom *Node) CompileCircleCI(output *circleci.Output) error {
+	for _, s := range custom.node.Steps() {
+		job := circleci.MakeJob(s.Target, s.Args...).
+			Name(stepName(s)).
+			Requires(s.DependsOn...)
+
+		for name, secret := range s.Secrets {
+			job.EnvironmentFromSecret(name, secret)
+		}
+
+		if s.OnlyOnTag {
+			job.OnlyOnTag()
+		}
+
+		// workflows don't distinguish pull requests, so the step runs on the default branch only
+		if s.ExceptPullRequest {
+			job.OnlyOnBranch(output.DefaultBranch)
+		}
+
+		output.Job(job)
+	}
+
+	return nil
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+//
+// Secrets should be set in the agent environment.
+func (custom *Node) CompileBuildkite(output *buildkite.Output) error {
+	for _, s := range custom.node.Steps() {
+		step := buildkite.MakeStep(s.Target, s.Args...).
+			Name(stepName(s)).
+			DependsOn(s.DependsOn...)
+
+		for name, secret := range s.Secrets {
+			step.EnvironmentFromVariable(name, secret)
+		}
+
+		if s.OnlyOnTag {
+			step.OnlyOnTag()
+		}
+
+		if s.ExceptPullRequest {
+			step.OnlyOnBranch(output.DefaultBranch)
+		}
+
+		output.Step(step)
+	}
+
+	return nil
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+//
+// Secrets should be set as secret variables of the pipeline.
+func (custom *Node) CompileAzurePipelines(output *azurepipelines.Output) error {
+	for _, s := range custom.node.Steps() {
+		job := azurepipelines.MakeJob(s.Target, s.Args...).
+			Name(stepName(s)).
+			DependsOn(s.DependsOn...)
+
+		for name, secret := range s.Secrets {
+			job.EnvironmentFromSecret(name, secret)
+		}
+
+		if s.OnlyOnTag {
+			job.OnlyOnTag()
+		}
+
+		if s.ExceptPullRequest {
+			job.OnlyOnBranch(output.DefaultBranch)
+		}
+
+		output.Job(job)
+	}
+
+	return nil
+}
+
+// CompileTekton implements tekton.Compiler.
+//
+// Pipeline runs are triggered externally and don't have conditions, so the tag-only steps are skipped.
+func (custom *Node) CompileTekton(output *tekton.Output) error {
+	for _, s := range custom.node.Steps() {
+		if s.OnlyOnTag {
+			custom.meta.Warn("custom node %q: step %q runs only on tags, which is not supported by Tekton, the step is skipped", custom.Name(), stepName(s))
+
+			continue
+		}
+
+		if len(s.Secrets) > 0 {
+			custom.meta.Warn("custom node %q: secrets of step %q are not passed by Tekton, they should be set in the pipeline environment", custom.Name(), stepName(s))
+		}
+
+		output.Task(tekton.MakeTask(s.Target, s.Args...).
+			Name(stepName(s)).
+			RunAfter(s.DependsOn...))
+	}
+
+	return nil
+}
+
+func stepName(step plugin.Step) string {
+	if step.Name != "" {
+		return step.Name
+	}
+
+	return step.Target
+}
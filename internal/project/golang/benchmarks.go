@@ -0,0 +1,136 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Benchmarks runs Go benchmarks.
+//
+// Benchmark results can be compared against the baseline branch with benchstat.
+type Benchmarks struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Enabled enables benchmarks in CI (benchmarks are slow, so they are opt-in).
+	Enabled bool `yaml:"enabled"`
+	// Compare enables comparison against the Baseline branch (on pull requests in CI).
+	Compare bool `yaml:"compare"`
+	// Baseline is the branch to compare benchmark results with.
+	Baseline string `yaml:"baseline"`
+	// Threshold is the maximum allowed regression (percent).
+	Threshold float64 `yaml:"threshold"`
+	// BenchstatVersion is the version of golang.org/x/perf/cmd/benchstat.
+	BenchstatVersion string `yaml:"benchstatVersion"`
+}
+
+// NewBenchmarks initializes Benchmarks.
+func NewBenchmarks(meta *meta.Options) *Benchmarks {
+	return &Benchmarks{
+		BaseNode: dag.NewBaseNode("benchmarks"),
+
+		meta: meta,
+
+		Baseline:         "master",
+		Threshold:        10,
+		BenchstatVersion: "latest",
+	}
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (bench *Benchmarks) CompileDockerfile(output *dockerfile.Output) error {
+	output.Stage("benchmarks-run").
+		Description("runs benchmarks").
+		From("base").
+		Step(withGoCache(bench.meta, step.Script(fmt.Sprintf(`go test -bench=. -benchmem -run='^$' -count 5 %s | tee /src/bench.txt`, strings.Join(directoryPackages(bench.meta), " ")))).
+			MountCache("/tmp"))
+
+	output.Stage("benchmarks").
+		From("scratch").
+		Step(step.Copy("/src/bench.txt", "/bench.txt").From("benchmarks-run"))
+
+	// benchstat is exported to compare the results outside of the build (baseline is built from the separate worktree)
+	output.Stage("benchstat-build").
+		Description("builds benchstat").
+		From("toolchain").
+		Step(withGoCache(bench.meta, step.Script(fmt.Sprintf(`cd $(mktemp -d) \
+	&& go mod init tmp \
+	&& go get golang.org/x/perf/cmd/benchstat@%s`, bench.BenchstatVersion)).
+			Env("GOBIN", "/bin").
+			Env("CGO_ENABLED", "0")))
+
+	output.Stage("benchstat").
+		From("scratch").
+		Step(step.Copy("/bin/benchstat", "/benchstat").From("benchstat-build"))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (bench *Benchmarks) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupCommon).
+		Variable(makefile.OverridableVariable("BENCH_BASELINE", bench.Baseline)).
+		Variable(makefile.OverridableVariable("BENCH_THRESHOLD", fmt.Sprintf("%g", bench.Threshold)))
+
+	output.Target("bench").
+		Description("Runs benchmarks, results are stored in $(ARTIFACTS)/bench.txt.").
+		Script("@$(MAKE) local-benchmarks DEST=$(ARTIFACTS)").
+		Phony()
+
+	// baseline is benchmarked in a separate worktree with the current Dockerfile,
+	// benchstat output is stored in $(ARTIFACTS)/bench-compare.txt, so that benchstat errors are not hidden by the pipe
+	output.Target("bench-compare").
+		Description("Compares benchmarks with $(BENCH_BASELINE), fails on regressions above $(BENCH_THRESHOLD)%.").
+		Depends("bench").
+		Script(
+			"@$(MAKE) local-benchstat DEST=$(ARTIFACTS)",
+			"@rm -rf $(ARTIFACTS)/bench-baseline-src $(ARTIFACTS)/bench-baseline",
+			"@git worktree add --force --detach $(ARTIFACTS)/bench-baseline-src $(BENCH_BASELINE)",
+			"@$(BUILD) --target=benchmarks $(COMMON_ARGS) --output=type=local,dest=$(ARTIFACTS)/bench-baseline $(CI_ARGS) $(ARTIFACTS)/bench-baseline-src; "+
+				"status=$$?; git worktree remove --force $(ARTIFACTS)/bench-baseline-src; exit $$status",
+			"@docker run --rm -v $(PWD)/$(ARTIFACTS):/bench -w /bench $(TOOLCHAIN) ./benchstat bench-baseline/bench.txt bench.txt > $(ARTIFACTS)/bench-compare.txt",
+			`@awk -v threshold=$(BENCH_THRESHOLD) '{ print } { for (i = 1; i <= NF; i++) if ($$i ~ /^\+[0-9.]+%$$/ && substr($$i, 2) + 0 > threshold) failed = 1 } END { exit failed }' $(ARTIFACTS)/bench-compare.txt`,
+		).
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (bench *Benchmarks) CompileDrone(output *drone.Output) error {
+	if !bench.Enabled {
+		return nil
+	}
+
+	output.Step(drone.MakeStep("bench").
+		Name(bench.Name()).
+		DependsOn(dag.GatherMatchingInputNames(bench, dag.Implements((*drone.Compiler)(nil)))...),
+	)
+
+	if bench.Compare {
+		output.Step(drone.MakeStep("bench-compare", "BENCH_BASELINE=FETCH_HEAD").
+			Name("benchmarks-compare").
+			BeforeCommands(fmt.Sprintf("git fetch origin %s", bench.Baseline)).
+			OnlyOnPullRequest().
+			DependsOn(bench.Name()),
+		)
+	}
+
+	return nil
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (bench *Benchmarks) SkipAsMakefileDependency() {
+}
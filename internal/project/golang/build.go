@@ -6,13 +6,23 @@ package golang
 
 import (
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
 	"github.com/talos-systems/kres/internal/output/dockerfile"
 	"github.com/talos-systems/kres/internal/output/dockerfile/step"
 	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/meta"
 )
 
@@ -22,25 +32,139 @@ type Build struct {
 
 	meta       *meta.Options
 	sourcePath string
+
+	// CGOEnabled builds the command with cgo, otherwise fully static binary is built.
+	CGOEnabled bool `yaml:"cgoEnabled"`
+
+	// LDFlags maps fully-qualified variable names to values injected with `-X`.
+	//
+	// Values are evaluated at generation time, except for references to build-time variables
+	// (${SHA}, ${TAG}, ${COMMIT}, ${BUILD_DATE} and ${BUILDER}), which are passed as build arguments.
+	// Only the build stages which reference a variable depend on it, so that build cache is kept
+	// for the rest (e.g. ${BUILD_DATE} invalidates the build stage on every build).
+	LDFlags map[string]string `yaml:"ldflags"`
+
+	// Description is shown for the target in `make help`, defaults to `Builds executable for <name>.`
+	Description string `yaml:"description"`
+
+	// OutputName is the name of the compiled binary, defaults to the name of the command.
+	OutputName string `yaml:"outputName"`
+
+	// InstallPath is the directory the binary is placed into (in the image and in the artifacts), defaults to `/`.
+	InstallPath string `yaml:"installPath"`
+
+	// Targets are the platforms loose binaries are cross-compiled for, e.g. to be attached to the releases.
+	//
+	// Binaries are named `<name>-<os>-<arch>`, the binary of the image platform is built regardless.
+	Targets []Platform `yaml:"targets"`
+}
+
+// Platform is a target of the cross-compiled binary.
+type Platform struct {
+	OS   string `yaml:"os"`
+	Arch string `yaml:"arch"`
+	// CGOEnabled builds the binary with cgo, which requires a C cross-compiler for the target in the toolchain.
+	CGOEnabled bool `yaml:"cgoEnabled"`
 }
 
+// suffix returns the suffix of the binary name for the platform.
+func (platform Platform) suffix() string {
+	return fmt.Sprintf("-%s-%s", platform.OS, platform.Arch)
+}
+
+// buildTimeVariables can be referenced in LDFlags values.
+var buildTimeVariables = []string{"SHA", "TAG", "COMMIT", "BUILD_DATE", "BUILDER"}
+
 // NewBuild initializes Build.
 func NewBuild(meta *meta.Options, name, sourcePath string) *Build {
 	return &Build{
 		BaseNode:   dag.NewBaseNode(name),
 		meta:       meta,
 		sourcePath: sourcePath,
+
+		OutputName:  name,
+		InstallPath: "/",
 	}
 }
 
+// ExecutablePath implements common.Executable.
+func (build *Build) ExecutablePath() string {
+	return path.Join("/", build.InstallPath, build.OutputName)
+}
+
 // CompileDockerfile implements dockerfile.Compiler.
 func (build *Build) CompileDockerfile(output *dockerfile.Output) error {
+	// base stage carries the sources along with the assets embedded with `//go:embed`
 	stage := output.Stage(fmt.Sprintf("%s-build", build.Name())).
 		Description(fmt.Sprintf("builds %s", build.Name())).
-		From("base").
-		Step(step.WorkDir(filepath.Join("/src", build.sourcePath)))
+		From("base")
+
+	build.compileBuildEnv(stage)
+
+	stage.Step(build.buildStep(build.buildScript("", "/"+build.OutputName)))
+
+	output.Stage(build.Name()).
+		From("scratch").
+		Step(step.Copy("/"+build.OutputName, build.ExecutablePath()).From(fmt.Sprintf("%s-build", build.Name())))
+
+	return build.compileTargets(output)
+}
+
+// compileTargets cross-compiles the binaries for the targets.
+//
+// Target platforms are fixed, so the build doesn't depend on the platform of the image build.
+func (build *Build) compileTargets(output *dockerfile.Output) error {
+	for _, target := range build.Targets {
+		if target.OS == "" || target.Arch == "" {
+			return fmt.Errorf("%s: both os and arch of the target should be set", build.Name())
+		}
+
+		name := build.Name() + target.suffix()
+		binary := build.targetBinary(target)
+
+		stage := output.Stage(fmt.Sprintf("%s-build", name)).
+			Description(fmt.Sprintf("builds %s for %s/%s", build.Name(), target.OS, target.Arch)).
+			From("base")
+
+		build.compileBuildEnv(stage)
+
+		cgoEnabled := "0"
+		if target.CGOEnabled {
+			cgoEnabled = "1"
+		}
+
+		stage.Step(withGoCache(build.meta, step.Script(build.buildScript("", "/"+binary))).
+			Env("CGO_ENABLED", cgoEnabled).
+			Env("GOARCH", target.Arch).
+			Env("GOOS", target.OS))
+
+		output.Stage(name).
+			From("scratch").
+			Step(step.Copy("/"+binary, path.Join("/", build.InstallPath, binary)).From(fmt.Sprintf("%s-build", name)))
+	}
+
+	return nil
+}
+
+// targetBinary returns the name of the binary built for the target.
+func (build *Build) targetBinary(target Platform) string {
+	binary := build.OutputName + target.suffix()
+
+	if target.OS == "windows" {
+		binary += ".exe"
+	}
+
+	return binary
+}
+
+// compileBuildEnv declares build arguments of the build in the stage.
+func (build *Build) compileBuildEnv(stage *dockerfile.Stage) {
+	stage.
+		Step(step.WorkDir(filepath.Join("/src", build.sourcePath))).
+		Step(step.Arg("TARGETARCH")).
+		Step(step.Arg("TARGETOS"))
 
-	ldflags := "-s -w"
+	declared := map[string]bool{}
 
 	if build.meta.VersionPackage != "" {
 		stage.
@@ -48,18 +172,139 @@ func (build *Build) CompileDockerfile(output *dockerfile.Output) error {
 			Step(step.Arg("SHA")).
 			Step(step.Arg("TAG"))
 
-		ldflags += fmt.Sprintf(" -X ${VERSION_PKG}.Name=%s", build.Name())
-		ldflags += " -X ${VERSION_PKG}.SHA=${SHA} -X ${VERSION_PKG}.Tag=${TAG}"
+		declared["SHA"], declared["TAG"] = true, true
 	}
 
-	stage.Step(step.Script(fmt.Sprintf(`go build -ldflags "%s" -o /%s`, ldflags, build.Name())).
-		MountCache(filepath.Join(build.meta.CachePath, "go-build")))
+	for _, variable := range build.referencedVariables() {
+		if !declared[variable] {
+			stage.Step(step.Arg(variable))
+		}
+	}
 
-	output.Stage(build.Name()).
-		From("scratch").
-		Step(step.Copy("/"+build.Name(), "/"+build.Name()).From(fmt.Sprintf("%s-build", build.Name())))
+	if build.CGOEnabled {
+		// official toolchain already has C compiler, custom toolchains might not
+		stage.Step(step.Script(`command -v gcc >/dev/null || apk --update --no-cache add build-base`))
+	}
+}
 
-	return nil
+// buildScript returns `go build` command producing the binary at the output path.
+//
+// Paths and build ID are stripped from the binary, so that the build is reproducible.
+func (build *Build) buildScript(flags, outputPath string) string {
+	ldflags := strings.Join(build.ldflags("${VERSION_PKG}", func(variable string) string {
+		return "${" + variable + "}"
+	}), " ")
+
+	var tags string
+
+	if buildTags := build.tags(); len(buildTags) > 0 {
+		tags = fmt.Sprintf(" -tags %s", strings.Join(buildTags, ","))
+	}
+
+	return fmt.Sprintf(`go build%s%s -trimpath -ldflags "%s" -o %s`, flags, tags, ldflags, outputPath)
+}
+
+// buildStep runs the build script in the build environment.
+func (build *Build) buildStep(script string) *step.RunStep {
+	// toolchain runs on the build platform, so cross-compile for the target platform
+	return withGoCache(build.meta, step.Script(script)).
+		Env("CGO_ENABLED", build.cgoEnabled()).
+		Env("GOARCH", "${TARGETARCH}").
+		Env("GOOS", "${TARGETOS}")
+}
+
+// ldflags returns linker flags for the build, version info is injected if VersionPackage is set.
+//
+// Build-time variables are substituted with the result of the variable func.
+func (build *Build) ldflags(versionPkg string, variable func(string) string) []string {
+	ldflags := []string{"-s", "-w", "-buildid="}
+
+	if build.meta.VersionPackage != "" {
+		ldflags = append(ldflags,
+			fmt.Sprintf("-X %s.Name=%s", versionPkg, build.OutputName),
+			fmt.Sprintf("-X %s.SHA=%s", versionPkg, variable("SHA")),
+			fmt.Sprintf("-X %s.Tag=%s", versionPkg, variable("TAG")),
+		)
+	}
+
+	names := make([]string, 0, len(build.LDFlags))
+
+	for name := range build.LDFlags {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := expandBuildTimeVariables(build.LDFlags[name], variable)
+
+		// the linker splits flags on spaces, unless quoted
+		if strings.ContainsAny(value, " \t") {
+			ldflags = append(ldflags, fmt.Sprintf("-X '%s=%s'", name, value))
+		} else {
+			ldflags = append(ldflags, fmt.Sprintf("-X %s=%s", name, value))
+		}
+	}
+
+	if !build.CGOEnabled {
+		ldflags = append(ldflags, `-extldflags '-static'`)
+	}
+
+	return ldflags
+}
+
+// referencedVariables returns build-time variables referenced in LDFlags.
+func (build *Build) referencedVariables() []string {
+	referenced := map[string]bool{}
+
+	for _, value := range build.LDFlags {
+		expandBuildTimeVariables(value, func(variable string) string {
+			referenced[variable] = true
+
+			return ""
+		})
+	}
+
+	var result []string
+
+	for _, variable := range buildTimeVariables {
+		if referenced[variable] {
+			result = append(result, variable)
+		}
+	}
+
+	return result
+}
+
+// expandBuildTimeVariables replaces references to build-time variables, other references are kept as is.
+func expandBuildTimeVariables(value string, variable func(string) string) string {
+	return os.Expand(value, func(name string) string {
+		for _, known := range buildTimeVariables {
+			if name == known {
+				return variable(name)
+			}
+		}
+
+		return "${" + name + "}"
+	})
+}
+
+// tags returns build tags for the build.
+func (build *Build) tags() []string {
+	if build.CGOEnabled {
+		return nil
+	}
+
+	// pure Go implementations of net and os/user
+	return []string{"netgo", "osusergo"}
+}
+
+func (build *Build) cgoEnabled() string {
+	if build.CGOEnabled {
+		return "1"
+	}
+
+	return "0"
 }
 
 // CompileDrone implements drone.Compiler.
@@ -69,15 +314,98 @@ func (build *Build) CompileDrone(output *drone.Output) error {
 	return nil
 }
 
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (build *Build) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	output.Job(ghworkflow.MakeJob(build.Name()).
+		Needs(dag.GatherMatchingInputNames(build, dag.Implements((*ghworkflow.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (build *Build) CompileGitLab(output *gitlab.Output) error {
+	output.Job(gitlab.MakeJob(build.Name()).
+		Stage(build.meta.GitLabStages.Build).
+		Needs(dag.GatherMatchingInputNames(build, dag.Implements((*gitlab.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+func (build *Build) CompileAzurePipelines(output *azurepipelines.Output) error {
+	output.Job(azurepipelines.MakeJob(build.Name()).
+		DependsOn(dag.GatherMatchingInputNames(build, dag.Implements((*azurepipelines.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+func (build *Build) CompileBuildkite(output *buildkite.Output) error {
+	output.Step(buildkite.MakeStep(build.Name()).
+		DependsOn(dag.GatherMatchingInputNames(build, dag.Implements((*buildkite.Compiler)(nil)))...).
+		ArtifactPaths(filepath.Join(build.meta.ArtifactsPath, build.ExecutablePath())),
+	)
+
+	return nil
+}
+
+// CompileCircleCI implements circleci.Compiler.
+func (build *Build) CompileCircleCI(output *circleci.Output) error {
+	output.Job(circleci.MakeJob(build.Name()).
+		Requires(dag.GatherMatchingInputNames(build, dag.Implements((*circleci.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileTekton implements tekton.Compiler.
+func (build *Build) CompileTekton(output *tekton.Output) error {
+	output.Task(tekton.MakeTask(build.Name()).
+		RunAfter(dag.GatherMatchingInputNames(build, dag.Implements((*tekton.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
 // CompileMakefile implements makefile.Compiler.
 func (build *Build) CompileMakefile(output *makefile.Output) error {
-	output.Target(fmt.Sprintf("$(ARTIFACTS)/%s", build.Name())).
+	output.Target("$(ARTIFACTS)" + build.ExecutablePath()).
 		Script(fmt.Sprintf("@$(MAKE) local-%s DEST=$(ARTIFACTS)", build.Name())).
 		Phony()
 
+	description := build.Description
+	if description == "" {
+		description = fmt.Sprintf("Builds executable for %s.", build.Name())
+	}
+
 	output.Target(build.Name()).
-		Description(fmt.Sprintf("Builds executable for %s.", build.Name())).
-		Depends(fmt.Sprintf("$(ARTIFACTS)/%s", build.Name())).
+		Description(description).
+		Depends("$(ARTIFACTS)" + build.ExecutablePath()).
+		Phony()
+
+	if len(build.Targets) == 0 {
+		return nil
+	}
+
+	targets := make([]string, 0, len(build.Targets))
+
+	for _, target := range build.Targets {
+		name := build.Name() + target.suffix()
+
+		output.Target(name).
+			Description(fmt.Sprintf("Builds executable for %s (%s/%s).", build.Name(), target.OS, target.Arch)).
+			Script("@$(MAKE) local-$@ DEST=$(ARTIFACTS)").
+			Phony()
+
+		targets = append(targets, name)
+	}
+
+	output.Target(build.Name() + "-all").
+		Description(fmt.Sprintf("Builds executables for %s for all the targets.", build.Name())).
+		Depends(targets...).
 		Phony()
 
 	return nil
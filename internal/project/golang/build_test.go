@@ -9,9 +9,16 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
 	"github.com/talos-systems/kres/internal/output/dockerfile"
 	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
+	"github.com/talos-systems/kres/internal/project/common"
 	"github.com/talos-systems/kres/internal/project/golang"
 )
 
@@ -19,4 +26,11 @@ func TestBuildInterfaces(t *testing.T) {
 	assert.Implements(t, (*dockerfile.Compiler)(nil), new(golang.Build))
 	assert.Implements(t, (*makefile.Compiler)(nil), new(golang.Build))
 	assert.Implements(t, (*drone.Compiler)(nil), new(golang.Build))
+	assert.Implements(t, (*gitlab.Compiler)(nil), new(golang.Build))
+	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(golang.Build))
+	assert.Implements(t, (*tekton.Compiler)(nil), new(golang.Build))
+	assert.Implements(t, (*common.Executable)(nil), new(golang.Build))
+	assert.Implements(t, (*circleci.Compiler)(nil), new(golang.Build))
+	assert.Implements(t, (*buildkite.Compiler)(nil), new(golang.Build))
+	assert.Implements(t, (*azurepipelines.Compiler)(nil), new(golang.Build))
 }
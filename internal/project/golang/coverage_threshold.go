@@ -0,0 +1,224 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// CoverageThreshold fails the build if the unit-tests coverage is below the threshold.
+//
+// Coverage is computed from the coverage profile of the UnitTests input as the share of the covered statements,
+// so the check doesn't depend on any coverage service.
+type CoverageThreshold struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Threshold is the minimum total coverage (percentage), the check is disabled unless a threshold is set.
+	Threshold float64 `yaml:"threshold"`
+	// Packages are the minimum coverage of the specific packages (import paths, `./` refers to the project module).
+	Packages map[string]float64 `yaml:"packages"`
+}
+
+// NewCoverageThreshold initializes CoverageThreshold.
+func NewCoverageThreshold(meta *meta.Options) *CoverageThreshold {
+	return &CoverageThreshold{
+		BaseNode: dag.NewBaseNode("coverage-check"),
+
+		meta: meta,
+	}
+}
+
+func (check *CoverageThreshold) enabled() bool {
+	return check.Threshold > 0 || len(check.Packages) > 0
+}
+
+func (check *CoverageThreshold) unitTests() (*UnitTests, error) {
+	for _, input := range check.Inputs() {
+		if tests, ok := input.(*UnitTests); ok {
+			return tests, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s: unit-tests input is required", check.Name())
+}
+
+// coverageScript sums up the statements of the profile blocks per package (directory of the file).
+//
+// Profile lines are `file:start.col,end.col statements count`, the first line is the cover mode.
+const coverageScript = `BEGIN { n = split(packages, p, " "); for (i = 1; i <= n; i++) { split(p[i], kv, "="); min[kv[1]] = kv[2]; } } \
+	NR > 1 { split($1, loc, ":"); pkg = loc[1]; sub("/[^/]*$", "", pkg); stmts[pkg] += $2; all += $2; if ($3 > 0) { hit[pkg] += $2; allhit += $2; } } \
+	END { \
+		failed = 0; \
+		for (pkg in stmts) { pct = 100 * hit[pkg] / stmts[pkg]; printf "%s: %.1f%%\n", pkg, pct; if ((pkg in min) && pct < min[pkg]) { printf "coverage of %s %.1f%% is below %s%%\n", pkg, pct, min[pkg]; failed = 1; } } \
+		total = all ? 100 * allhit / all : 100; printf "total: %.1f%%\n", total; \
+		if (total < threshold) { printf "total coverage %.1f%% is below %s%%\n", total, threshold; failed = 1; } \
+		exit failed; \
+	}`
+
+// packageThresholds returns `import/path=threshold` pairs in a stable order.
+func (check *CoverageThreshold) packageThresholds() string {
+	pairs := make([]string, 0, len(check.Packages))
+
+	for pkg, threshold := range check.Packages {
+		if strings.HasPrefix(pkg, "./") {
+			pkg = path.Join(check.meta.CanonicalPath, pkg)
+		}
+
+		pairs = append(pairs, pkg+"="+strconv.FormatFloat(threshold, 'f', -1, 64))
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, " ")
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (check *CoverageThreshold) CompileDockerfile(output *dockerfile.Output) error {
+	if !check.enabled() {
+		return nil
+	}
+
+	tests, err := check.unitTests()
+	if err != nil {
+		return err
+	}
+
+	output.Stage(check.Name()).
+		Description("verifies unit-tests coverage is above the threshold").
+		From("unit-tests-run").
+		Step(step.Script(fmt.Sprintf("awk -v threshold=%s -v packages='%s' '%s' %s",
+			strconv.FormatFloat(check.Threshold, 'f', -1, 64), check.packageThresholds(), coverageScript, tests.coverProfile())))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (check *CoverageThreshold) CompileMakefile(output *makefile.Output) error {
+	if !check.enabled() {
+		return nil
+	}
+
+	output.Target(check.Name()).
+		Description("Verifies unit-tests coverage is above the threshold.").
+		Script("@$(MAKE) target-$@").
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (check *CoverageThreshold) CompileDrone(output *drone.Output) error {
+	if !check.enabled() {
+		return nil
+	}
+
+	output.Step(drone.MakeStep(check.Name()).
+		DependsOn(dag.GatherMatchingInputNames(check, dag.Implements((*drone.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (check *CoverageThreshold) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	if !check.enabled() {
+		return nil
+	}
+
+	output.Job(ghworkflow.MakeJob(check.Name()).
+		Needs(dag.GatherMatchingInputNames(check, dag.Implements((*ghworkflow.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (check *CoverageThreshold) CompileGitLab(output *gitlab.Output) error {
+	if !check.enabled() {
+		return nil
+	}
+
+	output.Job(gitlab.MakeJob(check.Name()).
+		Stage(check.meta.GitLabStages.Test).
+		Needs(dag.GatherMatchingInputNames(check, dag.Implements((*gitlab.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+func (check *CoverageThreshold) CompileAzurePipelines(output *azurepipelines.Output) error {
+	if !check.enabled() {
+		return nil
+	}
+
+	output.Job(azurepipelines.MakeJob(check.Name()).
+		DependsOn(dag.GatherMatchingInputNames(check, dag.Implements((*azurepipelines.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+func (check *CoverageThreshold) CompileBuildkite(output *buildkite.Output) error {
+	if !check.enabled() {
+		return nil
+	}
+
+	output.Step(buildkite.MakeStep(check.Name()).
+		DependsOn(dag.GatherMatchingInputNames(check, dag.Implements((*buildkite.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileCircleCI implements circleci.Compiler.
+func (check *CoverageThreshold) CompileCircleCI(output *circleci.Output) error {
+	if !check.enabled() {
+		return nil
+	}
+
+	output.Job(circleci.MakeJob(check.Name()).
+		Requires(dag.GatherMatchingInputNames(check, dag.Implements((*circleci.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileTekton implements tekton.Compiler.
+func (check *CoverageThreshold) CompileTekton(output *tekton.Output) error {
+	if !check.enabled() {
+		return nil
+	}
+
+	output.Task(tekton.MakeTask(check.Name()).
+		RunAfter(dag.GatherMatchingInputNames(check, dag.Implements((*tekton.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (check *CoverageThreshold) SkipAsMakefileDependency() {
+}
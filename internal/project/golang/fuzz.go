@@ -0,0 +1,128 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Fuzz runs Go native fuzz targets (Go 1.18+).
+//
+// Crashers are copied back to `testdata/fuzz` of the package, so that once committed
+// they are replayed by the regular unit-tests run.
+type Fuzz struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// FuzzTime is the time budget per fuzz target for `make fuzz`.
+	FuzzTime string `yaml:"fuzzTime"`
+	// SmokeFuzzTime is the time budget per fuzz target on pull requests in CI.
+	SmokeFuzzTime string `yaml:"smokeFuzzTime"`
+	// Nightly enables a longer fuzz run triggered by the NightlyCron job in CI.
+	Nightly bool `yaml:"nightly"`
+	// NightlyFuzzTime is the time budget per fuzz target for the nightly run.
+	NightlyFuzzTime string `yaml:"nightlyFuzzTime"`
+	// NightlyCron is the name of the CI cron job.
+	NightlyCron string `yaml:"nightlyCron"`
+}
+
+// NewFuzz initializes Fuzz.
+func NewFuzz(meta *meta.Options) *Fuzz {
+	meta.BuildArgs = append(meta.BuildArgs, "FUZZTIME")
+
+	return &Fuzz{
+		BaseNode: dag.NewBaseNode("fuzz"),
+
+		meta: meta,
+
+		FuzzTime:        "1m",
+		SmokeFuzzTime:   "10s",
+		NightlyFuzzTime: "10m",
+		NightlyCron:     "nightly",
+	}
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (fuzz *Fuzz) CompileDockerfile(output *dockerfile.Output) error {
+	if fuzz.meta.GoVersion != "" && semver.Compare("v"+fuzz.meta.GoVersion, "v1.18") < 0 {
+		fuzz.meta.Warn("native fuzzing requires Go 1.18+, go.mod declares %s", fuzz.meta.GoVersion)
+	}
+
+	commands := make([]string, 0, len(fuzz.meta.GoFuzzTargets))
+
+	for _, target := range fuzz.meta.GoFuzzTargets {
+		commands = append(commands,
+			fmt.Sprintf(`go test -run='^$' -fuzz='^%s$' -fuzztime=${FUZZTIME} %s || status=1`, target.Name, target.Package))
+	}
+
+	// stage doesn't fail, so that crashers are exported, status is checked in the Makefile
+	output.Stage("fuzz-run").
+		Description("runs fuzz targets").
+		From("base").
+		Step(step.Arg("FUZZTIME")).
+		Step(withGoCache(fuzz.meta, step.Script(fmt.Sprintf(`status=0 \
+	; %s \
+	; mkdir -p /fuzz && echo ${status} > /fuzz/status \
+	&& for dir in $(find . -type d -path '*/testdata/fuzz'); do mkdir -p /fuzz/${dir} && cp -R ${dir}/. /fuzz/${dir}/; done`, strings.Join(commands, " \\\n\t; ")))).
+			MountCache("/tmp"))
+
+	output.Stage("fuzz").
+		From("scratch").
+		Step(step.Copy("/fuzz", "/").From("fuzz-run"))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (fuzz *Fuzz) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupCommon).
+		Variable(makefile.OverridableVariable("FUZZTIME", fuzz.FuzzTime))
+
+	output.Target("fuzz").
+		Description("Runs fuzz targets for $(FUZZTIME) each, crashers are stored in testdata/fuzz.").
+		Script(
+			"@rm -rf $(ARTIFACTS)/fuzz",
+			"@$(MAKE) local-$@ DEST=$(ARTIFACTS)/fuzz",
+			`@cd $(ARTIFACTS)/fuzz && for dir in $$(find . -type d -path '*/testdata/fuzz'); do mkdir -p $(CURDIR)/$$dir && cp -R $$dir/. $(CURDIR)/$$dir/; done`,
+			"@exit $$(cat $(ARTIFACTS)/fuzz/status)",
+		).
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (fuzz *Fuzz) CompileDrone(output *drone.Output) error {
+	output.Step(drone.MakeStep("fuzz", "FUZZTIME="+fuzz.SmokeFuzzTime).
+		Name("fuzz-smoke").
+		OnlyOnPullRequest().
+		DependsOn(dag.GatherMatchingInputNames(fuzz, dag.Implements((*drone.Compiler)(nil)))...),
+	)
+
+	if fuzz.Nightly {
+		output.Step(drone.MakeStep("fuzz", "FUZZTIME="+fuzz.NightlyFuzzTime).
+			Name("fuzz-nightly").
+			OnlyOnCron(fuzz.NightlyCron).
+			DependsOn(dag.GatherMatchingInputNames(fuzz, dag.Implements((*drone.Compiler)(nil)))...),
+		)
+	}
+
+	return nil
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (fuzz *Fuzz) SkipAsMakefileDependency() {
+}
@@ -0,0 +1,143 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// GenerateTool is a code generator installed into the toolchain for `go generate`.
+type GenerateTool struct {
+	// Package is the path of the main package, e.g. `github.com/vektra/mockery/v2`.
+	Package string `yaml:"package"`
+	// Version the tool is pinned to.
+	Version string `yaml:"version"`
+}
+
+// Generate runs `go generate` for the Go sources.
+type Generate struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Tools are installed into the toolchain, so that `//go:generate` directives can run them.
+	Tools []GenerateTool `yaml:"tools"`
+}
+
+// NewGenerate builds Generate node.
+func NewGenerate(meta *meta.Options) *Generate {
+	return &Generate{
+		BaseNode: dag.NewBaseNode("go-generate"),
+
+		meta: meta,
+	}
+}
+
+// ToolchainBuild implements common.ToolchainBuilder hook.
+func (generate *Generate) ToolchainBuild(stage *dockerfile.Stage) error {
+	if len(generate.Tools) == 0 {
+		return nil
+	}
+
+	commands := []string{"cd $(mktemp -d)", "go mod init tmp"}
+
+	for _, tool := range generate.Tools {
+		if tool.Package == "" || tool.Version == "" {
+			return fmt.Errorf("go-generate: tool requires both package and version: %q", tool.Package)
+		}
+
+		commands = append(commands, fmt.Sprintf("go get %s@%s", tool.Package, tool.Version))
+	}
+
+	stage.Step(step.Script(strings.Join(commands, " \\\n\t&& ")).
+		Env("GOBIN", generate.meta.BinPath))
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (generate *Generate) CompileDockerfile(output *dockerfile.Output) error {
+	output.Stage("go-generate-build").
+		Description("runs go generate").
+		From("base").
+		Step(withGoCache(generate.meta, step.Script(generate.command())))
+
+	stage := output.Stage("go-generate").
+		From("scratch")
+
+	for _, dir := range generate.meta.GoDirectories {
+		stage.Step(step.Copy("/src/"+dir, "/"+dir).From("go-generate-build"))
+	}
+
+	for _, file := range generate.meta.GoSourceFiles {
+		stage.Step(step.Copy("/src/"+file, "/"+file).From("go-generate-build"))
+	}
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (generate *Generate) CompileMakefile(output *makefile.Output) error {
+	output.Target("go-generate").Description("Runs go generate and updates generated files.").
+		Script("@$(MAKE) local-$@ DEST=./").
+		Phony()
+
+	return nil
+}
+
+// command returns shell command to run the generators.
+func (generate *Generate) command() string {
+	return "go generate " + strings.Join(packagePatterns(generate.meta), " ")
+}
+
+// GenerateCheck verifies that `go generate` output is up to date.
+type GenerateCheck struct {
+	dag.BaseNode
+
+	meta     *meta.Options
+	generate *Generate
+}
+
+// NewGenerateCheck builds GenerateCheck node.
+func NewGenerateCheck(meta *meta.Options, generate *Generate) *GenerateCheck {
+	return &GenerateCheck{
+		BaseNode: dag.NewBaseNode("check-generate"),
+
+		meta:     meta,
+		generate: generate,
+	}
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (check *GenerateCheck) CompileDockerfile(output *dockerfile.Output) error {
+	paths := append(append([]string(nil), check.meta.GoDirectories...), check.meta.GoSourceFiles...)
+	checksums := fmt.Sprintf("find %s -type f | sort | xargs -r sha256sum", strings.Join(paths, " "))
+
+	output.Stage("check-generate").
+		Description("verifies go generate output is up to date").
+		From("base").
+		Step(withGoCache(check.meta, step.Script(fmt.Sprintf(`%s > /tmp/generated.before \
+	&& %s \
+	&& %s > /tmp/generated.after \
+	&& { diff -u /tmp/generated.before /tmp/generated.after || { echo "Generated files are out of date, run 'make go-generate'"; exit 1; }; }`,
+			checksums, check.generate.command(), checksums))))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (check *GenerateCheck) CompileMakefile(output *makefile.Output) error {
+	output.Target("check-generate").Description("Verifies go generate output is up to date.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
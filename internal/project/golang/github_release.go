@@ -0,0 +1,187 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// GitHubRelease uploads the binaries of the Build inputs to the GitHub release of the tag.
+//
+// Binaries are built with the same Dockerfile stages as the images, and uploaded with `gh release upload`.
+type GitHubRelease struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Enabled bool `yaml:"enabled"`
+	// Platforms binaries are built for, defaults to the default image platforms.
+	//
+	// Binaries are named `<name>-<os>-<arch>` if there is more than one platform.
+	// Builds with Targets set are uploaded as cross-compiled for the targets instead.
+	Platforms []string `yaml:"platforms"`
+	// Archive packs each binary into .tar.gz archive.
+	Archive bool `yaml:"archive"`
+	// Checksums generates SHA256SUMS for the uploaded files.
+	Checksums bool `yaml:"checksums"`
+}
+
+// NewGitHubRelease initializes GitHubRelease.
+func NewGitHubRelease(meta *meta.Options) *GitHubRelease {
+	return &GitHubRelease{
+		BaseNode: dag.NewBaseNode("release-upload"),
+
+		meta: meta,
+
+		Platforms: append([]string(nil), meta.Platforms...),
+		Checksums: true,
+	}
+}
+
+func (release *GitHubRelease) builds() []*Build {
+	var builds []*Build
+
+	for _, input := range release.Inputs() {
+		if build, ok := input.(*Build); ok {
+			builds = append(builds, build)
+		}
+	}
+
+	return builds
+}
+
+// artifactName returns the name of the binary for the platform.
+func (release *GitHubRelease) artifactName(build *Build, platform string) string {
+	name := build.OutputName
+
+	goos, goarch := platform, ""
+	if parts := strings.SplitN(platform, "/", 2); len(parts) == 2 {
+		goos, goarch = parts[0], strings.ReplaceAll(parts[1], "/", "-")
+	}
+
+	if len(release.Platforms) > 1 {
+		name = fmt.Sprintf("%s-%s-%s", name, goos, goarch)
+	}
+
+	if goos == "windows" {
+		name += ".exe"
+	}
+
+	return name
+}
+
+// archive returns the commands packing the artifact into .tar.gz archive if enabled.
+func (release *GitHubRelease) archive(dest, artifact string) []string {
+	if !release.Archive {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("@tar -czf %s.tar.gz -C %s %s && rm %s", path.Join(dest, artifact), dest, artifact, path.Join(dest, artifact))}
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (release *GitHubRelease) CompileMakefile(output *makefile.Output) error {
+	if !release.Enabled {
+		return nil
+	}
+
+	const (
+		dest = "$(ARTIFACTS)/release"
+		tmp  = "$(ARTIFACTS)/release-tmp"
+	)
+
+	script := []string{
+		fmt.Sprintf("@rm -rf %s %s", dest, tmp),
+		fmt.Sprintf("@mkdir -p %s", dest),
+	}
+
+	for _, build := range release.builds() {
+		buildDest := path.Join(tmp, build.Name())
+
+		// binaries are already cross-compiled for the targets by the build stages
+		if len(build.Targets) > 0 {
+			for _, target := range build.Targets {
+				artifact := build.targetBinary(target)
+
+				script = append(script,
+					fmt.Sprintf("@$(MAKE) local-%s%s DEST=%s", build.Name(), target.suffix(), buildDest),
+					fmt.Sprintf("@mv %s %s", path.Join(buildDest, build.InstallPath, artifact), path.Join(dest, artifact)),
+				)
+
+				script = append(script, release.archive(dest, artifact)...)
+			}
+
+			continue
+		}
+
+		if len(release.Platforms) == 0 {
+			return fmt.Errorf("%s: at least one platform is required", release.Name())
+		}
+
+		script = append(script, fmt.Sprintf("@$(MAKE) local-%s DEST=%s PLATFORM=%s", build.Name(), buildDest, strings.Join(release.Platforms, ",")))
+
+		for _, platform := range release.Platforms {
+			// buildx puts the outputs of multi-platform builds into the per-platform directories
+			src := path.Join(buildDest, build.ExecutablePath())
+			if len(release.Platforms) > 1 {
+				src = path.Join(buildDest, strings.ReplaceAll(platform, "/", "_"), build.ExecutablePath())
+			}
+
+			artifact := release.artifactName(build, platform)
+
+			script = append(script, fmt.Sprintf("@mv %s %s", src, path.Join(dest, artifact)))
+			script = append(script, release.archive(dest, artifact)...)
+		}
+	}
+
+	script = append(script, fmt.Sprintf("@rm -rf %s", tmp))
+
+	if release.Checksums {
+		script = append(script, fmt.Sprintf("@cd %s && sha256sum * > SHA256SUMS", dest))
+	}
+
+	output.Target("release-artifacts").
+		Description("Builds release binaries into $(ARTIFACTS)/release.").
+		Script(script...).
+		Phony()
+
+	// release might be already created by the release notes step
+	output.Target(release.Name()).
+		Description("Uploads release binaries to the GitHub release for $(TAG).").
+		Depends("release-artifacts").
+		Script(
+			`@gh release view $(TAG) >/dev/null 2>&1 || gh release create $(TAG) --draft --title $(TAG) --notes ""`,
+			fmt.Sprintf("@gh release upload $(TAG) %s/* --clobber", dest),
+		).
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (release *GitHubRelease) CompileDrone(output *drone.Output) error {
+	if !release.Enabled {
+		return nil
+	}
+
+	output.Step(drone.MakeStep(release.Name()).
+		EnvironmentFromSecret("GITHUB_TOKEN", "github_token").
+		OnlyOnTag().
+		DependsOn(dag.GatherMatchingInputNames(release, dag.Implements((*drone.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (release *GitHubRelease) SkipAsMakefileDependency() {
+}
@@ -0,0 +1,48 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/golang"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+func TestGitHubReleaseInterfaces(t *testing.T) {
+	assert.Implements(t, (*makefile.Compiler)(nil), new(golang.GitHubRelease))
+	assert.Implements(t, (*drone.Compiler)(nil), new(golang.GitHubRelease))
+	assert.Implements(t, (*makefile.SkipAsMakefileDependency)(nil), new(golang.GitHubRelease))
+}
+
+func TestGitHubReleaseBuildTargets(t *testing.T) {
+	options := &meta.Options{Platforms: []string{"linux/amd64"}}
+
+	build := golang.NewBuild(options, "kres", "cmd/kres")
+	build.Targets = []golang.Platform{{OS: "darwin", Arch: "arm64"}, {OS: "windows", Arch: "amd64"}}
+
+	release := golang.NewGitHubRelease(options)
+	release.Enabled = true
+	release.AddInput(build)
+
+	output := makefile.NewOutput()
+
+	require.NoError(t, release.CompileMakefile(output))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, output.GenerateFile("Makefile", &buf))
+
+	// cross-compiled binaries are uploaded instead of the image platform builds
+	assert.Contains(t, buf.String(), "$(MAKE) local-kres-darwin-arm64 DEST=$(ARTIFACTS)/release-tmp/kres")
+	assert.Contains(t, buf.String(), "mv $(ARTIFACTS)/release-tmp/kres/kres-windows-amd64.exe $(ARTIFACTS)/release/kres-windows-amd64.exe")
+	assert.NotContains(t, buf.String(), "PLATFORM=linux/amd64")
+}
@@ -0,0 +1,161 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/module"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// GoDoc publishes documentation of the Go module.
+//
+// The released version is fetched via the module proxy, so that pkg.go.dev indexes it promptly,
+// static HTML docs are optionally rendered with godoc into $(ARTIFACTS)/docs.
+type GoDoc struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Enabled runs the indexing (and HTML docs generation) on tags in CI.
+	Enabled bool `yaml:"enabled"`
+	// Proxy is the module proxy the version is fetched from, pkg.go.dev discovers versions via proxy.golang.org.
+	Proxy string `yaml:"proxy"`
+	// HTML enables static HTML docs generation.
+	HTML bool `yaml:"html"`
+	// Version of golang.org/x/tools/cmd/godoc used to render HTML docs.
+	Version string `yaml:"version"`
+}
+
+// NewGoDoc initializes GoDoc.
+func NewGoDoc(meta *meta.Options) *GoDoc {
+	return &GoDoc{
+		BaseNode: dag.NewBaseNode("godoc"),
+
+		meta: meta,
+
+		Proxy:   "https://proxy.golang.org",
+		Version: "v0.1.12",
+	}
+}
+
+// indexURL returns the proxy URL of the version info, fetching it makes the proxy cache the version.
+func (godoc *GoDoc) indexURL() (string, error) {
+	escaped, err := module.EscapePath(godoc.meta.CanonicalPath)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", godoc.Name(), err)
+	}
+
+	return fmt.Sprintf("%s/%s/@v/$(TAG).info", strings.TrimSuffix(godoc.Proxy, "/"), escaped), nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (godoc *GoDoc) CompileDockerfile(output *dockerfile.Output) error {
+	if !godoc.HTML {
+		return nil
+	}
+
+	// godoc doesn't write static pages, so the pages of the module are mirrored from the running server,
+	// wget exits with 8 if some of the mirrored links are broken (server error responses), which is tolerated
+	output.Stage("godoc-build").
+		Description("renders static HTML docs").
+		From("base").
+		// busybox wget can't mirror the pages
+		Step(step.Script(`apk --update --no-cache add wget`)).
+		Step(withGoCache(godoc.meta, step.Script(fmt.Sprintf(`cd $(mktemp -d) \
+	&& go mod init tmp \
+	&& go get golang.org/x/tools/cmd/godoc@%s`, godoc.Version))).
+			Env("GOBIN", godoc.meta.BinPath)).
+		Step(withGoCache(godoc.meta, step.Script(fmt.Sprintf(`(godoc -http=127.0.0.1:6060 &) \
+	&& for i in $(seq 1 30); do wget -q -O /dev/null http://127.0.0.1:6060/pkg/%[1]s/ && break; sleep 1; done \
+	&& { wget -q -O /dev/null http://127.0.0.1:6060/pkg/%[1]s/ || { echo "godoc server failed to start"; exit 1; }; } \
+	&& { wget -q -r -np -nH -E -k -p -e robots=off -P /docs --include-directories=/pkg/%[1]s,/lib http://127.0.0.1:6060/pkg/%[1]s/ || [ $? -eq 8 ]; }`,
+			godoc.meta.CanonicalPath))))
+
+	output.Stage("godoc").
+		From("scratch").
+		Step(step.Copy("/docs", "/docs").From("godoc-build"))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (godoc *GoDoc) CompileMakefile(output *makefile.Output) error {
+	url, err := godoc.indexURL()
+	if err != nil {
+		return err
+	}
+
+	output.Target("godoc-index").
+		Description("Fetches $(TAG) via the module proxy, so that pkg.go.dev indexes it.").
+		Script(fmt.Sprintf(`@curl -sSfL "%s"`, url)).
+		Phony()
+
+	if godoc.HTML {
+		output.Target(godoc.Name()).
+			Description("Renders static HTML docs into $(ARTIFACTS)/docs.").
+			Script("@$(MAKE) local-$@ DEST=$(ARTIFACTS)").
+			Phony()
+	}
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (godoc *GoDoc) CompileDrone(output *drone.Output) error {
+	if !godoc.Enabled {
+		return nil
+	}
+
+	output.Step(drone.MakeStep("godoc-index").
+		OnlyOnTag().
+		DependsOn(dag.GatherMatchingInputNames(godoc, dag.Implements((*drone.Compiler)(nil)))...),
+	)
+
+	if godoc.HTML {
+		output.Step(drone.MakeStep(godoc.Name()).
+			OnlyOnTag().
+			DependsOn(dag.GatherMatchingInputNames(godoc, dag.Implements((*drone.Compiler)(nil)))...),
+		)
+	}
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (godoc *GoDoc) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	if !godoc.Enabled {
+		return nil
+	}
+
+	output.Job(ghworkflow.MakeJob("godoc-index").
+		OnlyOnTag().
+		Needs(dag.GatherMatchingInputNames(godoc, dag.Implements((*ghworkflow.Compiler)(nil)))...),
+	)
+
+	if godoc.HTML {
+		output.Job(ghworkflow.MakeJob(godoc.Name()).
+			OnlyOnTag().
+			Needs(dag.GatherMatchingInputNames(godoc, dag.Implements((*ghworkflow.Compiler)(nil)))...).
+			UploadArtifact("godoc", filepath.Join(godoc.meta.ArtifactsPath, "docs")),
+		)
+	}
+
+	return nil
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (godoc *GoDoc) SkipAsMakefileDependency() {
+}
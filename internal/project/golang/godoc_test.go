@@ -0,0 +1,76 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/golang"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+func TestGoDocInterfaces(t *testing.T) {
+	assert.Implements(t, (*dockerfile.Compiler)(nil), new(golang.GoDoc))
+	assert.Implements(t, (*makefile.Compiler)(nil), new(golang.GoDoc))
+	assert.Implements(t, (*drone.Compiler)(nil), new(golang.GoDoc))
+	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(golang.GoDoc))
+}
+
+func TestGoDocIndexURL(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		canonicalPath string
+		proxy         string
+
+		expected string
+	}{
+		{
+			name:          "default proxy",
+			canonicalPath: "github.com/talos-systems/kres",
+			expected:      `@curl -sSfL "https://proxy.golang.org/github.com/talos-systems/kres/@v/$(TAG).info"`,
+		},
+		{
+			name:          "uppercase letters are escaped",
+			canonicalPath: "github.com/Example/Project",
+			proxy:         "https://goproxy.example.com/",
+			expected:      `@curl -sSfL "https://goproxy.example.com/github.com/!example/!project/@v/$(TAG).info"`,
+		},
+	} {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			godoc := golang.NewGoDoc(&meta.Options{CanonicalPath: tt.canonicalPath})
+
+			if tt.proxy != "" {
+				godoc.Proxy = tt.proxy
+			}
+
+			output := makefile.NewOutput()
+
+			require.NoError(t, godoc.CompileMakefile(output))
+
+			var buf bytes.Buffer
+
+			require.NoError(t, output.GenerateFile("Makefile", &buf))
+
+			assert.Contains(t, buf.String(), "godoc-index:")
+			assert.Contains(t, buf.String(), tt.expected)
+		})
+	}
+}
+
+func TestGoDocInvalidPath(t *testing.T) {
+	godoc := golang.NewGoDoc(&meta.Options{CanonicalPath: "example.com/foo bar"})
+
+	assert.Error(t, godoc.CompileMakefile(makefile.NewOutput()))
+}
@@ -0,0 +1,94 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/precommit"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Gofmt provides `gofmt -s` linter, it's a lighter-weight alternative to gofumpt.
+//
+// Gofmt is enabled instead of gofumpt with `--go-formatter=gofmt`.
+type Gofmt struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// GoVersion is the version of the golang image `make fmt` runs gofmt in, defaults to the version from go.mod.
+	GoVersion string `yaml:"goVersion"`
+}
+
+// NewGofmt builds Gofmt node.
+func NewGofmt(meta *meta.Options) *Gofmt {
+	goVersion := meta.GoVersion
+	if goVersion == "" {
+		goVersion = "1.14"
+	}
+
+	return &Gofmt{
+		BaseNode: dag.NewBaseNode("lint-gofmt"),
+
+		meta: meta,
+
+		GoVersion: goVersion,
+	}
+}
+
+// LinterEnabled implements common.OptionalLinter.
+func (lint *Gofmt) LinterEnabled() bool {
+	return lint.meta.GoFormatter == meta.GoFormatterGofmt
+}
+
+// CompilePreCommit implements precommit.Compiler.
+func (lint *Gofmt) CompilePreCommit(output *precommit.Output) error {
+	if !lint.LinterEnabled() {
+		return nil
+	}
+
+	output.Hook("gofmt", lint.Name()).Types("go")
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (lint *Gofmt) CompileMakefile(output *makefile.Output) error {
+	if !lint.LinterEnabled() {
+		return nil
+	}
+
+	output.Target("lint-gofmt").Description("Runs gofmt linter.").
+		Script("@$(MAKE) target-$@")
+
+	output.VariableGroup(makefile.VariableGroupCommon).
+		Variable(makefile.OverridableVariable("GO_VERSION", lint.GoVersion))
+
+	output.Target("fmt").Description("Formats the source code").
+		Phony().
+		Script(`@docker run --rm -it -v $(PWD):/src -w /src golang:$(GO_VERSION) gofmt -s -w .`)
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (lint *Gofmt) CompileDockerfile(output *dockerfile.Output) error {
+	if !lint.LinterEnabled() {
+		return nil
+	}
+
+	output.Stage("lint-gofmt").
+		Description("runs gofmt").
+		From("base").
+		Step(step.Script(`find . -name '*.pb.go' | xargs -r rm`)).
+		Step(step.Script(
+			`FILES="$(gofmt -s -l .)" && test -z "${FILES}" || (echo -e "Source code is not formatted with 'gofmt -s -w .':\n${FILES}"; exit 1)`,
+		))
+
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/talos-systems/kres/internal/output/dockerfile"
 	"github.com/talos-systems/kres/internal/output/dockerfile/step"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/precommit"
 	"github.com/talos-systems/kres/internal/project/meta"
 )
 
@@ -38,8 +39,30 @@ func NewGofumpt(meta *meta.Options) *Gofumpt {
 	}
 }
 
+// LinterEnabled implements common.OptionalLinter.
+//
+// Gofumpt is disabled if the sources are formatted with gofmt.
+func (lint *Gofumpt) LinterEnabled() bool {
+	return lint.meta.GoFormatter != meta.GoFormatterGofmt
+}
+
+// CompilePreCommit implements precommit.Compiler.
+func (lint *Gofumpt) CompilePreCommit(output *precommit.Output) error {
+	if !lint.LinterEnabled() {
+		return nil
+	}
+
+	output.Hook("gofumpt", lint.Name()).Types("go")
+
+	return nil
+}
+
 // CompileMakefile implements makefile.Compiler.
 func (lint *Gofumpt) CompileMakefile(output *makefile.Output) error {
+	if !lint.LinterEnabled() {
+		return nil
+	}
+
 	output.Target("lint-gofumpt").Description("Runs gofumpt linter.").
 		Script("@$(MAKE) target-$@")
 
@@ -54,7 +77,7 @@ func (lint *Gofumpt) CompileMakefile(output *makefile.Output) error {
 	bash -c "export GO111MODULE=on; export GOPROXY=https://proxy.golang.org; \
 	cd /tmp && go mod init tmp && go get mvdan.cc/gofumpt/gofumports@$(GOFUMPT_VERSION) && \
 	cd - && gofumports -w -local %s ."`,
-			lint.meta.CanonicalPath,
+			localPrefixes(lint.meta),
 		))
 
 	return nil
@@ -62,6 +85,10 @@ func (lint *Gofumpt) CompileMakefile(output *makefile.Output) error {
 
 // ToolchainBuild implements common.ToolchainBuilder hook.
 func (lint *Gofumpt) ToolchainBuild(stage *dockerfile.Stage) error {
+	if !lint.LinterEnabled() {
+		return nil
+	}
+
 	stage.
 		Step(step.Arg("GOFUMPT_VERSION")).
 		Step(step.Script(fmt.Sprintf(`cd $(mktemp -d) \
@@ -74,6 +101,10 @@ func (lint *Gofumpt) ToolchainBuild(stage *dockerfile.Stage) error {
 
 // CompileDockerfile implements dockerfile.Compiler.
 func (lint *Gofumpt) CompileDockerfile(output *dockerfile.Output) error {
+	if !lint.LinterEnabled() {
+		return nil
+	}
+
 	output.Stage("lint-gofumpt").
 		Description("runs gofumpt").
 		From("base").
@@ -81,8 +112,8 @@ func (lint *Gofumpt) CompileDockerfile(output *dockerfile.Output) error {
 		Step(step.Script(
 			fmt.Sprintf(
 				`FILES="$(gofumports -l -local %s .)" && test -z "${FILES}" || (echo -e "Source code is not formatted with 'gofumports -w -local %s .':\n${FILES}"; exit 1)`,
-				lint.meta.CanonicalPath,
-				lint.meta.CanonicalPath,
+				localPrefixes(lint.meta),
+				localPrefixes(lint.meta),
 			),
 		))
 
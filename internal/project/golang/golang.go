@@ -4,3 +4,89 @@
 
 // Package golang provides building blocks for Go-based projects.
 package golang
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// goModCachePath is the module cache path (GOMODCACHE) in the toolchain.
+const goModCachePath = "/go/pkg/mod"
+
+// withGoCache mounts Go build and module caches for the step.
+//
+// Modules are downloaded into the cache mount, so every step which loads the packages should mount it.
+// Cache IDs are namespaced by the module path, so that projects sharing a BuildKit daemon don't share the caches.
+func withGoCache(meta *meta.Options, run *step.RunStep) *step.RunStep {
+	return run.
+		MountCacheWithID(filepath.Join(meta.CachePath, "go-build"), meta.CanonicalPath+"/go-build").
+		MountCacheWithID(goModCachePath, meta.CanonicalPath+"/go-mod")
+}
+
+// localPrefixes returns comma-separated canonical paths of the modules, as accepted by goimports `-local`.
+func localPrefixes(meta *meta.Options) string {
+	if !meta.GoWorkspace {
+		return meta.CanonicalPath
+	}
+
+	prefixes := make([]string, 0, len(meta.GoModules))
+
+	for _, module := range meta.GoModules {
+		prefixes = append(prefixes, module.CanonicalPath)
+	}
+
+	return strings.Join(prefixes, ",")
+}
+
+// packagePatterns returns patterns matching all the packages of the project.
+//
+// Go workspace root is not a module itself, so packages are matched per module.
+func packagePatterns(meta *meta.Options) []string {
+	if !meta.GoWorkspace {
+		return []string{"./..."}
+	}
+
+	patterns := make([]string, 0, len(meta.GoModules))
+
+	for _, module := range meta.GoModules {
+		patterns = append(patterns, "./"+path.Join(module.Directory, "..."))
+	}
+
+	return patterns
+}
+
+// directoryPackages returns patterns matching the packages at the root of the module(s) and in GoDirectories.
+func directoryPackages(meta *meta.Options) []string {
+	packages := make([]string, 0, len(meta.GoDirectories)+1)
+
+	seen := map[string]bool{}
+
+	// packages at the root of the module(s)
+	for _, file := range meta.GoSourceFiles {
+		if !strings.HasSuffix(file, ".go") {
+			continue
+		}
+
+		pkg := "."
+		if dir := path.Dir(file); dir != "." {
+			pkg = "./" + dir
+		}
+
+		if !seen[pkg] {
+			seen[pkg] = true
+
+			packages = append(packages, pkg)
+		}
+	}
+
+	for _, dir := range meta.GoDirectories {
+		packages = append(packages, fmt.Sprintf("./%s/...", dir))
+	}
+
+	return packages
+}
@@ -6,13 +6,19 @@ package golang
 
 import (
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/talos-systems/kres/internal/dag"
 	"github.com/talos-systems/kres/internal/output/dockerfile"
 	"github.com/talos-systems/kres/internal/output/dockerfile/step"
 	"github.com/talos-systems/kres/internal/output/golangci"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/precommit"
 	"github.com/talos-systems/kres/internal/project/meta"
 )
 
@@ -22,7 +28,42 @@ type GolangciLint struct {
 
 	meta *meta.Options
 
-	Version string
+	// Version of golangci-lint installed into the toolchain, it should match the linters in the config.
+	Version string `yaml:"version"`
+
+	// Linters are enabled and disabled in the generated config.
+	Linters GolangciLinters `yaml:"linters"`
+
+	// ExistingConfig keeps .golangci.yml on disk (if present) instead of generating it.
+	ExistingConfig bool `yaml:"existingConfig"`
+
+	// Exclusions relax the checks for the paths matching the glob (relative to the project root), e.g. `internal/legacy`.
+	//
+	// Glob matches the files in the matching directories, `**` matches any number of directories.
+	Exclusions map[string]GolangciExclusion `yaml:"exclusions"`
+
+	// GOOS are the platforms (`goos` or `goos/goarch`) golangci-lint runs for, so that the files guarded
+	// with build constraints are linted.
+	//
+	// Defaults to linux and the operating systems detected in the build constraints of the sources.
+	GOOS []string `yaml:"goos"`
+}
+
+// GolangciExclusion excludes issues of the linters (all linters if empty) with the text matching the regular expression.
+//
+// Either linters or text should be set.
+type GolangciExclusion struct {
+	Linters []string `yaml:"linters"`
+	Text    string   `yaml:"text"`
+}
+
+// GolangciLinters configures linters in the generated .golangci.yml.
+//
+// If Enable is set, only listed linters are enabled, otherwise all linters are enabled
+// except for the ones disabled by default and the ones listed in Disable.
+type GolangciLinters struct {
+	Enable  []string `yaml:"enable"`
+	Disable []string `yaml:"disable"`
 }
 
 // NewGolangciLint builds golangci-lint node.
@@ -40,8 +81,107 @@ func NewGolangciLint(meta *meta.Options) *GolangciLint {
 
 // CompileGolangci implements golangci.Compiler.
 func (lint *GolangciLint) CompileGolangci(output *golangci.Output) error {
+	if len(lint.Linters.Enable) > 0 && len(lint.Linters.Disable) > 0 {
+		return fmt.Errorf("golangci-lint: linters should be either enabled or disabled, not both")
+	}
+
+	if lint.ExistingConfig {
+		_, err := os.Stat(filepath.Join(lint.meta.Root, ".golangci.yml"))
+		if err == nil {
+			return nil
+		}
+
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
 	output.Enable()
-	output.CanonicalPath(lint.meta.CanonicalPath)
+	output.CanonicalPath(localPrefixes(lint.meta))
+	disable := lint.Linters.Disable
+
+	// gofumpt is stricter than gofmt, so it's disabled if the sources are formatted with gofmt
+	if lint.meta.GoFormatter == meta.GoFormatterGofmt && len(lint.Linters.Enable) == 0 {
+		disable = append(append([]string(nil), disable...), "gofumpt")
+	}
+
+	output.Linters(lint.Linters.Enable, disable)
+
+	rules, err := lint.excludeRules()
+	if err != nil {
+		return err
+	}
+
+	output.ExcludeRules(rules...)
+
+	return nil
+}
+
+// excludeRules converts exclusions to golangci-lint exclude rules in a stable order.
+func (lint *GolangciLint) excludeRules() ([]golangci.ExcludeRule, error) {
+	globs := make([]string, 0, len(lint.Exclusions))
+
+	for glob := range lint.Exclusions {
+		globs = append(globs, glob)
+	}
+
+	sort.Strings(globs)
+
+	rules := make([]golangci.ExcludeRule, 0, len(globs))
+
+	for _, glob := range globs {
+		exclusion := lint.Exclusions[glob]
+
+		// golangci-lint requires rules to match on something besides the path
+		if len(exclusion.Linters) == 0 && exclusion.Text == "" {
+			return nil, fmt.Errorf("golangci-lint: exclusion for %q should list linters or text", glob)
+		}
+
+		rules = append(rules, golangci.ExcludeRule{
+			Path:    globToRegexp(glob),
+			Linters: exclusion.Linters,
+			Text:    exclusion.Text,
+		})
+	}
+
+	return rules, nil
+}
+
+// globToRegexp converts path glob to the regular expression matching the paths and the files under them.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+
+	sb.WriteString("^")
+
+	glob = path.Clean(filepath.ToSlash(glob))
+
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(.*/)?")
+
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+
+			i++
+		case glob[i] == '*':
+			sb.WriteString("[^/]*")
+		case glob[i] == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+
+	sb.WriteString("(/|$)")
+
+	return sb.String()
+}
+
+// CompilePreCommit implements precommit.Compiler.
+func (lint *GolangciLint) CompilePreCommit(output *precommit.Output) error {
+	output.Hook("golangci-lint", lint.Name()).Types("go")
 
 	return nil
 }
@@ -62,17 +202,95 @@ func (lint *GolangciLint) ToolchainBuild(stage *dockerfile.Stage) error {
 	return nil
 }
 
+// args returns golangci-lint arguments, Go workspace modules are listed explicitly.
+func (lint *GolangciLint) args() []string {
+	args := []string{"run", "--config", ".golangci.yml"}
+
+	if lint.meta.GoWorkspace {
+		args = append(args, packagePatterns(lint.meta)...)
+	}
+
+	return args
+}
+
+// defaultArch is GOARCH for the operating systems which don't support amd64.
+var defaultArch = map[string]string{
+	"aix": "ppc64",
+	"js":  "wasm",
+}
+
+var lintPlatformRegexp = regexp.MustCompile(`^[a-z0-9]+(/[a-z0-9]+)?$`)
+
+// platforms returns GOOS/GOARCH pairs golangci-lint runs for.
+func (lint *GolangciLint) platforms() ([]string, error) {
+	platforms := lint.GOOS
+
+	if len(platforms) == 0 {
+		platforms = []string{"linux"}
+
+		for _, goos := range lint.meta.GoBuildOS {
+			if goos != "linux" {
+				platforms = append(platforms, goos)
+			}
+		}
+	}
+
+	linted := map[string]struct{}{}
+
+	for _, platform := range platforms {
+		if !lintPlatformRegexp.MatchString(platform) {
+			return nil, fmt.Errorf("golangci-lint: invalid platform %q", platform)
+		}
+
+		linted[strings.SplitN(platform, "/", 2)[0]] = struct{}{}
+	}
+
+	for _, goos := range lint.meta.GoBuildOS {
+		if _, ok := linted[goos]; !ok {
+			lint.meta.Warn("golangci-lint: sources constrained to %s are not linted, add it to goos", goos)
+		}
+	}
+
+	return platforms, nil
+}
+
 // CompileDockerfile implements dockerfile.Compiler.
 func (lint *GolangciLint) CompileDockerfile(output *dockerfile.Output) error {
-	output.Stage("lint-golangci-lint").
+	platforms, err := lint.platforms()
+	if err != nil {
+		return err
+	}
+
+	stage := output.Stage("lint-golangci-lint").
 		Description("runs golangci-lint").
 		From("base").
 		Step(step.Copy(".golangci.yml", ".")).
-		Step(step.Env("GOGC", "50")).
-		Step(step.Run("golangci-lint", "run", "--config", ".golangci.yml").
-			MountCache(filepath.Join(lint.meta.CachePath, "go-build")).
-			MountCache(filepath.Join(lint.meta.CachePath, "golangci-lint")),
-		)
+		Step(step.Env("GOGC", "50"))
+
+	// platforms are linted one by one, as golangci-lint type-checks the packages for a single GOOS/GOARCH
+	for _, platform := range platforms {
+		run := withGoCache(lint.meta, step.Run("golangci-lint", lint.args()...)).
+			MountCache(filepath.Join(lint.meta.CachePath, "golangci-lint"))
+
+		// linux is the native platform of the toolchain
+		if platform != "linux" {
+			goos, goarch := platform, ""
+
+			if parts := strings.SplitN(platform, "/", 2); len(parts) == 2 {
+				goos, goarch = parts[0], parts[1]
+			} else {
+				goarch = defaultArch[goos]
+			}
+
+			run.Env("GOOS", goos)
+
+			if goarch != "" {
+				run.Env("GOARCH", goarch)
+			}
+		}
+
+		stage.Step(run)
+	}
 
 	return nil
 }
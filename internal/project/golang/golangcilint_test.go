@@ -11,6 +11,7 @@ import (
 
 	"github.com/talos-systems/kres/internal/output/dockerfile"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/precommit"
 	"github.com/talos-systems/kres/internal/project/common"
 	"github.com/talos-systems/kres/internal/project/golang"
 )
@@ -19,4 +20,5 @@ func TestGolangciLintInterfaces(t *testing.T) {
 	assert.Implements(t, (*dockerfile.Compiler)(nil), new(golang.GolangciLint))
 	assert.Implements(t, (*makefile.Compiler)(nil), new(golang.GolangciLint))
 	assert.Implements(t, (*common.ToolchainBuilder)(nil), new(golang.GolangciLint))
+	assert.Implements(t, (*precommit.Compiler)(nil), new(golang.GolangciLint))
 }
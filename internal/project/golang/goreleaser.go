@@ -0,0 +1,181 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/gitignore"
+	"github.com/talos-systems/kres/internal/output/goreleaser"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// GoReleaser builds binary release artifacts with goreleaser.
+//
+// Builds are derived from the Build inputs, so that goreleaser config stays in sync with the Dockerfile.
+type GoReleaser struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Enabled bool   `yaml:"enabled"`
+	Version string `yaml:"version"`
+	// Targets are `<os>/<arch>` platforms of the builds which don't set their own Targets.
+	Targets             []string `yaml:"targets"`
+	Checksum            bool     `yaml:"checksum"`
+	ChecksumAlgorithm   string   `yaml:"checksumAlgorithm"`
+	ArchiveNameTemplate string   `yaml:"archiveNameTemplate"`
+}
+
+// NewGoReleaser initializes GoReleaser.
+func NewGoReleaser(meta *meta.Options) *GoReleaser {
+	return &GoReleaser{
+		BaseNode: dag.NewBaseNode("release-binaries"),
+
+		meta: meta,
+
+		Version:             "v0.155.0",
+		Targets:             []string{"linux/amd64", "linux/arm64", "darwin/amd64", "windows/amd64"},
+		Checksum:            true,
+		ChecksumAlgorithm:   "sha256",
+		ArchiveNameTemplate: "{{ .Binary }}_{{ .Version }}_{{ .Os }}_{{ .Arch }}",
+	}
+}
+
+// CompileGoreleaser implements goreleaser.Compiler.
+func (release *GoReleaser) CompileGoreleaser(output *goreleaser.Output) error {
+	if !release.Enabled {
+		return nil
+	}
+
+	output.Enable()
+
+	for _, input := range release.Inputs() {
+		build, ok := input.(*Build)
+		if !ok {
+			continue
+		}
+
+		// release binaries are reproducible the same way as the binaries built with Dockerfile
+		flags := []string{"-trimpath"}
+
+		if tags := build.tags(); len(tags) > 0 {
+			flags = append(flags, "-tags="+strings.Join(tags, ","))
+		}
+
+		output.Build(goreleaser.Build{
+			ID:      build.Name(),
+			Main:    "./" + build.sourcePath,
+			Binary:  build.OutputName,
+			Env:     []string{"CGO_ENABLED=" + build.cgoEnabled()},
+			Flags:   flags,
+			Ldflags: build.ldflags(release.meta.VersionPackage, goreleaserVariable),
+			Targets: release.targets(build),
+		})
+	}
+
+	output.Archive(goreleaser.Archive{
+		NameTemplate: release.ArchiveNameTemplate,
+	})
+
+	if release.Checksum {
+		output.Checksum(goreleaser.Checksum{
+			NameTemplate: "checksums.txt",
+			Algorithm:    release.ChecksumAlgorithm,
+		})
+	} else {
+		output.Checksum(goreleaser.Checksum{
+			Disable: true,
+		})
+	}
+
+	return nil
+}
+
+// targets returns goreleaser targets of the build, the cross-compile targets of the build take precedence.
+func (release *GoReleaser) targets(build *Build) []string {
+	var targets []string
+
+	if len(build.Targets) > 0 {
+		for _, target := range build.Targets {
+			targets = append(targets, target.OS+"_"+target.Arch)
+		}
+
+		return targets
+	}
+
+	for _, target := range release.Targets {
+		targets = append(targets, strings.ReplaceAll(target, "/", "_"))
+	}
+
+	return targets
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (release *GoReleaser) CompileMakefile(output *makefile.Output) error {
+	if !release.Enabled {
+		return nil
+	}
+
+	output.VariableGroup(makefile.VariableGroupCommon).
+		Variable(makefile.OverridableVariable("GORELEASER_ARGS", "--snapshot --skip-publish"))
+
+	output.Target(release.Name()).
+		Description("Builds binary release artifacts with goreleaser.").
+		Script(fmt.Sprintf("@docker run --rm -v $(PWD):/src -w /src -e GITHUB_TOKEN docker.io/goreleaser/goreleaser:%s release --rm-dist $(GORELEASER_ARGS)", release.Version)).
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (release *GoReleaser) CompileDrone(output *drone.Output) error {
+	if !release.Enabled {
+		return nil
+	}
+
+	output.Step(drone.MakeStep(release.Name(), `GORELEASER_ARGS=""`).
+		EnvironmentFromSecret("GITHUB_TOKEN", "github_token").
+		OnlyOnTag().
+		DependsOn(dag.GatherMatchingInputNames(release, dag.Implements((*drone.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileGitignore implements gitignore.Compiler.
+func (release *GoReleaser) CompileGitignore(output *gitignore.Output) error {
+	if !release.Enabled {
+		return nil
+	}
+
+	output.IgnorePath("dist")
+
+	return nil
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (release *GoReleaser) SkipAsMakefileDependency() {
+}
+
+// goreleaserVariable maps build-time variables to goreleaser templates.
+func goreleaserVariable(variable string) string {
+	switch variable {
+	case "SHA":
+		return "{{ .ShortCommit }}"
+	case "TAG":
+		return "{{ .Tag }}"
+	case "COMMIT":
+		return "{{ .FullCommit }}"
+	case "BUILD_DATE":
+		return "{{ .Date }}"
+	default:
+		return fmt.Sprintf("{{ .Env.%s }}", variable)
+	}
+}
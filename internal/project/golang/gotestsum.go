@@ -0,0 +1,70 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Gotestsum runs unit-tests via gotestsum to produce JUnit XML report along with the regular `go test -v` output.
+//
+// Gotestsum is opt-in, the report is written to the artifacts next to the coverage profile.
+type Gotestsum struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Enabled runs unit-tests via gotestsum.
+	Enabled bool `yaml:"enabled"`
+	// Version of gotest.tools/gotestsum.
+	Version string `yaml:"version"`
+	// JUnitFile is the name of the JUnit XML report in the artifacts directory.
+	JUnitFile string `yaml:"junitFile"`
+}
+
+// NewGotestsum builds Gotestsum node.
+func NewGotestsum(meta *meta.Options) *Gotestsum {
+	return &Gotestsum{
+		BaseNode: dag.NewBaseNode("gotestsum"),
+
+		meta: meta,
+
+		Version:   "v1.7.0",
+		JUnitFile: "junit.xml",
+	}
+}
+
+// ToolchainBuild implements common.ToolchainBuilder hook.
+func (gotestsum *Gotestsum) ToolchainBuild(stage *dockerfile.Stage) error {
+	if !gotestsum.Enabled {
+		return nil
+	}
+
+	stage.Step(step.Script(fmt.Sprintf(`cd $(mktemp -d) \
+	&& go mod init tmp \
+	&& go get gotest.tools/gotestsum@%s`, gotestsum.Version)).
+		Env("GOBIN", gotestsum.meta.BinPath))
+
+	return nil
+}
+
+func (gotestsum *Gotestsum) validate() error {
+	if gotestsum.JUnitFile == "" || strings.Contains(gotestsum.JUnitFile, "/") {
+		return fmt.Errorf("gotestsum: JUnit file should be a file name, got %q", gotestsum.JUnitFile)
+	}
+
+	return nil
+}
+
+// wrap runs `go test` arguments via gotestsum, `go test -v` output is kept with the standard-verbose format.
+func (gotestsum *Gotestsum) wrap(args string) string {
+	return fmt.Sprintf("gotestsum --format standard-verbose --junitfile %s -- %s", gotestsum.JUnitFile, args)
+}
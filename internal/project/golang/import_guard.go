@@ -0,0 +1,140 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// ImportGuard fails lint if the packages of the project import forbidden packages.
+//
+// Imports (including the imports of the tests) are listed with `go list` and checked against the rules.
+type ImportGuard struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Rules restrict the imports, the check is disabled unless rules are set.
+	Rules []ImportRule `yaml:"rules"`
+}
+
+// ImportRule forbids importing the packages matching the pattern, except for the allowed importers.
+//
+// Patterns are import paths, `...` matches any string (as in `go list`), e.g. `github.com/pkg/errors`
+// or `example.com/project/internal/legacy/...`.
+type ImportRule struct {
+	// Package is the pattern of the forbidden packages.
+	Package string `yaml:"package"`
+	// Allow are the patterns of the packages which may still import the package.
+	Allow []string `yaml:"allow"`
+}
+
+// NewImportGuard builds ImportGuard node.
+func NewImportGuard(meta *meta.Options) *ImportGuard {
+	return &ImportGuard{
+		BaseNode: dag.NewBaseNode("lint-import-guard"),
+
+		meta: meta,
+	}
+}
+
+// LinterEnabled implements common.OptionalLinter.
+func (guard *ImportGuard) LinterEnabled() bool {
+	return len(guard.Rules) > 0
+}
+
+// patternToRegexp converts import path pattern to the extended regular expression.
+//
+// Special characters are bracketed instead of escaped, as awk would interpret backslashes in the string literals.
+func patternToRegexp(patterns ...string) string {
+	alternatives := make([]string, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		var sb strings.Builder
+
+		for i := 0; i < len(pattern); i++ {
+			switch {
+			case strings.HasPrefix(pattern[i:], "/..."):
+				// as in `go list`, `x/...` matches x itself
+				sb.WriteString("(/.*)?")
+
+				i += 3
+			case strings.HasPrefix(pattern[i:], "..."):
+				sb.WriteString(".*")
+
+				i += 2
+			case strings.ContainsRune(".+*?()[]{}|$", rune(pattern[i])):
+				sb.WriteString("[" + pattern[i:i+1] + "]")
+			default:
+				sb.WriteByte(pattern[i])
+			}
+		}
+
+		alternatives = append(alternatives, sb.String())
+	}
+
+	return "^(" + strings.Join(alternatives, "|") + ")$"
+}
+
+// script returns the check of the imports listed by `go list` against the rules.
+func (guard *ImportGuard) script() (string, error) {
+	rules := make([]string, 0, len(guard.Rules))
+
+	for i, rule := range guard.Rules {
+		if rule.Package == "" {
+			return "", fmt.Errorf("%s: rule %d doesn't have a package pattern", guard.Name(), i)
+		}
+
+		allow := ""
+		if len(rule.Allow) > 0 {
+			allow = patternToRegexp(rule.Allow...)
+		}
+
+		rules = append(rules, fmt.Sprintf(`deny[%d] = "%s"; allow[%d] = "%s";`, i, patternToRegexp(rule.Package), i, allow))
+	}
+
+	return fmt.Sprintf(`go list -f '{{.ImportPath}} {{join .Imports " "}} {{join .TestImports " "}} {{join .XTestImports " "}}' %s \
+	| awk 'BEGIN { %s n = %d; } \
+	{ for (i = 2; i <= NF; i++) for (r = 0; r < n; r++) if ($i ~ deny[r] && !(allow[r] != "" && $1 ~ allow[r])) { printf "%%s: import of %%s is forbidden\n", $1, $i; failed = 1; } } \
+	END { exit failed; }'`, strings.Join(packagePatterns(guard.meta), " "), strings.Join(rules, " "), len(rules)), nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (guard *ImportGuard) CompileDockerfile(output *dockerfile.Output) error {
+	if !guard.LinterEnabled() {
+		return nil
+	}
+
+	script, err := guard.script()
+	if err != nil {
+		return err
+	}
+
+	output.Stage(guard.Name()).
+		Description("verifies the packages don't import forbidden packages").
+		From("base").
+		Step(withGoCache(guard.meta, step.Script(script)))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (guard *ImportGuard) CompileMakefile(output *makefile.Output) error {
+	if !guard.LinterEnabled() {
+		return nil
+	}
+
+	output.Target(guard.Name()).Description("Verifies the packages don't import forbidden packages.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
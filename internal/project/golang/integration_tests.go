@@ -0,0 +1,128 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// IntegrationTests runs tests for Go packages guarded by build tag.
+type IntegrationTests struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Tag      string               `yaml:"tag"`
+	Services []IntegrationService `yaml:"services"`
+
+	// Runner is the platform of the CI runners tests run on, e.g. `linux/arm64` (defaults to linux/amd64).
+	Runner string `yaml:"runner"`
+
+	// CoverPkg is passed to `go test -coverpkg` (e.g. `./...`) to collect coverage of all the packages
+	// exercised by the integration tests.
+	CoverPkg string `yaml:"coverPkg"`
+}
+
+// IntegrationService is a service started for the integration tests in CI.
+type IntegrationService struct {
+	Name        string            `yaml:"name"`
+	Image       string            `yaml:"image"`
+	Environment map[string]string `yaml:"environment"`
+}
+
+// IntegrationTestsCoverageFile is the name of the coverage file produced by integration tests.
+const IntegrationTestsCoverageFile = "coverage-integration.txt"
+
+// NewIntegrationTests initializes IntegrationTests.
+func NewIntegrationTests(meta *meta.Options) *IntegrationTests {
+	return &IntegrationTests{
+		BaseNode: dag.NewBaseNode("integration-tests"),
+		meta:     meta,
+
+		Tag: "integration",
+	}
+}
+
+// RunnerPlatform implements output.RunnerPlatform.
+func (tests *IntegrationTests) RunnerPlatform() string {
+	return tests.Runner
+}
+
+func (tests *IntegrationTests) command(packages string) string {
+	coverPkg := ""
+	if tests.CoverPkg != "" {
+		coverPkg = " -coverpkg=" + tests.CoverPkg
+	}
+
+	return fmt.Sprintf(`go test -v -tags %s -covermode=atomic%s -coverprofile=%s -count 1 %s`, tests.Tag, coverPkg, IntegrationTestsCoverageFile, packages)
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (tests *IntegrationTests) CompileDockerfile(output *dockerfile.Output) error {
+	output.Stage("integration-tests-run").
+		Description("runs integration tests").
+		From("base").
+		Step(step.Arg("TESTPKGS")).
+		Step(withGoCache(tests.meta, step.Script(tests.command("${TESTPKGS}"))).
+			MountCache("/tmp"))
+
+	output.Stage("integration-tests").
+		From("scratch").
+		Step(step.Copy("/src/"+IntegrationTestsCoverageFile, "/"+IntegrationTestsCoverageFile).From("integration-tests-run"))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (tests *IntegrationTests) CompileMakefile(output *makefile.Output) error {
+	output.Target("integration-tests").
+		Description(fmt.Sprintf("Performs integration tests (tests with '%s' build tag)", tests.Tag)).
+		Script("@$(MAKE) local-$@ DEST=$(ARTIFACTS)").
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+//
+// Integration tests are run directly in the toolchain image, so that services
+// of the pipeline are reachable.
+func (tests *IntegrationTests) CompileDrone(output *drone.Output) error {
+	for _, service := range tests.Services {
+		output.Service(service.Name, service.Image, service.Environment)
+	}
+
+	image := ""
+
+	for _, input := range tests.Inputs() {
+		if toolchain, ok := input.(*Toolchain); ok {
+			image = toolchain.image()
+		}
+	}
+
+	output.Step(drone.CustomStep("integration-tests",
+		fmt.Sprintf("mkdir -p %s", tests.meta.ArtifactsPath),
+		fmt.Sprintf("%s && mv %s %s/", tests.command("./..."), IntegrationTestsCoverageFile, tests.meta.ArtifactsPath),
+	).
+		Image(image).
+		Environment("CGO_ENABLED", "0").
+		DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*drone.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+//
+// Integration tests require the services, so they are not run as part of `make all`.
+func (tests *IntegrationTests) SkipAsMakefileDependency() {
+}
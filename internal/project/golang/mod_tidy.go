@@ -0,0 +1,143 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// ModTidy verifies that go.mod and go.sum are tidy.
+//
+// `make tidy` updates go.mod and go.sum with the output of `go mod tidy`.
+type ModTidy struct {
+	dag.BaseNode
+
+	meta      *meta.Options
+	toolchain *Toolchain
+
+	// Compat is passed to `go mod tidy -compat`, e.g. `1.17`.
+	Compat string `yaml:"compat"`
+}
+
+// NewModTidy builds ModTidy node.
+func NewModTidy(meta *meta.Options, toolchain *Toolchain) *ModTidy {
+	return &ModTidy{
+		BaseNode: dag.NewBaseNode("lint-mod-tidy"),
+
+		meta:      meta,
+		toolchain: toolchain,
+	}
+}
+
+// modFiles returns go.mod and go.sum files of the project modules.
+func (tidy *ModTidy) modFiles() []string {
+	var files []string
+
+	for _, file := range tidy.meta.SourceFiles {
+		if base := path.Base(file); base == "go.mod" || base == "go.sum" {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+// command returns shell command to tidy every module.
+func (tidy *ModTidy) command() string {
+	args := "go mod tidy"
+	if tidy.Compat != "" {
+		args += " -compat=" + tidy.Compat
+	}
+
+	var commands []string
+
+	for _, file := range tidy.modFiles() {
+		if path.Base(file) != "go.mod" {
+			continue
+		}
+
+		// go mod tidy ignores go.work, every module is tidied on its own
+		if dir := path.Dir(file); dir != "." {
+			commands = append(commands, fmt.Sprintf("(cd %s && %s)", dir, args))
+		} else {
+			commands = append(commands, args)
+		}
+	}
+
+	return strings.Join(commands, " \\\n\t&& ")
+}
+
+func (tidy *ModTidy) stage(output *dockerfile.Output, name, description string) (*dockerfile.Stage, error) {
+	stage := output.Stage(name).
+		Description(description).
+		From("tools").
+		Step(step.WorkDir("/src"))
+
+	for _, file := range tidy.meta.GoWorkspaceFiles {
+		stage.Step(step.Copy("./"+file, "./"+file))
+	}
+
+	for _, file := range tidy.modFiles() {
+		stage.Step(step.Copy("./"+file, "./"+file))
+	}
+
+	if err := tidy.toolchain.copySources(stage); err != nil {
+		return nil, err
+	}
+
+	return stage, nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (tidy *ModTidy) CompileDockerfile(output *dockerfile.Output) error {
+	checksums := "sha256sum " + strings.Join(tidy.modFiles(), " ")
+
+	check, err := tidy.stage(output, "lint-mod-tidy", "verifies go.mod and go.sum are tidy")
+	if err != nil {
+		return err
+	}
+
+	check.Step(withGoCache(tidy.meta, tidy.toolchain.withGitCredentials(step.Script(fmt.Sprintf(`%s > /tmp/mod.before \
+	&& %s \
+	&& %s > /tmp/mod.after \
+	&& { diff -u /tmp/mod.before /tmp/mod.after || { echo "go.mod or go.sum is not tidy, run 'make tidy'"; exit 1; }; }`,
+		checksums, tidy.command(), checksums)))))
+
+	run, err := tidy.stage(output, "tidy-run", "runs go mod tidy")
+	if err != nil {
+		return err
+	}
+
+	run.Step(withGoCache(tidy.meta, tidy.toolchain.withGitCredentials(step.Script(tidy.command()))))
+
+	result := output.Stage("tidy").
+		From("scratch")
+
+	for _, file := range tidy.modFiles() {
+		result.Step(step.Copy("/src/"+file, "/"+file).From("tidy-run"))
+	}
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (tidy *ModTidy) CompileMakefile(output *makefile.Output) error {
+	output.Target("lint-mod-tidy").Description("Verifies go.mod and go.sum are tidy.").
+		Script("@$(MAKE) target-$@")
+
+	output.Target("tidy").Description("Runs go mod tidy and updates go.mod and go.sum.").
+		Script("@$(MAKE) local-$@ DEST=./").
+		Phony()
+
+	return nil
+}
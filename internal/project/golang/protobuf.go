@@ -0,0 +1,165 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Protobuf generates Go code from protobuf definitions.
+type Protobuf struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	ProtocGenGoVersion     string `yaml:"protocGenGoVersion"`
+	ProtocGenGoGRPCVersion string `yaml:"protocGenGoGRPCVersion"`
+	BufVersion             string `yaml:"bufVersion"`
+}
+
+// NewProtobuf builds Protobuf node.
+func NewProtobuf(meta *meta.Options) *Protobuf {
+	meta.BuildArgs = append(meta.BuildArgs, "PROTOC_GEN_GO_VERSION", "PROTOC_GEN_GO_GRPC_VERSION")
+
+	if meta.ProtobufBuf {
+		meta.BuildArgs = append(meta.BuildArgs, "BUF_VERSION")
+	}
+
+	return &Protobuf{
+		BaseNode: dag.NewBaseNode("generate"),
+
+		meta: meta,
+
+		ProtocGenGoVersion:     "v1.25.0",
+		ProtocGenGoGRPCVersion: "v1.0.1",
+		BufVersion:             "0.36.0",
+	}
+}
+
+// ToolchainBuild implements common.ToolchainBuilder hook.
+func (proto *Protobuf) ToolchainBuild(stage *dockerfile.Stage) error {
+	stage.
+		Step(step.Arg("PROTOC_GEN_GO_VERSION")).
+		Step(step.Arg("PROTOC_GEN_GO_GRPC_VERSION")).
+		Step(step.Script(fmt.Sprintf(`cd $(mktemp -d) \
+	&& go mod init tmp \
+	&& go get google.golang.org/protobuf/cmd/protoc-gen-go@${PROTOC_GEN_GO_VERSION} \
+	&& go get google.golang.org/grpc/cmd/protoc-gen-go-grpc@${PROTOC_GEN_GO_GRPC_VERSION} \
+	&& mv /go/bin/protoc-gen-go /go/bin/protoc-gen-go-grpc %s/`, proto.meta.BinPath)))
+
+	if proto.meta.ProtobufBuf {
+		stage.
+			Step(step.Arg("BUF_VERSION")).
+			Step(step.Script(fmt.Sprintf(
+				`curl -sSfL https://github.com/bufbuild/buf/releases/download/v${BUF_VERSION}/buf-$(uname -s)-$(uname -m) -o %s/buf \
+	&& chmod +x %s/buf`, proto.meta.BinPath, proto.meta.BinPath)))
+
+		return nil
+	}
+
+	// custom toolchains might come with protoc pre-installed
+	stage.Step(step.Script(`command -v protoc >/dev/null || apk --update --no-cache add protoc protobuf-dev`))
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (proto *Protobuf) CompileDockerfile(output *dockerfile.Output) error {
+	output.Stage("generate-build").
+		Description("generates Go code from protobuf definitions").
+		From("base").
+		Step(step.Script(proto.command()))
+
+	stage := output.Stage("generate").
+		From("scratch")
+
+	for _, dir := range proto.meta.ProtobufDirectories {
+		stage.Step(step.Copy("/src/"+dir, "/"+dir).From("generate-build"))
+	}
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (proto *Protobuf) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupCommon).
+		Variable(makefile.OverridableVariable("PROTOC_GEN_GO_VERSION", proto.ProtocGenGoVersion)).
+		Variable(makefile.OverridableVariable("PROTOC_GEN_GO_GRPC_VERSION", proto.ProtocGenGoGRPCVersion))
+
+	if proto.meta.ProtobufBuf {
+		output.VariableGroup(makefile.VariableGroupCommon).
+			Variable(makefile.OverridableVariable("BUF_VERSION", proto.BufVersion))
+	}
+
+	output.Target("generate").Description("Generates Go code from protobuf definitions.").
+		Script("@$(MAKE) local-$@ DEST=./").
+		Phony()
+
+	return nil
+}
+
+// SourcePaths implements dag.Node.
+func (proto *Protobuf) SourcePaths() []string {
+	return proto.meta.ProtobufFiles
+}
+
+// command returns shell command to generate the code.
+func (proto *Protobuf) command() string {
+	if proto.meta.ProtobufBuf {
+		return "buf generate"
+	}
+
+	return fmt.Sprintf("protoc -I. --go_out=paths=source_relative:. --go-grpc_out=paths=source_relative:. %s",
+		strings.Join(proto.meta.ProtobufFiles, " "))
+}
+
+// ProtobufCheck verifies that generated code is up to date.
+type ProtobufCheck struct {
+	dag.BaseNode
+
+	meta     *meta.Options
+	protobuf *Protobuf
+}
+
+// NewProtobufCheck builds ProtobufCheck node.
+func NewProtobufCheck(meta *meta.Options, protobuf *Protobuf) *ProtobufCheck {
+	return &ProtobufCheck{
+		BaseNode: dag.NewBaseNode("lint-generate"),
+
+		meta:     meta,
+		protobuf: protobuf,
+	}
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (check *ProtobufCheck) CompileDockerfile(output *dockerfile.Output) error {
+	checksums := fmt.Sprintf("find %s -name '*.pb.go' | sort | xargs -r sha256sum", strings.Join(check.meta.ProtobufDirectories, " "))
+
+	output.Stage("lint-generate").
+		Description("verifies generated code is up to date").
+		From("base").
+		Step(step.Script(fmt.Sprintf(`%s > /tmp/generated.before \
+	&& %s \
+	&& %s > /tmp/generated.after \
+	&& { diff -u /tmp/generated.before /tmp/generated.after || { echo "Generated code is out of date, run 'make generate'"; exit 1; }; }`,
+			checksums, check.protobuf.command(), checksums)))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (check *ProtobufCheck) CompileMakefile(output *makefile.Output) error {
+	output.Target("lint-generate").Description("Verifies generated code is up to date.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
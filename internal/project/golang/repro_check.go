@@ -0,0 +1,82 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// ReproCheck verifies the binaries of the Build inputs are reproducible.
+//
+// Every command is built twice with a fixed SOURCE_DATE_EPOCH, the second build ignores the build cache,
+// the check fails if the checksums of the binaries differ.
+type ReproCheck struct {
+	dag.BaseNode
+
+	meta *meta.Options
+}
+
+// NewReproCheck initializes ReproCheck.
+func NewReproCheck(meta *meta.Options) *ReproCheck {
+	meta.BuildArgs = append(meta.BuildArgs, "SOURCE_DATE_EPOCH")
+
+	return &ReproCheck{
+		BaseNode: dag.NewBaseNode("repro-check"),
+
+		meta: meta,
+	}
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (check *ReproCheck) CompileDockerfile(output *dockerfile.Output) error {
+	stage := output.Stage(check.Name()).
+		Description("verifies the builds are reproducible").
+		From("base").
+		Step(step.Arg("SOURCE_DATE_EPOCH"))
+
+	for _, input := range check.Inputs() {
+		build, ok := input.(*Build)
+		if !ok {
+			continue
+		}
+
+		first := path.Join("/repro/first", build.OutputName)
+		second := path.Join("/repro/second", build.OutputName)
+
+		build.compileBuildEnv(stage)
+
+		stage.Step(build.buildStep(fmt.Sprintf(`%s \
+	&& GOCACHE=/tmp/repro-cache %s \
+	&& (test "$(sha256sum < %s)" = "$(sha256sum < %s)" || (echo "build of %s is not reproducible" && exit 1))`,
+			build.buildScript("", first), build.buildScript(" -a", second), first, second, build.Name()),
+		).Env("SOURCE_DATE_EPOCH", "${SOURCE_DATE_EPOCH}"))
+	}
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (check *ReproCheck) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupCommon).
+		Variable(makefile.OverridableVariable("SOURCE_DATE_EPOCH", "$(shell git log -1 --pretty=%ct)"))
+
+	output.Target(check.Name()).
+		Description("Verifies the builds are reproducible.").
+		Script("@$(MAKE) target-$@").
+		Phony()
+
+	return nil
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (check *ReproCheck) SkipAsMakefileDependency() {
+}
@@ -0,0 +1,179 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/precommit"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Staticcheck runs staticcheck linter independently of golangci-lint.
+//
+// Staticcheck is opt-in, as golangci-lint already runs bundled staticcheck checks.
+type Staticcheck struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Enabled enables staticcheck as part of lint.
+	Enabled bool `yaml:"enabled"`
+	// Version of honnef.co/go/tools/cmd/staticcheck.
+	Version string `yaml:"version"`
+	// Checks overrides the list of checks, e.g. `["all", "-ST1000"]`.
+	Checks []string `yaml:"checks"`
+	// ConfigPath is the path to the staticcheck.conf (relative to the project root).
+	ConfigPath string `yaml:"configPath"`
+}
+
+// NewStaticcheck builds Staticcheck node.
+func NewStaticcheck(meta *meta.Options) *Staticcheck {
+	return &Staticcheck{
+		BaseNode: dag.NewBaseNode("lint-staticcheck"),
+
+		meta: meta,
+
+		Version: "v0.2.2",
+	}
+}
+
+// LinterEnabled implements common.OptionalLinter.
+func (lint *Staticcheck) LinterEnabled() bool {
+	return lint.Enabled
+}
+
+// SourcePaths implements dag.Node.
+func (lint *Staticcheck) SourcePaths() []string {
+	if !lint.Enabled || lint.ConfigPath == "" {
+		return nil
+	}
+
+	return []string{lint.ConfigPath}
+}
+
+// ToolchainBuild implements common.ToolchainBuilder hook.
+func (lint *Staticcheck) ToolchainBuild(stage *dockerfile.Stage) error {
+	if !lint.Enabled {
+		return nil
+	}
+
+	stage.Step(step.Script(fmt.Sprintf(`cd $(mktemp -d) \
+	&& go mod init tmp \
+	&& go get honnef.co/go/tools/cmd/staticcheck@%s`, lint.Version)).
+		Env("GOBIN", lint.meta.BinPath))
+
+	return nil
+}
+
+// args returns staticcheck arguments.
+func (lint *Staticcheck) args() []string {
+	args := []string{}
+
+	if len(lint.Checks) > 0 {
+		args = append(args, "-checks", fmt.Sprintf("%q", strings.Join(lint.Checks, ",")))
+	}
+
+	return append(args, directoryPackages(lint.meta)...)
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (lint *Staticcheck) CompileDockerfile(output *dockerfile.Output) error {
+	if !lint.Enabled {
+		return nil
+	}
+
+	stage := output.Stage("lint-staticcheck").
+		Description("runs staticcheck").
+		From("base")
+
+	// staticcheck looks up the config in the package directory and its parents
+	if lint.ConfigPath != "" {
+		stage.Step(step.Copy("./"+lint.ConfigPath, "./staticcheck.conf"))
+	}
+
+	stage.Step(withGoCache(lint.meta, step.Run("staticcheck", lint.args()...)).
+		MountCache(filepath.Join(lint.meta.CachePath, "staticcheck")).
+		Env("XDG_CACHE_HOME", lint.meta.CachePath))
+
+	return nil
+}
+
+// CompileDockerignore implements dockerignore.Compiler.
+func (lint *Staticcheck) CompileDockerignore(output *dockerignore.Output) error {
+	if lint.Enabled && lint.ConfigPath != "" {
+		output.AllowLocalPath(lint.ConfigPath)
+	}
+
+	return nil
+}
+
+// CompilePreCommit implements precommit.Compiler.
+func (lint *Staticcheck) CompilePreCommit(output *precommit.Output) error {
+	if !lint.Enabled {
+		return nil
+	}
+
+	output.Hook("staticcheck", lint.Name()).Types("go")
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (lint *Staticcheck) CompileMakefile(output *makefile.Output) error {
+	if !lint.Enabled {
+		return nil
+	}
+
+	output.Target("lint-staticcheck").Description("Runs staticcheck linter.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (lint *Staticcheck) CompileDrone(output *drone.Output) error {
+	if !lint.Enabled {
+		return nil
+	}
+
+	output.Step(drone.MakeStep("lint-staticcheck").DependsOn("base"))
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (lint *Staticcheck) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	if !lint.Enabled {
+		return nil
+	}
+
+	output.Job(ghworkflow.MakeJob("lint-staticcheck"))
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (lint *Staticcheck) CompileGitLab(output *gitlab.Output) error {
+	if !lint.Enabled {
+		return nil
+	}
+
+	output.Job(gitlab.MakeJob("lint-staticcheck").
+		Stage(lint.meta.GitLabStages.Lint),
+	)
+
+	return nil
+}
@@ -0,0 +1,174 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// SwaggerOutput is the directory (relative to the module) OpenAPI docs are generated into.
+const SwaggerOutput = "docs"
+
+// Swagger generates OpenAPI docs (`docs/swagger.json`) from swag annotations of the HTTP handlers.
+//
+// Docs are generated as a Go package, so that they can be embedded into the binaries.
+type Swagger struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Version of swag, defaults to the version required in go.mod.
+	Version string `yaml:"version"`
+	// Dir is the package with the general API info (relative to the module), defaults to the command of the module
+	// if there's a single one.
+	Dir string `yaml:"dir"`
+	// GeneralInfo is the file in Dir with the general API info annotations.
+	GeneralInfo string `yaml:"generalInfo"`
+	// ExtraArgs are passed to `swag init`.
+	ExtraArgs []string `yaml:"extraArgs"`
+}
+
+// NewSwagger builds Swagger node.
+func NewSwagger(meta *meta.Options) *Swagger {
+	meta.BuildArgs = append(meta.BuildArgs, "SWAG_VERSION")
+
+	dir := "."
+
+	var commands []string
+
+	for _, cmd := range meta.Commands {
+		if strings.HasPrefix(cmd.Path, path.Join(meta.GoSwaggerModule, "cmd")+"/") {
+			commands = append(commands, strings.TrimPrefix(strings.TrimPrefix(cmd.Path, meta.GoSwaggerModule), "/"))
+		}
+	}
+
+	if len(commands) == 1 {
+		dir = commands[0]
+	}
+
+	return &Swagger{
+		BaseNode: dag.NewBaseNode("docs"),
+
+		meta: meta,
+
+		Version:     meta.GoSwaggerVersion,
+		Dir:         dir,
+		GeneralInfo: "main.go",
+		// handlers and models are usually outside of the main package
+		ExtraArgs: []string{"--parseInternal", "--parseDependency"},
+	}
+}
+
+// ToolchainBuild implements common.ToolchainBuilder hook.
+func (swagger *Swagger) ToolchainBuild(stage *dockerfile.Stage) error {
+	stage.
+		Step(step.Arg("SWAG_VERSION")).
+		Step(step.Script(`cd $(mktemp -d) \
+	&& go mod init tmp \
+	&& go get github.com/swaggo/swag/cmd/swag@${SWAG_VERSION}`).
+			Env("GOBIN", swagger.meta.BinPath))
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (swagger *Swagger) CompileDockerfile(output *dockerfile.Output) error {
+	output.Stage("docs-build").
+		Description("generates OpenAPI docs").
+		From("base").
+		Step(withGoCache(swagger.meta, step.Script(swagger.command())))
+
+	output.Stage("docs").
+		From("scratch").
+		Step(step.Copy("/src/"+swagger.output(), "/"+swagger.output()).From("docs-build"))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (swagger *Swagger) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupCommon).
+		Variable(makefile.OverridableVariable("SWAG_VERSION", swagger.Version))
+
+	output.Target("docs").Description("Generates OpenAPI docs from swag annotations.").
+		Script("@$(MAKE) local-$@ DEST=./").
+		Phony()
+
+	return nil
+}
+
+// output returns the docs directory relative to the project root.
+func (swagger *Swagger) output() string {
+	return path.Join(swagger.meta.GoSwaggerModule, SwaggerOutput)
+}
+
+// command returns shell command to generate the docs.
+func (swagger *Swagger) command() string {
+	args := append([]string{
+		"swag", "init",
+		"--dir", swagger.Dir,
+		"--generalInfo", swagger.GeneralInfo,
+		"--output", SwaggerOutput,
+	}, swagger.ExtraArgs...)
+
+	command := strings.Join(args, " ")
+
+	if swagger.meta.GoSwaggerModule != "" && swagger.meta.GoSwaggerModule != "." {
+		command = fmt.Sprintf("cd %s && %s", swagger.meta.GoSwaggerModule, command)
+	}
+
+	return command
+}
+
+// SwaggerCheck verifies that the committed OpenAPI docs are up to date.
+type SwaggerCheck struct {
+	dag.BaseNode
+
+	meta    *meta.Options
+	swagger *Swagger
+}
+
+// NewSwaggerCheck builds SwaggerCheck node.
+func NewSwaggerCheck(meta *meta.Options, swagger *Swagger) *SwaggerCheck {
+	return &SwaggerCheck{
+		BaseNode: dag.NewBaseNode("check-docs"),
+
+		meta:    meta,
+		swagger: swagger,
+	}
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (check *SwaggerCheck) CompileDockerfile(output *dockerfile.Output) error {
+	// docs might be missing before the first generation
+	checksums := fmt.Sprintf("find %s -type f 2>/dev/null | sort | xargs -r sha256sum", check.swagger.output())
+
+	output.Stage("check-docs").
+		Description("verifies OpenAPI docs are up to date").
+		From("base").
+		Step(withGoCache(check.meta, step.Script(fmt.Sprintf(`%s > /tmp/docs.before \
+	&& (%s) \
+	&& %s > /tmp/docs.after \
+	&& { diff -u /tmp/docs.before /tmp/docs.after || { echo "OpenAPI docs are out of date, run 'make docs'"; exit 1; }; }`,
+			checksums, check.swagger.command(), checksums))))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (check *SwaggerCheck) CompileMakefile(output *makefile.Output) error {
+	output.Target("check-docs").Description("Verifies OpenAPI docs are up to date.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
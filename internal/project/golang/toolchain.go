@@ -6,11 +6,19 @@ package golang
 
 import (
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
 	"github.com/talos-systems/kres/internal/output/dockerfile"
 	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
 	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
 	"github.com/talos-systems/kres/internal/output/makefile"
 	"github.com/talos-systems/kres/internal/project/common"
 	"github.com/talos-systems/kres/internal/project/meta"
@@ -25,15 +33,53 @@ const (
 	ToolchainTools
 )
 
+// Git credentials kinds for private modules.
+const (
+	GitCredentialsNone  = ""
+	GitCredentialsNetrc = "netrc"
+	GitCredentialsSSH   = "ssh"
+)
+
 // Toolchain provides Go compiler and common utilities.
 type Toolchain struct {
 	dag.BaseNode
 
 	meta *meta.Options
 
-	Kind    ToolchainKind
+	Kind ToolchainKind
+	// Version is the toolchain image tag, derived from GoVersion for the official toolchain by default.
 	Version string
 	Image   string
+	// GoVersion is the Go version to use, defaults to the version declared in go.mod.
+	GoVersion string `yaml:"goVersion"`
+
+	// GoPrivate sets GOPRIVATE (and GONOSUMDB) for private modules.
+	GoPrivate []string `yaml:"goPrivate"`
+	// GitCredentials enables injection of credentials to fetch private modules (netrc or ssh).
+	//
+	// Credentials are mounted via BuildKit secrets (ssh agent), so they never end up in the image layers.
+	GitCredentials string `yaml:"gitCredentials"`
+	// GitCredentialsSecret is the name of the CI secret holding netrc contents or SSH private key.
+	GitCredentialsSecret string `yaml:"gitCredentialsSecret"`
+	// GitKnownHostsSecret is the name of the CI secret holding SSH known_hosts of the private hosts.
+	//
+	// Host keys are pinned, so that modules are never fetched from the host which wasn't verified.
+	GitKnownHostsSecret string `yaml:"gitKnownHostsSecret"`
+
+	// Vendor builds with vendored dependencies (-mod=vendor) without fetching modules.
+	//
+	// Vendoring is enabled by default if vendor/modules.txt is present.
+	Vendor bool `yaml:"vendor"`
+
+	// Assets are glob patterns of the files embedded with `//go:embed` which are not detected automatically.
+	//
+	// Assets are copied into the base stage along with the Go sources.
+	Assets []string `yaml:"assets"`
+
+	// Env is extra environment (e.g. GOFLAGS, GOPROXY) set in the build stages and in CI.
+	//
+	// Env values end up in the image layers, so secrets should be passed via BuildKit secrets instead.
+	Env map[string]string `yaml:"env"`
 }
 
 // NewToolchain builds Toolchain with default values.
@@ -43,11 +89,19 @@ func NewToolchain(meta *meta.Options) *Toolchain {
 
 		meta: meta,
 
-		Kind:    ToolchainOfficial,
-		Version: "1.14-alpine",
+		Kind:      ToolchainOfficial,
+		GoVersion: "1.14",
+
+		GoPrivate: append([]string(nil), meta.GoPrivate...),
+
+		Vendor: meta.GoVendor,
+	}
+
+	if meta.GoVersion != "" {
+		toolchain.GoVersion = meta.GoVersion
 	}
 
-	meta.BuildArgs = append(meta.BuildArgs, "TOOLCHAIN")
+	meta.BuildArgs = append(meta.BuildArgs, "TOOLCHAIN", "TOOLCHAIN_GO_VERSION")
 	meta.BinPath = toolchain.binPath()
 	meta.CachePath = toolchain.cachePath()
 
@@ -59,16 +113,50 @@ func (toolchain *Toolchain) image() string {
 		return toolchain.Image
 	}
 
+	return toolchain.imageWithTag(toolchain.Version, toolchain.GoVersion)
+}
+
+// versionImage returns the toolchain image for the specified Go version (e.g. for the test matrix).
+func (toolchain *Toolchain) versionImage(goVersion string) (string, error) {
+	if toolchain.Image != "" || toolchain.Version != "" {
+		return "", fmt.Errorf("toolchain image for Go %s can't be derived from the explicitly configured image", goVersion)
+	}
+
+	return toolchain.imageWithTag("", goVersion), nil
+}
+
+func (toolchain *Toolchain) imageWithTag(version, goVersion string) string {
 	switch toolchain.Kind {
 	case ToolchainOfficial:
-		return fmt.Sprintf("docker.io/golang:%s", toolchain.Version)
+		if version == "" {
+			version = goVersion + "-alpine"
+		}
+
+		return fmt.Sprintf("docker.io/golang:%s", version)
 	case ToolchainTools:
-		return fmt.Sprintf("docker.io/autonomy/talos:%s", toolchain.Version)
+		if version == "" {
+			version = goVersion
+		}
+
+		return fmt.Sprintf("docker.io/autonomy/talos:%s", version)
 	default:
 		panic("unsupported toolchain kind")
 	}
 }
 
+// envNames returns sorted Env names, so that the output is stable.
+func (toolchain *Toolchain) envNames() []string {
+	names := make([]string, 0, len(toolchain.Env))
+
+	for name := range toolchain.Env {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
 func (toolchain *Toolchain) binPath() string {
 	switch toolchain.Kind {
 	case ToolchainOfficial:
@@ -94,21 +182,137 @@ func (toolchain *Toolchain) cachePath() string {
 // CompileMakefile implements makefile.Compiler.
 func (toolchain *Toolchain) CompileMakefile(output *makefile.Output) error {
 	output.VariableGroup(makefile.VariableGroupDocker).
-		Variable(makefile.OverridableVariable("TOOLCHAIN", toolchain.image()))
+		Variable(makefile.OverridableVariable("TOOLCHAIN", toolchain.image())).
+		Variable(makefile.OverridableVariable("TOOLCHAIN_GO_VERSION", toolchain.GoVersion))
+
+	// credentials are passed to every build, as all the stages fetching modules are derived from base
+	switch toolchain.GitCredentials {
+	case GitCredentialsNone:
+		output.Target("base").
+			Description("Prepare base toolchain").
+			Script("@$(MAKE) target-$@").
+			Phony()
+	case GitCredentialsNetrc:
+		output.VariableGroup(makefile.VariableGroupDocker).
+			Variable(makefile.OverridableVariable("NETRC", "$(HOME)/.netrc")).
+			Push("COMMON_ARGS", "--secret=id=netrc,src=$(NETRC)")
+
+		output.Target("base").
+			Description("Prepare base toolchain (fetches private modules using $(NETRC) credentials)").
+			Script("@$(MAKE) target-$@").
+			Phony()
+	case GitCredentialsSSH:
+		output.VariableGroup(makefile.VariableGroupDocker).
+			Variable(makefile.OverridableVariable("SSH_AGENT", "default")).
+			Variable(makefile.OverridableVariable("SSH_KNOWN_HOSTS", "$(HOME)/.ssh/known_hosts")).
+			Push("COMMON_ARGS", "--ssh=$(SSH_AGENT)").
+			Push("COMMON_ARGS", "--secret=id=known_hosts,src=$(SSH_KNOWN_HOSTS)")
+
+		output.Target("base").
+			Description("Prepare base toolchain (fetches private modules using SSH agent and $(SSH_KNOWN_HOSTS) host keys)").
+			Script("@$(MAKE) target-$@").
+			Phony()
+	default:
+		return fmt.Errorf("unsupported git credentials kind %q", toolchain.GitCredentials)
+	}
 
-	output.Target("base").
-		Description("Prepare base toolchain").
-		Script("@$(MAKE) target-$@").
-		Phony()
+	if toolchain.Vendor {
+		// local output doesn't remove stale files, so vendor is replaced as a whole
+		output.Target("vendor").
+			Description("Vendors Go dependencies").
+			Script(
+				`@$(MAKE) local-$@ DEST=$(ARTIFACTS)/vendor-tmp`,
+				`@rm -rf vendor && mv $(ARTIFACTS)/vendor-tmp/vendor vendor && rm -rf $(ARTIFACTS)/vendor-tmp`,
+			).
+			Phony()
+	}
 
 	return nil
 }
 
 // CompileDrone implements drone.Compiler.
 func (toolchain *Toolchain) CompileDrone(output *drone.Output) error {
-	output.Step(drone.MakeStep("base").
-		DependsOn("setup-ci"),
-	)
+	step := drone.MakeStep("base").
+		DependsOn("setup-ci")
+
+	switch toolchain.GitCredentials {
+	case GitCredentialsNetrc:
+		step.
+			EnvironmentFromSecret("GIT_NETRC", toolchain.gitCredentialsSecret()).
+			BeforeCommands(`printf '%s\n' "$${GIT_NETRC}" > /root/.netrc`)
+	case GitCredentialsSSH:
+		step.
+			EnvironmentFromSecret("GIT_SSH_KEY", toolchain.gitCredentialsSecret()).
+			EnvironmentFromSecret("GIT_SSH_KNOWN_HOSTS", toolchain.gitKnownHostsSecret()).
+			BeforeCommands(
+				"eval $(ssh-agent -s)",
+				`printf '%s\n' "$${GIT_SSH_KEY}" | ssh-add -`,
+				`printf '%s\n' "$${GIT_SSH_KNOWN_HOSTS}" >> /root/.ssh/known_hosts`,
+			)
+	}
+
+	output.Step(step)
+
+	for _, name := range toolchain.envNames() {
+		output.Environment(name, toolchain.Env[name])
+	}
+
+	return nil
+}
+
+func (toolchain *Toolchain) gitCredentialsSecret() string {
+	if toolchain.GitCredentialsSecret != "" {
+		return toolchain.GitCredentialsSecret
+	}
+
+	switch toolchain.GitCredentials {
+	case GitCredentialsSSH:
+		return "git_ssh_key"
+	default:
+		return "git_netrc"
+	}
+}
+
+func (toolchain *Toolchain) gitKnownHostsSecret() string {
+	if toolchain.GitKnownHostsSecret != "" {
+		return toolchain.GitKnownHostsSecret
+	}
+
+	return "git_ssh_known_hosts"
+}
+
+// CompileCircleCI implements circleci.Compiler.
+func (toolchain *Toolchain) CompileCircleCI(output *circleci.Output) error {
+	for _, name := range toolchain.envNames() {
+		output.Environment(name, toolchain.Env[name])
+	}
+
+	return nil
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+func (toolchain *Toolchain) CompileAzurePipelines(output *azurepipelines.Output) error {
+	for _, name := range toolchain.envNames() {
+		output.Environment(name, toolchain.Env[name])
+	}
+
+	return nil
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+func (toolchain *Toolchain) CompileBuildkite(output *buildkite.Output) error {
+	for _, name := range toolchain.envNames() {
+		output.Environment(name, toolchain.Env[name])
+	}
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (toolchain *Toolchain) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	for _, name := range toolchain.envNames() {
+		output.Environment(name, toolchain.Env[name])
+	}
 
 	return nil
 }
@@ -119,18 +323,61 @@ func (toolchain *Toolchain) CompileDockerfile(output *dockerfile.Output) error {
 
 	toolchainStage := output.Stage("toolchain").
 		Description("base toolchain image").
-		From("${TOOLCHAIN}")
+		From("--platform=${BUILDPLATFORM} ${TOOLCHAIN}")
+
+	if toolchain.GoVersion != "" {
+		declared := toolchain.meta.GoVersion
+
+		// go.mod declares the minimum version, so only major.minor should match
+		if declared != "" && toolchain.GoVersion != declared && !strings.HasPrefix(toolchain.GoVersion, declared+".") {
+			toolchain.meta.Warn("configured Go version %s doesn't match go.mod version %s", toolchain.GoVersion, declared)
+		}
+
+		// assert Go version, as TOOLCHAIN might be overridden (along with TOOLCHAIN_GO_VERSION for the test matrix)
+		toolchainStage.
+			Step(step.Arg("TOOLCHAIN_GO_VERSION=" + toolchain.GoVersion)).
+			Step(step.Script(`case "$(go version)" in *" go${TOOLCHAIN_GO_VERSION} "*|*" go${TOOLCHAIN_GO_VERSION}."*) ;; ` +
+				`*) go version; echo "Go ${TOOLCHAIN_GO_VERSION} is required"; exit 1 ;; esac`))
+	}
 
 	if toolchain.Kind == ToolchainOfficial {
+		packages := []string{"bash", "curl", "build-base"}
+
+		if toolchain.GitCredentials == GitCredentialsSSH {
+			packages = append(packages, "git", "openssh-client")
+		}
+
 		toolchainStage.
-			Step(step.Run("apk", "--update", "--no-cache", "add", "bash", "curl", "build-base"))
+			Step(step.Run("apk", append([]string{"--update", "--no-cache", "add"}, packages...)...))
 	}
 
 	tools := output.Stage("tools").
 		Description("build tools").
 		From("toolchain").
 		Step(step.Env("GO111MODULE", "on")).
-		Step(step.Env("CGO_ENABLED", "0"))
+		Step(step.Env("CGO_ENABLED", "0")).
+		Step(step.Env("GOMODCACHE", goModCachePath))
+
+	if len(toolchain.GoPrivate) > 0 {
+		goPrivate := strings.Join(toolchain.GoPrivate, ",")
+
+		tools.
+			Step(step.Env("GOPRIVATE", goPrivate)).
+			Step(step.Env("GONOSUMDB", goPrivate))
+	}
+
+	// all the build stages are derived from tools, so they inherit the environment
+	for _, name := range toolchain.envNames() {
+		tools.Step(step.Env(name, toolchain.Env[name]))
+	}
+
+	if toolchain.GitCredentials == GitCredentialsSSH {
+		// fetch private modules via ssh instead of https, host keys are mounted from the known_hosts secret
+		for _, host := range toolchain.privateHosts() {
+			tools.
+				Step(step.Script(fmt.Sprintf(`git config --global url."git@%s:".insteadOf "https://%s/"`, host, host)))
+		}
+	}
 
 	if err := dag.WalkNode(toolchain, func(node dag.Node) error {
 		if builder, ok := node.(common.ToolchainBuilder); ok {
@@ -142,28 +389,281 @@ func (toolchain *Toolchain) CompileDockerfile(output *dockerfile.Output) error {
 		return err
 	}
 
+	if toolchain.meta.GoWorkspace {
+		return toolchain.compileWorkspace(output)
+	}
+
+	// module files are copied before the sources, so that downloaded dependencies and installed tools
+	// are cached as separate layers, and only changes to go.mod/go.sum invalidate them
 	base := output.Stage("base").
 		Description("tools and sources").
 		From("tools").
-		Step(step.WorkDir("/src")).
-		Step(step.Copy("./go.mod", ".")).
-		Step(step.Copy("./go.sum", ".")).
-		Step(step.Run("go", "mod", "download")).
-		Step(step.Run("go", "mod", "verify"))
+		Step(step.WorkDir("/src"))
+
+	toolchain.copyModFiles(base)
+
+	if !toolchain.Vendor {
+		// modules are kept in the cache mount, so they are not downloaded again when go.mod changes
+		base.
+			Step(withGoCache(toolchain.meta, toolchain.withGitCredentials(step.Run("go", "mod", "download")))).
+			Step(withGoCache(toolchain.meta, step.Run("go", "mod", "verify")))
+
+		toolchain.installTools(base)
+
+		if err := toolchain.copySources(base); err != nil {
+			return err
+		}
+
+		base.Step(withGoCache(toolchain.meta, step.Script(`go list -mod=readonly all >/dev/null`)))
+
+		return nil
+	}
+
+	// GOFLAGS is inherited by all the stages built from base (builds, tests, etc.)
+	base.
+		Step(step.Copy("./vendor", "./vendor")).
+		Step(step.Env("GOFLAGS", "-mod=vendor"))
+
+	toolchain.installTools(base)
+
+	if err := toolchain.copySources(base); err != nil {
+		return err
+	}
+
+	// verifies vendor/modules.txt is consistent with go.mod
+	base.Step(step.Script(`go list all >/dev/null`))
+
+	vendor := output.Stage("vendor-build").
+		Description("vendors dependencies").
+		From("tools").
+		Step(step.WorkDir("/src"))
+
+	toolchain.copyModFiles(vendor)
+
+	if err := toolchain.copySources(vendor); err != nil {
+		return err
+	}
+
+	vendor.Step(withGoCache(toolchain.meta, toolchain.withGitCredentials(step.Run("go", "mod", "vendor"))))
+
+	output.Stage("vendor").
+		From("scratch").
+		Step(step.Copy("/src/vendor", "/vendor").From("vendor-build"))
+
+	return nil
+}
+
+// compileWorkspace builds base stage for Go workspace, all modules share the same stage.
+//
+// Modules of the workspace depend on each other via go.work, so none of them can be built separately.
+func (toolchain *Toolchain) compileWorkspace(output *dockerfile.Output) error {
+	base := output.Stage("base").
+		Description("tools and sources").
+		From("tools").
+		Step(step.WorkDir("/src"))
+
+	for _, file := range toolchain.meta.GoWorkspaceFiles {
+		base.Step(step.Copy("./"+file, "./"+file))
+	}
+
+	toolchain.copyModFiles(base)
+
+	// dependencies are downloaded per module, as 'go mod download' doesn't support workspace mode
+	for _, module := range toolchain.meta.GoModules {
+		base.Step(withGoCache(toolchain.meta, toolchain.withGitCredentials(step.Script(fmt.Sprintf("cd %s && go mod download && go mod verify", module.Directory)))).
+			Env("GOWORK", "off"))
+	}
+
+	toolchain.installTools(base)
+
+	if err := toolchain.copySources(base); err != nil {
+		return err
+	}
+
+	base.Step(withGoCache(toolchain.meta, step.Script(`go list -mod=readonly all >/dev/null`)))
+
+	return nil
+}
+
+// copyModFiles copies go.mod and go.sum of every module into the stage.
+//
+// go.sum is copied only if it exists, so that modules without dependencies are supported.
+func (toolchain *Toolchain) copyModFiles(stage *dockerfile.Stage) {
+	for _, module := range toolchain.meta.GoModules {
+		for _, file := range module.SourceFiles {
+			stage.Step(step.Copy("./"+file, "./"+file))
+		}
+	}
+}
+
+// copySources copies Go sources and embedded assets into the stage.
+func (toolchain *Toolchain) copySources(stage *dockerfile.Stage) error {
+	assets, err := toolchain.assets()
+	if err != nil {
+		return err
+	}
 
 	for _, directory := range toolchain.meta.GoDirectories {
-		base.Step(step.Copy("./"+directory, "./"+directory))
+		stage.Step(step.Copy("./"+directory, "./"+directory))
 	}
 
 	for _, file := range toolchain.meta.GoSourceFiles {
-		base.Step(step.Copy("./"+file, "./"+file))
+		stage.Step(step.Copy("./"+file, "./"+file))
+	}
+
+	// test fixtures outside of the Go directories, e.g. `testdata` of the packages at the module root
+	for _, directory := range toolchain.meta.GoTestdataDirectories {
+		stage.Step(step.Copy("./"+directory, "./"+directory))
+	}
+
+	for _, asset := range assets {
+		stage.Step(step.Copy("./"+asset, "./"+asset))
+	}
+
+	for _, file := range toolchain.meta.GoToolsFiles {
+		stage.Step(step.Copy("./"+file, "./"+file))
+	}
+
+	return nil
+}
+
+// installTools installs the tools pinned by the tools package, so that `go generate` can run them.
+//
+// Versions of the tools are resolved from go.mod.
+func (toolchain *Toolchain) installTools(stage *dockerfile.Stage) {
+	if len(toolchain.meta.GoTools) == 0 {
+		return
+	}
+
+	stage.Step(withGoCache(toolchain.meta, step.Run("go", append([]string{"install"}, toolchain.meta.GoTools...)...)).
+		Env("GOBIN", toolchain.meta.BinPath))
+}
+
+// assets returns paths of the embedded assets: detected ones and matches of the Assets patterns.
+func (toolchain *Toolchain) assets() ([]string, error) {
+	assets := append([]string(nil), toolchain.meta.GoEmbedPaths...)
+
+	for _, pattern := range toolchain.Assets {
+		matches, err := toolchain.globAssets(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		assets = append(assets, matches...)
 	}
 
-	base.Step(step.Script(`go list -mod=readonly all >/dev/null`))
+	return assets, nil
+}
+
+// globAssets returns matches of the assets pattern relative to the project root.
+func (toolchain *Toolchain) globAssets(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(toolchain.meta.Root, pattern))
+	if err != nil {
+		return nil, fmt.Errorf("invalid assets pattern %q: %w", pattern, err)
+	}
+
+	for i, match := range matches {
+		rel, err := filepath.Rel(toolchain.meta.Root, match)
+		if err != nil {
+			return nil, err
+		}
+
+		matches[i] = filepath.ToSlash(rel)
+	}
+
+	return matches, nil
+}
+
+// CompileDockerignore implements dockerignore.Compiler.
+func (toolchain *Toolchain) CompileDockerignore(output *dockerignore.Output) error {
+	assets, err := toolchain.assets()
+	if err != nil {
+		return err
+	}
+
+	output.AllowLocalPath(assets...)
+
+	// reported once, as the assets are resolved for every stage
+	for _, pattern := range toolchain.Assets {
+		if matches, _ := toolchain.globAssets(pattern); len(matches) == 0 {
+			toolchain.meta.Warn("assets pattern %q doesn't match any files", pattern)
+		}
+	}
 
 	return nil
 }
 
+func (toolchain *Toolchain) withGitCredentials(run *step.RunStep) *step.RunStep {
+	switch toolchain.GitCredentials {
+	case GitCredentialsNetrc:
+		return run.MountSecret("netrc", "/root/.netrc")
+	case GitCredentialsSSH:
+		return run.MountSSH().MountSecret("known_hosts", "/root/.ssh/known_hosts")
+	default:
+		return run
+	}
+}
+
+// privateHosts returns list of unique hosts from GoPrivate patterns.
+func (toolchain *Toolchain) privateHosts() []string {
+	var hosts []string
+
+	for _, pattern := range toolchain.GoPrivate {
+		host := strings.SplitN(pattern, "/", 2)[0]
+
+		if strings.ContainsAny(host, "*?[") {
+			continue
+		}
+
+		found := false
+
+		for _, h := range hosts {
+			if h == host {
+				found = true
+			}
+		}
+
+		if !found {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts
+}
+
+// SourcePaths implements dag.Node.
+func (toolchain *Toolchain) SourcePaths() []string {
+	paths := []string{"go.mod", "go.sum"}
+
+	if toolchain.meta.GoWorkspace {
+		paths = append([]string(nil), toolchain.meta.GoWorkspaceFiles...)
+
+		for _, module := range toolchain.meta.GoModules {
+			paths = append(paths, module.SourceFiles...)
+		}
+	}
+
+	for _, directory := range toolchain.meta.GoDirectories {
+		paths = append(paths, directory+"/**")
+	}
+
+	paths = append(paths, toolchain.meta.GoSourceFiles...)
+	paths = append(paths, toolchain.meta.GoToolsFiles...)
+
+	// embedded assets might be either directories or files
+	for _, asset := range toolchain.meta.GoEmbedPaths {
+		paths = append(paths, asset, asset+"/**")
+	}
+
+	paths = append(paths, toolchain.Assets...)
+
+	if toolchain.Vendor {
+		paths = append(paths, "vendor/**")
+	}
+
+	return paths
+}
+
 // SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
 func (toolchain *Toolchain) SkipAsMakefileDependency() {
 }
@@ -5,19 +5,57 @@
 package golang_test
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
 	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
 	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
 	"github.com/talos-systems/kres/internal/output/makefile"
 	"github.com/talos-systems/kres/internal/project/golang"
+	"github.com/talos-systems/kres/internal/project/meta"
 )
 
 func TestToolchainInterfaces(t *testing.T) {
 	assert.Implements(t, (*dockerfile.Compiler)(nil), new(golang.Toolchain))
+	assert.Implements(t, (*dockerignore.Compiler)(nil), new(golang.Toolchain))
 	assert.Implements(t, (*makefile.Compiler)(nil), new(golang.Toolchain))
 	assert.Implements(t, (*drone.Compiler)(nil), new(golang.Toolchain))
 	assert.Implements(t, (*makefile.SkipAsMakefileDependency)(nil), new(golang.Toolchain))
+	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(golang.Toolchain))
+	assert.Implements(t, (*circleci.Compiler)(nil), new(golang.Toolchain))
+	assert.Implements(t, (*buildkite.Compiler)(nil), new(golang.Toolchain))
+	assert.Implements(t, (*azurepipelines.Compiler)(nil), new(golang.Toolchain))
+}
+
+func TestToolchainAssets(t *testing.T) {
+	root := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "assets"), 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "assets", "index.html"), nil, 0o644))
+
+	options := &meta.Options{Root: root}
+	toolchain := golang.NewToolchain(options)
+	toolchain.Assets = []string{"assets/*.html", "missing/*"}
+
+	output := dockerignore.NewOutput()
+	require.NoError(t, toolchain.CompileDockerignore(output))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, output.GenerateFile(".dockerignore", &buf))
+
+	// matches are relative to the project root, not to the working directory
+	assert.Contains(t, buf.String(), "\n!assets/index.html\n")
+	assert.Equal(t, []string{`assets pattern "missing/*" doesn't match any files`}, options.Warnings())
 }
@@ -5,13 +5,23 @@
 package golang
 
 import (
+	"fmt"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
 	"github.com/talos-systems/kres/internal/output/dockerfile"
 	"github.com/talos-systems/kres/internal/output/dockerfile/step"
 	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/meta"
 )
 
@@ -20,6 +30,93 @@ type UnitTests struct {
 	dag.BaseNode
 
 	meta *meta.Options
+
+	// Race enables a separate unit-tests pass with race detector enabled.
+	//
+	// Default `unit-tests` target stays race-free, race pass is run via `test-race`.
+	Race bool `yaml:"race"`
+
+	// Timeout is passed to `go test -timeout`, test binaries are killed if tests run longer than that.
+	Timeout string `yaml:"timeout"`
+
+	// ExcludePackages are not tested (and not included into the coverage).
+	//
+	// Patterns are import paths, `./` refers to the project module and `/...` suffix matches subpackages,
+	// e.g. `./internal/mocks/...`.
+	ExcludePackages []string `yaml:"excludePackages"`
+
+	// Description is shown for the target in `make help`.
+	Description string `yaml:"description"`
+
+	// CoverMode is passed to `go test -covermode`, it should be `atomic` if tests run with `-race`.
+	CoverMode string `yaml:"coverMode"`
+
+	// CoverProfile is the name of the coverage file in the artifacts directory.
+	CoverProfile string `yaml:"coverProfile"`
+
+	// CoverPkg is passed to `go test -coverpkg` (e.g. `./...`), so that coverage is credited to all the packages
+	// exercised by the tests, not only to the package under test.
+	//
+	// Instrumenting many packages slows down test builds, so it's disabled by default.
+	CoverPkg string `yaml:"coverPkg"`
+
+	// Short runs unit-tests in CI for pull requests with `-short`, so that tests guarded by `testing.Short()` are skipped.
+	//
+	// Full test suite is run for the branches and tags (and locally unless `TEST_SHORT=true` is set),
+	// so that coverage of the default branch is stable.
+	Short bool `yaml:"short"`
+
+	// ExtraArgs are passed to `go test` (both regular and race passes), e.g. `-shuffle=on`.
+	ExtraArgs []string `yaml:"extraArgs"`
+
+	// Runner is the platform of the CI runners tests run on, e.g. `linux/arm64` (defaults to linux/amd64).
+	//
+	// Builds and images stay on the default runners.
+	Runner string `yaml:"runner"`
+
+	// TestMatrix are additional Go versions unit-tests are run with in CI (in parallel).
+	//
+	// Each version is tested with the toolchain image of that version, coverage is only
+	// collected for the toolchain version.
+	TestMatrix []string `yaml:"testMatrix"`
+
+	// OS are operating systems (GOOS) unit-tests are run on in CI (Drone and GitHub Actions), defaults to linux only.
+	//
+	// Tests for the OS other than linux are run natively on the runners of that OS (Drone exec runners,
+	// GitHub-hosted runners) without Docker, coverage is only collected on linux. Excluded packages are not
+	// filtered out on the native runners.
+	OS []string `yaml:"os"`
+
+	// Secrets are BuildKit secrets exposed to the tests as environment variables (e.g. API tokens).
+	//
+	// Secrets are mounted only while tests run, so they never end up in the image layers or history.
+	// Locally secrets are read from the environment variables by `make`, in CI they come from the CI secrets.
+	Secrets []TestSecret `yaml:"secrets"`
+}
+
+// TestSecret is a secret passed to the tests.
+type TestSecret struct {
+	// ID of the BuildKit secret, the secret is mounted at `/run/secrets/<id>`.
+	ID string `yaml:"id"`
+	// Env is the name of the environment variable the secret is read from (by `make`) and set to (for `go test`).
+	Env string `yaml:"env"`
+	// CISecret is the name of the CI secret with the value, defaults to the lowercase Env
+	// (uppercase for CI systems other than Drone).
+	CISecret string `yaml:"ciSecret"`
+}
+
+func (secret TestSecret) ciSecret() string {
+	if secret.CISecret != "" {
+		return secret.CISecret
+	}
+
+	return strings.ToLower(secret.Env)
+}
+
+// matrixJob is a unit-tests run with a different Go version.
+type matrixJob struct {
+	name string
+	args []string
 }
 
 // NewUnitTests initializes UnitTests.
@@ -29,62 +126,637 @@ func NewUnitTests(meta *meta.Options) *UnitTests {
 	return &UnitTests{
 		BaseNode: dag.NewBaseNode("unit-tests"),
 		meta:     meta,
+
+		Race:    true,
+		Timeout: "10m",
+
+		CoverMode:    "atomic",
+		CoverProfile: "coverage.txt",
+
+		Description: "Performs unit tests",
+	}
+}
+
+// RunnerPlatform implements output.RunnerPlatform.
+func (tests *UnitTests) RunnerPlatform() string {
+	return tests.Runner
+}
+
+// CoverageFile implements service.CoverageProducer.
+func (tests *UnitTests) CoverageFile() string {
+	return tests.coverProfile()
+}
+
+func (tests *UnitTests) coverProfile() string {
+	if tests.CoverProfile == "" {
+		return "coverage.txt"
+	}
+
+	return tests.CoverProfile
+}
+
+func (tests *UnitTests) validate() error {
+	switch tests.CoverMode {
+	case "", "set", "count", "atomic":
+	default:
+		return fmt.Errorf("unit-tests: unsupported cover mode %q", tests.CoverMode)
+	}
+
+	for _, arg := range tests.ExtraArgs {
+		// empty mode defaults to `set` in go test, which is not safe with race detector
+		if (arg == "-race" || arg == "-race=true") && tests.CoverMode != "atomic" {
+			return fmt.Errorf("unit-tests: cover mode should be \"atomic\" with -race, got %q", tests.CoverMode)
+		}
+	}
+
+	if strings.Contains(tests.coverProfile(), "/") {
+		return fmt.Errorf("unit-tests: cover profile should be a file name, got %q", tests.CoverProfile)
+	}
+
+	for _, goos := range tests.OS {
+		switch goos {
+		case "linux", "windows", "darwin":
+		default:
+			return fmt.Errorf("unit-tests: unsupported OS %q", goos)
+		}
+	}
+
+	ids := map[string]struct{}{}
+
+	for _, secret := range tests.Secrets {
+		if !secretIDRegexp.MatchString(secret.ID) {
+			return fmt.Errorf("unit-tests: invalid secret id %q", secret.ID)
+		}
+
+		if !secretEnvRegexp.MatchString(secret.Env) {
+			return fmt.Errorf("unit-tests: invalid environment variable %q of secret %q", secret.Env, secret.ID)
+		}
+
+		if _, exists := ids[secret.ID]; exists {
+			return fmt.Errorf("unit-tests: duplicate secret id %q", secret.ID)
+		}
+
+		ids[secret.ID] = struct{}{}
+	}
+
+	return nil
+}
+
+var (
+	secretIDRegexp  = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+	secretEnvRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// withSecrets mounts the secrets for the test run, values are set only in the environment of the script.
+func (tests *UnitTests) withSecrets(script string) *step.RunStep {
+	if len(tests.Secrets) == 0 {
+		return step.Script(script)
+	}
+
+	assignments := make([]string, 0, len(tests.Secrets))
+
+	for _, secret := range tests.Secrets {
+		assignments = append(assignments, fmt.Sprintf(`%s="$(cat /run/secrets/%s)"`, secret.Env, secret.ID))
+	}
+
+	run := step.Script(strings.Join(assignments, " ") + " " + script)
+
+	for _, secret := range tests.Secrets {
+		run.MountSecret(secret.ID, "/run/secrets/"+secret.ID)
+	}
+
+	return run
+}
+
+// secretArgs returns build arguments passing the secrets from the environment.
+func (tests *UnitTests) secretArgs() string {
+	args := ""
+
+	for _, secret := range tests.Secrets {
+		args += fmt.Sprintf("--secret=id=%s,env=%s ", secret.ID, secret.Env)
+	}
+
+	return args
+}
+
+// testFlags returns common `go test` flags.
+func (tests *UnitTests) testFlags() string {
+	flags := tests.timeoutFlag()
+
+	if tests.Short {
+		flags += " -short=${TEST_SHORT}"
+	}
+
+	if len(tests.ExtraArgs) > 0 {
+		flags += " " + strings.Join(tests.ExtraArgs, " ")
+	}
+
+	return flags
+}
+
+// gotestsum returns gotestsum node if JUnit report is enabled.
+func (tests *UnitTests) gotestsum() *Gotestsum {
+	var result *Gotestsum
+
+	dag.WalkNode(tests, func(node dag.Node) error { //nolint: errcheck
+		if gotestsum, ok := node.(*Gotestsum); ok && gotestsum.Enabled {
+			result = gotestsum
+		}
+
+		return nil
+	}, nil)
+
+	return result
+}
+
+// junitArtifacts returns the path of the JUnit report in the artifacts, if enabled.
+func (tests *UnitTests) junitArtifacts() []string {
+	gotestsum := tests.gotestsum()
+	if gotestsum == nil {
+		return nil
 	}
+
+	return []string{filepath.Join(tests.meta.ArtifactsPath, gotestsum.JUnitFile)}
 }
 
 // CompileDockerfile implements dockerfile.Compiler.
 func (tests *UnitTests) CompileDockerfile(output *dockerfile.Output) error {
-	output.Stage("unit-tests-run").
+	if err := tests.validate(); err != nil {
+		return err
+	}
+
+	coverMode := ""
+	if tests.CoverMode != "" {
+		coverMode = " -covermode=" + tests.CoverMode
+	}
+
+	if tests.CoverPkg != "" {
+		coverMode += " -coverpkg=" + tests.CoverPkg
+	}
+
+	run := output.Stage("unit-tests-run").
 		Description("runs unit-tests").
 		From("base").
-		Step(step.Arg("TESTPKGS")).
-		Step(step.Script(`go test -v -covermode=atomic -coverprofile=coverage.txt -count 1 ${TESTPKGS}`).
-			MountCache(filepath.Join(tests.meta.CachePath, "go-build")).
-			MountCache("/tmp"))
+		Step(step.Arg("TESTPKGS"))
+
+	// short mode is enabled via TEST_SHORT build argument
+	if tests.Short {
+		run.Step(step.Arg("TEST_SHORT"))
+	}
+
+	command := fmt.Sprintf(`go test -v%s -coverprofile=%s -count 1%s %s`, coverMode, tests.coverProfile(), tests.testFlags(), tests.packages())
+
+	gotestsum := tests.gotestsum()
+	if gotestsum != nil {
+		if err := gotestsum.validate(); err != nil {
+			return err
+		}
+
+		command = gotestsum.wrap(strings.TrimPrefix(command, "go test "))
+	}
+
+	run.Step(withGoCache(tests.meta, tests.withSecrets(command)).
+		MountCache("/tmp"))
 
-	output.Stage("unit-tests").
+	artifacts := output.Stage("unit-tests").
 		From("scratch").
-		Step(step.Copy("/src/coverage.txt", "/coverage.txt").From("unit-tests-run"))
+		Step(step.Copy("/src/"+tests.coverProfile(), "/"+tests.coverProfile()).From("unit-tests-run"))
 
-	output.Stage("unit-tests-race").
+	if gotestsum != nil {
+		artifacts.Step(step.Copy("/src/"+gotestsum.JUnitFile, "/"+gotestsum.JUnitFile).From("unit-tests-run"))
+	}
+
+	if !tests.Race {
+		return nil
+	}
+
+	race := output.Stage("unit-tests-race").
 		Description("runs unit-tests with race detector").
 		From("base").
-		Step(step.Arg("TESTPKGS")).
-		Step(step.Script(`go test -v -race -count 1 ${TESTPKGS}`).
-			MountCache(filepath.Join(tests.meta.CachePath, "go-build")).
-			MountCache("/tmp").
-			Env("CGO_ENABLED", "1"))
+		// race detector requires cgo, so make sure C compiler is available
+		Step(step.Script(`command -v gcc >/dev/null || apk --update --no-cache add build-base`)).
+		Step(step.Arg("TESTPKGS"))
+
+	if tests.Short {
+		race.Step(step.Arg("TEST_SHORT"))
+	}
+
+	race.Step(withGoCache(tests.meta, tests.withSecrets(fmt.Sprintf(`go test -v -race -count 1%s %s`, tests.testFlags(), tests.packages()))).
+		MountCache("/tmp").
+		Env("CGO_ENABLED", "1"))
 
 	return nil
 }
 
+func (tests *UnitTests) timeoutFlag() string {
+	if tests.Timeout == "" {
+		return ""
+	}
+
+	return " -timeout " + tests.Timeout
+}
+
+// packages returns the list of packages to test.
+//
+// Excluded packages are filtered out from the package list, so that coverage is only collected
+// for the tested packages.
+func (tests *UnitTests) packages() string {
+	if len(tests.ExcludePackages) == 0 {
+		return "${TESTPKGS}"
+	}
+
+	patterns := make([]string, 0, len(tests.ExcludePackages))
+
+	for _, pkg := range tests.ExcludePackages {
+		if strings.HasPrefix(pkg, "./") {
+			pkg = path.Join(tests.meta.CanonicalPath, pkg)
+		}
+
+		if strings.HasSuffix(pkg, "/...") {
+			patterns = append(patterns, regexp.QuoteMeta(strings.TrimSuffix(pkg, "/..."))+"(/.*)?")
+		} else {
+			patterns = append(patterns, regexp.QuoteMeta(pkg))
+		}
+	}
+
+	return fmt.Sprintf(`$(go list ${TESTPKGS} | grep -vE '^(%s)$')`, strings.Join(patterns, "|"))
+}
+
 // CompileMakefile implements makefile.Compiler.
 func (tests *UnitTests) CompileMakefile(output *makefile.Output) error {
 	output.VariableGroup(makefile.VariableGroupCommon).
-		Variable(makefile.OverridableVariable("TESTPKGS", "./..."))
+		Variable(makefile.OverridableVariable("TESTPKGS", strings.Join(packagePatterns(tests.meta), " ")))
+
+	targetArgs := tests.secretArgs()
+
+	if tests.Short {
+		output.VariableGroup(makefile.VariableGroupCommon).
+			Variable(makefile.OverridableVariable("TEST_SHORT", "false"))
+
+		targetArgs += "--build-arg=TEST_SHORT=$(TEST_SHORT) "
+	}
+
+	if targetArgs != "" {
+		targetArgs = fmt.Sprintf(` TARGET_ARGS="%s$(TARGET_ARGS)"`, targetArgs)
+	}
 
 	output.Target("unit-tests").
-		Description("Performs unit tests").
-		Script("@$(MAKE) local-$@ DEST=$(ARTIFACTS)").
+		Description(tests.Description).
+		Script("@$(MAKE) local-$@ DEST=$(ARTIFACTS)" + targetArgs).
 		Phony()
 
-	output.Target("unit-tests-race").
+	if !tests.Race {
+		return nil
+	}
+
+	output.Target("test-race").
 		Description("Performs unit tests with race detection enabled.").
-		Script("@$(MAKE) target-$@").
+		Script("@$(MAKE) target-unit-tests-race" + targetArgs).
+		Phony()
+
+	// CI steps are named after the Dockerfile stage
+	output.Target("unit-tests-race").
+		Description("Alias for test-race.").
+		Depends("test-race").
 		Phony()
 
 	return nil
 }
 
+// matrix returns unit-tests runs for the TestMatrix versions other than the toolchain version.
+func (tests *UnitTests) matrix() ([]matrixJob, error) {
+	if len(tests.TestMatrix) == 0 {
+		return nil, nil
+	}
+
+	var toolchain *Toolchain
+
+	for _, input := range tests.Inputs() {
+		if t, ok := input.(*Toolchain); ok {
+			toolchain = t
+		}
+	}
+
+	if toolchain == nil {
+		return nil, fmt.Errorf("test matrix requires Go toolchain")
+	}
+
+	var jobs []matrixJob
+
+	for _, version := range tests.TestMatrix {
+		if version == toolchain.GoVersion {
+			continue
+		}
+
+		image, err := toolchain.versionImage(version)
+		if err != nil {
+			return nil, err
+		}
+
+		// job names can't contain dots in GitHub Actions
+		name := "unit-tests-go" + strings.ReplaceAll(version, ".", "-")
+
+		jobs = append(jobs, matrixJob{
+			name: name,
+			// coverage is written to a separate directory, so that it doesn't replace the primary one
+			args: []string{"TOOLCHAIN=" + image, "TOOLCHAIN_GO_VERSION=" + version, "ARTIFACTS=" + path.Join(tests.meta.ArtifactsPath, name)},
+		})
+	}
+
+	return jobs, nil
+}
+
+// nativeOS returns operating systems the tests are run on natively (other than linux).
+func (tests *UnitTests) nativeOS() []string {
+	var result []string
+
+	for _, goos := range tests.OS {
+		if goos != "linux" {
+			result = append(result, goos)
+		}
+	}
+
+	return result
+}
+
+// nativeCommand returns the command running the tests natively with the Go toolchain of the runner.
+func (tests *UnitTests) nativeCommand() string {
+	flags := tests.timeoutFlag()
+
+	if len(tests.ExtraArgs) > 0 {
+		flags += " " + strings.Join(tests.ExtraArgs, " ")
+	}
+
+	return fmt.Sprintf("go test -v -count 1%s %s", flags, strings.Join(packagePatterns(tests.meta), " "))
+}
+
+// goVersion returns the Go version of the toolchain to set up on the native runners.
+func (tests *UnitTests) goVersion() string {
+	for _, input := range tests.Inputs() {
+		if toolchain, ok := input.(*Toolchain); ok && toolchain.GoVersion != "" {
+			return toolchain.GoVersion
+		}
+	}
+
+	return tests.meta.GoVersion
+}
+
 // CompileDrone implements drone.Compiler.
 func (tests *UnitTests) CompileDrone(output *drone.Output) error {
-	output.Step(drone.MakeStep("unit-tests").
-		DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*drone.Compiler)(nil)))...),
+	matrix, err := tests.matrix()
+	if err != nil {
+		return err
+	}
+
+	if err = tests.validate(); err != nil {
+		return err
+	}
+
+	// native steps depend on the Go toolchain installed on the exec runners
+	for _, goos := range tests.nativeOS() {
+		if err = output.NativeStep(goos+"/amd64", tests.droneSecrets(drone.CustomStep("unit-tests-"+goos, tests.nativeCommand()))); err != nil {
+			return err
+		}
+	}
+
+	unitTests := tests.droneSecrets(drone.MakeStep("unit-tests").
+		DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*drone.Compiler)(nil)))...))
+
+	// pull requests run the short tests, full test suite is run for everything else
+	if tests.Short {
+		unitTests.ExceptPullRequest()
+
+		output.Step(tests.droneSecrets(drone.MakeStep("unit-tests", "TEST_SHORT=true").
+			Name("unit-tests-short").
+			OnlyOnPullRequest().
+			DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*drone.Compiler)(nil)))...)),
+		)
+	}
+
+	output.Step(unitTests)
+
+	for _, job := range matrix {
+		output.Step(tests.droneSecrets(drone.MakeStep("unit-tests", job.args...).
+			Name(job.name).
+			DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*drone.Compiler)(nil)))...)),
+		)
+	}
+
+	if tests.Race {
+		race := tests.droneSecrets(drone.MakeStep("unit-tests-race").
+			DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*drone.Compiler)(nil)))...))
+
+		if tests.Short {
+			race.ExceptPullRequest()
+
+			output.Step(tests.droneSecrets(drone.MakeStep("unit-tests-race", "TEST_SHORT=true").
+				Name("unit-tests-race-short").
+				OnlyOnPullRequest().
+				DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*drone.Compiler)(nil)))...)),
+			)
+		}
+
+		output.Step(race)
+	}
+
+	return nil
+}
+
+// droneSecrets passes the secrets to the step.
+func (tests *UnitTests) droneSecrets(step *drone.Step) *drone.Step {
+	for _, secret := range tests.Secrets {
+		step.EnvironmentFromSecret(secret.Env, secret.ciSecret())
+	}
+
+	return step
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (tests *UnitTests) CompileGitLab(output *gitlab.Output) error {
+	output.Job(tests.gitlabSecrets(gitlab.MakeJob("unit-tests").
+		Stage(tests.meta.GitLabStages.Test).
+		Needs(dag.GatherMatchingInputNames(tests, dag.Implements((*gitlab.Compiler)(nil)))...).
+		Artifacts(filepath.Join(tests.meta.ArtifactsPath, tests.coverProfile())).
+		Artifacts(tests.junitArtifacts()...).
+		JUnitReports(tests.junitArtifacts()...)),
+	)
+
+	if tests.Race {
+		output.Job(tests.gitlabSecrets(gitlab.MakeJob("unit-tests-race").
+			Stage(tests.meta.GitLabStages.Test).
+			Needs(dag.GatherMatchingInputNames(tests, dag.Implements((*gitlab.Compiler)(nil)))...)),
+		)
+	}
+
+	return nil
+}
+
+// gitlabSecrets passes the secrets (CI/CD variables) to the job.
+func (tests *UnitTests) gitlabSecrets(job *gitlab.Job) *gitlab.Job {
+	for _, secret := range tests.Secrets {
+		job.Variable(secret.Env, "${"+strings.ToUpper(secret.ciSecret())+"}")
+	}
+
+	return job
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+func (tests *UnitTests) CompileAzurePipelines(output *azurepipelines.Output) error {
+	output.Job(tests.azureSecrets(azurepipelines.MakeJob("unit-tests").
+		DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*azurepipelines.Compiler)(nil)))...)),
+	)
+
+	if tests.Race {
+		output.Job(tests.azureSecrets(azurepipelines.MakeJob("unit-tests-race").
+			DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*azurepipelines.Compiler)(nil)))...)),
+		)
+	}
+
+	return nil
+}
+
+// azureSecrets maps the secret pipeline variables to the job.
+func (tests *UnitTests) azureSecrets(job *azurepipelines.Job) *azurepipelines.Job {
+	for _, secret := range tests.Secrets {
+		job.EnvironmentFromSecret(secret.Env, strings.ToUpper(secret.ciSecret()))
+	}
+
+	return job
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+func (tests *UnitTests) CompileBuildkite(output *buildkite.Output) error {
+	output.Step(tests.buildkiteSecrets(buildkite.MakeStep("unit-tests").
+		DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*buildkite.Compiler)(nil)))...).
+		ArtifactPaths(filepath.Join(tests.meta.ArtifactsPath, tests.coverProfile())).
+		ArtifactPaths(tests.junitArtifacts()...)),
 	)
 
-	output.Step(drone.MakeStep("unit-tests-race").
-		DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*drone.Compiler)(nil)))...),
+	if tests.Race {
+		output.Step(tests.buildkiteSecrets(buildkite.MakeStep("unit-tests-race").
+			DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*buildkite.Compiler)(nil)))...)),
+		)
+	}
+
+	return nil
+}
+
+// buildkiteSecrets passes the agent environment variables (secrets) to the step.
+func (tests *UnitTests) buildkiteSecrets(step *buildkite.Step) *buildkite.Step {
+	for _, secret := range tests.Secrets {
+		step.EnvironmentFromVariable(secret.Env, strings.ToUpper(secret.ciSecret()))
+	}
+
+	return step
+}
+
+// CompileCircleCI implements circleci.Compiler.
+func (tests *UnitTests) CompileCircleCI(output *circleci.Output) error {
+	output.Job(tests.circleciSecrets(circleci.MakeJob("unit-tests").
+		Requires(dag.GatherMatchingInputNames(tests, dag.Implements((*circleci.Compiler)(nil)))...)),
 	)
 
+	if tests.Race {
+		output.Job(tests.circleciSecrets(circleci.MakeJob("unit-tests-race").
+			Requires(dag.GatherMatchingInputNames(tests, dag.Implements((*circleci.Compiler)(nil)))...)),
+		)
+	}
+
 	return nil
 }
+
+// circleciSecrets passes the project environment variables (secrets) to the job.
+func (tests *UnitTests) circleciSecrets(job *circleci.Job) *circleci.Job {
+	for _, secret := range tests.Secrets {
+		job.EnvironmentFromSecret(secret.Env, strings.ToUpper(secret.ciSecret()))
+	}
+
+	return job
+}
+
+// CompileTekton implements tekton.Compiler.
+func (tests *UnitTests) CompileTekton(output *tekton.Output) error {
+	output.Task(tekton.MakeTask("unit-tests").
+		RunAfter(dag.GatherMatchingInputNames(tests, dag.Implements((*tekton.Compiler)(nil)))...),
+	)
+
+	if tests.Race {
+		output.Task(tekton.MakeTask("unit-tests-race").
+			RunAfter(dag.GatherMatchingInputNames(tests, dag.Implements((*tekton.Compiler)(nil)))...),
+		)
+	}
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (tests *UnitTests) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	matrix, err := tests.matrix()
+	if err != nil {
+		return err
+	}
+
+	if err = tests.validate(); err != nil {
+		return err
+	}
+
+	for _, goos := range tests.nativeOS() {
+		var runner string
+
+		if runner, err = ghworkflow.HostedRunner(goos); err != nil {
+			return err
+		}
+
+		// short mode is not supported by the native command, so only the secrets are passed
+		output.Job(tests.githubSecrets(ghworkflow.CommandJob("unit-tests-"+goos, tests.nativeCommand()).
+			RunsOn(runner).
+			SetupGo(tests.goVersion())),
+		)
+	}
+
+	unitTests := tests.githubEnv(ghworkflow.MakeJob("unit-tests").
+		Needs(dag.GatherMatchingInputNames(tests, dag.Implements((*ghworkflow.Compiler)(nil)))...).
+		UploadArtifact("coverage", filepath.Join(tests.meta.ArtifactsPath, tests.coverProfile())))
+
+	for _, report := range tests.junitArtifacts() {
+		unitTests.UploadArtifact("junit", report)
+	}
+
+	output.Job(unitTests)
+
+	// coverage of the matrix runs is not uploaded, so that it's not counted twice
+	for _, job := range matrix {
+		output.Job(tests.githubEnv(ghworkflow.MakeJob("unit-tests", job.args...).
+			Name(job.name).
+			Needs(dag.GatherMatchingInputNames(tests, dag.Implements((*ghworkflow.Compiler)(nil)))...)),
+		)
+	}
+
+	if tests.Race {
+		output.Job(tests.githubEnv(ghworkflow.MakeJob("unit-tests-race").
+			Needs(dag.GatherMatchingInputNames(tests, dag.Implements((*ghworkflow.Compiler)(nil)))...)),
+		)
+	}
+
+	return nil
+}
+
+// githubEnv enables short mode of the job for pull requests and passes the secrets to the job.
+func (tests *UnitTests) githubEnv(job *ghworkflow.Job) *ghworkflow.Job {
+	tests.githubSecrets(job)
+
+	if !tests.Short {
+		return job
+	}
+
+	return job.Environment("TEST_SHORT", "${{ github.event_name == 'pull_request' }}")
+}
+
+// githubSecrets passes the secrets to the job.
+func (tests *UnitTests) githubSecrets(job *ghworkflow.Job) *ghworkflow.Job {
+	for _, secret := range tests.Secrets {
+		job.EnvironmentFromSecret(secret.Env, strings.ToUpper(secret.ciSecret()))
+	}
+
+	return job
+}
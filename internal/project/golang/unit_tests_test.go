@@ -9,14 +9,30 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/talos-systems/kres/internal/output"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
 	"github.com/talos-systems/kres/internal/output/dockerfile"
 	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/golang"
+	"github.com/talos-systems/kres/internal/project/service"
 )
 
 func TestUnitTestsInterfaces(t *testing.T) {
 	assert.Implements(t, (*dockerfile.Compiler)(nil), new(golang.UnitTests))
 	assert.Implements(t, (*makefile.Compiler)(nil), new(golang.UnitTests))
 	assert.Implements(t, (*drone.Compiler)(nil), new(golang.UnitTests))
+	assert.Implements(t, (*gitlab.Compiler)(nil), new(golang.UnitTests))
+	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(golang.UnitTests))
+	assert.Implements(t, (*tekton.Compiler)(nil), new(golang.UnitTests))
+	assert.Implements(t, (*service.CoverageProducer)(nil), new(golang.UnitTests))
+	assert.Implements(t, (*output.RunnerPlatform)(nil), new(golang.UnitTests))
+	assert.Implements(t, (*circleci.Compiler)(nil), new(golang.UnitTests))
+	assert.Implements(t, (*buildkite.Compiler)(nil), new(golang.UnitTests))
+	assert.Implements(t, (*azurepipelines.Compiler)(nil), new(golang.UnitTests))
 }
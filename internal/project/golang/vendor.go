@@ -0,0 +1,80 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// VendorCheck verifies that vendored dependencies are up to date.
+type VendorCheck struct {
+	dag.BaseNode
+
+	meta      *meta.Options
+	toolchain *Toolchain
+}
+
+// NewVendorCheck builds VendorCheck node.
+func NewVendorCheck(meta *meta.Options, toolchain *Toolchain) *VendorCheck {
+	return &VendorCheck{
+		BaseNode: dag.NewBaseNode("lint-vendor"),
+
+		meta:      meta,
+		toolchain: toolchain,
+	}
+}
+
+// LinterEnabled implements common.OptionalLinter.
+func (check *VendorCheck) LinterEnabled() bool {
+	return check.toolchain.Vendor
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (check *VendorCheck) CompileDockerfile(output *dockerfile.Output) error {
+	if !check.LinterEnabled() {
+		return nil
+	}
+
+	const checksums = "find vendor -type f | sort | xargs -r sha256sum"
+
+	stage := output.Stage("lint-vendor").
+		Description("verifies vendored dependencies are up to date").
+		From("tools").
+		Step(step.WorkDir("/src"))
+
+	check.toolchain.copyModFiles(stage)
+
+	stage.Step(step.Copy("./vendor", "./vendor"))
+
+	if err := check.toolchain.copySources(stage); err != nil {
+		return err
+	}
+
+	stage.Step(withGoCache(check.meta, check.toolchain.withGitCredentials(step.Script(fmt.Sprintf(`%s > /tmp/vendor.before \
+	&& go mod vendor \
+	&& %s > /tmp/vendor.after \
+	&& { diff -u /tmp/vendor.before /tmp/vendor.after || { echo "Vendored dependencies are out of date, run 'make vendor'"; exit 1; }; }`,
+		checksums, checksums)))))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (check *VendorCheck) CompileMakefile(output *makefile.Output) error {
+	if !check.LinterEnabled() {
+		return nil
+	}
+
+	output.Target("lint-vendor").Description("Verifies vendored dependencies are up to date.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
@@ -0,0 +1,36 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/common"
+	"github.com/talos-systems/kres/internal/project/golang"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+func TestVendorCheckInterfaces(t *testing.T) {
+	assert.Implements(t, (*dockerfile.Compiler)(nil), new(golang.VendorCheck))
+	assert.Implements(t, (*makefile.Compiler)(nil), new(golang.VendorCheck))
+	assert.Implements(t, (*common.OptionalLinter)(nil), new(golang.VendorCheck))
+}
+
+func TestVendorCheckEnabled(t *testing.T) {
+	options := &meta.Options{GoVendor: true}
+	toolchain := golang.NewToolchain(options)
+	check := golang.NewVendorCheck(options, toolchain)
+
+	assert.True(t, check.LinterEnabled())
+
+	// vendoring disabled in the config after the detection
+	toolchain.Vendor = false
+
+	assert.False(t, check.LinterEnabled())
+}
@@ -0,0 +1,131 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/precommit"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// VulnCheck runs govulncheck, which reports known vulnerabilities in the code reachable from the module.
+type VulnCheck struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Enabled turns the check off when set to false.
+	Enabled bool `yaml:"enabled"`
+	// Version of golang.org/x/vuln/cmd/govulncheck.
+	Version string `yaml:"version"`
+	// Allowlist is a list of vulnerability IDs (e.g. GO-2022-0969) which don't fail the check.
+	Allowlist []string `yaml:"allowlist"`
+}
+
+// NewVulnCheck builds VulnCheck node.
+func NewVulnCheck(meta *meta.Options) *VulnCheck {
+	return &VulnCheck{
+		BaseNode: dag.NewBaseNode("vulncheck"),
+
+		meta: meta,
+
+		Enabled: true,
+		Version: "v1.0.1",
+	}
+}
+
+// supportedToolchain reports whether the module toolchain can build govulncheck, which requires Go 1.18+.
+func (check *VulnCheck) supportedToolchain() bool {
+	return check.meta.GoVersion == "" || semver.Compare("v"+check.meta.GoVersion, "v1.18") >= 0
+}
+
+// LinterEnabled implements common.OptionalLinter.
+func (check *VulnCheck) LinterEnabled() bool {
+	return check.Enabled && check.supportedToolchain()
+}
+
+// ToolchainBuild implements common.ToolchainBuilder hook.
+func (check *VulnCheck) ToolchainBuild(stage *dockerfile.Stage) error {
+	if check.Enabled && !check.supportedToolchain() {
+		check.meta.Warn("govulncheck requires Go 1.18+, go.mod declares %s, vulncheck is skipped", check.meta.GoVersion)
+	}
+
+	if !check.LinterEnabled() {
+		return nil
+	}
+
+	// toolchain is Go 1.18+, where `go get` no longer installs binaries
+	stage.Step(step.Script(fmt.Sprintf("go install golang.org/x/vuln/cmd/govulncheck@%s", check.Version)).
+		Env("GOBIN", check.meta.BinPath))
+
+	return nil
+}
+
+// script returns the command to run govulncheck.
+//
+// govulncheck doesn't support suppressions, so with the allowlist the IDs of the reported
+// vulnerabilities are checked against it, and the check fails on the first unlisted one.
+func (check *VulnCheck) script() string {
+	command := "govulncheck " + strings.Join(packagePatterns(check.meta), " ")
+
+	if len(check.Allowlist) == 0 {
+		return command
+	}
+
+	const found = `grep -oE 'Vulnerability #[0-9]+: GO-[0-9]{4}-[0-9]+' /tmp/vulncheck.txt | grep -oE 'GO-[0-9]{4}-[0-9]+'`
+
+	return fmt.Sprintf(`%s > /tmp/vulncheck.txt 2>&1; status=$?; cat /tmp/vulncheck.txt; \
+	if [ $status -ne 0 ]; then \
+		FOUND="$(%s)"; test -n "${FOUND}" || exit $status; \
+		VULNS="$(echo "${FOUND}" | grep -vxE '%s')"; \
+		test -z "${VULNS}" || { echo "Vulnerabilities not in the allowlist:" ${VULNS}; exit 1; }; \
+	fi`, command, found, strings.Join(check.Allowlist, "|"))
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (check *VulnCheck) CompileDockerfile(output *dockerfile.Output) error {
+	if !check.LinterEnabled() {
+		return nil
+	}
+
+	output.Stage("vulncheck").
+		Description("runs govulncheck").
+		From("base").
+		Step(withGoCache(check.meta, step.Script(check.script())))
+
+	return nil
+}
+
+// CompilePreCommit implements precommit.Compiler.
+func (check *VulnCheck) CompilePreCommit(output *precommit.Output) error {
+	if !check.LinterEnabled() {
+		return nil
+	}
+
+	// vulnerability database is fetched on every run, so the hook is opt-in
+	output.Hook("vulncheck", check.Name()).Types("go").Optional()
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (check *VulnCheck) CompileMakefile(output *makefile.Output) error {
+	if !check.LinterEnabled() {
+		return nil
+	}
+
+	output.Target("vulncheck").Description("Runs govulncheck over the module.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
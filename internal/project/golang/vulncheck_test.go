@@ -0,0 +1,55 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/precommit"
+	"github.com/talos-systems/kres/internal/project/common"
+	"github.com/talos-systems/kres/internal/project/golang"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+func TestVulnCheckInterfaces(t *testing.T) {
+	assert.Implements(t, (*dockerfile.Compiler)(nil), new(golang.VulnCheck))
+	assert.Implements(t, (*makefile.Compiler)(nil), new(golang.VulnCheck))
+	assert.Implements(t, (*common.ToolchainBuilder)(nil), new(golang.VulnCheck))
+	assert.Implements(t, (*precommit.Compiler)(nil), new(golang.VulnCheck))
+	assert.Implements(t, (*common.OptionalLinter)(nil), new(golang.VulnCheck))
+}
+
+func TestVulnCheckEnabled(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		goVersion string
+		enabled   bool
+		expected  bool
+		warnings  int
+	}{
+		{name: "unknown", enabled: true, expected: true},
+		{name: "go1.18", goVersion: "1.18", enabled: true, expected: true},
+		{name: "go1.17", goVersion: "1.17", enabled: true, expected: false, warnings: 1},
+		{name: "disabled", goVersion: "1.19", enabled: false, expected: false},
+	} {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			options := &meta.Options{GoVersion: tt.goVersion}
+			check := golang.NewVulnCheck(options)
+			check.Enabled = tt.enabled
+
+			assert.Equal(t, tt.expected, check.LinterEnabled())
+
+			stage := dockerfile.NewOutput().Stage("toolchain")
+			assert.NoError(t, check.ToolchainBuild(stage))
+			assert.Len(t, options.Warnings(), tt.warnings)
+		})
+	}
+}
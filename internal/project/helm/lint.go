@@ -0,0 +1,97 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package helm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Lint runs helm lint for the charts.
+type Lint struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Strict bool `yaml:"strict"`
+}
+
+// NewLint builds Lint node.
+func NewLint(meta *meta.Options) *Lint {
+	return &Lint{
+		BaseNode: dag.NewBaseNode("lint-helm"),
+
+		meta: meta,
+
+		Strict: true,
+	}
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (lint *Lint) CompileMakefile(output *makefile.Output) error {
+	output.Target(lint.Name()).Description("Runs helm lint.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (lint *Lint) CompileDockerfile(output *dockerfile.Output) error {
+	args := []string{"lint"}
+
+	if lint.Strict {
+		args = append(args, "--strict")
+	}
+
+	output.Stage(lint.Name()).
+		Description("runs helm lint").
+		From("helm").
+		Step(step.Script(fmt.Sprintf("helm %s %s", strings.Join(args, " "), strings.Join(lint.meta.HelmCharts, " "))))
+
+	return nil
+}
+
+// Template validates that charts can be rendered with helm template.
+type Template struct {
+	dag.BaseNode
+
+	meta *meta.Options
+}
+
+// NewTemplate builds Template node.
+func NewTemplate(meta *meta.Options) *Template {
+	return &Template{
+		BaseNode: dag.NewBaseNode("lint-helm-template"),
+
+		meta: meta,
+	}
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (template *Template) CompileMakefile(output *makefile.Output) error {
+	output.Target(template.Name()).Description("Validates rendering of helm charts.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (template *Template) CompileDockerfile(output *dockerfile.Output) error {
+	stage := output.Stage(template.Name()).
+		Description("validates helm templates").
+		From("helm")
+
+	for _, chart := range template.meta.HelmCharts {
+		stage.Step(step.Script(fmt.Sprintf("helm template %s > /dev/null", chart)))
+	}
+
+	return nil
+}
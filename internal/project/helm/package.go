@@ -0,0 +1,132 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package helm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Package packages helm charts and pushes them to the OCI registry on releases.
+type Package struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	// Repository is the OCI repository charts are pushed to.
+	Repository string `yaml:"repository"`
+}
+
+// NewPackage builds Package node.
+func NewPackage(meta *meta.Options) *Package {
+	return &Package{
+		BaseNode: dag.NewBaseNode("helm-package"),
+
+		meta: meta,
+
+		Repository: "$(REGISTRY)/$(USERNAME)/charts",
+	}
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (pkg *Package) CompileMakefile(output *makefile.Output) error {
+	output.Target(pkg.Name()).
+		Description("Packages helm charts into $(ARTIFACTS)/helm.").
+		Script("@$(MAKE) local-$@ DEST=$(ARTIFACTS)/helm").
+		Phony()
+
+	output.Target("helm-push").
+		Description("Pushes packaged helm charts to the OCI registry.").
+		Depends(pkg.Name()).
+		Script(fmt.Sprintf(`@docker run --rm -v $(PWD)/$(ARTIFACTS)/helm:/charts -w /charts -e HELM_EXPERIMENTAL_OCI=1 -e DOCKER_USERNAME -e DOCKER_PASSWORD --entrypoint sh $(HELM_IMAGE) \
+	-c 'echo "$${DOCKER_PASSWORD}" | helm registry login --username "$${DOCKER_USERNAME}" --password-stdin $(REGISTRY) \
+	&& for chart in *.tgz; do helm push "$${chart}" oci://%s; done'`, pkg.Repository)).
+		Phony()
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (pkg *Package) CompileDockerfile(output *dockerfile.Output) error {
+	stage := output.Stage(pkg.Name() + "-build").
+		Description("packages helm charts").
+		From("helm")
+
+	args := []string{"package", "--destination", "/out"}
+
+	// charts are versioned the same way as Go binaries
+	if pkg.meta.VersionPackage != "" {
+		stage.Step(step.Arg("TAG"))
+
+		args = append(args, "--version", "${TAG}", "--app-version", "${TAG}")
+	}
+
+	stage.Step(step.Script(fmt.Sprintf("helm %s %s", strings.Join(args, " "), strings.Join(pkg.meta.HelmCharts, " "))))
+
+	output.Stage(pkg.Name()).
+		From("scratch").
+		Step(step.Copy("/out", "/").From(pkg.Name() + "-build"))
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (pkg *Package) CompileDrone(output *drone.Output) error {
+	output.Step(drone.MakeStep(pkg.Name()).
+		DependsOn(dag.GatherMatchingInputNames(pkg, dag.Implements((*drone.Compiler)(nil)))...),
+	)
+
+	output.Step(drone.MakeStep("helm-push").
+		OnlyOnTag().
+		DockerLogin().
+		DependsOn(pkg.Name()),
+	)
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (pkg *Package) CompileGitLab(output *gitlab.Output) error {
+	output.Job(gitlab.MakeJob(pkg.Name()).
+		Stage(pkg.meta.GitLabStages.Build).
+		Artifacts(pkg.meta.ArtifactsPath + "/helm").
+		Needs(dag.GatherMatchingInputNames(pkg, dag.Implements((*gitlab.Compiler)(nil)))...),
+	)
+
+	// DOCKER_USERNAME and DOCKER_PASSWORD CI/CD variables are passed to helm registry login by the helm-push target
+	output.Job(gitlab.MakeJob("helm-push").
+		Stage(pkg.meta.GitLabStages.Build).
+		OnlyOnTag().
+		DockerLogin().
+		Needs(pkg.Name()),
+	)
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (pkg *Package) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	output.Job(ghworkflow.MakeJob(pkg.Name()).
+		Needs(dag.GatherMatchingInputNames(pkg, dag.Implements((*ghworkflow.Compiler)(nil)))...),
+	)
+
+	output.Job(ghworkflow.MakeJob("helm-push").
+		OnlyOnTag().
+		EnvironmentFromSecret("DOCKER_USERNAME", "DOCKER_USERNAME").
+		EnvironmentFromSecret("DOCKER_PASSWORD", "DOCKER_PASSWORD").
+		Needs(pkg.Name()),
+	)
+
+	return nil
+}
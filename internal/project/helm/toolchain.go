@@ -0,0 +1,76 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package helm
+
+import (
+	"path"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Toolchain provides helm and chart sources.
+type Toolchain struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Image string `yaml:"image"`
+}
+
+// NewToolchain builds Toolchain with default values.
+func NewToolchain(meta *meta.Options) *Toolchain {
+	meta.BuildArgs = append(meta.BuildArgs, "HELM_IMAGE")
+
+	return &Toolchain{
+		BaseNode: dag.NewBaseNode("helm"),
+
+		meta: meta,
+
+		Image: "docker.io/alpine/helm:3.4.2",
+	}
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (toolchain *Toolchain) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupDocker).
+		Variable(makefile.OverridableVariable("HELM_IMAGE", toolchain.Image))
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (toolchain *Toolchain) CompileDockerfile(output *dockerfile.Output) error {
+	output.Arg(step.Arg("HELM_IMAGE"))
+
+	stage := output.Stage(toolchain.Name()).
+		Description("helm and chart sources").
+		From("--platform=${BUILDPLATFORM} ${HELM_IMAGE}").
+		Step(step.WorkDir("/src"))
+
+	for _, chart := range toolchain.meta.HelmCharts {
+		stage.Step(step.Copy("./"+chart, "./"+chart))
+	}
+
+	return nil
+}
+
+// SourcePaths implements dag.Node.
+func (toolchain *Toolchain) SourcePaths() []string {
+	paths := make([]string, 0, len(toolchain.meta.HelmCharts))
+
+	for _, chart := range toolchain.meta.HelmCharts {
+		paths = append(paths, path.Join(chart, "**"))
+	}
+
+	return paths
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (toolchain *Toolchain) SkipAsMakefileDependency() {
+}
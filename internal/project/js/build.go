@@ -0,0 +1,135 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package js
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Build runs package build script producing static assets.
+type Build struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Script    string `yaml:"script"`
+	OutputDir string `yaml:"outputDir"`
+}
+
+// NewBuild initializes Build.
+func NewBuild(meta *meta.Options, name string) *Build {
+	return &Build{
+		BaseNode: dag.NewBaseNode(name),
+
+		meta: meta,
+
+		Script:    "build",
+		OutputDir: "dist",
+	}
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (build *Build) CompileDockerfile(output *dockerfile.Output) error {
+	output.Stage(fmt.Sprintf("%s-build", build.Name())).
+		Description(fmt.Sprintf("builds %s", build.Name())).
+		From("js").
+		Step(step.Script(runCommand(build.meta.JSPackageManager, build.Script)))
+
+	output.Stage(build.Name()).
+		From("scratch").
+		Step(step.Copy(filepath.Join("/src", build.meta.JSRoot, build.OutputDir), "/"+build.Name()).From(fmt.Sprintf("%s-build", build.Name())))
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (build *Build) CompileDrone(output *drone.Output) error {
+	output.Step(drone.MakeStep(build.Name()).DependsOn(dag.GatherMatchingInputNames(build, dag.Implements((*drone.Compiler)(nil)))...))
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (build *Build) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	output.Job(ghworkflow.MakeJob(build.Name()).
+		Needs(dag.GatherMatchingInputNames(build, dag.Implements((*ghworkflow.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (build *Build) CompileGitLab(output *gitlab.Output) error {
+	output.Job(gitlab.MakeJob(build.Name()).
+		Stage(build.meta.GitLabStages.Build).
+		Needs(dag.GatherMatchingInputNames(build, dag.Implements((*gitlab.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+func (build *Build) CompileAzurePipelines(output *azurepipelines.Output) error {
+	output.Job(azurepipelines.MakeJob(build.Name()).
+		DependsOn(dag.GatherMatchingInputNames(build, dag.Implements((*azurepipelines.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+func (build *Build) CompileBuildkite(output *buildkite.Output) error {
+	output.Step(buildkite.MakeStep(build.Name()).
+		DependsOn(dag.GatherMatchingInputNames(build, dag.Implements((*buildkite.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileCircleCI implements circleci.Compiler.
+func (build *Build) CompileCircleCI(output *circleci.Output) error {
+	output.Job(circleci.MakeJob(build.Name()).
+		Requires(dag.GatherMatchingInputNames(build, dag.Implements((*circleci.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileTekton implements tekton.Compiler.
+func (build *Build) CompileTekton(output *tekton.Output) error {
+	output.Task(tekton.MakeTask(build.Name()).
+		RunAfter(dag.GatherMatchingInputNames(build, dag.Implements((*tekton.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (build *Build) CompileMakefile(output *makefile.Output) error {
+	output.Target(fmt.Sprintf("$(ARTIFACTS)/%s", build.Name())).
+		Script(fmt.Sprintf("@$(MAKE) local-%s DEST=$(ARTIFACTS)", build.Name())).
+		Phony()
+
+	output.Target(build.Name()).
+		Description(fmt.Sprintf("Builds assets for %s.", build.Name())).
+		Depends(fmt.Sprintf("$(ARTIFACTS)/%s", build.Name())).
+		Phony()
+
+	return nil
+}
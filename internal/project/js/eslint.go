@@ -0,0 +1,51 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package js
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// ESLint provides eslint linter.
+type ESLint struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Args string `yaml:"args"`
+}
+
+// NewESLint builds ESLint node.
+func NewESLint(meta *meta.Options) *ESLint {
+	return &ESLint{
+		BaseNode: dag.NewBaseNode("lint-eslint"),
+
+		meta: meta,
+
+		Args: ".",
+	}
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (lint *ESLint) CompileMakefile(output *makefile.Output) error {
+	output.Target("lint-eslint").Description("Runs eslint linter.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (lint *ESLint) CompileDockerfile(output *dockerfile.Output) error {
+	output.Stage("lint-eslint").
+		Description("runs eslint").
+		From("js").
+		Step(step.Script(execCommand(lint.meta.JSPackageManager, "eslint "+lint.Args)))
+
+	return nil
+}
@@ -0,0 +1,85 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package js
+
+import (
+	"path/filepath"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Install installs dependencies and copies JS sources.
+type Install struct {
+	dag.BaseNode
+
+	meta *meta.Options
+}
+
+// NewInstall initializes Install.
+func NewInstall(meta *meta.Options) *Install {
+	return &Install{
+		BaseNode: dag.NewBaseNode("js"),
+
+		meta: meta,
+	}
+}
+
+// CompileDockerignore implements dockerignore.Compiler.
+func (install *Install) CompileDockerignore(output *dockerignore.Output) error {
+	output.IgnoreLocalPath(filepath.Join(install.meta.JSRoot, "node_modules"))
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (install *Install) CompileDockerfile(output *dockerfile.Output) error {
+	stage := output.Stage(install.Name()).
+		Description("JS dependencies and sources").
+		From("js-toolchain").
+		Step(step.WorkDir(filepath.Join("/src", install.meta.JSRoot)))
+
+	// package.json and lock files go first, so that dependencies are cached
+	for _, file := range install.meta.JSSourceFiles {
+		stage.Step(step.Copy("./"+filepath.Join(install.meta.JSRoot, file), "./"+file))
+	}
+
+	stage.Step(step.Script(installCommand(install.meta.JSPackageManager)).
+		MountCache(cachePath(install.meta.JSPackageManager)))
+
+	for _, directory := range install.meta.JSDirectories {
+		stage.Step(step.Copy("./"+directory, filepath.Join("/src", directory)))
+	}
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (install *Install) CompileMakefile(output *makefile.Output) error {
+	output.Target(install.Name()).
+		Description("Prepare JS dependencies and sources").
+		Script("@$(MAKE) target-$@").
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (install *Install) CompileDrone(output *drone.Output) error {
+	output.Step(drone.MakeStep(install.Name()).
+		DependsOn("setup-ci"),
+	)
+
+	return nil
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (install *Install) SkipAsMakefileDependency() {
+}
@@ -0,0 +1,67 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package js provides building blocks for JavaScript/TypeScript projects.
+package js
+
+import "fmt"
+
+// Supported package managers.
+const (
+	NPM  = "npm"
+	Yarn = "yarn"
+	PNPM = "pnpm"
+)
+
+func installCommand(packageManager string) string {
+	switch packageManager {
+	case NPM, "":
+		return "npm ci"
+	case Yarn:
+		return "yarn install --frozen-lockfile"
+	case PNPM:
+		return "pnpm install --frozen-lockfile"
+	default:
+		panic("unsupported package manager: " + packageManager)
+	}
+}
+
+func runCommand(packageManager, script string) string {
+	switch packageManager {
+	case NPM, "":
+		return fmt.Sprintf("npm run %s", script)
+	case Yarn:
+		return fmt.Sprintf("yarn run %s", script)
+	case PNPM:
+		return fmt.Sprintf("pnpm run %s", script)
+	default:
+		panic("unsupported package manager: " + packageManager)
+	}
+}
+
+func execCommand(packageManager, command string) string {
+	switch packageManager {
+	case NPM, "":
+		return fmt.Sprintf("npx --no-install %s", command)
+	case Yarn:
+		return fmt.Sprintf("yarn %s", command)
+	case PNPM:
+		return fmt.Sprintf("pnpm exec %s", command)
+	default:
+		panic("unsupported package manager: " + packageManager)
+	}
+}
+
+func cachePath(packageManager string) string {
+	switch packageManager {
+	case NPM, "":
+		return "/root/.npm"
+	case Yarn:
+		return "/usr/local/share/.cache/yarn"
+	case PNPM:
+		return "/root/.pnpm-store"
+	default:
+		panic("unsupported package manager: " + packageManager)
+	}
+}
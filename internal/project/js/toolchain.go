@@ -0,0 +1,84 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package js
+
+import (
+	"fmt"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Toolchain provides Node.js and package manager.
+type Toolchain struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Version string `yaml:"version"`
+	Image   string `yaml:"image"`
+}
+
+// NewToolchain builds Toolchain with default values.
+func NewToolchain(meta *meta.Options) *Toolchain {
+	meta.BuildArgs = append(meta.BuildArgs, "JS_TOOLCHAIN")
+
+	return &Toolchain{
+		BaseNode: dag.NewBaseNode("js-toolchain"),
+
+		meta: meta,
+
+		Version: "14-alpine",
+	}
+}
+
+func (toolchain *Toolchain) image() string {
+	if toolchain.Image != "" {
+		return toolchain.Image
+	}
+
+	return fmt.Sprintf("docker.io/node:%s", toolchain.Version)
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (toolchain *Toolchain) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupDocker).
+		Variable(makefile.OverridableVariable("JS_TOOLCHAIN", toolchain.image()))
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (toolchain *Toolchain) CompileDockerfile(output *dockerfile.Output) error {
+	output.Arg(step.Arg("JS_TOOLCHAIN"))
+
+	stage := output.Stage(toolchain.Name()).
+		Description("base JS toolchain image").
+		From("--platform=${BUILDPLATFORM} ${JS_TOOLCHAIN}")
+
+	if toolchain.meta.JSPackageManager == PNPM {
+		stage.Step(step.Run("npm", "install", "-g", "pnpm"))
+	}
+
+	return nil
+}
+
+// SourcePaths implements dag.Node.
+func (toolchain *Toolchain) SourcePaths() []string {
+	paths := append([]string(nil), toolchain.meta.JSSourceFiles...)
+
+	for _, directory := range toolchain.meta.JSDirectories {
+		paths = append(paths, directory+"/**")
+	}
+
+	return paths
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (toolchain *Toolchain) SkipAsMakefileDependency() {
+}
@@ -5,16 +5,40 @@
 // Package meta provides project options from source code.
 package meta
 
-import "github.com/talos-systems/kres/internal/config"
+import (
+	"fmt"
+	"sync"
+
+	"github.com/talos-systems/kres/internal/config"
+)
 
 // Options for the project.
+//
+// Options are printed by `kres detect`: fields tagged with `kres:"-"` are skipped,
+// values of the fields tagged with `kres:"secret"` are redacted.
 type Options struct {
 	// Config provider.
-	Config *config.Provider
+	Config *config.Provider `kres:"-"`
+
+	// Root is the directory of the project relative to the current directory (empty for the current directory).
+	//
+	// Generated files are written to the root, paths in the generated files are relative to it.
+	Root string
 
 	// CanonicalPath, import path for Go projects.
+	//
+	// For Go workspaces, it's the import path of the first module.
 	CanonicalPath string
 
+	// GoWorkspace is set if the project is a Go workspace (go.work is present).
+	GoWorkspace bool
+
+	// GoWorkspaceFiles are go.work and go.work.sum (if present).
+	GoWorkspaceFiles []string
+
+	// GoModules are Go modules of the project, a single module at the root unless it's a Go workspace.
+	GoModules []GoModule
+
 	// VersionPackage is a canonical path to version package (if any).
 	VersionPackage string
 
@@ -24,21 +48,314 @@ type Options struct {
 	// GoDirectories are non-standard directories containing Go source code.
 	GoDirectories []string
 
+	// GoTestdataDirectories are `testdata` directories with the test fixtures outside of GoDirectories
+	// (e.g. fixtures of the packages at the module root).
+	GoTestdataDirectories []string
+
 	// Source files on top level.
 	SourceFiles []string
 
 	// Go source files on top level.
 	GoSourceFiles []string
 
+	// ExcludePaths are glob patterns (path.Match syntax, relative to the project root) of the paths
+	// excluded from the build context, so they are not built, linted or tested.
+	//
+	// Pattern matching a directory excludes everything under it.
+	ExcludePaths []string
+
+	// GoVersion is the Go version declared in go.mod.
+	GoVersion string
+
+	// GoTestBuildTags are build tags used in Go test files.
+	GoTestBuildTags []string
+
+	// GoBuildOS are operating systems (GOOS) Go source files are constrained to, either with build constraints
+	// (`//go:build windows`) or with file name suffixes (`_windows.go`), sorted.
+	GoBuildOS []string
+
+	// GoFuzzTargets are native fuzz targets (`FuzzXxx` functions) found in Go test files.
+	GoFuzzTargets []GoFuzzTarget
+
+	// GoGenerate is set if Go sources contain `//go:generate` directives.
+	GoGenerate bool
+
+	// GoEmbedPaths are directories and files outside of GoDirectories referenced by `//go:embed` directives.
+	GoEmbedPaths []string
+
+	// GoTools are packages of the tools pinned in go.mod via the tools package (`tools.go`).
+	GoTools []string
+
+	// GoToolsFiles are files of the tools package outside of GoDirectories.
+	GoToolsFiles []string
+
+	// GoPrivate are module path prefixes of private Go modules.
+	GoPrivate []string
+
+	// GoVendor is set if Go dependencies are vendored (vendor/modules.txt is present).
+	GoVendor bool
+
+	// GoFormatter is the formatter Go sources are verified with (gofumpt or gofmt).
+	GoFormatter string
+
+	// GoSwaggerModule is the directory of the Go module which depends on swag (OpenAPI docs are generated from annotations).
+	GoSwaggerModule string
+
+	// GoSwaggerVersion is the version of swag required in go.mod.
+	GoSwaggerVersion string
+
+	// ProtobufFiles are .proto files (relative to the project root).
+	ProtobufFiles []string
+
+	// ProtobufDirectories are directories Go code is generated into from .proto files.
+	ProtobufDirectories []string
+
+	// ProtobufBuf is set if buf configuration is present, so that code is generated with `buf generate`.
+	ProtobufBuf bool
+
+	// RustDirectories are directories containing Rust source code (crate sources and workspace members).
+	RustDirectories []string
+
+	// Rust source files on top level (Cargo manifest and lock file).
+	RustSourceFiles []string
+
+	// RustPackage is the name of the top-level Cargo package (if any).
+	RustPackage string
+
+	// CMakeProjects are C/C++ projects built with CMake.
+	CMakeProjects []CMakeProject
+
+	// CMakeDirectories are directories containing C/C++ sources and CMake files.
+	CMakeDirectories []string
+
+	// CMakeSourceFiles are top-level CMake files and configs of the C/C++ linters.
+	CMakeSourceFiles []string
+
+	// ClangFormat is set if .clang-format config is present.
+	ClangFormat bool
+
+	// ClangTidy is set if .clang-tidy config is present.
+	ClangTidy bool
+
+	// JSRoot is a directory containing package.json.
+	JSRoot string
+
+	// JSPackageName is the name of the package from package.json.
+	JSPackageName string
+
+	// JSPackageManager is one of npm, yarn or pnpm.
+	JSPackageManager string
+
+	// JSDirectories are directories containing JS/TS source code.
+	JSDirectories []string
+
+	// JSSourceFiles are package.json and lock files.
+	JSSourceFiles []string
+
+	// PythonRoot is a directory containing pyproject.toml or setup.py.
+	PythonRoot string
+
+	// PythonPackageName is the name of the Python project.
+	PythonPackageName string
+
+	// PythonPackageManager is one of pip, poetry or pdm.
+	PythonPackageManager string
+
+	// PythonDirectories are directories containing Python source code.
+	PythonDirectories []string
+
+	// PythonSourceFiles are project metadata, requirements and lock files (relative to PythonRoot).
+	PythonSourceFiles []string
+
+	// PythonFlake8 is set if flake8 is configured for the project, otherwise ruff is used.
+	PythonFlake8 bool
+
+	// HelmCharts are directories containing Helm charts (Chart.yaml).
+	HelmCharts []string
+
+	// Dockerfiles are Dockerfiles found in the project (relative to the project root), except for the generated one.
+	Dockerfiles []string
+
+	// MarkdownFiles are Markdown documents found in the project (relative to the project root).
+	MarkdownFiles []string
+
+	// GitLFS is set if the project stores files in Git LFS, so LFS objects should be pulled after the checkout.
+	GitLFS bool
+
+	// PackageManagers are detected dependency managers (in terms of Renovate managers).
+	PackageManagers []string
+
 	// Commands are top-level binaries to be built.
-	Commands []string
+	Commands []Command
+
+	// ImageGroups map image names to the commands which share that image (instead of an image per command).
+	//
+	// Entrypoint of the image is the first command of the group.
+	ImageGroups map[string][]string
 
 	// BuildArgs passed down to Dockerfiles.
 	BuildArgs []string
 
+	// Platforms are default target platforms for the images.
+	Platforms []string
+
 	// Path to /bin.
 	BinPath string
 
 	// Path to ~/.cache.
 	CachePath string
+
+	// ArtifactsPath is a path to the build artifacts (relative to the project root).
+	ArtifactsPath string
+
+	// ExtraVariables are injected into the Makefile and CI configuration (as global environment).
+	//
+	// Values might reference other variables, e.g. `$(REGISTRY)/proxy` (Makefile syntax).
+	ExtraVariables map[string]string
+
+	// BuildkiteAgents are agent tags (e.g. `queue=builders`) Buildkite pipeline steps are targeted to.
+	BuildkiteAgents map[string]string
+
+	// CosignKey is a cosign key reference to sign images with: file path, KMS URI or `keyless`.
+	//
+	// Images are not signed if not set.
+	CosignKey string
+
+	// BuildCache is a remote cache backend for image builds in CI.
+	//
+	// Remote cache is not used if the type is not set.
+	BuildCache BuildCache
+
+	// GitHubActions is set if GitHub Actions workflow is generated.
+	GitHubActions bool
+
+	// DependabotSchedule is the interval of Dependabot updates (daily, weekly or monthly).
+	DependabotSchedule string
+
+	// DependabotReviewers are assigned to Dependabot pull requests.
+	DependabotReviewers []string
+
+	// CodeOwners are the default owners of the project files (`*` rule in CODEOWNERS).
+	CodeOwners []string
+
+	// DirectoryOwners maps directories (relative to the project root) to their owners.
+	//
+	// Directories without explicit owners fall back to CodeOwners.
+	DirectoryOwners map[string][]string
+
+	// MakeAliases are Makefile targets running the listed targets one by one, e.g. `ci` -> `lint`, `unit-tests`.
+	MakeAliases map[string][]string
+
+	// SecurityContact is the address vulnerabilities are reported to, SECURITY.md is generated if set.
+	SecurityContact string
+
+	// SecurityOverwrite replaces hand-written SECURITY.md with the generated one.
+	SecurityOverwrite bool
+
+	// Funding maps the sponsorship platforms of .github/FUNDING.yml to the handles, e.g. `github` -> `org`.
+	Funding map[string][]string
+
+	// CommunityOverwrite replaces hand-written community health files (FUNDING.yml, templates) with the generated ones.
+	CommunityOverwrite bool
+
+	// EditorConfig overrides properties of .editorconfig sections: glob -> property -> value.
+	EditorConfig map[string]map[string]string
+
+	// GitLabStages are names of GitLab CI stages.
+	GitLabStages GitLabStages
+
+	warningsMu sync.Mutex
+	warnings   []string
+}
+
+// Warn records a warning to be reported to the user.
+//
+// Warn is safe to be called concurrently.
+func (options *Options) Warn(format string, args ...interface{}) {
+	options.warningsMu.Lock()
+	defer options.warningsMu.Unlock()
+
+	options.warnings = append(options.warnings, fmt.Sprintf(format, args...))
+}
+
+// Warnings returns recorded warnings.
+func (options *Options) Warnings() []string {
+	options.warningsMu.Lock()
+	defer options.warningsMu.Unlock()
+
+	return append([]string(nil), options.warnings...)
+}
+
+// GoFuzzTarget is a Go native fuzz target.
+type GoFuzzTarget struct {
+	// Package is a relative package path, e.g. `./internal/parser`.
+	Package string
+	// Name is a fuzz function name, e.g. `FuzzParse`.
+	Name string
+}
+
+// CMakeProject is a C/C++ project built with CMake.
+type CMakeProject struct {
+	// Name of the project from the `project()` command, defaults to the directory name.
+	Name string
+	// Directory is CMakeLists.txt location relative to the project root.
+	Directory string
+}
+
+// GoModule is a Go module of the project.
+type GoModule struct {
+	// Directory is the module root relative to the project root, e.g. `.` or `services/api`.
+	Directory string
+	// CanonicalPath is the module path from go.mod.
+	CanonicalPath string
+	// SourceFiles are go.mod and go.sum (if present) relative to the project root.
+	SourceFiles []string
+}
+
+// Command is a binary to be built.
+type Command struct {
+	// Name of the binary, unique across the project.
+	Name string
+	// Path is the directory of the main package relative to the project root, e.g. `cmd/kres`.
+	Path string
+	// OutputName is the name of the compiled binary, defaults to Name.
+	OutputName string
+	// InstallPath is the directory the binary is installed to in the image, defaults to `/`.
+	InstallPath string
+	// BinaryOnly commands are built without an image.
+	BinaryOnly bool
+}
+
+// Go source formatters.
+const (
+	GoFormatterGofumpt = "gofumpt"
+	GoFormatterGofmt   = "gofmt"
+)
+
+// Build cache backend types.
+const (
+	BuildCacheRegistry = "registry"
+	BuildCacheS3       = "s3"
+)
+
+// BuildCache is a remote cache backend of docker buildx.
+type BuildCache struct {
+	// Type is one of registry or s3.
+	Type string
+	// Ref is the image repository (for registry) or the bucket name (for s3).
+	Ref string
+	// Region of the S3 bucket.
+	Region string
+	// Endpoint is an S3 endpoint URL for S3-compatible storage.
+	Endpoint string
+	// AccessKeySecret and SecretKeySecret are names of the CI secrets with S3 credentials.
+	AccessKeySecret string
+	SecretKeySecret string
+}
+
+// GitLabStages are names of GitLab CI stages jobs are assigned to.
+type GitLabStages struct {
+	Lint  string
+	Test  string
+	Build string
 }
@@ -6,6 +6,9 @@
 package project
 
 import (
+	"runtime"
+	"sync"
+
 	"github.com/talos-systems/kres/internal/config"
 	"github.com/talos-systems/kres/internal/dag"
 	"github.com/talos-systems/kres/internal/output"
@@ -14,14 +17,55 @@ import (
 // Contents is a DAG of the project.
 type Contents struct {
 	dag.BaseGraph
+
+	// Workers is the number of outputs compiled concurrently (number of CPUs by default).
+	Workers int
 }
 
 // Compile the project to specified outputs.
+//
+// Outputs are compiled concurrently, but nodes are compiled into each output in the
+// topological order, so that generated files are deterministic.
 func (project *Contents) Compile(outputs []output.Writer) error {
-	for _, output := range outputs {
-		visited := make(map[dag.Node]struct{})
+	nodes, err := dag.TopologicalSort(project)
+	if err != nil {
+		return err
+	}
+
+	workers := project.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var wg sync.WaitGroup
+
+	errs := make([]error, len(outputs))
+	sem := make(chan struct{}, workers)
+
+	for i := range outputs {
+		i := i
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			for _, node := range nodes {
+				if errs[i] = outputs[i].Compile(node); errs[i] != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
 
-		if err := project.CompileTo(output, visited); err != nil {
+	// report errors in the order of outputs
+	for _, err := range errs {
+		if err != nil {
 			return err
 		}
 	}
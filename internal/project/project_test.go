@@ -0,0 +1,112 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package project_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output"
+	"github.com/talos-systems/kres/internal/project"
+)
+
+type node struct {
+	dag.BaseNode
+}
+
+func newNode(name string, inputs ...dag.Node) *node {
+	n := &node{
+		BaseNode: dag.NewBaseNode(name),
+	}
+
+	n.AddInput(inputs...)
+
+	return n
+}
+
+// recorder records the names of the compiled nodes, failing on the node with the specified name.
+type recorder struct {
+	failOn   string
+	compiled []string
+}
+
+func (r *recorder) Generate() error { return nil }
+
+func (r *recorder) Diff() ([]output.Change, error) { return nil, nil }
+
+func (r *recorder) Root(string) {}
+
+func (r *recorder) Compile(n interface{}) error {
+	name := n.(dag.Node).Name()
+
+	if name == r.failOn {
+		return errors.New("failed on " + name)
+	}
+
+	r.compiled = append(r.compiled, name)
+
+	return nil
+}
+
+func TestCompile(t *testing.T) {
+	toolchain := newNode("toolchain")
+	lint := newNode("lint", toolchain)
+	build := newNode("build", toolchain, lint)
+
+	for _, workers := range []int{0, 1, 3} {
+		proj := &project.Contents{Workers: workers}
+		proj.AddTarget(build)
+
+		outputs := []*recorder{{}, {}, {}, {}}
+
+		writers := make([]output.Writer, 0, len(outputs))
+		for _, out := range outputs {
+			writers = append(writers, out)
+		}
+
+		require.NoError(t, proj.Compile(writers))
+
+		for _, out := range outputs {
+			assert.Equal(t, []string{"toolchain", "lint", "build"}, out.compiled)
+		}
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	toolchain := newNode("toolchain")
+	lint := newNode("lint", toolchain)
+	build := newNode("build", toolchain, lint)
+
+	proj := &project.Contents{}
+	proj.AddTarget(build)
+
+	first, second, third := &recorder{}, &recorder{failOn: "build"}, &recorder{failOn: "lint"}
+
+	// errors are reported in the order of outputs, compilation of the failed output stops
+	assert.EqualError(t, proj.Compile([]output.Writer{first, second, third}), "failed on build")
+
+	assert.Equal(t, []string{"toolchain", "lint", "build"}, first.compiled)
+	assert.Equal(t, []string{"toolchain", "lint"}, second.compiled)
+	assert.Equal(t, []string{"toolchain"}, third.compiled)
+}
+
+func TestCompileCycle(t *testing.T) {
+	toolchain := newNode("toolchain")
+	build := newNode("build", toolchain)
+
+	toolchain.AddInput(build)
+
+	proj := &project.Contents{}
+	proj.AddTarget(build)
+
+	out := &recorder{}
+
+	assert.EqualError(t, proj.Compile([]output.Writer{out}), "cycle detected: build -> toolchain -> build")
+	assert.Empty(t, out.compiled)
+}
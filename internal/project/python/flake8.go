@@ -0,0 +1,55 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package python
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Flake8 provides flake8 linter.
+type Flake8 struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Version string `yaml:"version"`
+	Args    string `yaml:"args"`
+}
+
+// NewFlake8 builds Flake8 node.
+func NewFlake8(meta *meta.Options) *Flake8 {
+	return &Flake8{
+		BaseNode: dag.NewBaseNode("lint-flake8"),
+
+		meta: meta,
+
+		Version: "6.0.0",
+		Args:    ".",
+	}
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (lint *Flake8) CompileMakefile(output *makefile.Output) error {
+	output.Target("lint-flake8").Description("Runs flake8 linter.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (lint *Flake8) CompileDockerfile(output *dockerfile.Output) error {
+	output.Stage("lint-flake8").
+		Description("runs flake8").
+		From("python").
+		Step(step.Script(toolInstallCommand("flake8==" + lint.Version)).
+			MountCache(pipCachePath)).
+		Step(step.Script("flake8 " + lint.Args))
+
+	return nil
+}
@@ -0,0 +1,100 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package python
+
+import (
+	"path/filepath"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/dockerignore"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Install installs dependencies and copies Python sources.
+type Install struct {
+	dag.BaseNode
+
+	meta *meta.Options
+}
+
+// NewInstall initializes Install.
+func NewInstall(meta *meta.Options) *Install {
+	return &Install{
+		BaseNode: dag.NewBaseNode("python"),
+
+		meta: meta,
+	}
+}
+
+// CompileDockerignore implements dockerignore.Compiler.
+func (install *Install) CompileDockerignore(output *dockerignore.Output) error {
+	output.IgnoreLocalPath(filepath.Join(install.meta.PythonRoot, ".venv"))
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (install *Install) CompileDockerfile(output *dockerfile.Output) error {
+	stage := output.Stage(install.Name()).
+		Description("Python dependencies and sources").
+		From("python-toolchain").
+		Step(step.WorkDir(filepath.Join("/src", install.meta.PythonRoot)))
+
+	copySourceFiles(stage, install.meta)
+
+	// dependencies go first, so that they are cached
+	if command := installDependenciesCommand(install.meta.PythonPackageManager, install.meta.PythonSourceFiles); command != "" {
+		stage.Step(step.Script(command).
+			MountCache(pipCachePath))
+	}
+
+	copyDirectories(stage, install.meta)
+
+	stage.Step(step.Script(installCommand(install.meta.PythonPackageManager, install.meta.PythonSourceFiles)).
+		MountCache(pipCachePath))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (install *Install) CompileMakefile(output *makefile.Output) error {
+	output.Target(install.Name()).
+		Description("Prepare Python dependencies and sources").
+		Script("@$(MAKE) target-$@").
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (install *Install) CompileDrone(output *drone.Output) error {
+	output.Step(drone.MakeStep(install.Name()).
+		DependsOn("setup-ci"),
+	)
+
+	return nil
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (install *Install) SkipAsMakefileDependency() {
+}
+
+// copySourceFiles copies project metadata, requirements and lock files into the stage.
+func copySourceFiles(stage *dockerfile.Stage, meta *meta.Options) {
+	for _, file := range meta.PythonSourceFiles {
+		stage.Step(step.Copy("./"+filepath.Join(meta.PythonRoot, file), "./"+file))
+	}
+}
+
+// copyDirectories copies Python sources into the stage.
+func copyDirectories(stage *dockerfile.Stage, meta *meta.Options) {
+	for _, directory := range meta.PythonDirectories {
+		stage.Step(step.Copy("./"+directory, filepath.Join("/src", directory)))
+	}
+}
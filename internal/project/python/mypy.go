@@ -0,0 +1,55 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package python
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// MyPy provides mypy type checker.
+type MyPy struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Version string `yaml:"version"`
+	Args    string `yaml:"args"`
+}
+
+// NewMyPy builds MyPy node.
+func NewMyPy(meta *meta.Options) *MyPy {
+	return &MyPy{
+		BaseNode: dag.NewBaseNode("lint-mypy"),
+
+		meta: meta,
+
+		Version: "0.991",
+		Args:    ".",
+	}
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (lint *MyPy) CompileMakefile(output *makefile.Output) error {
+	output.Target("lint-mypy").Description("Runs mypy type checker.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (lint *MyPy) CompileDockerfile(output *dockerfile.Output) error {
+	output.Stage("lint-mypy").
+		Description("runs mypy").
+		From("python").
+		Step(step.Script(toolInstallCommand("mypy==" + lint.Version)).
+			MountCache(pipCachePath)).
+		Step(step.Script("mypy " + lint.Args))
+
+	return nil
+}
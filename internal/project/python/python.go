@@ -0,0 +1,78 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package python provides building blocks for Python projects.
+package python
+
+import "strings"
+
+// Supported package managers.
+const (
+	Pip    = "pip"
+	Poetry = "poetry"
+	PDM    = "pdm"
+)
+
+// pipCachePath is the cache used by pip (and by tools installed with pip).
+const pipCachePath = "/root/.cache/pip"
+
+// installDependenciesCommand installs project dependencies (including development ones)
+// without the project itself, so that dependencies are cached separately from the sources.
+//
+// Dependencies are installed into the system interpreter, so that tools are available in PATH.
+func installDependenciesCommand(packageManager string, sourceFiles []string) string {
+	switch packageManager {
+	case Pip, "":
+		for _, file := range sourceFiles {
+			if file == "requirements.txt" {
+				return "pip install -r requirements.txt"
+			}
+		}
+
+		return ""
+	case Poetry:
+		return "poetry install --no-root"
+	case PDM:
+		return "pdm export --dev --without-hashes -o /tmp/requirements.txt && pip install -r /tmp/requirements.txt"
+	default:
+		panic("unsupported package manager: " + packageManager)
+	}
+}
+
+// installCommand installs the project itself.
+func installCommand(packageManager string, sourceFiles []string) string {
+	switch packageManager {
+	case Pip, "":
+		if installDependenciesCommand(packageManager, sourceFiles) == "" {
+			return "pip install ."
+		}
+
+		return "pip install --no-deps ."
+	case PDM:
+		return "pip install --no-deps ."
+	case Poetry:
+		return "poetry install"
+	default:
+		panic("unsupported package manager: " + packageManager)
+	}
+}
+
+// buildCommand installs the project with runtime dependencies only.
+func buildCommand(packageManager string) string {
+	switch packageManager {
+	case Pip, "":
+		return "pip install ."
+	case Poetry:
+		return "poetry install --no-dev"
+	case PDM:
+		return "pdm export --prod --without-hashes -o /tmp/requirements.txt && pip install -r /tmp/requirements.txt && pip install --no-deps ."
+	default:
+		panic("unsupported package manager: " + packageManager)
+	}
+}
+
+// toolInstallCommand installs a tool into the system interpreter.
+func toolInstallCommand(packages ...string) string {
+	return "pip install " + strings.Join(packages, " ")
+}
@@ -0,0 +1,55 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package python
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Ruff provides ruff linter.
+type Ruff struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Version string `yaml:"version"`
+	Args    string `yaml:"args"`
+}
+
+// NewRuff builds Ruff node.
+func NewRuff(meta *meta.Options) *Ruff {
+	return &Ruff{
+		BaseNode: dag.NewBaseNode("lint-ruff"),
+
+		meta: meta,
+
+		Version: "0.0.241",
+		Args:    ".",
+	}
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (lint *Ruff) CompileMakefile(output *makefile.Output) error {
+	output.Target("lint-ruff").Description("Runs ruff linter.").
+		Script("@$(MAKE) target-$@")
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (lint *Ruff) CompileDockerfile(output *dockerfile.Output) error {
+	output.Stage("lint-ruff").
+		Description("runs ruff").
+		From("python").
+		Step(step.Script(toolInstallCommand("ruff==" + lint.Version)).
+			MountCache(pipCachePath)).
+		Step(step.Script("ruff check " + lint.Args))
+
+	return nil
+}
@@ -0,0 +1,104 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package python
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Toolchain provides Python toolchain and the package manager.
+type Toolchain struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Version string `yaml:"version"`
+	Image   string `yaml:"image"`
+
+	PoetryVersion string `yaml:"poetryVersion"`
+	PDMVersion    string `yaml:"pdmVersion"`
+}
+
+// NewToolchain builds Toolchain with default values.
+func NewToolchain(meta *meta.Options) *Toolchain {
+	meta.BuildArgs = append(meta.BuildArgs, "PYTHON_TOOLCHAIN")
+
+	return &Toolchain{
+		BaseNode: dag.NewBaseNode("python-toolchain"),
+
+		meta: meta,
+
+		Version:       "3.9-slim",
+		PoetryVersion: "1.1.13",
+		PDMVersion:    "1.15.0",
+	}
+}
+
+func (toolchain *Toolchain) image() string {
+	if toolchain.Image != "" {
+		return toolchain.Image
+	}
+
+	return fmt.Sprintf("docker.io/python:%s", toolchain.Version)
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (toolchain *Toolchain) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupDocker).
+		Variable(makefile.OverridableVariable("PYTHON_TOOLCHAIN", toolchain.image()))
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (toolchain *Toolchain) CompileDockerfile(output *dockerfile.Output) error {
+	output.Arg(step.Arg("PYTHON_TOOLCHAIN"))
+
+	// toolchain is built for the target platform, as the image is based on it
+	stage := output.Stage(toolchain.Name()).
+		Description("base Python toolchain image").
+		From("${PYTHON_TOOLCHAIN}").
+		Step(step.Env("PIP_DISABLE_PIP_VERSION_CHECK", "1"))
+
+	switch toolchain.meta.PythonPackageManager {
+	case Poetry:
+		// dependencies are installed into the system interpreter, so that tools are available in PATH
+		stage.
+			Step(step.Env("POETRY_VIRTUALENVS_CREATE", "false")).
+			Step(step.Run("pip", "install", "poetry=="+toolchain.PoetryVersion).
+				MountCache(pipCachePath))
+	case PDM:
+		stage.Step(step.Run("pip", "install", "pdm=="+toolchain.PDMVersion).
+			MountCache(pipCachePath))
+	}
+
+	return nil
+}
+
+// SourcePaths implements dag.Node.
+func (toolchain *Toolchain) SourcePaths() []string {
+	paths := make([]string, 0, len(toolchain.meta.PythonSourceFiles)+len(toolchain.meta.PythonDirectories))
+
+	for _, file := range toolchain.meta.PythonSourceFiles {
+		paths = append(paths, path.Join(toolchain.meta.PythonRoot, file))
+	}
+
+	for _, directory := range toolchain.meta.PythonDirectories {
+		paths = append(paths, directory+"/**")
+	}
+
+	return paths
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (toolchain *Toolchain) SkipAsMakefileDependency() {
+}
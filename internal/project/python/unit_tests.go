@@ -0,0 +1,113 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package python
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// UnitTestsCoverageFile is the name of the coverage file produced by Python unit-tests.
+const UnitTestsCoverageFile = "coverage-python.xml"
+
+// UnitTests runs unit-tests with pytest.
+type UnitTests struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	PytestVersion    string `yaml:"pytestVersion"`
+	PytestCovVersion string `yaml:"pytestCovVersion"`
+	Args             string `yaml:"args"`
+
+	// Description is shown for the target in `make help`.
+	Description string `yaml:"description"`
+}
+
+// NewUnitTests initializes UnitTests.
+func NewUnitTests(meta *meta.Options) *UnitTests {
+	return &UnitTests{
+		BaseNode: dag.NewBaseNode("unit-tests-python"),
+
+		meta: meta,
+
+		PytestVersion:    "7.2.1",
+		PytestCovVersion: "4.0.0",
+
+		Description: "Performs Python unit tests",
+	}
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (tests *UnitTests) CompileDockerfile(output *dockerfile.Output) error {
+	coverageFile := filepath.Join("/src", UnitTestsCoverageFile)
+
+	command := fmt.Sprintf("pytest --cov --cov-report=xml:%s", coverageFile)
+	if tests.Args != "" {
+		command += " " + tests.Args
+	}
+
+	output.Stage("unit-tests-python-run").
+		Description("runs Python unit-tests").
+		From("python").
+		Step(step.Script(toolInstallCommand("pytest=="+tests.PytestVersion, "pytest-cov=="+tests.PytestCovVersion)).
+			MountCache(pipCachePath)).
+		Step(step.Script(command))
+
+	output.Stage("unit-tests-python").
+		From("scratch").
+		Step(step.Copy(coverageFile, "/"+UnitTestsCoverageFile).From("unit-tests-python-run"))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (tests *UnitTests) CompileMakefile(output *makefile.Output) error {
+	output.Target(tests.Name()).
+		Description(tests.Description).
+		Script("@$(MAKE) local-$@ DEST=$(ARTIFACTS)").
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (tests *UnitTests) CompileDrone(output *drone.Output) error {
+	output.Step(drone.MakeStep(tests.Name()).
+		DependsOn(dag.GatherMatchingInputNames(tests, dag.Implements((*drone.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (tests *UnitTests) CompileGitLab(output *gitlab.Output) error {
+	output.Job(gitlab.MakeJob(tests.Name()).
+		Stage(tests.meta.GitLabStages.Test).
+		Needs(dag.GatherMatchingInputNames(tests, dag.Implements((*gitlab.Compiler)(nil)))...).
+		Artifacts(filepath.Join(tests.meta.ArtifactsPath, UnitTestsCoverageFile)),
+	)
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (tests *UnitTests) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	output.Job(ghworkflow.MakeJob(tests.Name()).
+		Needs(dag.GatherMatchingInputNames(tests, dag.Implements((*ghworkflow.Compiler)(nil)))...).
+		UploadArtifact("coverage", filepath.Join(tests.meta.ArtifactsPath, UnitTestsCoverageFile)),
+	)
+
+	return nil
+}
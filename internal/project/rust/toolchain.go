@@ -0,0 +1,111 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package rust
+
+import (
+	"fmt"
+
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/dockerfile"
+	"github.com/talos-systems/kres/internal/output/dockerfile/step"
+	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/project/meta"
+)
+
+// Toolchain provides Rust compiler and cargo.
+type Toolchain struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Version string `yaml:"version"`
+	Image   string `yaml:"image"`
+}
+
+// NewToolchain builds Toolchain with default values.
+func NewToolchain(meta *meta.Options) *Toolchain {
+	meta.BuildArgs = append(meta.BuildArgs, "RUST_TOOLCHAIN")
+
+	return &Toolchain{
+		BaseNode: dag.NewBaseNode("rust-base"),
+
+		meta: meta,
+
+		Version: "1.46",
+	}
+}
+
+func (toolchain *Toolchain) image() string {
+	if toolchain.Image != "" {
+		return toolchain.Image
+	}
+
+	return fmt.Sprintf("docker.io/rust:%s", toolchain.Version)
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (toolchain *Toolchain) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupDocker).
+		Variable(makefile.OverridableVariable("RUST_TOOLCHAIN", toolchain.image()))
+
+	output.Target(toolchain.Name()).
+		Description("Prepare base Rust toolchain").
+		Script("@$(MAKE) target-$@").
+		Phony()
+
+	return nil
+}
+
+// CompileDrone implements drone.Compiler.
+func (toolchain *Toolchain) CompileDrone(output *drone.Output) error {
+	output.Step(drone.MakeStep(toolchain.Name()).
+		DependsOn("setup-ci"),
+	)
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (toolchain *Toolchain) CompileDockerfile(output *dockerfile.Output) error {
+	output.Arg(step.Arg("RUST_TOOLCHAIN"))
+
+	output.Stage("rust-toolchain").
+		Description("base Rust toolchain image").
+		From("${RUST_TOOLCHAIN}")
+
+	base := output.Stage(toolchain.Name()).
+		Description("Rust toolchain and sources").
+		From("rust-toolchain").
+		Step(step.WorkDir("/src"))
+
+	for _, file := range toolchain.meta.RustSourceFiles {
+		base.Step(step.Copy("./"+file, "./"+file))
+	}
+
+	for _, directory := range toolchain.meta.RustDirectories {
+		base.Step(step.Copy("./"+directory, "./"+directory))
+	}
+
+	base.Step(step.Run("cargo", "fetch").
+		MountCache("/usr/local/cargo/registry"))
+
+	return nil
+}
+
+// SourcePaths implements dag.Node.
+func (toolchain *Toolchain) SourcePaths() []string {
+	paths := append([]string(nil), toolchain.meta.RustSourceFiles...)
+
+	for _, directory := range toolchain.meta.RustDirectories {
+		paths = append(paths, directory+"/**")
+	}
+
+	return paths
+}
+
+// SkipAsMakefileDependency implements makefile.SkipAsMakefileDependency.
+func (toolchain *Toolchain) SkipAsMakefileDependency() {
+}
@@ -6,11 +6,18 @@ package service
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
 	"github.com/talos-systems/kres/internal/output/codecov"
 	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/meta"
 )
 
@@ -20,9 +27,16 @@ type CodeCov struct {
 
 	meta *meta.Options
 
-	Enabled         bool   `yaml:"enabled"`
-	InputPath       string `yaml:"inputPath"`
-	TargetThreshold int    `yaml:"targetThreshold"`
+	Enabled         bool     `yaml:"enabled"`
+	InputPath       string   `yaml:"inputPath"`
+	ExtraInputPaths []string `yaml:"extraInputPaths"`
+	TargetThreshold int      `yaml:"targetThreshold"`
+}
+
+// CoverageProducer is implemented by the inputs which produce coverage files.
+type CoverageProducer interface {
+	// CoverageFile is the path of the coverage file relative to the artifacts directory.
+	CoverageFile() string
 }
 
 // NewCodeCov initializes CodeCov.
@@ -37,6 +51,53 @@ func NewCodeCov(meta *meta.Options) *CodeCov {
 	}
 }
 
+// AddInputPath adds coverage file to be uploaded (path is relative to the artifacts directory).
+func (coverage *CodeCov) AddInputPath(path string) {
+	if coverage.InputPath == "" {
+		coverage.InputPath = path
+
+		return
+	}
+
+	coverage.ExtraInputPaths = append(coverage.ExtraInputPaths, path)
+}
+
+// inputPaths returns configured coverage files followed by the files produced by the inputs.
+func (coverage *CodeCov) inputPaths() []string {
+	var paths []string
+
+	if coverage.InputPath != "" {
+		paths = append(paths, coverage.InputPath)
+	}
+
+	paths = append(paths, coverage.ExtraInputPaths...)
+
+	for _, input := range coverage.Inputs() {
+		producer, ok := input.(CoverageProducer)
+		if !ok {
+			continue
+		}
+
+		path := producer.CoverageFile()
+
+		found := false
+
+		for _, p := range paths {
+			if p == path {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
+
 // CompileDrone implements drone.Compiler.
 func (coverage *CodeCov) CompileDrone(output *drone.Output) error {
 	if !coverage.Enabled {
@@ -51,14 +112,80 @@ func (coverage *CodeCov) CompileDrone(output *drone.Output) error {
 	return nil
 }
 
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (coverage *CodeCov) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	if !coverage.Enabled {
+		return nil
+	}
+
+	output.Job(ghworkflow.MakeJob("coverage").
+		Needs(dag.GatherMatchingInputNames(coverage, dag.Implements((*ghworkflow.Compiler)(nil)))...).
+		DownloadArtifact("coverage", coverage.meta.ArtifactsPath).
+		EnvironmentFromSecret("CODECOV_TOKEN", "CODECOV_TOKEN"),
+	)
+
+	return nil
+}
+
+// CompileGitLab implements gitlab.Compiler.
+func (coverage *CodeCov) CompileGitLab(output *gitlab.Output) error {
+	if !coverage.Enabled {
+		return nil
+	}
+
+	// CODECOV_TOKEN should be set as CI/CD variable of the project
+	output.Job(gitlab.MakeJob("coverage").
+		Stage(coverage.meta.GitLabStages.Test).
+		Needs(dag.GatherMatchingInputNames(coverage, dag.Implements((*gitlab.Compiler)(nil)))...),
+	)
+
+	return nil
+}
+
+// skipUpload reports that the CI system doesn't pass the coverage files between the steps.
+func (coverage *CodeCov) skipUpload(system string) error {
+	if coverage.Enabled {
+		coverage.meta.Warn("coverage upload is not supported by %s, as the coverage files are not shared between the steps, the step is skipped", system)
+	}
+
+	return nil
+}
+
+// CompileCircleCI implements circleci.Compiler.
+func (coverage *CodeCov) CompileCircleCI(output *circleci.Output) error {
+	return coverage.skipUpload("CircleCI")
+}
+
+// CompileBuildkite implements buildkite.Compiler.
+func (coverage *CodeCov) CompileBuildkite(output *buildkite.Output) error {
+	return coverage.skipUpload("Buildkite")
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler.
+func (coverage *CodeCov) CompileAzurePipelines(output *azurepipelines.Output) error {
+	return coverage.skipUpload("Azure Pipelines")
+}
+
+// CompileTekton implements tekton.Compiler.
+func (coverage *CodeCov) CompileTekton(output *tekton.Output) error {
+	return coverage.skipUpload("Tekton")
+}
+
 // CompileMakefile implements makefile.Compiler.
 func (coverage *CodeCov) CompileMakefile(output *makefile.Output) error {
 	if !coverage.Enabled {
 		return nil
 	}
 
+	paths := coverage.inputPaths()
+	files := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		files = append(files, fmt.Sprintf("-f $(ARTIFACTS)/%s", path))
+	}
+
 	output.Target("coverage").Description("Upload coverage data to codecov.io.").
-		Script(fmt.Sprintf(`bash -c "bash <(curl -s https://codecov.io/bash) -f $(ARTIFACTS)/%s -X fix"`, coverage.InputPath)).
+		Script(fmt.Sprintf(`bash -c "bash <(curl -s https://codecov.io/bash) %s -X fix"`, strings.Join(files, " "))).
 		Phony()
 
 	return nil
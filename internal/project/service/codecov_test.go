@@ -9,12 +9,24 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+	"github.com/talos-systems/kres/internal/output/circleci"
 	"github.com/talos-systems/kres/internal/output/drone"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+	"github.com/talos-systems/kres/internal/output/gitlab"
 	"github.com/talos-systems/kres/internal/output/makefile"
+	"github.com/talos-systems/kres/internal/output/tekton"
 	"github.com/talos-systems/kres/internal/project/service"
 )
 
 func TestCodeCovInterfaces(t *testing.T) {
 	assert.Implements(t, (*makefile.Compiler)(nil), new(service.CodeCov))
 	assert.Implements(t, (*drone.Compiler)(nil), new(service.CodeCov))
+	assert.Implements(t, (*circleci.Compiler)(nil), new(service.CodeCov))
+	assert.Implements(t, (*buildkite.Compiler)(nil), new(service.CodeCov))
+	assert.Implements(t, (*azurepipelines.Compiler)(nil), new(service.CodeCov))
+	assert.Implements(t, (*tekton.Compiler)(nil), new(service.CodeCov))
+	assert.Implements(t, (*gitlab.Compiler)(nil), new(service.CodeCov))
+	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(service.CodeCov))
 }
@@ -0,0 +1,25 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wrap
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/azurepipelines"
+)
+
+// AzurePipelinesWrapper wraps the node so that it has only azurepipelines.Compiler interface exposed.
+type AzurePipelinesWrapper struct {
+	dag.Node
+}
+
+// AzurePipelines returns new AzurePipelinesWrapper.
+func AzurePipelines(wrapped dag.Node) *AzurePipelinesWrapper {
+	return &AzurePipelinesWrapper{wrapped}
+}
+
+// CompileAzurePipelines implements azurepipelines.Compiler interface.
+func (azurepipelines *AzurePipelinesWrapper) CompileAzurePipelines(*azurepipelines.Output) error {
+	return nil
+}
@@ -0,0 +1,25 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wrap
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/buildkite"
+)
+
+// BuildkiteWrapper wraps the node so that it has only buildkite.Compiler interface exposed.
+type BuildkiteWrapper struct {
+	dag.Node
+}
+
+// Buildkite returns new BuildkiteWrapper.
+func Buildkite(wrapped dag.Node) *BuildkiteWrapper {
+	return &BuildkiteWrapper{wrapped}
+}
+
+// CompileBuildkite implements buildkite.Compiler interface.
+func (buildkite *BuildkiteWrapper) CompileBuildkite(*buildkite.Output) error {
+	return nil
+}
@@ -0,0 +1,22 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wrap
+
+import "github.com/talos-systems/kres/internal/dag"
+
+// CI wraps the node into wrappers for every supported CI system.
+//
+// Use it to add CI-only dependency on the node.
+func CI(wrapped dag.Node) []dag.Node {
+	return []dag.Node{
+		Drone(wrapped),
+		GitLab(wrapped),
+		GitHubWorkflow(wrapped),
+		Tekton(wrapped),
+		CircleCI(wrapped),
+		Buildkite(wrapped),
+		AzurePipelines(wrapped),
+	}
+}
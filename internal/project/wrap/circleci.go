@@ -0,0 +1,25 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wrap
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/circleci"
+)
+
+// CircleCIWrapper wraps the node so that it has only circleci.Compiler interface exposed.
+type CircleCIWrapper struct {
+	dag.Node
+}
+
+// CircleCI returns new CircleCIWrapper.
+func CircleCI(wrapped dag.Node) *CircleCIWrapper {
+	return &CircleCIWrapper{wrapped}
+}
+
+// CompileCircleCI implements circleci.Compiler interface.
+func (circleci *CircleCIWrapper) CompileCircleCI(*circleci.Output) error {
+	return nil
+}
@@ -0,0 +1,25 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wrap
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/ghworkflow"
+)
+
+// GitHubWorkflowWrapper wraps the node so that it has only ghworkflow.Compiler interface exposed.
+type GitHubWorkflowWrapper struct {
+	dag.Node
+}
+
+// GitHubWorkflow returns new GitHubWorkflowWrapper.
+func GitHubWorkflow(wrapped dag.Node) *GitHubWorkflowWrapper {
+	return &GitHubWorkflowWrapper{wrapped}
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler interface.
+func (ghworkflow *GitHubWorkflowWrapper) CompileGitHubWorkflow(*ghworkflow.Output) error {
+	return nil
+}
@@ -0,0 +1,25 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wrap
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/gitlab"
+)
+
+// GitLabWrapper wraps the node so that it has only gitlab.Compiler interface exposed.
+type GitLabWrapper struct {
+	dag.Node
+}
+
+// GitLab returns new GitLabWrapper.
+func GitLab(wrapped dag.Node) *GitLabWrapper {
+	return &GitLabWrapper{wrapped}
+}
+
+// CompileGitLab implements gitlab.Compiler interface.
+func (gitlab *GitLabWrapper) CompileGitLab(*gitlab.Output) error {
+	return nil
+}
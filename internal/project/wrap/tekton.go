@@ -0,0 +1,25 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package wrap
+
+import (
+	"github.com/talos-systems/kres/internal/dag"
+	"github.com/talos-systems/kres/internal/output/tekton"
+)
+
+// TektonWrapper wraps the node so that it has only tekton.Compiler interface exposed.
+type TektonWrapper struct {
+	dag.Node
+}
+
+// Tekton returns new TektonWrapper.
+func Tekton(wrapped dag.Node) *TektonWrapper {
+	return &TektonWrapper{wrapped}
+}
+
+// CompileTekton implements tekton.Compiler interface.
+func (tekton *TektonWrapper) CompileTekton(*tekton.Output) error {
+	return nil
+}
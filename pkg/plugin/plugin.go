@@ -0,0 +1,122 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package plugin provides the registry of custom node types.
+//
+// Custom nodes are registered by the kres builds which import the packages implementing them,
+// and are instantiated from the `.kres.yaml` documents of kind `custom.Node`:
+//
+//	kind: custom.Node
+//	name: upload-artifacts
+//	spec:
+//	  type: acme.UploadArtifacts
+//	  params:
+//	    bucket: releases
+//
+// Node renders Makefile targets, every CI step runs one of the targets, so that the
+// node is supported by all CI systems kres generates configuration for.
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Node is the interface a custom node should implement.
+type Node interface {
+	// Name of the node, unique across the project.
+	Name() string
+	// Targets returns Makefile targets of the node.
+	Targets() []Target
+	// Steps returns CI steps of the node.
+	Steps() []Step
+}
+
+// Target is a Makefile target.
+type Target struct {
+	// Name of the target.
+	Name string
+	// Description is shown in `make help`.
+	Description string
+	// Depends are names of the targets which should be built first.
+	Depends []string
+	// Script are commands of the target (Makefile syntax).
+	Script []string
+	// Phony marks target as not producing a file.
+	Phony bool
+}
+
+// Step is a CI step which runs a Makefile target.
+type Step struct {
+	// Name of the step, defaults to the target.
+	Name string
+	// Target is the Makefile target to run.
+	Target string
+	// Args are extra arguments passed to make, e.g. `PUSH=true`.
+	Args []string
+	// DependsOn are names of the steps which should succeed first.
+	DependsOn []string
+	// Secrets maps environment variables of the step to the names of CI secrets.
+	Secrets map[string]string
+	// OnlyOnTag runs the step only on tags.
+	OnlyOnTag bool
+	// ExceptPullRequest skips the step on pull requests.
+	ExceptPullRequest bool
+}
+
+// Params are the parameters of the node from the config.
+type Params interface {
+	// Decode parameters into the value, as with yaml.Unmarshal.
+	Decode(v interface{}) error
+}
+
+// Factory creates a node with the name and parameters from the config.
+type Factory func(name string, params Params) (Node, error)
+
+var (
+	factoriesMu sync.Mutex
+	factories   = map[string]Factory{}
+)
+
+// Register registers the node type.
+//
+// Register should be called before the project is built (e.g. from init()), it panics
+// if the type is registered twice.
+func Register(kind string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if _, exists := factories[kind]; exists {
+		panic(fmt.Sprintf("node type %q is already registered", kind))
+	}
+
+	factories[kind] = factory
+}
+
+// Lookup returns the factory of the node type.
+func Lookup(kind string) (Factory, bool) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	factory, ok := factories[kind]
+
+	return factory, ok
+}
+
+// Kinds returns sorted list of the registered node types.
+func Kinds() []string {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	kinds := make([]string, 0, len(factories))
+
+	for kind := range factories {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Strings(kinds)
+
+	return kinds
+}